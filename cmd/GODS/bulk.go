@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Nokeni/GODS/internal/db"
+	"github.com/Nokeni/GODS/internal/db/migrations"
+	"github.com/Nokeni/GODS/internal/passhash"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/bulk"
+	"gorm.io/gorm"
+)
+
+// importDirectory runs the import command, exiting with an error status when some rows weren't imported.
+func importDirectory(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "format of the file, csv or json (guessed from its extension by default)")
+	dryRun := flags.Bool("dry-run", false, "only validate the rows, without importing them")
+	atomic := flags.Bool("atomic", false, "import every row or none of them")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatalf("missing file to import\n%s", usage)
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = bulk.FormatOf(path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	directory, err := bulk.Decode(file, *format)
+	if err != nil {
+		log.Fatalf("failed to read file: %v", err)
+	}
+
+	report, err := newBulkService().Import(context.Background(), directory, *dryRun, *atomic)
+	if err != nil {
+		log.Fatalf("failed to import file: %v", err)
+	}
+	for _, row := range report.Rows {
+		fmt.Printf("%-5s %4d %-30s %s", row.Type, row.Row, row.Name, row.Status)
+		if len(row.Errors) > 0 {
+			fmt.Printf(": %s", strings.Join(row.Errors, ", "))
+		}
+		fmt.Println()
+	}
+	if report.DryRun {
+		fmt.Printf("dry run: %d valid, %d invalid\n", len(report.Rows)-report.Invalid, report.Invalid)
+	} else {
+		fmt.Printf("%d created, %d existing, %d invalid, %d failed\n", report.Created, report.Existing, report.Invalid, report.Failed)
+	}
+
+	if report.Invalid > 0 || report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// exportDirectory runs the export command, writing to the standard output when no file is given.
+func exportDirectory(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", bulk.FormatJSON, "format of the file, csv or json")
+	includePasswordHashes := flags.Bool("password-hashes", false, "include the password hashes of the users")
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		log.Fatalf("too many arguments\n%s", usage)
+	}
+
+	directory, err := newBulkService().Export(context.Background(), *includePasswordHashes)
+	if err != nil {
+		log.Fatalf("failed to export: %v", err)
+	}
+
+	var output io.Writer = os.Stdout
+	if flags.NArg() == 1 {
+		file, err := os.Create(flags.Arg(0))
+		if err != nil {
+			log.Fatalf("failed to create file: %v", err)
+		}
+		defer file.Close()
+		output = file
+	}
+	if err := bulk.Encode(output, *format, directory); err != nil {
+		log.Fatalf("failed to write file: %v", err)
+	}
+}
+
+// newBulkService connects to an up-to-date database and returns the service importing and exporting its content.
+func newBulkService() services.BulkService {
+	database, err := db.NewDatabase()
+	if err != nil {
+		log.Fatalf("failed to init database: %v", err)
+	}
+	checkSchema(database)
+
+	// The events are queued for the webhooks, the server delivers them
+	auditService := services.NewAuditService(repositories.NewAuditEventRepository(database))
+	webhookService := services.NewWebhookService(repositories.NewWebhookRepository(database), repositories.NewWebhookDeliveryRepository(database), auditService)
+	passwordHasher, err := passhash.NewHasher()
+	if err != nil {
+		log.Fatalf("failed to init password hasher: %v", err)
+	}
+	passwordPolicyService, err := services.NewPasswordPolicyService(repositories.NewPasswordHistoryRepository(database), passwordHasher)
+	if err != nil {
+		log.Fatalf("failed to load password policy: %v", err)
+	}
+	return services.NewBulkService(
+		repositories.NewBulkRepository(database),
+		repositories.NewUserRepository(database),
+		repositories.NewGroupRepository(database),
+		passwordPolicyService,
+		auditService,
+		webhookService,
+	)
+}
+
+// checkSchema exits when migrations are pending.
+func checkSchema(database *gorm.DB) {
+	pending, err := migrations.Pending(database)
+	if err != nil {
+		log.Fatalf("failed to check database schema: %v", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("database schema is behind by %d migration(s), run `GODS migrate up` first", len(pending))
+	}
+}
@@ -1,30 +1,143 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/Nokeni/GODS/config"
 	"github.com/Nokeni/GODS/internal/db"
+	"github.com/Nokeni/GODS/internal/db/migrations"
 	"github.com/Nokeni/GODS/internal/web"
 	"github.com/spf13/viper"
 )
 
+const usage = `Usage: GODS [command]
+
+Commands:
+  serve                 Run the web server (default)
+  migrate up            Apply every pending migration
+  migrate down [steps]  Revert the last applied migrations (1 by default)
+  migrate status        List the migrations and whether they have been applied
+  import [--format csv|json] [--dry-run] [--atomic] FILE
+                        Import users, groups and memberships from a CSV or JSON file
+  export [--format csv|json] [--password-hashes] [FILE]
+                        Export every user, group and membership, to the standard output by default`
+
 func main() {
 	if err := config.LoadConfig(); err != nil {
 		log.Fatalf("failed to load configuration: %v", err)
 	}
 
+	command := "serve"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "serve":
+		serve()
+	case "migrate":
+		migrate(os.Args[2:])
+	case "import":
+		importDirectory(os.Args[2:])
+	case "export":
+		exportDirectory(os.Args[2:])
+	default:
+		log.Fatalf("unknown command %q\n%s", command, usage)
+	}
+}
+
+// serve runs the web server until it receives SIGINT or SIGTERM, then lets the requests in progress and the
+// background tasks finish before closing the database.
+func serve() {
 	database, err := db.NewDatabase()
 	if err != nil {
 		log.Fatalf("failed to init database: %v", err)
 	}
 
-	server, err := web.NewHTTPServer(database)
+	// Refuse to serve with an outdated schema, unless configured to migrate it automatically
+	pending, err := migrations.Pending(database)
+	if err != nil {
+		log.Fatalf("failed to check database schema: %v", err)
+	}
+	if len(pending) > 0 {
+		if !viper.GetBool("DB_AUTO_MIGRATE") {
+			log.Fatalf("database schema is behind by %d migration(s), run `GODS migrate up` first", len(pending))
+		}
+		if _, err := migrations.Up(database); err != nil {
+			log.Fatalf("failed to migrate database: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	handler, background, err := web.NewHTTPServer(ctx, database)
 	if err != nil {
 		log.Fatalf("failed to init web server: %v", err)
 	}
 
-	if err := server.Run(":" + viper.GetString("WEB_PORT")); err != nil {
+	server := &http.Server{
+		Addr:              ":" + viper.GetString("WEB_PORT"),
+		Handler:           handler,
+		ReadHeaderTimeout: viper.GetDuration("WEB_READ_HEADER_TIMEOUT"),
+		ReadTimeout:       viper.GetDuration("WEB_READ_TIMEOUT"),
+		WriteTimeout:      viper.GetDuration("WEB_WRITE_TIMEOUT"),
+		IdleTimeout:       viper.GetDuration("WEB_IDLE_TIMEOUT"),
+	}
+
+	// Serve over TLS when a certificate is configured, picking up the renewed certificates
+	tlsReloader, err := web.NewTLSReloader()
+	if err != nil {
+		log.Fatalf("failed to init TLS: %v", err)
+	}
+	if tlsReloader != nil {
+		server.TLSConfig = tlsReloader.TLSConfig()
+		go tlsReloader.Run(ctx)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if tlsReloader != nil {
+			log.Printf("web server listening on %s over TLS", server.Addr)
+			serveErr <- server.ListenAndServeTLS("", "")
+		} else {
+			log.Printf("web server listening on %s", server.Addr)
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("failed to run web server: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Stop accepting connections and wait for the requests in progress, for at most WEB_SHUTDOWN_TIMEOUT
+	log.Printf("shutting down the web server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("WEB_SHUTDOWN_TIMEOUT"))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shut down the web server gracefully: %v", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("web server stopped: %v", err)
+	}
+
+	// The webhook deliveries, the purge of the deleted records and the LDAP connections stop with the context
+	background.Wait()
+
+	sqlDatabase, err := database.DB()
+	if err != nil {
+		log.Fatalf("failed to close database: %v", err)
+	}
+	if err := sqlDatabase.Close(); err != nil {
+		log.Fatalf("failed to close database: %v", err)
 	}
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/Nokeni/GODS/internal/db"
+	"github.com/Nokeni/GODS/internal/db/migrations"
+)
+
+// migrate runs the migrate subcommands.
+func migrate(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("missing migrate subcommand\n%s", usage)
+	}
+
+	database, err := db.NewDatabase()
+	if err != nil {
+		log.Fatalf("failed to init database: %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(database)
+		for _, migration := range applied {
+			fmt.Printf("applied %s\n", migration.ID)
+		}
+		if err != nil {
+			log.Fatalf("failed to apply migrations: %v", err)
+		}
+		fmt.Printf("%d migration(s) applied\n", len(applied))
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatalf("invalid number of steps: %s", args[1])
+			}
+		}
+		reverted, err := migrations.Down(database, steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %s\n", migration.ID)
+		}
+		if err != nil {
+			log.Fatalf("failed to revert migrations: %v", err)
+		}
+		fmt.Printf("%d migration(s) reverted\n", len(reverted))
+	case "status":
+		statuses, err := migrations.GetStatus(database)
+		if err != nil {
+			log.Fatalf("failed to get migrations status: %v", err)
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-40s %-28s %s\n", status.Migration.ID, appliedAt, status.Migration.Description)
+		}
+	default:
+		log.Fatalf("unknown migrate subcommand %q\n%s", args[0], usage)
+	}
+}
@@ -11,6 +11,86 @@ func LoadConfig() error {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yml")
 
+	// Default values for optional settings
+	viper.SetDefault("DB_DRIVER", "sqlite")
+	viper.SetDefault("DB_AUTO_MIGRATE", false)
+	viper.SetDefault("DB_MAX_OPEN_CONNS", 10)
+	viper.SetDefault("DB_MAX_IDLE_CONNS", 5)
+	viper.SetDefault("DB_CONN_MAX_LIFETIME", "1h")
+	viper.SetDefault("DB_CONN_MAX_IDLE_TIME", "10m")
+	viper.SetDefault("JWT_ALGORITHM", "RS256")
+	viper.SetDefault("JWT_KEY_ROTATION_INTERVAL", "720h")
+	viper.SetDefault("JWT_KEY_RETENTION", "24h")
+	viper.SetDefault("JWT_KEYSTORE_SECRET", "")
+	viper.SetDefault("JWT_ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("JWT_REFRESH_TOKEN_TTL", "720h")
+	viper.SetDefault("API_KEY_DEFAULT_TTL", "2160h")
+	viper.SetDefault("API_KEY_MAX_TTL", "8760h")
+	viper.SetDefault("WEB_BASE_URL", "")
+	viper.SetDefault("WEB_READ_HEADER_TIMEOUT", "10s")
+	viper.SetDefault("WEB_READ_TIMEOUT", "30s")
+	viper.SetDefault("WEB_WRITE_TIMEOUT", "60s")
+	viper.SetDefault("WEB_IDLE_TIMEOUT", "120s")
+	viper.SetDefault("WEB_SHUTDOWN_TIMEOUT", "30s")
+	viper.SetDefault("WEB_TLS_CERT_FILE", "")
+	viper.SetDefault("WEB_TLS_KEY_FILE", "")
+	viper.SetDefault("WEB_TLS_RELOAD_INTERVAL", "1m")
+	viper.SetDefault("WEB_TLS_CLIENT_AUTH", "none")
+	viper.SetDefault("WEB_TLS_CLIENT_CA_FILE", "")
+	viper.SetDefault("MAIL_DRIVER", "log")
+	viper.SetDefault("MAIL_FROM", "GODS <noreply@localhost>")
+	viper.SetDefault("MAIL_SMTP_PORT", 587)
+	viper.SetDefault("MAIL_FILE_DIR", "mails")
+	viper.SetDefault("AUTH_REQUIRE_VERIFIED_EMAIL", false)
+	viper.SetDefault("EMAIL_VERIFICATION_TOKEN_TTL", "48h")
+	viper.SetDefault("PASSWORD_RESET_TOKEN_TTL", "1h")
+	viper.SetDefault("MFA_ISSUER", "GODS")
+	viper.SetDefault("MFA_REQUIRED_GROUPS", []string{"admin"})
+	viper.SetDefault("MFA_CHALLENGE_TTL", "5m")
+	viper.SetDefault("OIDC_ISSUER", "")
+	viper.SetDefault("OIDC_ACCESS_TOKEN_TTL", "1h")
+	viper.SetDefault("OIDC_ID_TOKEN_TTL", "1h")
+	viper.SetDefault("OIDC_CODE_TTL", "1m")
+	viper.SetDefault("OIDC_SESSION_TTL", "8h")
+	viper.SetDefault("CONSOLE_SESSION_TTL", "8h")
+	viper.SetDefault("LDAP_ENABLED", false)
+	viper.SetDefault("LDAP_ADDRESS", ":3389")
+	viper.SetDefault("LDAP_BASE_DN", "dc=gods,dc=local")
+	viper.SetDefault("LDAP_TLS_CERT_FILE", "")
+	viper.SetDefault("LDAP_TLS_KEY_FILE", "")
+	viper.SetDefault("LDAP_SIZE_LIMIT", 1000)
+	viper.SetDefault("LDAP_IDLE_TIMEOUT", "5m")
+	viper.SetDefault("LOCKOUT_ACCOUNT_THRESHOLD", 5)
+	viper.SetDefault("LOCKOUT_IP_THRESHOLD", 20)
+	viper.SetDefault("LOCKOUT_SIGNUP_THRESHOLD", 10)
+	viper.SetDefault("LOCKOUT_EMAIL_THRESHOLD", 10)
+	viper.SetDefault("LOCKOUT_BASE_DURATION", "1m")
+	viper.SetDefault("LOCKOUT_MAX_DURATION", "1h")
+	viper.SetDefault("LOCKOUT_RESET_AFTER", "1h")
+	viper.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	viper.SetDefault("WEBHOOK_RETRY_BASE_DELAY", "30s")
+	viper.SetDefault("WEBHOOK_RETRY_MAX_DELAY", "6h")
+	viper.SetDefault("WEBHOOK_POLL_INTERVAL", "10s")
+	viper.SetDefault("DELETED_RETENTION_DAYS", 30)
+	viper.SetDefault("DELETED_PURGE_INTERVAL", "1h")
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_MAX_LENGTH", 64)
+	viper.SetDefault("PASSWORD_REQUIRE_UPPERCASE", true)
+	viper.SetDefault("PASSWORD_REQUIRE_LOWERCASE", true)
+	viper.SetDefault("PASSWORD_REQUIRE_DIGIT", true)
+	viper.SetDefault("PASSWORD_REQUIRE_SPECIAL", true)
+	viper.SetDefault("PASSWORD_BLOCKLIST_FILE", "")
+	viper.SetDefault("PASSWORD_REJECT_SIMILAR", true)
+	viper.SetDefault("PASSWORD_HISTORY", 5)
+	viper.SetDefault("PASSWORD_MAX_AGE", "0")
+	viper.SetDefault("PASSWORD_RENEWAL_TOKEN_TTL", "10m")
+	viper.SetDefault("PASSWORD_HASH_ALGORITHM", "argon2id")
+	viper.SetDefault("PASSWORD_ARGON2_MEMORY", 19456)
+	viper.SetDefault("PASSWORD_ARGON2_ITERATIONS", 2)
+	viper.SetDefault("PASSWORD_ARGON2_PARALLELISM", 1)
+	viper.SetDefault("PASSWORD_BCRYPT_COST", 10)
+
 	if err := viper.ReadInConfig(); err != nil {
 		return fmt.Errorf("error reading configuration file: %v", err)
 	}
@@ -1,21 +1,50 @@
 package db
 
 import (
-	"github.com/Nokeni/GODS/internal/web/api/models"
+	"fmt"
+
 	"github.com/spf13/viper"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// NewDatabase opens the configured database. Its schema is managed by the migrations package.
 func NewDatabase() (*gorm.DB, error) {
-	database, err := gorm.Open(sqlite.Open(viper.GetString("DB_PATH")), &gorm.Config{})
+	dialector, err := newDialector(viper.GetString("DB_DRIVER"))
 	if err != nil {
 		return nil, err
 	}
 
-	if err := database.AutoMigrate(&models.User{}); err != nil {
+	database, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
 		return nil, err
 	}
 
+	// Tune the connection pool
+	sqlDatabase, err := database.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDatabase.SetMaxOpenConns(viper.GetInt("DB_MAX_OPEN_CONNS"))
+	sqlDatabase.SetMaxIdleConns(viper.GetInt("DB_MAX_IDLE_CONNS"))
+	sqlDatabase.SetConnMaxLifetime(viper.GetDuration("DB_CONN_MAX_LIFETIME"))
+	sqlDatabase.SetConnMaxIdleTime(viper.GetDuration("DB_CONN_MAX_IDLE_TIME"))
+
 	return database, nil
 }
+
+// newDialector returns the Gorm dialector of the configured database driver.
+func newDialector(driver string) (gorm.Dialector, error) {
+	switch driver {
+	case "", "sqlite":
+		return sqlite.Open(viper.GetString("DB_PATH")), nil
+	case "postgres":
+		return postgres.Open(viper.GetString("DB_DSN")), nil
+	case "mysql":
+		return mysql.Open(viper.GetString("DB_DSN")), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
@@ -0,0 +1,85 @@
+// Package dbtest opens the databases the tests run against. The driver is chosen like the server's, from the
+// DB_DRIVER and DB_DSN environment variables, so that the same tests run against every supported database:
+//
+//	go test ./...                                                    # SQLite, in a temporary file
+//	DB_DRIVER=postgres DB_DSN="host=localhost ..." go test -p 1 ./... # PostgreSQL
+//	DB_DRIVER=mysql DB_DSN="gods:gods@tcp(localhost:3306)/gods?parseTime=True" go test -p 1 ./...
+//
+// PostgreSQL and MySQL databases are shared by the test packages, which must then run one at a time with -p 1.
+package dbtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/db"
+	"github.com/Nokeni/GODS/internal/db/migrations"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Driver returns the driver the tests run against, sqlite by default.
+func Driver() string {
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		return driver
+	}
+	return "sqlite"
+}
+
+// OpenEmpty opens the test database without any table, which is closed at the end of the test.
+func OpenEmpty(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	viper.Set("DB_DRIVER", Driver())
+	viper.Set("DB_PATH", filepath.Join(t.TempDir(), "gods.db"))
+	viper.Set("DB_DSN", os.Getenv("DB_DSN"))
+	if Driver() != "sqlite" && os.Getenv("DB_DSN") == "" {
+		t.Fatalf("DB_DSN is required to run the tests against %s", Driver())
+	}
+
+	database, err := db.NewDatabase()
+	if err != nil {
+		t.Fatalf("failed to open the %s database: %v", Driver(), err)
+	}
+	database.Logger = logger.Default.LogMode(logger.Silent)
+
+	// A shared database may keep the schema of a previous run
+	reset(t, database)
+	t.Cleanup(func() {
+		reset(t, database)
+		if sqlDatabase, err := database.DB(); err == nil {
+			sqlDatabase.Close()
+		}
+	})
+
+	return database
+}
+
+// Open opens the test database with every migration applied, which is emptied at the end of the test.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	database := OpenEmpty(t)
+	if _, err := migrations.Up(database); err != nil {
+		t.Fatalf("failed to migrate the %s database: %v", Driver(), err)
+	}
+	return database
+}
+
+// reset reverts every applied migration.
+func reset(t testing.TB, database *gorm.DB) {
+	t.Helper()
+
+	statuses, err := migrations.GetStatus(database)
+	if err != nil {
+		t.Fatalf("failed to read the migrations: %v", err)
+	}
+	if _, err := migrations.Down(database, len(statuses)); err != nil {
+		t.Fatalf("failed to revert the migrations: %v", err)
+	}
+	if err := database.Migrator().DropTable(&migrations.SchemaMigration{}); err != nil {
+		t.Fatalf("failed to drop the migrations table: %v", err)
+	}
+}
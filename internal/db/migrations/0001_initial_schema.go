@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// initialSchema creates the users, groups, roles and permissions tables, their associations and the tokens tables.
+// It only creates what is missing, so databases previously created with AutoMigrate are adopted as they are.
+var initialSchema = &Migration{
+	ID:          "0001_initial_schema",
+	Description: "Create the users, groups, roles, permissions and tokens tables",
+	Up: func(tx *gorm.DB) error {
+		type Group struct {
+			gorm.Model
+			Name        string `gorm:"size:255;not null;unique"`
+			Description string
+		}
+		type User struct {
+			gorm.Model
+			Name         string   `gorm:"size:255;not null;unique"`
+			Email        string   `gorm:"not null"`
+			Password     string   `gorm:"not null"`
+			TokenVersion uint     `gorm:"not null;default:0"`
+			Groups       []*Group `gorm:"many2many:user_groups;"`
+		}
+		type Permission struct {
+			gorm.Model
+			Name        string `gorm:"size:255;not null;unique"`
+			Description string
+		}
+		type Role struct {
+			gorm.Model
+			Name        string `gorm:"size:255;not null;unique"`
+			Description string
+			Permissions []*Permission `gorm:"many2many:role_permissions;"`
+			Groups      []*Group      `gorm:"many2many:group_roles;"`
+		}
+		type RefreshToken struct {
+			gorm.Model
+			UserID    uint      `gorm:"not null;index"`
+			FamilyID  string    `gorm:"size:64;not null;index"`
+			TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+			ExpiresAt time.Time `gorm:"not null"`
+			UsedAt    *time.Time
+			RevokedAt *time.Time
+		}
+		type RevokedToken struct {
+			gorm.Model
+			TokenID   string    `gorm:"size:64;not null;uniqueIndex"`
+			ExpiresAt time.Time `gorm:"not null;index"`
+		}
+
+		return tx.AutoMigrate(&Group{}, &User{}, &Permission{}, &Role{}, &RefreshToken{}, &RevokedToken{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(
+			"revoked_tokens",
+			"refresh_tokens",
+			"group_roles",
+			"role_permissions",
+			"roles",
+			"permissions",
+			"user_groups",
+			"users",
+			"groups",
+		)
+	},
+}
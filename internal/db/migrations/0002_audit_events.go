@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditEvents creates the append-only audit log.
+var auditEvents = &Migration{
+	ID:          "0002_audit_events",
+	Description: "Create the audit events table",
+	Up: func(tx *gorm.DB) error {
+		type AuditEvent struct {
+			ID         uint      `gorm:"primarykey"`
+			CreatedAt  time.Time `gorm:"not null;index"`
+			ActorID    *uint     `gorm:"index"`
+			Action     string    `gorm:"size:64;not null;index"`
+			TargetType string    `gorm:"size:64;index"`
+			TargetID   *uint     `gorm:"index"`
+			Details    string
+			Changes    json.RawMessage
+			IP         string `gorm:"size:64"`
+			UserAgent  string
+		}
+
+		return tx.AutoMigrate(&AuditEvent{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("audit_events")
+	},
+}
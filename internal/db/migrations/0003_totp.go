@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// totp adds the TOTP settings of the users and their recovery codes.
+var totp = &Migration{
+	ID:          "0003_totp",
+	Description: "Add the TOTP columns to the users table and create the recovery codes table",
+	Up: func(tx *gorm.DB) error {
+		type User struct {
+			TOTPSecret      string `gorm:"size:64"`
+			TOTPEnabled     bool   `gorm:"not null;default:false"`
+			TOTPLastCounter int64  `gorm:"not null;default:0"`
+		}
+		type RecoveryCode struct {
+			gorm.Model
+			UserID   uint   `gorm:"not null;index"`
+			CodeHash string `gorm:"size:64;not null;index"`
+			UsedAt   *time.Time
+		}
+
+		for _, column := range []string{"TOTPSecret", "TOTPEnabled", "TOTPLastCounter"} {
+			if !tx.Migrator().HasColumn(&User{}, column) {
+				if err := tx.Migrator().AddColumn(&User{}, column); err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.AutoMigrate(&RecoveryCode{})
+	},
+	Down: func(tx *gorm.DB) error {
+		type User struct {
+			TOTPSecret      string
+			TOTPEnabled     bool
+			TOTPLastCounter int64
+		}
+
+		if err := tx.Migrator().DropTable("recovery_codes"); err != nil {
+			return err
+		}
+		for _, column := range []string{"TOTPSecret", "TOTPEnabled", "TOTPLastCounter"} {
+			if err := tx.Migrator().DropColumn(&User{}, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// verificationTokens adds the email verification date of the users and the tokens sent by email.
+var verificationTokens = &Migration{
+	ID:          "0004_verification_tokens",
+	Description: "Add the email verification date to the users table and create the verification tokens table",
+	Up: func(tx *gorm.DB) error {
+		type User struct {
+			EmailVerifiedAt *time.Time
+		}
+		type VerificationToken struct {
+			gorm.Model
+			UserID    uint      `gorm:"not null;index"`
+			Purpose   string    `gorm:"size:32;not null;index"`
+			Email     string    `gorm:"not null"`
+			TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+			ExpiresAt time.Time `gorm:"not null"`
+			UsedAt    *time.Time
+		}
+
+		if !tx.Migrator().HasColumn(&User{}, "EmailVerifiedAt") {
+			if err := tx.Migrator().AddColumn(&User{}, "EmailVerifiedAt"); err != nil {
+				return err
+			}
+		}
+
+		return tx.AutoMigrate(&VerificationToken{})
+	},
+	Down: func(tx *gorm.DB) error {
+		type User struct {
+			EmailVerifiedAt *time.Time
+		}
+
+		if err := tx.Migrator().DropTable("verification_tokens"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&User{}, "EmailVerifiedAt")
+	},
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// oidc creates the OpenID Connect clients and authorization codes tables.
+var oidc = &Migration{
+	ID:          "0005_oidc",
+	Description: "Create the OpenID Connect clients and authorization codes tables",
+	Up: func(tx *gorm.DB) error {
+		type Client struct {
+			gorm.Model
+			ClientID     string `gorm:"size:64;not null;uniqueIndex"`
+			SecretHash   string
+			Name         string   `gorm:"size:255;not null"`
+			RedirectURIs []string `gorm:"serializer:json;type:text"`
+			GrantTypes   []string `gorm:"serializer:json;type:text"`
+			Scopes       []string `gorm:"serializer:json;type:text"`
+			Public       bool     `gorm:"not null;default:false"`
+		}
+		type AuthorizationCode struct {
+			gorm.Model
+			CodeHash            string `gorm:"size:64;not null;uniqueIndex"`
+			ClientID            string `gorm:"size:64;not null;index"`
+			UserID              uint   `gorm:"not null;index"`
+			RedirectURI         string `gorm:"not null"`
+			Scope               string
+			Nonce               string
+			CodeChallenge       string    `gorm:"size:128"`
+			CodeChallengeMethod string    `gorm:"size:16"`
+			AuthTime            time.Time `gorm:"not null"`
+			ExpiresAt           time.Time `gorm:"not null"`
+			UsedAt              *time.Time
+		}
+
+		return tx.AutoMigrate(&Client{}, &AuthorizationCode{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("authorization_codes", "clients")
+	},
+}
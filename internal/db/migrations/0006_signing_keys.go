@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// signingKeys creates the keystore signing the JWTs.
+var signingKeys = &Migration{
+	ID:          "0006_signing_keys",
+	Description: "Create the signing keys table",
+	Up: func(tx *gorm.DB) error {
+		type SigningKey struct {
+			gorm.Model
+			KeyID      string `gorm:"size:64;not null;uniqueIndex"`
+			Algorithm  string `gorm:"size:16;not null"`
+			PrivateKey string `gorm:"type:text;not null"`
+			Encrypted  bool   `gorm:"not null;default:false"`
+			RetiredAt  *time.Time
+			ExpiresAt  *time.Time `gorm:"index"`
+		}
+
+		return tx.AutoMigrate(&SigningKey{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("signing_keys")
+	},
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiKeys adds the service accounts and the API keys authenticating automation.
+var apiKeys = &Migration{
+	ID:          "0007_api_keys",
+	Description: "Add the service account flag to the users table and create the API keys table",
+	Up: func(tx *gorm.DB) error {
+		type User struct {
+			ServiceAccount bool `gorm:"not null;default:false"`
+		}
+		type APIKey struct {
+			gorm.Model
+			UserID     uint      `gorm:"not null;index"`
+			Name       string    `gorm:"size:255;not null"`
+			Prefix     string    `gorm:"size:16;not null"`
+			TokenHash  string    `gorm:"size:64;not null;uniqueIndex"`
+			Scopes     string    `gorm:"type:text;not null"`
+			ExpiresAt  time.Time `gorm:"not null"`
+			LastUsedAt *time.Time
+			LastUsedIP string `gorm:"size:64"`
+		}
+
+		if !tx.Migrator().HasColumn(&User{}, "ServiceAccount") {
+			if err := tx.Migrator().AddColumn(&User{}, "ServiceAccount"); err != nil {
+				return err
+			}
+		}
+
+		return tx.AutoMigrate(&APIKey{})
+	},
+	Down: func(tx *gorm.DB) error {
+		type User struct {
+			ServiceAccount bool
+		}
+
+		if err := tx.Migrator().DropTable("api_keys"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&User{}, "ServiceAccount")
+	},
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// nestedGroups lets groups contain other groups.
+var nestedGroups = &Migration{
+	ID:          "0008_nested_groups",
+	Description: "Create the group subgroups join table",
+	Up: func(tx *gorm.DB) error {
+		type Group struct {
+			gorm.Model
+			Subgroups []*Group `gorm:"many2many:group_subgroups;joinForeignKey:GroupID;joinReferences:SubgroupID"`
+		}
+
+		return tx.AutoMigrate(&Group{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("group_subgroups")
+	},
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// webhooks adds the webhooks notified of the identity lifecycle events, and the queue of their deliveries.
+var webhooks = &Migration{
+	ID:          "0009_webhooks",
+	Description: "Create the webhooks and webhook deliveries tables",
+	Up: func(tx *gorm.DB) error {
+		type Webhook struct {
+			gorm.Model
+			Name     string `gorm:"size:255;not null"`
+			URL      string `gorm:"not null"`
+			Secret   string `gorm:"size:64;not null"`
+			Events   string `gorm:"type:text"`
+			Disabled bool   `gorm:"not null;default:false"`
+		}
+		type WebhookDelivery struct {
+			ID             uint            `gorm:"primarykey"`
+			CreatedAt      time.Time       `gorm:"not null"`
+			UpdatedAt      time.Time       `gorm:"not null"`
+			WebhookID      uint            `gorm:"not null;index"`
+			EventID        string          `gorm:"size:64;not null;index"`
+			Event          string          `gorm:"size:64;not null;index"`
+			Payload        json.RawMessage `gorm:"not null"`
+			Status         string          `gorm:"size:16;not null;index"`
+			Attempts       int             `gorm:"not null;default:0"`
+			NextAttemptAt  *time.Time      `gorm:"index"`
+			LastAttemptAt  *time.Time
+			ResponseStatus int
+			ResponseBody   string `gorm:"type:text"`
+			LastError      string
+		}
+
+		return tx.AutoMigrate(&Webhook{}, &WebhookDelivery{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("webhook_deliveries", "webhooks")
+	},
+}
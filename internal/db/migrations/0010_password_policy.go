@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// passwordPolicy adds the date the passwords were changed, for their maximum age, and the history of the former
+// passwords, which can't be reused.
+var passwordPolicy = &Migration{
+	ID:          "0010_password_policy",
+	Description: "Add the password change date to the users table and create the password histories table",
+	Up: func(tx *gorm.DB) error {
+		type User struct {
+			PasswordChangedAt *time.Time
+		}
+		type PasswordHistory struct {
+			ID        uint      `gorm:"primarykey"`
+			CreatedAt time.Time `gorm:"not null"`
+			UserID    uint      `gorm:"not null;index"`
+			Hash      string    `gorm:"not null"`
+		}
+
+		if !tx.Migrator().HasColumn(&User{}, "PasswordChangedAt") {
+			if err := tx.Migrator().AddColumn(&User{}, "PasswordChangedAt"); err != nil {
+				return err
+			}
+		}
+		// The existing passwords start their maximum age now rather than being expired at once
+		if err := tx.Exec("UPDATE users SET password_changed_at = ? WHERE password <> ''", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.AutoMigrate(&PasswordHistory{})
+	},
+	Down: func(tx *gorm.DB) error {
+		type User struct {
+			PasswordChangedAt *time.Time
+		}
+
+		if err := tx.Migrator().DropTable("password_histories"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&User{}, "PasswordChangedAt")
+	},
+}
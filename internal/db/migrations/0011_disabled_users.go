@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// disabledUsers adds the flag of the users deactivated without being deleted, who can't authenticate.
+var disabledUsers = &Migration{
+	ID:          "0011_disabled_users",
+	Description: "Add the disabled flag to the users table",
+	Up: func(tx *gorm.DB) error {
+		type User struct {
+			Disabled bool `gorm:"not null;default:false"`
+		}
+
+		if tx.Migrator().HasColumn(&User{}, "Disabled") {
+			return nil
+		}
+		return tx.Migrator().AddColumn(&User{}, "Disabled")
+	},
+	Down: func(tx *gorm.DB) error {
+		type User struct {
+			Disabled bool
+		}
+
+		return tx.Migrator().DropColumn(&User{}, "Disabled")
+	},
+}
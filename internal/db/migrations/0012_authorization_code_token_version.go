@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// authorizationCodeTokenVersion adds the token version of the users to the authorization codes, so that the codes
+// are revoked along with the other tokens of the users.
+var authorizationCodeTokenVersion = &Migration{
+	ID:          "0012_authorization_code_token_version",
+	Description: "Add the token version to the authorization_codes table",
+	Up: func(tx *gorm.DB) error {
+		type AuthorizationCode struct {
+			TokenVersion uint `gorm:"not null;default:0"`
+		}
+
+		if tx.Migrator().HasColumn(&AuthorizationCode{}, "TokenVersion") {
+			return nil
+		}
+		return tx.Migrator().AddColumn(&AuthorizationCode{}, "TokenVersion")
+	},
+	Down: func(tx *gorm.DB) error {
+		type AuthorizationCode struct {
+			TokenVersion uint
+		}
+
+		return tx.Migrator().DropColumn(&AuthorizationCode{}, "TokenVersion")
+	},
+}
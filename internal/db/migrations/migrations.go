@@ -0,0 +1,139 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a versioned change of the database schema.
+type Migration struct {
+	ID          string                  // ID identifies the migration, migrations are applied in the order of the registry.
+	Description string                  // Description is the migration's description.
+	Up          func(tx *gorm.DB) error // Up applies the migration.
+	Down        func(tx *gorm.DB) error // Down reverts the migration.
+}
+
+// SchemaMigration is a model that records an applied migration.
+type SchemaMigration struct {
+	ID        string    `gorm:"primaryKey;size:255"` // ID is the applied migration's ID.
+	AppliedAt time.Time `gorm:"not null"`            // AppliedAt is the date the migration was applied.
+}
+
+// Status describes whether a migration has been applied.
+type Status struct {
+	Migration *Migration
+	AppliedAt *time.Time
+}
+
+// registry is the ordered list of migrations. New migrations must be appended at the end.
+var registry = []*Migration{
+	initialSchema,
+	auditEvents,
+	totp,
+	verificationTokens,
+	oidc,
+	signingKeys,
+	apiKeys,
+	nestedGroups,
+	webhooks,
+	passwordPolicy,
+	disabledUsers,
+	authorizationCodeTokenVersion,
+}
+
+// Up applies every pending migration and returns them. When a migration fails, the ones applied before it are
+// returned along with the error.
+func Up(database *gorm.DB) ([]*Migration, error) {
+	pending, err := Pending(database)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		if err := database.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{ID: migration.ID, AppliedAt: time.Now()}).Error
+		}); err != nil {
+			return pending[:i], fmt.Errorf("migration %s: %w", migration.ID, err)
+		}
+	}
+
+	return pending, nil
+}
+
+// Down reverts the given number of the most recently applied migrations and returns them.
+func Down(database *gorm.DB, steps int) ([]*Migration, error) {
+	statuses, err := GetStatus(database)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []*Migration
+	for i := len(statuses) - 1; i >= 0 && len(reverted) < steps; i-- {
+		migration := statuses[i].Migration
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+
+		if err := database.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{ID: migration.ID}).Error
+		}); err != nil {
+			return reverted, fmt.Errorf("migration %s: %w", migration.ID, err)
+		}
+		reverted = append(reverted, migration)
+	}
+
+	return reverted, nil
+}
+
+// GetStatus returns the status of every registered migration.
+func GetStatus(database *gorm.DB) ([]*Status, error) {
+	if err := database.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	var applied []*SchemaMigration
+	if err := database.Find(&applied).Error; err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[string]time.Time, len(applied))
+	for _, schemaMigration := range applied {
+		appliedAt[schemaMigration.ID] = schemaMigration.AppliedAt
+	}
+
+	statuses := make([]*Status, 0, len(registry))
+	for _, migration := range registry {
+		status := &Status{Migration: migration}
+		if date, ok := appliedAt[migration.ID]; ok {
+			status.AppliedAt = &date
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the migrations that haven't been applied yet.
+func Pending(database *gorm.DB) ([]*Migration, error) {
+	statuses, err := GetStatus(database)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*Migration
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Migration)
+		}
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,175 @@
+package migrations_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/db/migrations"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+)
+
+// tables are the tables of the models, which must all exist once migrated.
+var tables = []any{
+	&models.User{},
+	&models.Group{},
+	&models.Role{},
+	&models.Permission{},
+	&models.RefreshToken{},
+	&models.RevokedToken{},
+	&models.AuditEvent{},
+	&models.RecoveryCode{},
+	&models.VerificationToken{},
+	&models.Client{},
+	&models.AuthorizationCode{},
+	&models.SigningKey{},
+	&models.APIKey{},
+	&models.Webhook{},
+	&models.WebhookDelivery{},
+	&models.PasswordHistory{},
+	"user_groups",
+	"group_roles",
+	"role_permissions",
+	"group_subgroups",
+}
+
+func TestUpAppliesEveryMigration(t *testing.T) {
+	database := dbtest.OpenEmpty(t)
+
+	pending, err := migrations.Pending(database)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) == 0 {
+		t.Fatal("Pending() returned no migration on an empty database")
+	}
+
+	applied, err := migrations.Up(database)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if len(applied) != len(pending) {
+		t.Errorf("Up() applied %d migrations, want %d", len(applied), len(pending))
+	}
+	for _, table := range tables {
+		if !database.Migrator().HasTable(table) {
+			t.Errorf("table of %T doesn't exist after Up()", table)
+		}
+	}
+
+	// The models must match the migrated schema
+	for _, table := range tables {
+		if _, ok := table.(string); ok {
+			continue
+		}
+		if err := database.Find(table).Error; err != nil {
+			t.Errorf("querying %T error = %v", table, err)
+		}
+	}
+
+	if pending, err := migrations.Pending(database); err != nil || len(pending) != 0 {
+		t.Errorf("Pending() after Up() = %d migrations, %v, want none", len(pending), err)
+	}
+	if applied, err := migrations.Up(database); err != nil || len(applied) != 0 {
+		t.Errorf("second Up() applied %d migrations, %v, want none", len(applied), err)
+	}
+}
+
+func TestDownRevertsEveryMigration(t *testing.T) {
+	database := dbtest.OpenEmpty(t)
+
+	applied, err := migrations.Up(database)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	// Revert the migrations one at a time, each Down must undo its Up whatever the driver
+	for i := len(applied) - 1; i >= 0; i-- {
+		reverted, err := migrations.Down(database, 1)
+		if err != nil {
+			t.Fatalf("Down() of %s error = %v", applied[i].ID, err)
+		}
+		if len(reverted) != 1 || reverted[0].ID != applied[i].ID {
+			t.Fatalf("Down() reverted %v, want %s", reverted, applied[i].ID)
+		}
+	}
+	for _, table := range tables {
+		if database.Migrator().HasTable(table) {
+			t.Errorf("table of %T still exists after reverting every migration", table)
+		}
+	}
+
+	statuses, err := migrations.GetStatus(database)
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			t.Errorf("migration %s is still applied", status.Migration.ID)
+		}
+	}
+
+	// The schema can be migrated again from scratch
+	if reapplied, err := migrations.Up(database); err != nil || len(reapplied) != len(applied) {
+		t.Errorf("Up() after Down() applied %d migrations, %v, want %d", len(reapplied), err, len(applied))
+	}
+}
+
+func TestUpRecordsAppliedMigrations(t *testing.T) {
+	database := dbtest.OpenEmpty(t)
+
+	if _, err := migrations.Up(database); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if _, err := migrations.Down(database, 1); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	statuses, err := migrations.GetStatus(database)
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	for i, status := range statuses {
+		wantApplied := i < len(statuses)-1
+		if (status.AppliedAt != nil) != wantApplied {
+			t.Errorf("migration %s applied = %v, want %v", status.Migration.ID, status.AppliedAt != nil, wantApplied)
+		}
+	}
+
+	pending, err := migrations.Pending(database)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != statuses[len(statuses)-1].Migration.ID {
+		t.Errorf("Pending() = %d migrations, want the last one", len(pending))
+	}
+}
+
+func TestUpReturnsTheMigrationsAppliedBeforeAFailure(t *testing.T) {
+	database := dbtest.OpenEmpty(t)
+
+	// The recovery codes table of 0003_totp can't be created over a view of the same name
+	if err := database.Exec("CREATE VIEW recovery_codes AS SELECT 1 AS id").Error; err != nil {
+		t.Fatalf("creating the view error = %v", err)
+	}
+	t.Cleanup(func() {
+		database.Exec("DROP VIEW recovery_codes")
+	})
+
+	applied, err := migrations.Up(database)
+	if err == nil || !strings.Contains(err.Error(), "0003_totp") {
+		t.Fatalf("Up() error = %v, want the failure of 0003_totp", err)
+	}
+	var ids []string
+	for _, migration := range applied {
+		ids = append(ids, migration.ID)
+	}
+	if want := []string{"0001_initial_schema", "0002_audit_events"}; !slices.Equal(ids, want) {
+		t.Errorf("Up() applied %v, want %v", ids, want)
+	}
+
+	if pending, err := migrations.Pending(database); err != nil || len(pending) == 0 || pending[0].ID != "0003_totp" {
+		t.Errorf("Pending() after a failure = %v, %v, want 0003_totp first", pending, err)
+	}
+}
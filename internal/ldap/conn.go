@@ -0,0 +1,242 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// connection is the session of an LDAP client.
+type connection struct {
+	server *Server
+	ip     string
+
+	mutex  sync.Mutex // mutex guards the connection, which the server closes from another goroutine.
+	conn   net.Conn
+	reader *bufio.Reader
+	tls    bool
+	bind   *services.DirectoryBind // bind is the identity of the client, nil while anonymous.
+}
+
+// newConnection creates the session of a client.
+func newConnection(server *Server, conn net.Conn) *connection {
+	ip, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	return &connection{server: server, ip: ip, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// serve processes the requests of the client one at a time, until they unbind or the connection fails.
+func (c *connection) serve() {
+	defer c.close()
+
+	for {
+		if c.server.idleTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(c.server.idleTimeout))
+		}
+		msg, err := readMessage(c.reader)
+		if err != nil {
+			return
+		}
+
+		if msg.operation.Tag == applicationUnbindRequest {
+			return
+		}
+		if err := c.handle(msg); err != nil {
+			return
+		}
+	}
+}
+
+// connectionError is a failure of the connection itself, after which it's closed.
+type connectionError struct {
+	err error
+}
+
+func (err *connectionError) Error() string {
+	return err.err.Error()
+}
+
+// handle processes a request, replying with the result code of the failures.
+// An error is only returned when the connection has to be closed.
+func (c *connection) handle(msg *message) error {
+	var err error
+	switch msg.operation.Tag {
+	case applicationBindRequest:
+		err = c.handleBind(msg)
+	case applicationSearchRequest:
+		err = c.handleSearch(msg)
+	case applicationCompareRequest:
+		err = c.handleCompare(msg)
+	case applicationExtendedRequest:
+		err = c.handleExtended(msg)
+	case applicationAbandonRequest:
+		// Requests are processed one at a time, there's never any operation left to abandon
+		return nil
+	case applicationAddRequest, applicationDelRequest, applicationModifyRequest, applicationModifyDNRequest:
+		err = newResultError(resultUnwillingToPerform, "the directory is read-only, manage the users and groups through the API")
+	default:
+		return errProtocol
+	}
+
+	var resultErr *resultError
+	var connErr *connectionError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &connErr):
+		return err
+	case errors.As(err, &resultErr):
+	case errors.Is(err, errProtocol):
+		err = newResultError(resultProtocolError, "%v", err)
+	case errors.Is(err, errInvalidDN):
+		err = newResultError(resultInvalidDNSyntax, "%v", err)
+	default:
+		log.Printf("LDAP request from %s failed: %v", c.ip, err)
+		err = newResultError(resultOperationsError, "internal error")
+	}
+
+	tag, _ := responseTag(msg.operation.Tag)
+	return c.write(newResponse(msg.id, newErrorResult(tag, err)))
+}
+
+// handleBind authenticates the client with a simple bind, the name being either the DN of a user or their name.
+func (c *connection) handleBind(msg *message) error {
+	if err := msg.checkControls(); err != nil {
+		return err
+	}
+	children := msg.operation.Children
+	if len(children) != 3 {
+		return errProtocol
+	}
+	if version, _ := children[0].Value.(int64); version != 3 {
+		return newResultError(resultProtocolError, "only LDAPv3 is supported")
+	}
+	name, err := octetString(children[1])
+	if err != nil {
+		return err
+	}
+	if children[2].ClassType != ber.ClassContext || children[2].Tag != 0 {
+		return newResultError(resultAuthMethodNotSupported, "only simple binds are supported")
+	}
+	password, err := octetString(children[2])
+	if err != nil {
+		return err
+	}
+
+	// A new bind starts anonymous, whatever its outcome
+	c.bind = nil
+
+	switch {
+	case name == "" && password == "":
+		return c.write(newResponse(msg.id, newResult(applicationBindResponse, resultSuccess, "", "")))
+	case password == "":
+		return newResultError(resultUnwillingToPerform, "unauthenticated binds are not allowed")
+	case c.server.tlsConfig != nil && !c.tls:
+		return newResultError(resultConfidentialityRequired, "use StartTLS before binding")
+	}
+
+	userName, ok := c.server.tree.userName(name)
+	if !ok {
+		return newResultError(resultInvalidCredentials, "invalid username or password")
+	}
+	ctx := services.WithActor(context.Background(), services.Actor{IP: c.ip, UserAgent: "LDAP"})
+	bind, err := c.server.directoryService.Bind(ctx, userName, password)
+	if err != nil {
+		return newResultError(resultInvalidCredentials, "%v", err)
+	}
+	c.bind = bind
+
+	return c.write(newResponse(msg.id, newResult(applicationBindResponse, resultSuccess, "", "")))
+}
+
+// handleExtended processes the WhoAmI and StartTLS extended operations.
+func (c *connection) handleExtended(msg *message) error {
+	if err := msg.checkControls(); err != nil {
+		return err
+	}
+	children := msg.operation.Children
+	if len(children) == 0 || children[0].ClassType != ber.ClassContext || children[0].Tag != 0 {
+		return errProtocol
+	}
+	oid, err := octetString(children[0])
+	if err != nil {
+		return err
+	}
+
+	switch oid {
+	case oidWhoAmI:
+		authzID := ""
+		if c.bind != nil {
+			authzID = "dn:" + c.server.tree.userDN(c.bind.User.Name)
+		}
+		response := newResult(applicationExtendedResponse, resultSuccess, "", "")
+		response.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 11, authzID, "responseValue"))
+		return c.write(newResponse(msg.id, response))
+	case oidStartTLS:
+		if c.server.tlsConfig == nil {
+			return newResultError(resultProtocolError, "StartTLS isn't configured")
+		}
+		if c.tls {
+			return newResultError(resultOperationsError, "TLS is already established")
+		}
+		response := newResult(applicationExtendedResponse, resultSuccess, "", "")
+		response.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 10, oidStartTLS, "responseName"))
+		if err := c.write(newResponse(msg.id, response)); err != nil {
+			return err
+		}
+		return c.startTLS()
+	default:
+		return newResultError(resultProtocolError, "unsupported extended operation %s", oid)
+	}
+}
+
+// startTLS upgrades the connection to TLS.
+func (c *connection) startTLS() error {
+	// The client mustn't send anything before the handshake
+	if c.reader.Buffered() > 0 {
+		return &connectionError{err: errProtocol}
+	}
+
+	tlsConn := tls.Server(c.conn, c.server.tlsConfig)
+	tlsConn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := tlsConn.Handshake(); err != nil {
+		return &connectionError{err: err}
+	}
+	tlsConn.SetDeadline(time.Time{})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.conn, c.reader, c.tls = tlsConn, bufio.NewReader(tlsConn), true
+
+	return nil
+}
+
+// write sends a response to the client.
+func (c *connection) write(packet *ber.Packet) error {
+	c.mutex.Lock()
+	conn := c.conn
+	c.mutex.Unlock()
+
+	if c.server.idleTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(c.server.idleTimeout))
+	}
+	if _, err := conn.Write(packet.Bytes()); err != nil {
+		return &connectionError{err: err}
+	}
+	return nil
+}
+
+// close closes the connection, which ends the session.
+func (c *connection) close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.conn.Close()
+}
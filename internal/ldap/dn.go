@@ -0,0 +1,135 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// errInvalidDN is returned for the distinguished names that can't be parsed.
+var errInvalidDN = errors.New("invalid DN syntax")
+
+// rdn is a relative distinguished name, only single-valued ones are supported.
+type rdn struct {
+	attribute string
+	value     string
+}
+
+// parseDN splits a distinguished name in its relative distinguished names, as described by RFC 4514.
+func parseDN(dn string) ([]rdn, error) {
+	var rdns []rdn
+	if strings.TrimSpace(dn) == "" {
+		return rdns, nil
+	}
+
+	var current rdn
+	var value strings.Builder
+	inValue := false
+	// escaped is the length of the value up to its last escaped character, the spaces before being kept
+	escaped := 0
+	for i := 0; i < len(dn); i++ {
+		c := dn[i]
+		switch {
+		case !inValue && c == '=':
+			current.attribute = strings.TrimSpace(current.attribute)
+			if current.attribute == "" {
+				return nil, errInvalidDN
+			}
+			inValue = true
+		case !inValue:
+			if c == ',' || c == '+' || c == '\\' {
+				return nil, errInvalidDN
+			}
+			current.attribute += string(c)
+		case c == '\\':
+			if i+1 >= len(dn) {
+				return nil, errInvalidDN
+			}
+			if decoded, err := hex.DecodeString(dn[i+1 : min(i+3, len(dn))]); err == nil && len(decoded) == 1 {
+				value.WriteByte(decoded[0])
+				i += 2
+			} else {
+				value.WriteByte(dn[i+1])
+				i++
+			}
+			escaped = value.Len()
+		case c == '+':
+			return nil, errInvalidDN
+		case c == ',':
+			current.value = trimValue(value.String(), escaped)
+			rdns = append(rdns, current)
+			current, inValue, escaped = rdn{}, false, 0
+			value.Reset()
+		case c == ' ' && value.Len() == 0:
+			// The spaces before the value aren't part of it
+		default:
+			value.WriteByte(c)
+		}
+	}
+	if !inValue {
+		return nil, errInvalidDN
+	}
+	current.value = trimValue(value.String(), escaped)
+
+	return append(rdns, current), nil
+}
+
+// trimValue removes the spaces after an attribute value, but not the escaped ones.
+func trimValue(value string, escaped int) string {
+	return value[:escaped] + strings.TrimRight(value[escaped:], " ")
+}
+
+// normalizeDN returns a canonical form of a distinguished name, so that equivalent names can be compared as strings.
+func normalizeDN(dn string) (string, error) {
+	rdns, err := parseDN(dn)
+	if err != nil {
+		return "", err
+	}
+	normalized := make([]string, len(rdns))
+	for i, r := range rdns {
+		normalized[i] = strings.ToLower(r.attribute) + "=" + escapeDNValue(strings.ToLower(r.value))
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// escapeDNValue escapes the special characters of an attribute value to be used in a distinguished name.
+func escapeDNValue(value string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case strings.IndexByte(`,+"\<>;=`, c) >= 0,
+			c == '#' && i == 0,
+			c == ' ' && (i == 0 || i == len(value)-1):
+			escaped.WriteByte('\\')
+			escaped.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			escaped.WriteString(`\` + hex.EncodeToString([]byte{c}))
+		default:
+			escaped.WriteByte(c)
+		}
+	}
+	return escaped.String()
+}
+
+// isDescendant checks if a normalized distinguished name is below another one, at any depth.
+func isDescendant(dn string, ancestor string) bool {
+	if ancestor == "" {
+		return dn != ""
+	}
+	return strings.HasSuffix(dn, ","+ancestor)
+}
+
+// parentDN returns the parent of a normalized distinguished name.
+func parentDN(dn string) string {
+	// The separators of a normalized name are the only unescaped commas
+	for i := 0; i < len(dn); i++ {
+		switch dn[i] {
+		case '\\':
+			i++
+		case ',':
+			return dn[i+1:]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package ldap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDN(t *testing.T) {
+	tests := []struct {
+		name    string
+		dn      string
+		want    []rdn
+		wantErr bool
+	}{
+		{name: "empty", dn: " ", want: nil},
+		{name: "single", dn: "dc=example", want: []rdn{{attribute: "dc", value: "example"}}},
+		{
+			name: "spaces around the separators",
+			dn:   " uid = alice , ou=users,dc=example ",
+			want: []rdn{{attribute: "uid", value: "alice"}, {attribute: "ou", value: "users"}, {attribute: "dc", value: "example"}},
+		},
+		{name: "escaped special characters", dn: `cn=Smith\, John\+1,dc=example`, want: []rdn{{attribute: "cn", value: "Smith, John+1"}, {attribute: "dc", value: "example"}}},
+		{name: "hex escape", dn: `cn=a\2cb\0a`, want: []rdn{{attribute: "cn", value: "a,b\n"}}},
+		{name: "value with an equal sign", dn: "cn=a=b", want: []rdn{{attribute: "cn", value: "a=b"}}},
+		{name: "empty value", dn: "cn=", want: []rdn{{attribute: "cn", value: ""}}},
+		{name: "no attribute", dn: "=alice", wantErr: true},
+		{name: "no value", dn: "dc=example,ou", wantErr: true},
+		{name: "trailing separator", dn: "dc=example,", wantErr: true},
+		{name: "multi-valued", dn: "cn=a+sn=b", wantErr: true},
+		{name: "dangling escape", dn: `cn=a\`, wantErr: true},
+		{name: "escape in the attribute", dn: `c\n=a`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseDN(test.dn)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseDN() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseDN() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeDN(t *testing.T) {
+	tests := []struct {
+		dn   string
+		want string
+	}{
+		{dn: "UID=Alice, OU=Users, DC=Example", want: "uid=alice,ou=users,dc=example"},
+		{dn: `cn=Smith\2C John,dc=example`, want: `cn=smith\, john,dc=example`},
+		{dn: `cn=\ lead,dc=example`, want: `cn=\ lead,dc=example`},
+		{dn: "", want: ""},
+	}
+
+	for _, test := range tests {
+		got, err := normalizeDN(test.dn)
+		if err != nil {
+			t.Errorf("normalizeDN(%q) error = %v", test.dn, err)
+		} else if got != test.want {
+			t.Errorf("normalizeDN(%q) = %q, want %q", test.dn, got, test.want)
+		}
+	}
+
+	if _, err := normalizeDN("not a dn"); err != errInvalidDN {
+		t.Errorf("normalizeDN() of an invalid DN error = %v, want errInvalidDN", err)
+	}
+}
+
+func TestEscapeDNValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "alice", want: "alice"},
+		{value: `a,b+c"d\e<f>g;h=i`, want: `a\,b\+c\"d\\e\<f\>g\;h\=i`},
+		{value: "#tag", want: `\#tag`},
+		{value: "a#b", want: "a#b"},
+		{value: " padded ", want: `\ padded\ `},
+		{value: "inner space", want: "inner space"},
+		{value: "line\nbreak", want: `line\0abreak`},
+		{value: "émile", want: "émile"},
+	}
+
+	for _, test := range tests {
+		got := escapeDNValue(test.value)
+		if got != test.want {
+			t.Errorf("escapeDNValue(%q) = %q, want %q", test.value, got, test.want)
+		}
+		// An escaped value is parsed back to itself
+		if rdns, err := parseDN("cn=" + got); err != nil || len(rdns) != 1 || rdns[0].value != test.value {
+			t.Errorf("parseDN() of the escaped %q = %+v, %v", test.value, rdns, err)
+		}
+	}
+}
+
+func TestParentDNAndIsDescendant(t *testing.T) {
+	tests := []struct {
+		dn   string
+		want string
+	}{
+		{dn: "uid=alice,ou=users,dc=example", want: "ou=users,dc=example"},
+		{dn: `cn=smith\, john,ou=groups,dc=example`, want: "ou=groups,dc=example"},
+		{dn: `cn=a\\,dc=example`, want: "dc=example"},
+		{dn: "dc=example", want: ""},
+		{dn: "", want: ""},
+	}
+
+	for _, test := range tests {
+		if got := parentDN(test.dn); got != test.want {
+			t.Errorf("parentDN(%q) = %q, want %q", test.dn, got, test.want)
+		}
+	}
+
+	descendants := []struct {
+		dn       string
+		ancestor string
+		want     bool
+	}{
+		{dn: "uid=alice,ou=users,dc=example", ancestor: "dc=example", want: true},
+		{dn: "uid=alice,ou=users,dc=example", ancestor: "ou=users,dc=example", want: true},
+		{dn: "ou=users,dc=example", ancestor: "ou=users,dc=example", want: false},
+		{dn: "uid=alice,ou=users,dc=example", ancestor: "ou=groups,dc=example", want: false},
+		{dn: "dc=myexample", ancestor: "example", want: false},
+		{dn: "dc=example", ancestor: "", want: true},
+		{dn: "", ancestor: "", want: false},
+	}
+	for _, test := range descendants {
+		if got := isDescendant(test.dn, test.ancestor); got != test.want {
+			t.Errorf("isDescendant(%q, %q) = %v, want %v", test.dn, test.ancestor, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package ldap
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+)
+
+// generalizedTimeFormat is the format of the GeneralizedTime attribute values.
+const generalizedTimeFormat = "20060102150405Z"
+
+// entry is an object of the directory.
+type entry struct {
+	dn         string       // dn is the distinguished name of the entry.
+	normalized string       // normalized is the canonical form of the distinguished name.
+	attributes []*attribute // attributes are the attributes of the entry, in the order they're returned.
+}
+
+// attribute is an attribute of an entry.
+type attribute struct {
+	name        string   // name is the attribute's description, as returned to the clients.
+	values      []string // values are the attribute's values.
+	operational bool     // operational is true for the attributes only returned when explicitly requested.
+}
+
+// newEntry creates an entry whose distinguished name is known to be valid.
+func newEntry(dn string) *entry {
+	normalized, _ := normalizeDN(dn)
+	return &entry{dn: dn, normalized: normalized}
+}
+
+// add appends an attribute to the entry, skipping the ones without values.
+func (e *entry) add(name string, values ...string) *entry {
+	if len(values) > 0 {
+		e.attributes = append(e.attributes, &attribute{name: name, values: values})
+	}
+	return e
+}
+
+// addOperational appends an operational attribute to the entry.
+func (e *entry) addOperational(name string, values ...string) *entry {
+	if len(values) > 0 {
+		e.attributes = append(e.attributes, &attribute{name: name, values: values, operational: true})
+	}
+	return e
+}
+
+// attribute returns an attribute of the entry by case-insensitive name, nil when the entry doesn't have it.
+func (e *entry) attribute(name string) *attribute {
+	for _, attr := range e.attributes {
+		if strings.EqualFold(attr.name, name) {
+			return attr
+		}
+	}
+	return nil
+}
+
+// tree maps the users and groups to the entries of the directory.
+type tree struct {
+	baseDN   string
+	usersDN  string
+	groupsDN string
+}
+
+// newTree creates the layout of a directory rooted at a base distinguished name.
+func newTree(baseDN string) *tree {
+	return &tree{
+		baseDN:   baseDN,
+		usersDN:  "ou=users," + baseDN,
+		groupsDN: "ou=groups," + baseDN,
+	}
+}
+
+// userDN returns the distinguished name of a user.
+func (t *tree) userDN(name string) string {
+	return "uid=" + escapeDNValue(name) + "," + t.usersDN
+}
+
+// groupDN returns the distinguished name of a group.
+func (t *tree) groupDN(name string) string {
+	return "cn=" + escapeDNValue(name) + "," + t.groupsDN
+}
+
+// userName returns the name of the user a bind DN designates, the name itself being accepted as well.
+func (t *tree) userName(name string) (string, bool) {
+	if !strings.Contains(name, "=") {
+		return name, name != ""
+	}
+
+	rdns, err := parseDN(name)
+	if err != nil || len(rdns) == 0 || !strings.EqualFold(rdns[0].attribute, "uid") {
+		return "", false
+	}
+	normalized, _ := normalizeDN(name)
+	usersDN, _ := normalizeDN(t.usersDN)
+	if parentDN(normalized) != usersDN {
+		return "", false
+	}
+
+	return rdns[0].value, true
+}
+
+// entries returns every entry of the directory, the containers first. The memberOf attribute of the users lists
+// their groups, which the directory service resolves through nested groups too, while the member attribute of the
+// groups lists their direct members, users and subgroups.
+func (t *tree) entries(users []*models.User, groups []*models.Group) []*entry {
+	entries := make([]*entry, 0, len(users)+len(groups)+3)
+
+	base := newEntry(t.baseDN).add("objectClass", "top", "extensibleObject")
+	if rdns, err := parseDN(t.baseDN); err == nil && len(rdns) > 0 {
+		base.add(rdns[0].attribute, rdns[0].value)
+	}
+	entries = append(entries,
+		base,
+		newEntry(t.usersDN).add("objectClass", "top", "organizationalUnit").add("ou", "users"),
+		newEntry(t.groupsDN).add("objectClass", "top", "organizationalUnit").add("ou", "groups"),
+	)
+
+	for _, user := range users {
+		memberOf := make([]string, 0, len(user.Groups))
+		for _, group := range user.Groups {
+			memberOf = append(memberOf, t.groupDN(group.Name))
+		}
+		e := newEntry(t.userDN(user.Name)).
+			add("objectClass", "top", "person", "organizationalPerson", "inetOrgPerson").
+			add("uid", user.Name).
+			add("cn", user.Name).
+			add("sn", user.Name).
+			add("displayName", user.Name).
+			add("memberOf", memberOf...)
+		if user.Email != "" {
+			e.add("mail", user.Email)
+		}
+		entries = append(entries, withTimestamps(e, user.CreatedAt, user.UpdatedAt))
+	}
+
+	for _, group := range groups {
+		members := make([]string, 0, len(group.Users)+len(group.Subgroups))
+		for _, user := range group.Users {
+			members = append(members, t.userDN(user.Name))
+		}
+		for _, subgroup := range group.Subgroups {
+			members = append(members, t.groupDN(subgroup.Name))
+		}
+		e := newEntry(t.groupDN(group.Name)).
+			add("objectClass", "top", "groupOfNames").
+			add("cn", group.Name).
+			add("member", members...)
+		if group.Description != "" {
+			e.add("description", group.Description)
+		}
+		entries = append(entries, withTimestamps(e, group.CreatedAt, group.UpdatedAt))
+	}
+
+	return entries
+}
+
+// withTimestamps adds the operational attributes holding the creation and modification dates of an entry.
+func withTimestamps(e *entry, createdAt time.Time, updatedAt time.Time) *entry {
+	return e.
+		addOperational("createTimestamp", createdAt.UTC().Format(generalizedTimeFormat)).
+		addOperational("modifyTimestamp", updatedAt.UTC().Format(generalizedTimeFormat))
+}
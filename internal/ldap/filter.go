@@ -0,0 +1,208 @@
+package ldap
+
+import (
+	"strings"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// Tags of the search filter choices, as defined by RFC 4511.
+const (
+	filterAnd             ber.Tag = 0
+	filterOr              ber.Tag = 1
+	filterNot             ber.Tag = 2
+	filterEqualityMatch   ber.Tag = 3
+	filterSubstrings      ber.Tag = 4
+	filterGreaterOrEqual  ber.Tag = 5
+	filterLessOrEqual     ber.Tag = 6
+	filterPresent         ber.Tag = 7
+	filterApproxMatch     ber.Tag = 8
+	filterExtensibleMatch ber.Tag = 9
+)
+
+// Tags of the substrings filter parts.
+const (
+	substringInitial ber.Tag = 0
+	substringAny     ber.Tag = 1
+	substringFinal   ber.Tag = 2
+)
+
+// matchFilter checks if an entry matches a search filter. Every value is compared case-insensitively,
+// which suits the attributes of the directory, and the extensible matches never match.
+func matchFilter(e *entry, filter *ber.Packet) (bool, error) {
+	if filter.ClassType != ber.ClassContext {
+		return false, errProtocol
+	}
+
+	switch filter.Tag {
+	case filterAnd:
+		for _, child := range filter.Children {
+			matched, err := matchFilter(e, child)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	case filterOr:
+		for _, child := range filter.Children {
+			matched, err := matchFilter(e, child)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	case filterNot:
+		if len(filter.Children) != 1 {
+			return false, errProtocol
+		}
+		matched, err := matchFilter(e, filter.Children[0])
+		return !matched, err
+	case filterPresent:
+		name, err := octetString(filter)
+		if err != nil {
+			return false, err
+		}
+		return e.attribute(name) != nil, nil
+	case filterEqualityMatch, filterApproxMatch, filterGreaterOrEqual, filterLessOrEqual:
+		name, assertion, err := attributeValueAssertion(filter)
+		if err != nil {
+			return false, err
+		}
+		return matchAssertion(e, filter.Tag, name, assertion), nil
+	case filterSubstrings:
+		return matchSubstrings(e, filter)
+	case filterExtensibleMatch:
+		return false, nil
+	default:
+		return false, errProtocol
+	}
+}
+
+// matchAssertion checks if one of the values of an entry's attribute compares to an assertion value as required.
+func matchAssertion(e *entry, tag ber.Tag, name string, assertion string) bool {
+	assertion = strings.ToLower(assertion)
+	if isDNAttribute(name) {
+		// Distinguished names are compared in their canonical form
+		normalized, err := normalizeDN(assertion)
+		if err != nil {
+			return false
+		}
+		assertion = normalized
+	}
+
+	return matchValues(e.attribute(name), func(value string) bool {
+		value = strings.ToLower(value)
+		if isDNAttribute(name) {
+			value, _ = normalizeDN(value)
+		}
+		switch tag {
+		case filterGreaterOrEqual:
+			return value >= assertion
+		case filterLessOrEqual:
+			return value <= assertion
+		default:
+			return value == assertion
+		}
+	})
+}
+
+// matchSubstrings checks if an entry matches a substrings filter.
+func matchSubstrings(e *entry, filter *ber.Packet) (bool, error) {
+	if len(filter.Children) != 2 {
+		return false, errProtocol
+	}
+	name, err := octetString(filter.Children[0])
+	if err != nil {
+		return false, err
+	}
+	parts := filter.Children[1].Children
+	for _, part := range parts {
+		if _, err := octetString(part); err != nil {
+			return false, err
+		}
+	}
+
+	return matchValues(e.attribute(name), func(value string) bool {
+		value = strings.ToLower(value)
+		for _, part := range parts {
+			substring, _ := octetString(part)
+			substring = strings.ToLower(substring)
+			switch part.Tag {
+			case substringInitial:
+				if !strings.HasPrefix(value, substring) {
+					return false
+				}
+				value = value[len(substring):]
+			case substringAny:
+				i := strings.Index(value, substring)
+				if i < 0 {
+					return false
+				}
+				value = value[i+len(substring):]
+			case substringFinal:
+				if !strings.HasSuffix(value, substring) {
+					return false
+				}
+				value = ""
+			}
+		}
+		return true
+	}), nil
+}
+
+// matchValues checks if one of the values of an attribute matches.
+func matchValues(attr *attribute, match func(value string) bool) bool {
+	if attr == nil {
+		return false
+	}
+	for _, value := range attr.values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDNAttribute checks if the values of an attribute are distinguished names.
+func isDNAttribute(name string) bool {
+	return strings.EqualFold(name, "member") || strings.EqualFold(name, "memberOf")
+}
+
+// equalityValue returns the value an attribute must equal for an entry to match a filter, when the filter is an
+// equality match of the attribute or a conjunction including one.
+func equalityValue(filter *ber.Packet, name string) (string, bool) {
+	if filter == nil || filter.ClassType != ber.ClassContext {
+		return "", false
+	}
+
+	switch filter.Tag {
+	case filterAnd:
+		for _, child := range filter.Children {
+			if value, ok := equalityValue(child, name); ok {
+				return value, true
+			}
+		}
+	case filterEqualityMatch:
+		attribute, value, err := attributeValueAssertion(filter)
+		if err == nil && strings.EqualFold(attribute, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// attributeValueAssertion decodes the attribute and the value of an assertion.
+func attributeValueAssertion(packet *ber.Packet) (string, string, error) {
+	if len(packet.Children) != 2 {
+		return "", "", errProtocol
+	}
+	name, err := octetString(packet.Children[0])
+	if err != nil {
+		return "", "", err
+	}
+	value, err := octetString(packet.Children[1])
+	if err != nil {
+		return "", "", err
+	}
+	return name, value, nil
+}
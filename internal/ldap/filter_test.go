@@ -0,0 +1,159 @@
+package ldap
+
+import (
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// newFilter builds a filter choice from its children, decoding it back as a request's filter would be.
+func newFilter(tag ber.Tag, children ...*ber.Packet) *ber.Packet {
+	packet := ber.Encode(ber.ClassContext, ber.TypeConstructed, tag, nil, "filter")
+	for _, child := range children {
+		packet.AppendChild(child)
+	}
+	return ber.DecodePacket(packet.Bytes())
+}
+
+// newAssertion builds an attribute value assertion filter, as an equality or ordering match.
+func newAssertion(tag ber.Tag, name string, value string) *ber.Packet {
+	return newFilter(tag, newOctetString(name), newOctetString(value))
+}
+
+// newPresent builds a presence filter.
+func newPresent(name string) *ber.Packet {
+	return ber.DecodePacket(ber.NewString(ber.ClassContext, ber.TypePrimitive, filterPresent, name, "present").Bytes())
+}
+
+// newSubstrings builds a substrings filter from parts alternating their tag and their value.
+func newSubstrings(name string, parts ...any) *ber.Packet {
+	substrings := ber.NewSequence("substrings")
+	for i := 0; i < len(parts); i += 2 {
+		substrings.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, parts[i].(ber.Tag), parts[i+1].(string), "substring"))
+	}
+	return newFilter(filterSubstrings, newOctetString(name), substrings)
+}
+
+func newOctetString(value string) *ber.Packet {
+	return ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "")
+}
+
+func TestMatchFilter(t *testing.T) {
+	alice := newEntry("uid=alice,ou=users,dc=example").
+		add("objectClass", "top", "inetOrgPerson").
+		add("uid", "alice").
+		add("cn", "Alice Liddell").
+		add("mail", "Alice@Example.com").
+		add("memberOf", "cn=admins,ou=groups,dc=example", "cn=Wonderland\\, Inc,ou=groups,dc=example")
+
+	tests := []struct {
+		name   string
+		filter *ber.Packet
+		want   bool
+	}{
+		{name: "equality", filter: newAssertion(filterEqualityMatch, "uid", "alice"), want: true},
+		{name: "equality ignoring case", filter: newAssertion(filterEqualityMatch, "MAIL", "alice@example.COM"), want: true},
+		{name: "equality of another value", filter: newAssertion(filterEqualityMatch, "uid", "bob")},
+		{name: "equality of a missing attribute", filter: newAssertion(filterEqualityMatch, "telephoneNumber", "1")},
+		{name: "equality of one of the values", filter: newAssertion(filterEqualityMatch, "objectClass", "inetorgperson"), want: true},
+		{name: "equality of an equivalent DN", filter: newAssertion(filterEqualityMatch, "memberOf", "CN=Admins, OU=Groups, DC=Example"), want: true},
+		{name: "equality of an escaped DN", filter: newAssertion(filterEqualityMatch, "memberOf", `cn=wonderland\2c inc,ou=groups,dc=example`), want: true},
+		{name: "equality of an invalid DN", filter: newAssertion(filterEqualityMatch, "memberOf", "admins")},
+		{name: "approximate", filter: newAssertion(filterApproxMatch, "cn", "alice liddell"), want: true},
+		{name: "greater or equal", filter: newAssertion(filterGreaterOrEqual, "uid", "al"), want: true},
+		{name: "not greater or equal", filter: newAssertion(filterGreaterOrEqual, "uid", "b")},
+		{name: "less or equal", filter: newAssertion(filterLessOrEqual, "uid", "alice"), want: true},
+		{name: "not less or equal", filter: newAssertion(filterLessOrEqual, "uid", "a")},
+		{name: "present", filter: newPresent("mail"), want: true},
+		{name: "present ignoring case", filter: newPresent("OBJECTCLASS"), want: true},
+		{name: "not present", filter: newPresent("telephoneNumber")},
+		{name: "initial", filter: newSubstrings("cn", substringInitial, "ALI"), want: true},
+		{name: "any", filter: newSubstrings("cn", substringAny, "ce li"), want: true},
+		{name: "final", filter: newSubstrings("mail", substringFinal, "@example.com"), want: true},
+		{name: "initial, any and final", filter: newSubstrings("cn", substringInitial, "a", substringAny, "e", substringAny, "d", substringFinal, "l"), want: true},
+		{name: "any in order", filter: newSubstrings("cn", substringAny, "liddell", substringAny, "alice")},
+		{name: "initial and final overlapping", filter: newSubstrings("uid", substringInitial, "alic", substringFinal, "ice")},
+		{name: "wrong initial", filter: newSubstrings("cn", substringInitial, "bob")},
+		{name: "and", filter: newFilter(filterAnd, newPresent("uid"), newAssertion(filterEqualityMatch, "uid", "alice")), want: true},
+		{name: "and with a mismatch", filter: newFilter(filterAnd, newPresent("uid"), newAssertion(filterEqualityMatch, "uid", "bob"))},
+		{name: "empty and", filter: newFilter(filterAnd), want: true},
+		{name: "or", filter: newFilter(filterOr, newAssertion(filterEqualityMatch, "uid", "bob"), newPresent("mail")), want: true},
+		{name: "or without a match", filter: newFilter(filterOr, newAssertion(filterEqualityMatch, "uid", "bob"), newPresent("sn"))},
+		{name: "empty or", filter: newFilter(filterOr)},
+		{name: "not", filter: newFilter(filterNot, newAssertion(filterEqualityMatch, "uid", "bob")), want: true},
+		{name: "not of a match", filter: newFilter(filterNot, newPresent("uid"))},
+		{name: "extensible", filter: newFilter(filterExtensibleMatch, newOctetString("uid"))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := matchFilter(alice, test.filter)
+			if err != nil {
+				t.Fatalf("matchFilter() error = %v", err)
+			}
+			if got != test.want {
+				t.Errorf("matchFilter() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMatchFilterMalformed(t *testing.T) {
+	alice := newEntry("uid=alice,ou=users,dc=example").add("uid", "alice")
+
+	tests := []struct {
+		name   string
+		filter *ber.Packet
+	}{
+		{name: "not of the context class", filter: newOctetString("uid=alice")},
+		{name: "unknown choice", filter: newFilter(10)},
+		{name: "not without a filter", filter: newFilter(filterNot)},
+		{name: "not with two filters", filter: newFilter(filterNot, newPresent("uid"), newPresent("cn"))},
+		{name: "constructed present", filter: newFilter(filterPresent, newOctetString("uid"))},
+		{name: "assertion without a value", filter: newFilter(filterEqualityMatch, newOctetString("uid"))},
+		{name: "assertion with a constructed value", filter: newFilter(filterEqualityMatch, newOctetString("uid"), ber.NewSequence("value"))},
+		{name: "substrings without parts", filter: newFilter(filterSubstrings, newOctetString("uid"))},
+		{name: "substrings with a constructed part", filter: newFilter(filterSubstrings, newOctetString("uid"), newFilter(0, ber.NewSequence("part")))},
+		{name: "malformed child of and", filter: newFilter(filterAnd, newPresent("uid"), newFilter(filterNot))},
+		{name: "malformed child of or", filter: newFilter(filterOr, newFilter(filterNot))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matched, err := matchFilter(alice, test.filter); err != errProtocol {
+				t.Errorf("matchFilter() = %v, %v, want errProtocol", matched, err)
+			}
+		})
+	}
+}
+
+func TestEqualityValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    *ber.Packet
+		want      string
+		wantFound bool
+	}{
+		{name: "equality", filter: newAssertion(filterEqualityMatch, "uid", "alice"), want: "alice", wantFound: true},
+		{name: "equality ignoring the attribute case", filter: newAssertion(filterEqualityMatch, "UID", "Alice"), want: "Alice", wantFound: true},
+		{name: "equality of another attribute", filter: newAssertion(filterEqualityMatch, "cn", "alice")},
+		{name: "and", filter: newFilter(filterAnd, newPresent("objectClass"), newAssertion(filterEqualityMatch, "uid", "alice")), want: "alice", wantFound: true},
+		{name: "nested and", filter: newFilter(filterAnd, newFilter(filterAnd, newAssertion(filterEqualityMatch, "uid", "alice"))), want: "alice", wantFound: true},
+		// Only the conjunctions narrow down the entries that can match
+		{name: "or", filter: newFilter(filterOr, newAssertion(filterEqualityMatch, "uid", "alice"), newPresent("cn"))},
+		{name: "not", filter: newFilter(filterNot, newAssertion(filterEqualityMatch, "uid", "alice"))},
+		{name: "approximate", filter: newAssertion(filterApproxMatch, "uid", "alice")},
+		{name: "substrings", filter: newSubstrings("uid", substringInitial, "alice")},
+		{name: "malformed", filter: newFilter(filterEqualityMatch, newOctetString("uid"))},
+		{name: "nil", filter: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := equalityValue(test.filter, "uid")
+			if got != test.want || found != test.wantFound {
+				t.Errorf("equalityValue() = %q, %v, want %q, %v", got, found, test.want, test.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// Tags of the protocol operations, as defined by RFC 4511.
+const (
+	applicationBindRequest      ber.Tag = 0
+	applicationBindResponse     ber.Tag = 1
+	applicationUnbindRequest    ber.Tag = 2
+	applicationSearchRequest    ber.Tag = 3
+	applicationSearchResultItem ber.Tag = 4
+	applicationSearchResultDone ber.Tag = 5
+	applicationModifyRequest    ber.Tag = 6
+	applicationModifyResponse   ber.Tag = 7
+	applicationAddRequest       ber.Tag = 8
+	applicationAddResponse      ber.Tag = 9
+	applicationDelRequest       ber.Tag = 10
+	applicationDelResponse      ber.Tag = 11
+	applicationModifyDNRequest  ber.Tag = 12
+	applicationModifyDNResponse ber.Tag = 13
+	applicationCompareRequest   ber.Tag = 14
+	applicationCompareResponse  ber.Tag = 15
+	applicationAbandonRequest   ber.Tag = 16
+	applicationExtendedRequest  ber.Tag = 23
+	applicationExtendedResponse ber.Tag = 24
+)
+
+// Result codes, as defined by RFC 4511.
+const (
+	resultSuccess                      = 0
+	resultOperationsError              = 1
+	resultProtocolError                = 2
+	resultSizeLimitExceeded            = 4
+	resultCompareFalse                 = 5
+	resultCompareTrue                  = 6
+	resultAuthMethodNotSupported       = 7
+	resultUnavailableCriticalExtension = 12
+	resultConfidentialityRequired      = 13
+	resultNoSuchAttribute              = 16
+	resultNoSuchObject                 = 32
+	resultInvalidDNSyntax              = 34
+	resultInvalidCredentials           = 49
+	resultInsufficientAccessRights     = 50
+	resultUnwillingToPerform           = 53
+	resultOther                        = 80
+)
+
+// Object identifiers of the supported extended operations and controls.
+const (
+	oidStartTLS     = "1.3.6.1.4.1.1466.20037"
+	oidWhoAmI       = "1.3.6.1.4.1.4203.1.11.3"
+	oidPagedResults = "1.2.840.113556.1.4.319"
+)
+
+// maxPacketLength is the maximum size of a request, bounding the memory a client can make the server allocate.
+const maxPacketLength = 1 << 20
+
+// errProtocol is returned when a request doesn't follow the protocol, after which the connection is closed.
+var errProtocol = errors.New("malformed LDAP message")
+
+// message is a request sent by a client.
+type message struct {
+	id        int64
+	operation *ber.Packet
+	controls  []control
+}
+
+// control is a control attached to a request.
+type control struct {
+	oid      string
+	critical bool
+	value    []byte
+}
+
+// resultError is an operation failure reported to the client with a result code.
+type resultError struct {
+	code    int
+	message string
+}
+
+func (err *resultError) Error() string {
+	return err.message
+}
+
+// newResultError creates an operation failure with a formatted diagnostic message.
+func newResultError(code int, format string, args ...any) *resultError {
+	return &resultError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// readMessage reads a request, which can't be longer than maxPacketLength.
+func readMessage(reader io.Reader) (*message, error) {
+	packet, err := ber.ReadPacket(io.LimitReader(reader, maxPacketLength))
+	if err != nil {
+		return nil, err
+	}
+	return parseMessage(packet)
+}
+
+// parseMessage decodes the envelope of a request.
+func parseMessage(packet *ber.Packet) (*message, error) {
+	if packet.ClassType != ber.ClassUniversal || packet.Tag != ber.TagSequence || len(packet.Children) < 2 {
+		return nil, errProtocol
+	}
+	id, ok := packet.Children[0].Value.(int64)
+	if !ok {
+		return nil, errProtocol
+	}
+	msg := &message{id: id, operation: packet.Children[1]}
+	if msg.operation.ClassType != ber.ClassApplication {
+		return nil, errProtocol
+	}
+
+	if len(packet.Children) > 2 {
+		controls := packet.Children[2]
+		if controls.ClassType != ber.ClassContext || controls.Tag != 0 {
+			return nil, errProtocol
+		}
+		for _, child := range controls.Children {
+			if len(child.Children) == 0 {
+				return nil, errProtocol
+			}
+			oid, ok := child.Children[0].Value.(string)
+			if !ok {
+				return nil, errProtocol
+			}
+			ctrl := control{oid: oid}
+			for _, field := range child.Children[1:] {
+				switch field.Tag {
+				case ber.TagBoolean:
+					ctrl.critical, _ = field.Value.(bool)
+				case ber.TagOctetString:
+					ctrl.value = field.ByteValue
+				}
+			}
+			msg.controls = append(msg.controls, ctrl)
+		}
+	}
+
+	return msg, nil
+}
+
+// control returns the control of the request having the given object identifier, nil when there's none.
+func (msg *message) control(oid string) *control {
+	for i := range msg.controls {
+		if msg.controls[i].oid == oid {
+			return &msg.controls[i]
+		}
+	}
+	return nil
+}
+
+// checkControls fails when the request has a critical control the server doesn't implement.
+func (msg *message) checkControls(supported ...string) error {
+	for _, ctrl := range msg.controls {
+		if ctrl.critical && !slices.Contains(supported, ctrl.oid) {
+			return newResultError(resultUnavailableCriticalExtension, "unsupported critical control %s", ctrl.oid)
+		}
+	}
+	return nil
+}
+
+// newResponse creates the envelope of a response to a request.
+func newResponse(id int64, operation *ber.Packet, controls ...*ber.Packet) *ber.Packet {
+	packet := ber.NewSequence("LDAPMessage")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "messageID"))
+	packet.AppendChild(operation)
+	if len(controls) > 0 {
+		controlsPacket := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "controls")
+		for _, ctrl := range controls {
+			controlsPacket.AppendChild(ctrl)
+		}
+		packet.AppendChild(controlsPacket)
+	}
+	return packet
+}
+
+// newResult creates an operation result, as shared by most responses.
+func newResult(tag ber.Tag, code int, matchedDN string, diagnosticMessage string) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "result")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "resultCode"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, matchedDN, "matchedDN"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, diagnosticMessage, "diagnosticMessage"))
+	return packet
+}
+
+// newErrorResult creates the result reporting an error, using the result code of operation failures.
+func newErrorResult(tag ber.Tag, err error) *ber.Packet {
+	var resultErr *resultError
+	if errors.As(err, &resultErr) {
+		return newResult(tag, resultErr.code, "", resultErr.message)
+	}
+	return newResult(tag, resultOther, "", err.Error())
+}
+
+// newControl creates a response control.
+func newControl(oid string, value *ber.Packet) *ber.Packet {
+	packet := ber.NewSequence("Control")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, oid, "controlType"))
+	if value != nil {
+		packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, string(value.Bytes()), "controlValue"))
+	}
+	return packet
+}
+
+// responseTag returns the tag of the response to an operation, and whether the operation has a response at all.
+func responseTag(requestTag ber.Tag) (ber.Tag, bool) {
+	switch requestTag {
+	case applicationBindRequest:
+		return applicationBindResponse, true
+	case applicationSearchRequest:
+		return applicationSearchResultDone, true
+	case applicationModifyRequest:
+		return applicationModifyResponse, true
+	case applicationAddRequest:
+		return applicationAddResponse, true
+	case applicationDelRequest:
+		return applicationDelResponse, true
+	case applicationModifyDNRequest:
+		return applicationModifyDNResponse, true
+	case applicationCompareRequest:
+		return applicationCompareResponse, true
+	case applicationExtendedRequest:
+		return applicationExtendedResponse, true
+	default:
+		return 0, false
+	}
+}
+
+// octetString returns the content of a string packet of any class.
+func octetString(packet *ber.Packet) (string, error) {
+	if packet.TagType != ber.TypePrimitive {
+		return "", errProtocol
+	}
+	return string(packet.Data.Bytes()), nil
+}
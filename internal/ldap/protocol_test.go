@@ -0,0 +1,127 @@
+package ldap
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string // data is the hex encoding of the request.
+		wantID  int64
+		wantTag ber.Tag
+		wantErr bool
+	}{
+		{name: "unbind", data: "30050201034200", wantID: 3, wantTag: applicationUnbindRequest},
+		{name: "anonymous bind", data: "300c020101600702010304008000", wantID: 1, wantTag: applicationBindRequest},
+		{name: "long form length", data: "3081050201074200", wantID: 7, wantTag: applicationUnbindRequest},
+		{name: "not a sequence", data: "0403616263", wantErr: true},
+		{name: "no operation", data: "3003020101", wantErr: true},
+		{name: "message ID not an integer", data: "30050401014200", wantErr: true},
+		{name: "operation not of the application class", data: "30050201010400", wantErr: true},
+		{name: "controls not tagged", data: "300902010142003002040000", wantErr: true},
+		{name: "control without OID", data: "30090201014200a0023000", wantErr: true},
+		{name: "truncated", data: "30050201", wantErr: true},
+		{name: "length beyond the maximum", data: "30847fffffff020101", wantErr: true},
+		{name: "indefinite length primitive", data: "3080048000000000", wantErr: true},
+		{name: "empty", data: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := hex.DecodeString(test.data)
+			if err != nil {
+				t.Fatalf("invalid test data: %v", err)
+			}
+			msg, err := readMessage(bytes.NewReader(data))
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("readMessage() = %+v, want an error", msg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readMessage() error = %v", err)
+			}
+			if msg.id != test.wantID || msg.operation.Tag != test.wantTag {
+				t.Errorf("readMessage() = message %d with operation %d, want %d with %d", msg.id, msg.operation.Tag, test.wantID, test.wantTag)
+			}
+		})
+	}
+}
+
+func TestParseMessageControls(t *testing.T) {
+	pagingValue := ber.NewSequence("realSearchControlValue")
+	pagingValue.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, 10, "size"))
+	pagingValue.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "cookie"))
+
+	paging := newControl(oidPagedResults, pagingValue)
+	critical := ber.NewSequence("Control")
+	critical.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "1.2.3.4", "controlType"))
+	critical.AppendChild(ber.NewBoolean(ber.ClassUniversal, ber.TypePrimitive, ber.TagBoolean, true, "criticality"))
+
+	request := newResponse(5, ber.Encode(ber.ClassApplication, ber.TypePrimitive, applicationAbandonRequest, nil, "abandon"), paging, critical)
+	msg, err := readMessage(bytes.NewReader(request.Bytes()))
+	if err != nil {
+		t.Fatalf("readMessage() error = %v", err)
+	}
+	if len(msg.controls) != 2 {
+		t.Fatalf("readMessage() controls = %+v, want 2", msg.controls)
+	}
+
+	ctrl := msg.control(oidPagedResults)
+	if ctrl == nil || ctrl.critical || !bytes.Equal(ctrl.value, pagingValue.Bytes()) {
+		t.Errorf("control(%s) = %+v, want the paging control", oidPagedResults, ctrl)
+	}
+	if ctrl := msg.control("1.2.3.4"); ctrl == nil || !ctrl.critical {
+		t.Errorf("control(1.2.3.4) = %+v, want a critical control", ctrl)
+	}
+	if ctrl := msg.control("1.2.3.5"); ctrl != nil {
+		t.Errorf("control(1.2.3.5) = %+v, want nil", ctrl)
+	}
+
+	// Unsupported critical controls fail the operation, while unsupported non-critical ones are ignored
+	var resultErr *resultError
+	if err := msg.checkControls(oidPagedResults); !errors.As(err, &resultErr) || resultErr.code != resultUnavailableCriticalExtension {
+		t.Errorf("checkControls() error = %v, want unavailableCriticalExtension", err)
+	}
+	if err := msg.checkControls("1.2.3.4"); err != nil {
+		t.Errorf("checkControls() of the critical control error = %v", err)
+	}
+}
+
+func TestNewErrorResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int64
+	}{
+		{name: "result error", err: newResultError(resultNoSuchObject, "no such object: %s", "cn=x"), wantCode: resultNoSuchObject},
+		{name: "other error", err: errors.New("database is down"), wantCode: resultOther},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := newErrorResult(applicationSearchResultDone, test.err)
+			// Encoding and decoding the result checks that it's valid BER
+			decoded, err := ber.DecodePacketErr(result.Bytes())
+			if err != nil {
+				t.Fatalf("DecodePacketErr() error = %v", err)
+			}
+			if decoded.Tag != applicationSearchResultDone || len(decoded.Children) != 3 {
+				t.Fatalf("newErrorResult() = %v, want a searchResDone result", decoded)
+			}
+			if code, _ := decoded.Children[0].Value.(int64); code != test.wantCode {
+				t.Errorf("newErrorResult() code = %d, want %d", code, test.wantCode)
+			}
+			if message, _ := octetString(decoded.Children[2]); message != test.err.Error() {
+				t.Errorf("newErrorResult() message = %q, want %q", message, test.err.Error())
+			}
+		})
+	}
+}
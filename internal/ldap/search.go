@@ -0,0 +1,313 @@
+package ldap
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// Search scopes, as defined by RFC 4511.
+const (
+	scopeBaseObject   = 0
+	scopeSingleLevel  = 1
+	scopeWholeSubtree = 2
+)
+
+// handleSearch returns the entries matching a search. The root DSE can be read anonymously,
+// the rest of the directory requires the ldap:search permission.
+func (c *connection) handleSearch(msg *message) error {
+	if err := msg.checkControls(oidPagedResults); err != nil {
+		return err
+	}
+	children := msg.operation.Children
+	if len(children) != 8 {
+		return errProtocol
+	}
+	baseDN, err := octetString(children[0])
+	if err != nil {
+		return err
+	}
+	scope, _ := children[1].Value.(int64)
+	sizeLimit, _ := children[3].Value.(int64)
+	typesOnly, _ := children[5].Value.(bool)
+	filter := children[6]
+	requested := make([]string, 0, len(children[7].Children))
+	for _, child := range children[7].Children {
+		name, err := octetString(child)
+		if err != nil {
+			return err
+		}
+		requested = append(requested, name)
+	}
+
+	base, err := normalizeDN(baseDN)
+	if err != nil {
+		return err
+	}
+	var entries []*entry
+	if base == "" && scope == scopeBaseObject {
+		entries = []*entry{c.rootDSE()}
+	} else {
+		if entries, err = c.entries(c.server.tree.query(base, scope, filter)); err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(entries, func(e *entry) bool { return e.normalized == base }) {
+			return newResultError(resultNoSuchObject, "no such object: %s", baseDN)
+		}
+	}
+
+	var matches []*entry
+	for _, e := range entries {
+		switch {
+		case scope == scopeBaseObject && e.normalized != base,
+			scope == scopeSingleLevel && parentDN(e.normalized) != base,
+			scope == scopeWholeSubtree && e.normalized != base && !isDescendant(e.normalized, base):
+			continue
+		}
+		matched, err := matchFilter(e, filter)
+		if err != nil {
+			return err
+		}
+		if matched {
+			matches = append(matches, e)
+		}
+	}
+
+	if paging := msg.control(oidPagedResults); paging != nil {
+		return c.writePage(msg, matches, paging, requested, typesOnly)
+	}
+
+	limit := c.server.sizeLimit
+	if sizeLimit > 0 && (limit <= 0 || int(sizeLimit) < limit) {
+		limit = int(sizeLimit)
+	}
+	code := resultSuccess
+	if limit > 0 && len(matches) > limit {
+		matches, code = matches[:limit], resultSizeLimitExceeded
+	}
+	for _, e := range matches {
+		if err := c.write(newResponse(msg.id, encodeEntry(e, requested, typesOnly))); err != nil {
+			return err
+		}
+	}
+
+	return c.write(newResponse(msg.id, newResult(applicationSearchResultDone, code, "", "")))
+}
+
+// writePage returns a page of the entries matching a search, as requested with the simple paged results control
+// described by RFC 2696. The cookie is the offset of the next page.
+func (c *connection) writePage(msg *message, matches []*entry, paging *control, requested []string, typesOnly bool) error {
+	value, err := ber.DecodePacketErr(paging.value)
+	if err != nil || len(value.Children) != 2 {
+		return errProtocol
+	}
+	size, _ := value.Children[0].Value.(int64)
+	cookie, err := octetString(value.Children[1])
+	if err != nil {
+		return err
+	}
+	offset := 0
+	if cookie != "" {
+		if offset, err = strconv.Atoi(cookie); err != nil || offset < 0 || offset > len(matches) {
+			return newResultError(resultUnwillingToPerform, "invalid paged results cookie")
+		}
+	}
+
+	// A size of zero abandons the paged search
+	end := offset
+	if size > 0 {
+		end = min(offset+int(size), len(matches))
+		if c.server.sizeLimit > 0 {
+			end = min(end, offset+c.server.sizeLimit)
+		}
+	}
+	for _, e := range matches[offset:end] {
+		if err := c.write(newResponse(msg.id, encodeEntry(e, requested, typesOnly))); err != nil {
+			return err
+		}
+	}
+
+	next := ""
+	if size > 0 && end < len(matches) {
+		next = strconv.Itoa(end)
+	}
+	pagingValue := ber.NewSequence("realSearchControlValue")
+	pagingValue.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, len(matches), "size"))
+	pagingValue.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, next, "cookie"))
+
+	return c.write(newResponse(msg.id, newResult(applicationSearchResultDone, resultSuccess, "", ""), newControl(oidPagedResults, pagingValue)))
+}
+
+// handleCompare checks if an entry has an attribute value.
+func (c *connection) handleCompare(msg *message) error {
+	if err := msg.checkControls(); err != nil {
+		return err
+	}
+	children := msg.operation.Children
+	if len(children) != 2 {
+		return errProtocol
+	}
+	dn, err := octetString(children[0])
+	if err != nil {
+		return err
+	}
+	name, value, err := attributeValueAssertion(children[1])
+	if err != nil {
+		return err
+	}
+
+	normalized, err := normalizeDN(dn)
+	if err != nil {
+		return err
+	}
+	entries, err := c.entries(c.server.tree.query(normalized, scopeBaseObject, nil))
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(entries, func(e *entry) bool { return e.normalized == normalized })
+	if i < 0 {
+		return newResultError(resultNoSuchObject, "no such object: %s", dn)
+	}
+	if entries[i].attribute(name) == nil {
+		return newResultError(resultNoSuchAttribute, "no such attribute: %s", name)
+	}
+
+	code := resultCompareFalse
+	if matchAssertion(entries[i], filterEqualityMatch, name, value) {
+		code = resultCompareTrue
+	}
+	return c.write(newResponse(msg.id, newResult(applicationCompareResponse, code, "", "")))
+}
+
+// directoryQuery describes the users and groups an operation needs, their filters being nil to retrieve all of them.
+type directoryQuery struct {
+	users, groups             bool
+	userFilters, groupFilters []query.Filter
+}
+
+// query narrows down the users and groups a search may return. The search of a user or a group entry only needs
+// that one, while a filter requiring the uid, cn or mail attribute to equal a value only needs the users and groups
+// having it. The filter can be nil, as when an operation targets a single entry.
+func (t *tree) query(base string, scope int64, filter *ber.Packet) *directoryQuery {
+	baseDN, _ := normalizeDN(t.baseDN)
+	usersDN, _ := normalizeDN(t.usersDN)
+	groupsDN, _ := normalizeDN(t.groupsDN)
+	rdns, _ := parseDN(base)
+
+	switch {
+	case parentDN(base) == usersDN && len(rdns) > 0:
+		if !strings.EqualFold(rdns[0].attribute, "uid") {
+			return &directoryQuery{}
+		}
+		return &directoryQuery{users: true, userFilters: []query.Filter{nameFilter(rdns[0].value)}}
+	case parentDN(base) == groupsDN && len(rdns) > 0:
+		if !strings.EqualFold(rdns[0].attribute, "cn") {
+			return &directoryQuery{}
+		}
+		return &directoryQuery{groups: true, groupFilters: []query.Filter{nameFilter(rdns[0].value)}}
+	case scope == scopeBaseObject, base != baseDN && !isDescendant(base, baseDN),
+		isDescendant(base, usersDN), isDescendant(base, groupsDN):
+		// The containers are the only other entries, and the users and groups don't have children
+		return &directoryQuery{}
+	}
+
+	q := &directoryQuery{users: base != groupsDN, groups: base != usersDN}
+	if value, ok := equalityValue(filter, "uid"); ok {
+		q.groups = false
+		q.userFilters = append(q.userFilters, nameFilter(value))
+	}
+	if value, ok := equalityValue(filter, "cn"); ok {
+		q.userFilters = append(q.userFilters, nameFilter(value))
+		q.groupFilters = append(q.groupFilters, nameFilter(value))
+	}
+	if value, ok := equalityValue(filter, "mail"); ok {
+		q.groups = false
+		q.userFilters = append(q.userFilters, query.Filter{Column: "email", Operator: "~=", Value: value})
+	}
+	return q
+}
+
+// nameFilter returns the filter of the users or groups named after a value, compared case-insensitively as in
+// the search filters.
+func nameFilter(value string) query.Filter {
+	return query.Filter{Column: "name", Operator: "~=", Value: value}
+}
+
+// entries returns the entries of the directory an operation needs, the containers being always returned, provided
+// the client may read the directory.
+func (c *connection) entries(q *directoryQuery) ([]*entry, error) {
+	allowed, err := c.server.directoryService.CanSearch(c.bind)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, newResultError(resultInsufficientAccessRights, "bind as a user having the ldap:search permission to read the directory")
+	}
+
+	var users []*models.User
+	if q.users {
+		if users, err = c.server.directoryService.GetUsers(q.userFilters); err != nil {
+			return nil, err
+		}
+	}
+	var groups []*models.Group
+	if q.groups {
+		if groups, err = c.server.directoryService.GetGroups(q.groupFilters); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.server.tree.entries(users, groups), nil
+}
+
+// rootDSE returns the root entry describing the server, as defined by RFC 4512.
+func (c *connection) rootDSE() *entry {
+	extensions := []string{oidWhoAmI}
+	if c.server.tlsConfig != nil {
+		extensions = append(extensions, oidStartTLS)
+	}
+
+	e := newEntry("").add("objectClass", "top")
+	e.addOperational("namingContexts", c.server.tree.baseDN)
+	e.addOperational("supportedLDAPVersion", "3")
+	e.addOperational("supportedExtension", extensions...)
+	e.addOperational("supportedControl", oidPagedResults)
+	e.addOperational("vendorName", "GODS")
+	return e
+}
+
+// encodeEntry encodes a search result entry with the requested attributes, as described by RFC 4511:
+// the user attributes when none or "*" is requested, the operational ones with "+", none with "1.1".
+func encodeEntry(e *entry, requested []string, typesOnly bool) *ber.Packet {
+	allUser := len(requested) == 0 || slices.Contains(requested, "*")
+	allOperational := slices.Contains(requested, "+")
+
+	attributes := ber.NewSequence("attributes")
+	for _, attr := range e.attributes {
+		selected := allUser && !attr.operational || allOperational && attr.operational ||
+			slices.ContainsFunc(requested, func(name string) bool { return strings.EqualFold(name, attr.name) })
+		if !selected {
+			continue
+		}
+
+		partial := ber.NewSequence("partialAttribute")
+		partial.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, attr.name, "type"))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		if !typesOnly {
+			for _, value := range attr.values {
+				values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+			}
+		}
+		partial.AppendChild(values)
+		attributes.AppendChild(partial)
+	}
+
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, applicationSearchResultItem, nil, "searchResEntry")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, "objectName"))
+	packet.AppendChild(attributes)
+	return packet
+}
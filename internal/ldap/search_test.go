@@ -0,0 +1,185 @@
+package ldap
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// stubDirectory is a directory service returning fixed users and groups to any client.
+type stubDirectory struct {
+	users  []*models.User
+	groups []*models.Group
+}
+
+func (directory *stubDirectory) Bind(ctx context.Context, name string, password string) (*services.DirectoryBind, error) {
+	return &services.DirectoryBind{User: &models.User{Name: name}}, nil
+}
+
+func (directory *stubDirectory) CanSearch(bind *services.DirectoryBind) (bool, error) {
+	return true, nil
+}
+
+func (directory *stubDirectory) GetUsers(filters []query.Filter) ([]*models.User, error) {
+	return directory.users, nil
+}
+
+func (directory *stubDirectory) GetGroups(filters []query.Filter) ([]*models.Group, error) {
+	return directory.groups, nil
+}
+
+func TestTreeQuery(t *testing.T) {
+	tree := newTree("dc=example,dc=com")
+	emailFilter := query.Filter{Column: "email", Operator: "~=", Value: "alice@example.com"}
+
+	tests := []struct {
+		name   string
+		base   string
+		scope  int64
+		filter *ber.Packet
+		want   *directoryQuery
+	}{
+		{
+			name:  "whole directory",
+			base:  "dc=example,dc=com",
+			scope: scopeWholeSubtree,
+			want:  &directoryQuery{users: true, groups: true},
+		},
+		{
+			name:  "users container",
+			base:  "ou=users,dc=example,dc=com",
+			scope: scopeSingleLevel,
+			want:  &directoryQuery{users: true},
+		},
+		{
+			name:  "groups container",
+			base:  "ou=groups,dc=example,dc=com",
+			scope: scopeWholeSubtree,
+			want:  &directoryQuery{groups: true},
+		},
+		{
+			name:   "user",
+			base:   "uid=alice,ou=users,dc=example,dc=com",
+			scope:  scopeBaseObject,
+			filter: newPresent("objectClass"),
+			want:   &directoryQuery{users: true, userFilters: []query.Filter{nameFilter("alice")}},
+		},
+		{
+			name:  "user by cn",
+			base:  "cn=alice,ou=users,dc=example,dc=com",
+			scope: scopeBaseObject,
+			want:  &directoryQuery{},
+		},
+		{
+			name:  "group",
+			base:  "cn=admins,ou=groups,dc=example,dc=com",
+			scope: scopeWholeSubtree,
+			want:  &directoryQuery{groups: true, groupFilters: []query.Filter{nameFilter("admins")}},
+		},
+		{
+			name:  "group by uid",
+			base:  "uid=admins,ou=groups,dc=example,dc=com",
+			scope: scopeWholeSubtree,
+			want:  &directoryQuery{},
+		},
+		{
+			name:  "base object of a container",
+			base:  "ou=users,dc=example,dc=com",
+			scope: scopeBaseObject,
+			want:  &directoryQuery{},
+		},
+		{
+			name:  "below a user",
+			base:  "cn=x,uid=alice,ou=users,dc=example,dc=com",
+			scope: scopeWholeSubtree,
+			want:  &directoryQuery{},
+		},
+		{
+			name:  "outside the directory",
+			base:  "dc=other,dc=com",
+			scope: scopeWholeSubtree,
+			want:  &directoryQuery{},
+		},
+		{
+			name:   "uid",
+			base:   "dc=example,dc=com",
+			scope:  scopeWholeSubtree,
+			filter: newFilter(filterAnd, newAssertion(filterEqualityMatch, "objectClass", "inetOrgPerson"), newAssertion(filterEqualityMatch, "uid", "alice")),
+			want:   &directoryQuery{users: true, userFilters: []query.Filter{nameFilter("alice")}},
+		},
+		{
+			name:   "cn",
+			base:   "dc=example,dc=com",
+			scope:  scopeWholeSubtree,
+			filter: newAssertion(filterEqualityMatch, "cn", "admins"),
+			want:   &directoryQuery{users: true, groups: true, userFilters: []query.Filter{nameFilter("admins")}, groupFilters: []query.Filter{nameFilter("admins")}},
+		},
+		{
+			name:   "cn of the groups",
+			base:   "ou=groups,dc=example,dc=com",
+			scope:  scopeSingleLevel,
+			filter: newAssertion(filterEqualityMatch, "cn", "admins"),
+			want:   &directoryQuery{groups: true, userFilters: []query.Filter{nameFilter("admins")}, groupFilters: []query.Filter{nameFilter("admins")}},
+		},
+		{
+			name:   "mail",
+			base:   "ou=users,dc=example,dc=com",
+			scope:  scopeSingleLevel,
+			filter: newAssertion(filterEqualityMatch, "mail", "alice@example.com"),
+			want:   &directoryQuery{users: true, userFilters: []query.Filter{emailFilter}},
+		},
+		{
+			name:   "uid or cn",
+			base:   "dc=example,dc=com",
+			scope:  scopeWholeSubtree,
+			filter: newFilter(filterOr, newAssertion(filterEqualityMatch, "uid", "alice"), newAssertion(filterEqualityMatch, "cn", "alice")),
+			want:   &directoryQuery{users: true, groups: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := tree.query(test.base, test.scope, test.filter); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("query() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEntriesOfTheUsersContainer(t *testing.T) {
+	staff := &models.Group{Name: "staff"}
+	developers := &models.Group{Name: "developers"}
+	staff.ID, developers.ID = 1, 2
+	staff.Subgroups = []*models.Group{developers}
+	// The directory service returns the groups users belong to through nested groups as well
+	alice := &models.User{Name: "alice", Groups: []*models.Group{developers, staff}}
+	alice.ID = 1
+
+	server := &Server{directoryService: &stubDirectory{users: []*models.User{alice}, groups: []*models.Group{staff, developers}}, tree: newTree("dc=example,dc=com")}
+	c := &connection{server: server, bind: &services.DirectoryBind{User: alice}}
+
+	// A search of the users doesn't retrieve the groups, their entries aren't returned
+	q := server.tree.query("ou=users,dc=example,dc=com", scopeSingleLevel, nil)
+	entries, err := c.entries(q)
+	if err != nil {
+		t.Fatalf("entries() error = %v", err)
+	}
+	var memberOf []string
+	for _, e := range entries {
+		if e.dn == "cn=staff,ou=groups,dc=example,dc=com" {
+			t.Error("entries() of the users container returned a group")
+		}
+		if e.dn == "uid=alice,ou=users,dc=example,dc=com" {
+			memberOf = e.attribute("memberOf").values
+		}
+	}
+	want := []string{"cn=developers,ou=groups,dc=example,dc=com", "cn=staff,ou=groups,dc=example,dc=com"}
+	if !reflect.DeepEqual(memberOf, want) {
+		t.Errorf("memberOf of alice = %v, want %v", memberOf, want)
+	}
+}
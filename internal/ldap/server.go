@@ -0,0 +1,151 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/spf13/viper"
+)
+
+// Server is a read-only LDAPv3 server exposing the users and groups of the directory service.
+type Server struct {
+	directoryService services.DirectoryService
+	tree             *tree
+	tlsConfig        *tls.Config // tlsConfig enables StartTLS, nil when no certificate is configured.
+	sizeLimit        int
+	idleTimeout      time.Duration
+
+	mutex       sync.Mutex
+	listener    net.Listener
+	connections map[*connection]struct{}
+	closed      bool
+	served      sync.WaitGroup // served tracks the connections being served, which Wait waits for.
+}
+
+// NewServer creates an LDAP server from the LDAP_* settings.
+func NewServer(directoryService services.DirectoryService) (*Server, error) {
+	baseDN := viper.GetString("LDAP_BASE_DN")
+	if rdns, err := parseDN(baseDN); err != nil || len(rdns) == 0 {
+		return nil, fmt.Errorf("invalid LDAP base DN: %q", baseDN)
+	}
+
+	server := &Server{
+		directoryService: directoryService,
+		tree:             newTree(baseDN),
+		sizeLimit:        viper.GetInt("LDAP_SIZE_LIMIT"),
+		idleTimeout:      viper.GetDuration("LDAP_IDLE_TIMEOUT"),
+		connections:      make(map[*connection]struct{}),
+	}
+
+	certFile, keyFile := viper.GetString("LDAP_TLS_CERT_FILE"), viper.GetString("LDAP_TLS_KEY_FILE")
+	if certFile != "" || keyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the LDAP certificate: %v", err)
+		}
+		server.tlsConfig = &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+	}
+
+	return server, nil
+}
+
+// Listen opens the listening socket, so that the errors such as an address already in use are reported before serving.
+func (server *Server) Listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.listener = listener
+
+	return nil
+}
+
+// Serve accepts the connections until the server is closed.
+func (server *Server) Serve() error {
+	server.mutex.Lock()
+	listener := server.listener
+	server.mutex.Unlock()
+	if listener == nil {
+		return errors.New("the LDAP server isn't listening")
+	}
+
+	log.Printf("LDAP server listening on %s", listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			server.mutex.Lock()
+			closed := server.closed
+			server.mutex.Unlock()
+			if closed {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		c := newConnection(server, conn)
+		if !server.track(c) {
+			conn.Close()
+			return nil
+		}
+		go func() {
+			defer server.untrack(c)
+			c.serve()
+		}()
+	}
+}
+
+// Close stops accepting connections and closes the open ones.
+func (server *Server) Close() error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.closed = true
+	for c := range server.connections {
+		c.close()
+	}
+	if server.listener == nil {
+		return nil
+	}
+	return server.listener.Close()
+}
+
+// Wait waits for the connections to be done, once the server has been closed.
+func (server *Server) Wait() {
+	server.served.Wait()
+}
+
+// track registers an open connection, unless the server has been closed.
+func (server *Server) track(c *connection) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.closed {
+		return false
+	}
+	server.connections[c] = struct{}{}
+	server.served.Add(1)
+	return true
+}
+
+// untrack unregisters a closed connection.
+func (server *Server) untrack(c *connection) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	delete(server.connections, c)
+	server.served.Done()
+}
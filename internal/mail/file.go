@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer is an implementation of the Mailer writing the emails as .eml files in a directory,
+// to test the flows sending emails without a mail server.
+type FileMailer struct {
+	directory string
+	from      string
+}
+
+// NewFileMailer creates a mailer writing in the given directory.
+func NewFileMailer(directory string, from string) *FileMailer {
+	return &FileMailer{directory: directory, from: from}
+}
+
+// Send writes an email to a new file.
+func (mailer *FileMailer) Send(message *Message) error {
+	if err := os.MkdirAll(mailer.directory, 0o700); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d.eml", time.Now().UnixNano())
+	return os.WriteFile(filepath.Join(mailer.directory, name), format(mailer.from, message), 0o600)
+}
+
+// LogMailer is an implementation of the Mailer printing the emails to the log.
+type LogMailer struct{}
+
+// NewLogMailer creates a mailer printing to the log.
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Send prints an email to the log.
+func (mailer *LogMailer) Send(message *Message) error {
+	log.Printf("email to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
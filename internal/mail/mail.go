@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Message is an email to send.
+type Message struct {
+	To      string // To is the recipient's address.
+	Subject string // Subject is the email's subject.
+	Body    string // Body is the plain text body of the email.
+}
+
+// Mailer defines the methods for sending emails.
+type Mailer interface {
+	Send(message *Message) error
+}
+
+// NewMailer returns the mailer selected by the MAIL_DRIVER setting.
+func NewMailer() (Mailer, error) {
+	switch driver := viper.GetString("MAIL_DRIVER"); driver {
+	case "smtp":
+		return NewSMTPMailer(
+			viper.GetString("MAIL_SMTP_HOST"),
+			viper.GetInt("MAIL_SMTP_PORT"),
+			viper.GetString("MAIL_SMTP_USERNAME"),
+			viper.GetString("MAIL_SMTP_PASSWORD"),
+			viper.GetString("MAIL_FROM"),
+		), nil
+	case "file":
+		return NewFileMailer(viper.GetString("MAIL_FILE_DIR"), viper.GetString("MAIL_FROM")), nil
+	case "log":
+		return NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %q", driver)
+	}
+}
+
+// format renders a message as an RFC 5322 email.
+func format(from string, message *Message) []byte {
+	return []byte("From: " + from + "\r\n" +
+		"To: " + message.To + "\r\n" +
+		"Subject: " + message.Subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		message.Body + "\r\n")
+}
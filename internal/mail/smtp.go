@@ -0,0 +1,31 @@
+package mail
+
+import (
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPMailer is an implementation of the Mailer sending the emails through an SMTP server.
+type SMTPMailer struct {
+	address string
+	auth    smtp.Auth
+	from    string
+}
+
+// NewSMTPMailer creates a mailer sending through the given server, authenticating when a username is provided.
+func NewSMTPMailer(host string, port int, username string, password string, from string) *SMTPMailer {
+	mailer := &SMTPMailer{
+		address: net.JoinHostPort(host, strconv.Itoa(port)),
+		from:    from,
+	}
+	if username != "" {
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return mailer
+}
+
+// Send sends an email, upgrading the connection with STARTTLS when the server supports it.
+func (mailer *SMTPMailer) Send(message *Message) error {
+	return smtp.SendMail(mailer.address, mailer.auth, mailer.from, []string{message.To}, format(mailer.from, message))
+}
@@ -0,0 +1,134 @@
+package passhash
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idPrefix     = "$argon2id$"
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+	// The hashes imported from other systems are verified with their own parameters, which are bounded so that
+	// a login doesn't take more than a few seconds.
+	argon2idMaxMemory      = 1 << 20 // 1 GiB
+	argon2idMaxIterations  = 64
+	argon2idMaxCost        = 4 << 20 // memory × iterations, 4 GiB
+	argon2idMaxParallelism = 64
+	argon2idMaxKeyLength   = 128
+)
+
+// Argon2idHasher hashes passwords with argon2id, in the PHC string format:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type Argon2idHasher struct {
+	Memory      uint32 // Memory is the memory used, in KiB.
+	Iterations  uint32 // Iterations is the number of passes over the memory.
+	Parallelism uint8  // Parallelism is the number of threads.
+}
+
+// argon2idHash is a parsed argon2id hash.
+type argon2idHash struct {
+	params Argon2idHasher
+	salt   []byte
+	key    []byte
+}
+
+// NewArgon2idHasher returns an argon2id hasher with the given parameters.
+func NewArgon2idHasher(memory uint32, iterations uint32, parallelism uint8) (*Argon2idHasher, error) {
+	hasher := &Argon2idHasher{Memory: memory, Iterations: iterations, Parallelism: parallelism}
+	if iterations < 1 || parallelism < 1 || memory < 8*uint32(parallelism) {
+		return nil, errors.New("invalid argon2id parameters: iterations and parallelism must be at least 1, memory at least 8 KiB per thread")
+	}
+	if !hasher.bounded() {
+		return nil, fmt.Errorf("invalid argon2id parameters: memory must be at most %d KiB, iterations at most %d, memory × iterations at most %d and parallelism at most %d",
+			argon2idMaxMemory, argon2idMaxIterations, argon2idMaxCost, argon2idMaxParallelism)
+	}
+	return hasher, nil
+}
+
+// Name returns the name of the scheme.
+func (hasher *Argon2idHasher) Name() string {
+	return "argon2id"
+}
+
+// Identifies tells if a hash is an argon2id one.
+func (hasher *Argon2idHasher) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+// Validate checks that an argon2id hash can be parsed.
+func (hasher *Argon2idHasher) Validate(hash string) error {
+	_, err := parseArgon2id(hash)
+	return err
+}
+
+// Hash hashes a password with a random salt.
+func (hasher *Argon2idHasher) Hash(password string) (string, error) {
+	salt, err := randomSalt(argon2idSaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, hasher.Iterations, hasher.Memory, hasher.Parallelism, argon2idKeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, hasher.Memory, hasher.Iterations, hasher.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify checks a password against an argon2id hash, with the parameters of the hash.
+func (hasher *Argon2idHasher) Verify(hash string, password string) (bool, error) {
+	parsed, err := parseArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), parsed.salt, parsed.params.Iterations, parsed.params.Memory, parsed.params.Parallelism, uint32(len(parsed.key)))
+	return subtle.ConstantTimeCompare(key, parsed.key) == 1, nil
+}
+
+// NeedsRehash tells if a hash isn't an argon2id one with the parameters of the hasher.
+func (hasher *Argon2idHasher) NeedsRehash(hash string) bool {
+	parsed, err := parseArgon2id(hash)
+	return err != nil || parsed.params != *hasher || len(parsed.salt) != argon2idSaltLength || len(parsed.key) != argon2idKeyLength
+}
+
+// bounded tells if the parameters don't exceed the maximum cost of a verification.
+func (hasher *Argon2idHasher) bounded() bool {
+	return hasher.Memory <= argon2idMaxMemory && hasher.Iterations <= argon2idMaxIterations &&
+		uint64(hasher.Memory)*uint64(hasher.Iterations) <= argon2idMaxCost && hasher.Parallelism <= argon2idMaxParallelism
+}
+
+// parseArgon2id parses an argon2id hash in the PHC string format.
+func parseArgon2id(hash string) (*argon2idHash, error) {
+	parts := strings.Split(strings.TrimPrefix(hash, argon2idPrefix), "$")
+	if !strings.HasPrefix(hash, argon2idPrefix) || len(parts) != 4 {
+		return nil, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrUnsupportedHash
+	}
+
+	parsed := &argon2idHash{}
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &parsed.params.Memory, &parsed.params.Iterations, &parsed.params.Parallelism); err != nil {
+		return nil, ErrUnsupportedHash
+	}
+	if parsed.params.Iterations < 1 || parsed.params.Parallelism < 1 || !parsed.params.bounded() {
+		return nil, ErrUnsupportedHash
+	}
+
+	var err error
+	if parsed.salt, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return nil, ErrUnsupportedHash
+	}
+	if parsed.key, err = base64.RawStdEncoding.DecodeString(parts[3]); err != nil || len(parsed.key) < 4 || len(parsed.key) > argon2idMaxKeyLength {
+		return nil, ErrUnsupportedHash
+	}
+
+	return parsed, nil
+}
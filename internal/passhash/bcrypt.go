@@ -0,0 +1,74 @@
+package passhash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher hashes passwords with bcrypt, in the modular crypt format: $2a$<cost>$<salt and hash>.
+// The $2b$ and $2y$ variants of other implementations are verified as well.
+type BcryptHasher struct {
+	Cost int // Cost is the logarithm of the number of rounds.
+}
+
+// NewBcryptHasher returns a bcrypt hasher with the given cost.
+func NewBcryptHasher(cost int) (*BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("invalid bcrypt cost: must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &BcryptHasher{Cost: cost}, nil
+}
+
+// Name returns the name of the scheme.
+func (hasher *BcryptHasher) Name() string {
+	return "bcrypt"
+}
+
+// Identifies tells if a hash is a bcrypt one.
+func (hasher *BcryptHasher) Identifies(hash string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that a bcrypt hash can be parsed.
+func (hasher *BcryptHasher) Validate(hash string) error {
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return ErrUnsupportedHash
+	}
+	return nil
+}
+
+// Hash hashes a password with a random salt.
+func (hasher *BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), hasher.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+// Verify checks a password against a bcrypt hash, with the cost of the hash.
+func (hasher *BcryptHasher) Verify(hash string, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, ErrUnsupportedHash
+	}
+}
+
+// NeedsRehash tells if a hash isn't a bcrypt one with the cost of the hasher.
+func (hasher *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || !hasher.Identifies(hash) || cost != hasher.Cost
+}
@@ -0,0 +1,85 @@
+package passhash
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// ErrUnsupportedHash is returned for the hashes of an algorithm that isn't supported, or that can't be parsed.
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+// Scheme is a password hashing algorithm. Its hashes are self-describing: they start with the identifier of the
+// scheme and embed its version and parameters, so that hashes of different schemes and parameters can coexist.
+type Scheme interface {
+	Name() string
+	Identifies(hash string) bool
+	Validate(hash string) error
+	Verify(hash string, password string) (bool, error)
+}
+
+// Hasher is a scheme that new passwords are hashed with.
+type Hasher interface {
+	Scheme
+	Hash(password string) (string, error)
+	NeedsRehash(hash string) bool
+}
+
+// schemes are the supported schemes. Only argon2id and bcrypt hash new passwords, the others verify the hashes
+// imported from other systems until they're replaced.
+var schemes = []Scheme{&Argon2idHasher{}, &BcryptHasher{}, &PBKDF2{}, &SHACrypt{}}
+
+// NewHasher returns the hasher selected by the PASSWORD_HASH_ALGORITHM setting, with its configured parameters.
+func NewHasher() (Hasher, error) {
+	switch algorithm := viper.GetString("PASSWORD_HASH_ALGORITHM"); algorithm {
+	case "argon2id":
+		return NewArgon2idHasher(
+			viper.GetUint32("PASSWORD_ARGON2_MEMORY"),
+			viper.GetUint32("PASSWORD_ARGON2_ITERATIONS"),
+			uint8(viper.GetUint("PASSWORD_ARGON2_PARALLELISM")),
+		)
+	case "bcrypt":
+		return NewBcryptHasher(viper.GetInt("PASSWORD_BCRYPT_COST"))
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm: %q", algorithm)
+	}
+}
+
+// Identify returns the scheme of a hash.
+func Identify(hash string) (Scheme, error) {
+	for _, scheme := range schemes {
+		if scheme.Identifies(hash) {
+			return scheme, nil
+		}
+	}
+	return nil, ErrUnsupportedHash
+}
+
+// Verify checks a password against a hash of any supported scheme.
+func Verify(hash string, password string) (bool, error) {
+	scheme, err := Identify(hash)
+	if err != nil {
+		return false, err
+	}
+	return scheme.Verify(hash, password)
+}
+
+// Validate checks that a hash belongs to a supported scheme and can be parsed, such as an imported one.
+func Validate(hash string) error {
+	scheme, err := Identify(hash)
+	if err != nil {
+		return err
+	}
+	return scheme.Validate(hash)
+}
+
+// randomSalt generates a random salt of the given length.
+func randomSalt(length int) ([]byte, error) {
+	salt := make([]byte, length)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
@@ -0,0 +1,236 @@
+package passhash_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/passhash"
+)
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+	}{
+		// SHA-crypt vectors of the specification of Ulrich Drepper
+		{
+			name:     "sha256-crypt",
+			hash:     "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5",
+			password: "Hello world!",
+			want:     true,
+		},
+		{
+			name:     "sha256-crypt with rounds",
+			hash:     "$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA",
+			password: "Hello world!",
+			want:     true,
+		},
+		{
+			name:     "sha256-crypt with too few rounds",
+			hash:     "$5$rounds=10$roundstoolow$yfvwcWrQ8l/K0DAWyuPMDNHpIVlTQebY9l/gL972bIC",
+			password: "the minimum number is still observed",
+			want:     true,
+		},
+		{
+			name:     "sha256-crypt with an empty salt",
+			hash:     "$5$$qu3REWa/3sl1BuquQ3B23Cna49jUWzQNbVo5saPx3d1",
+			password: "empty salt",
+			want:     true,
+		},
+		{
+			name:     "sha512-crypt",
+			hash:     "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+			password: "Hello world!",
+			want:     true,
+		},
+		{
+			name:     "sha512-crypt with too few rounds",
+			hash:     "$6$rounds=10$roundstoolow$kUMsbe306n21p9R.FRkW3IGn.S9NPN0x50YhH1xhLsPuWGsUSklZt58jaTfF4ZEQpyUNGc0dqbpBYYBaHHrsX.",
+			password: "the minimum number is still observed",
+			want:     true,
+		},
+		{
+			name:     "sha512-crypt with the wrong password",
+			hash:     "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+			password: "Hello world",
+			want:     false,
+		},
+		// Django uses the salt as is, while Passlib decodes it: the same salt text gives different keys
+		{
+			name:     "django pbkdf2_sha256",
+			hash:     "pbkdf2_sha256$1000$c2FsdA$/WytJJjODwOlQ1RVnEQ2Ww07VAuysFr2g9vSSOAeCUU=",
+			password: "correct horse",
+			want:     true,
+		},
+		{
+			name:     "passlib pbkdf2-sha256",
+			hash:     "$pbkdf2-sha256$1000$c2FsdA$boRToEKoQr22LeDTyHDsEkHpDsDOTyWJq16HknEiweU",
+			password: "correct horse",
+			want:     true,
+		},
+		{
+			name:     "passlib key with a django salt",
+			hash:     "pbkdf2_sha256$1000$c2FsdA$boRToEKoQr22LeDTyHDsEkHpDsDOTyWJq16HknEiweU=",
+			password: "correct horse",
+			want:     false,
+		},
+		{
+			name:     "django pbkdf2_sha1",
+			hash:     "pbkdf2_sha1$1000$seasalt$iQvkNOF1wEL4Khh8eogJ8rUhipM=",
+			password: "correct horse",
+			want:     true,
+		},
+		{
+			name:     "passlib pbkdf2 with adapted base64",
+			hash:     "$pbkdf2$131000$....AQID./8QIDBAUGBwgA$NuOswyEs1gUyGw6bAcfAQFEf.8U",
+			password: "correct horse",
+			want:     true,
+		},
+		{
+			name:     "passlib pbkdf2-sha512",
+			hash:     "$pbkdf2-sha512$25000$....AQID./8QIDBAUGBwgA$R//LwuSHwpffZM4FCWrUlI8ciZO/WuXiEAA8W31RX5CE55CTAmiVLZm/fBmbw0r3VTouOXr4erpL79WwjFKvhA",
+			password: "correct horse",
+			want:     true,
+		},
+		// Test vector of the reference implementation of argon2
+		{
+			name:     "argon2id",
+			hash:     "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+			password: "password",
+			want:     true,
+		},
+		{
+			name:     "argon2id with the wrong password",
+			hash:     "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+			password: "Password",
+			want:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := passhash.Validate(test.hash); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			got, err := passhash.Verify(test.hash, test.password)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if got != test.want {
+				t.Errorf("Verify() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "unknown scheme", hash: "$1$salt$checksum"},
+		{name: "empty", hash: ""},
+		{name: "sha-crypt without checksum", hash: "$5$salt$"},
+		{name: "sha-crypt without salt separator", hash: "$5$saltandchecksum"},
+		{name: "sha-crypt salt too long", hash: "$5$saltstringsaltstring$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA"},
+		{name: "sha-crypt rounds not a number", hash: "$5$rounds=many$salt$checksum"},
+		{name: "sha-crypt rounds without salt", hash: "$5$rounds=5000"},
+		{name: "sha-crypt extra field", hash: "$6$salt$checksum$extra"},
+		{name: "pbkdf2 zero iterations", hash: "pbkdf2_sha256$0$seasalt$mQnueSakb748zqBAC1tmWVZsZbi2zPGZarEzTGdfmso="},
+		{name: "pbkdf2 missing key", hash: "pbkdf2_sha256$1000$seasalt"},
+		{name: "pbkdf2 empty key", hash: "pbkdf2_sha256$1000$seasalt$"},
+		{name: "pbkdf2 invalid base64 key", hash: "pbkdf2_sha256$1000$seasalt$not base64!"},
+		{name: "passlib invalid salt", hash: "$pbkdf2-sha256$1000$c2F*dA$boRToEKoQr22LeDTyHDsEkHpDsDOTyWJq16HknEiweU"},
+		{name: "argon2id wrong version", hash: "$argon2id$v=16$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{name: "argon2id zero iterations", hash: "$argon2id$v=19$m=65536,t=0,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{name: "argon2id missing parameters", hash: "$argon2id$v=19$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{name: "argon2id key too short", hash: "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTE"},
+		{name: "pbkdf2 too many iterations", hash: "pbkdf2_sha256$5000001$seasalt$mQnueSakb748zqBAC1tmWVZsZbi2zPGZarEzTGdfmso="},
+		{name: "passlib too many iterations", hash: "$pbkdf2-sha256$2147483647$c2FsdA$boRToEKoQr22LeDTyHDsEkHpDsDOTyWJq16HknEiweU"},
+		{name: "pbkdf2 key too long", hash: "pbkdf2_sha1$1000$seasalt$" + strings.Repeat("A", 88)},
+		{name: "argon2id too much memory", hash: "$argon2id$v=19$m=1048577,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{name: "argon2id memory out of range", hash: "$argon2id$v=19$m=4294967296,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{name: "argon2id too many iterations", hash: "$argon2id$v=19$m=64,t=65,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{name: "argon2id too costly", hash: "$argon2id$v=19$m=1048576,t=5,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{name: "argon2id too many threads", hash: "$argon2id$v=19$m=65536,t=2,p=65$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{name: "argon2id key too long", hash: "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$" + strings.Repeat("A", 172)},
+		{name: "argon2id padded base64", hash: "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ=$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := passhash.Validate(test.hash); !errors.Is(err, passhash.ErrUnsupportedHash) {
+				t.Errorf("Validate() error = %v, want ErrUnsupportedHash", err)
+			}
+			if _, err := passhash.Verify(test.hash, "password"); !errors.Is(err, passhash.ErrUnsupportedHash) {
+				t.Errorf("Verify() error = %v, want ErrUnsupportedHash", err)
+			}
+		})
+	}
+}
+
+func TestValidateClampsRounds(t *testing.T) {
+	// Out of range rounds are clamped rather than rejected, as by the reference implementation
+	for _, hash := range []string{
+		"$5$rounds=1$salt$checksum",
+		"$6$rounds=1000000000$salt$checksum",
+	} {
+		if err := passhash.Validate(hash); err != nil {
+			t.Errorf("Validate(%q) error = %v", hash, err)
+		}
+	}
+}
+
+func TestHashers(t *testing.T) {
+	argon2id, err := passhash.NewArgon2idHasher(64, 1, 1)
+	if err != nil {
+		t.Fatalf("NewArgon2idHasher() error = %v", err)
+	}
+	bcrypt, err := passhash.NewBcryptHasher(4)
+	if err != nil {
+		t.Fatalf("NewBcryptHasher() error = %v", err)
+	}
+
+	for _, hasher := range []passhash.Hasher{argon2id, bcrypt} {
+		t.Run(hasher.Name(), func(t *testing.T) {
+			hash, err := hasher.Hash("password")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if scheme, err := passhash.Identify(hash); err != nil || scheme.Name() != hasher.Name() {
+				t.Errorf("Identify() = %v, %v, want %s", scheme, err, hasher.Name())
+			}
+			if ok, err := passhash.Verify(hash, "password"); err != nil || !ok {
+				t.Errorf("Verify() = %v, %v, want true", ok, err)
+			}
+			if ok, err := passhash.Verify(hash, "wrong"); err != nil || ok {
+				t.Errorf("Verify() of the wrong password = %v, %v, want false", ok, err)
+			}
+			if hasher.NeedsRehash(hash) {
+				t.Error("NeedsRehash() of a hash with the parameters of the hasher = true")
+			}
+		})
+	}
+
+	// A hash of another scheme or with other parameters needs to be rehashed
+	hash, err := bcrypt.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !argon2id.NeedsRehash(hash) {
+		t.Error("NeedsRehash() of a bcrypt hash = false")
+	}
+	stronger, _ := passhash.NewArgon2idHasher(128, 1, 1)
+	if hash, _ := argon2id.Hash("password"); !stronger.NeedsRehash(hash) {
+		t.Error("NeedsRehash() of a hash with less memory = false")
+	}
+	if _, err := passhash.NewArgon2idHasher(4, 1, 1); err == nil {
+		t.Error("NewArgon2idHasher() with less than 8 KiB per thread succeeded")
+	}
+	if _, err := passhash.NewArgon2idHasher(2<<20, 1, 1); err == nil {
+		t.Error("NewArgon2idHasher() with 2 GiB of memory succeeded")
+	}
+}
@@ -0,0 +1,113 @@
+package passhash
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// The iterations of the imported hashes are bounded so that a login doesn't take more than a few seconds, well
+	// above the defaults of Django and Passlib.
+	pbkdf2MaxIterations = 5000000
+	pbkdf2MaxKeyLength  = 64
+)
+
+// pbkdf2Digests are the digests of the supported PBKDF2 formats, by prefix.
+var pbkdf2Digests = map[string]func() hash.Hash{
+	// Django: pbkdf2_<digest>$<iterations>$<salt>$<base64 key>
+	"pbkdf2_sha1$":   sha1.New,
+	"pbkdf2_sha256$": sha256.New,
+	// Passlib: $pbkdf2[-<digest>]$<iterations>$<adapted base64 salt>$<adapted base64 key>
+	"$pbkdf2$":        sha1.New,
+	"$pbkdf2-sha256$": sha256.New,
+	"$pbkdf2-sha512$": sha512.New,
+}
+
+// pbkdf2Hash is a parsed PBKDF2 hash.
+type pbkdf2Hash struct {
+	digest     func() hash.Hash
+	iterations int
+	salt       []byte
+	key        []byte
+}
+
+// PBKDF2 verifies the PBKDF2 hashes of Django and Passlib, imported from other systems.
+type PBKDF2 struct{}
+
+// Name returns the name of the scheme.
+func (scheme *PBKDF2) Name() string {
+	return "pbkdf2"
+}
+
+// Identifies tells if a hash is a PBKDF2 one.
+func (scheme *PBKDF2) Identifies(hash string) bool {
+	for prefix := range pbkdf2Digests {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that a PBKDF2 hash can be parsed.
+func (scheme *PBKDF2) Validate(hash string) error {
+	_, err := parsePBKDF2(hash)
+	return err
+}
+
+// Verify checks a password against a PBKDF2 hash.
+func (scheme *PBKDF2) Verify(hash string, password string) (bool, error) {
+	parsed, err := parsePBKDF2(hash)
+	if err != nil {
+		return false, err
+	}
+
+	key := pbkdf2.Key([]byte(password), parsed.salt, parsed.iterations, len(parsed.key), parsed.digest)
+	return subtle.ConstantTimeCompare(key, parsed.key) == 1, nil
+}
+
+// parsePBKDF2 parses a PBKDF2 hash of Django or Passlib.
+func parsePBKDF2(hash string) (*pbkdf2Hash, error) {
+	for prefix, digest := range pbkdf2Digests {
+		if !strings.HasPrefix(hash, prefix) {
+			continue
+		}
+
+		parts := strings.Split(strings.TrimPrefix(hash, prefix), "$")
+		if len(parts) != 3 {
+			return nil, ErrUnsupportedHash
+		}
+		iterations, err := strconv.Atoi(parts[0])
+		if err != nil || iterations < 1 || iterations > pbkdf2MaxIterations {
+			return nil, ErrUnsupportedHash
+		}
+
+		parsed := &pbkdf2Hash{digest: digest, iterations: iterations}
+		if strings.HasPrefix(prefix, "$") {
+			// Passlib encodes the salt too, with "." in place of "+" and without padding
+			parsed.salt, err = base64.RawStdEncoding.DecodeString(strings.ReplaceAll(parts[1], ".", "+"))
+			if err != nil {
+				return nil, ErrUnsupportedHash
+			}
+			parsed.key, err = base64.RawStdEncoding.DecodeString(strings.ReplaceAll(parts[2], ".", "+"))
+		} else {
+			parsed.salt = []byte(parts[1])
+			parsed.key, err = base64.StdEncoding.DecodeString(parts[2])
+		}
+		if err != nil || len(parsed.key) == 0 || len(parsed.key) > pbkdf2MaxKeyLength {
+			return nil, ErrUnsupportedHash
+		}
+
+		return parsed, nil
+	}
+
+	return nil, ErrUnsupportedHash
+}
@@ -0,0 +1,198 @@
+package passhash
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+const (
+	shaCryptAlphabet      = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	shaCryptRoundsPrefix  = "rounds="
+	shaCryptDefaultRounds = 5000
+	shaCryptMinRounds     = 1000
+	shaCryptMaxRounds     = 999999999
+	shaCryptMaxSalt       = 16
+)
+
+// shaCryptVariant is a digest of SHA-crypt, with the order in which the bytes of the digest are encoded.
+type shaCryptVariant struct {
+	digest func() hash.Hash
+	order  [][]int
+}
+
+// shaCryptVariants are the SHA-256 and SHA-512 variants of SHA-crypt, by prefix.
+var shaCryptVariants = map[string]*shaCryptVariant{
+	"$5$": {digest: sha256.New, order: [][]int{
+		{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14}, {15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8},
+		{9, 19, 29}, {31, 30},
+	}},
+	"$6$": {digest: sha512.New, order: [][]int{
+		{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4}, {47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29},
+		{9, 30, 51}, {31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35}, {15, 36, 57}, {37, 58, 16},
+		{59, 17, 38}, {18, 39, 60}, {40, 61, 19}, {62, 20, 41}, {63},
+	}},
+}
+
+// shaCryptHash is a parsed SHA-crypt hash.
+type shaCryptHash struct {
+	variant  *shaCryptVariant
+	prefix   string
+	rounds   int
+	explicit bool
+	salt     string
+	checksum string
+}
+
+// SHACrypt verifies the SHA-crypt hashes of the Unix systems, $5$ for SHA-256 and $6$ for SHA-512, imported from
+// other systems: $<variant>$[rounds=<rounds>$]<salt>$<checksum>.
+type SHACrypt struct{}
+
+// Name returns the name of the scheme.
+func (scheme *SHACrypt) Name() string {
+	return "sha-crypt"
+}
+
+// Identifies tells if a hash is a SHA-crypt one.
+func (scheme *SHACrypt) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, "$5$") || strings.HasPrefix(hash, "$6$")
+}
+
+// Validate checks that a SHA-crypt hash can be parsed.
+func (scheme *SHACrypt) Validate(hash string) error {
+	_, err := parseSHACrypt(hash)
+	return err
+}
+
+// Verify checks a password against a SHA-crypt hash.
+func (scheme *SHACrypt) Verify(hash string, password string) (bool, error) {
+	parsed, err := parseSHACrypt(hash)
+	if err != nil {
+		return false, err
+	}
+
+	checksum := shaCrypt(parsed.variant, []byte(password), []byte(parsed.salt), parsed.rounds)
+	return subtle.ConstantTimeCompare([]byte(checksum), []byte(parsed.checksum)) == 1, nil
+}
+
+// parseSHACrypt parses a SHA-crypt hash.
+func parseSHACrypt(hash string) (*shaCryptHash, error) {
+	if len(hash) < 3 {
+		return nil, ErrUnsupportedHash
+	}
+	variant, ok := shaCryptVariants[hash[:3]]
+	if !ok {
+		return nil, ErrUnsupportedHash
+	}
+
+	parsed := &shaCryptHash{variant: variant, prefix: hash[:3], rounds: shaCryptDefaultRounds}
+	rest := hash[3:]
+	if strings.HasPrefix(rest, shaCryptRoundsPrefix) {
+		rounds, after, found := strings.Cut(strings.TrimPrefix(rest, shaCryptRoundsPrefix), "$")
+		value, err := strconv.Atoi(rounds)
+		if !found || err != nil {
+			return nil, ErrUnsupportedHash
+		}
+		// Out of range rounds are clamped, as by the reference implementation
+		parsed.rounds = min(max(value, shaCryptMinRounds), shaCryptMaxRounds)
+		parsed.explicit = true
+		rest = after
+	}
+
+	salt, checksum, found := strings.Cut(rest, "$")
+	if !found || len(salt) > shaCryptMaxSalt || checksum == "" || strings.Contains(checksum, "$") {
+		return nil, ErrUnsupportedHash
+	}
+	parsed.salt = salt
+	parsed.checksum = checksum
+
+	return parsed, nil
+}
+
+// shaCrypt computes the checksum of a password, as described by the SHA-crypt specification of Ulrich Drepper.
+func shaCrypt(variant *shaCryptVariant, password []byte, salt []byte, rounds int) string {
+	// Digest B of password, salt, password
+	digest := variant.digest()
+	digest.Write(password)
+	digest.Write(salt)
+	digest.Write(password)
+	b := digest.Sum(nil)
+
+	// Digest A of password, salt, B repeated for the length of the password, and a mix of B and the password driven
+	// by the bits of the length of the password
+	digest = variant.digest()
+	digest.Write(password)
+	digest.Write(salt)
+	digest.Write(repeatBytes(b, len(password)))
+	for length := len(password); length > 0; length >>= 1 {
+		if length&1 != 0 {
+			digest.Write(b)
+		} else {
+			digest.Write(password)
+		}
+	}
+	a := digest.Sum(nil)
+
+	// Sequence P of the password digest, and sequence S of the salt digest, as long as the password and the salt
+	digest = variant.digest()
+	for range password {
+		digest.Write(password)
+	}
+	p := repeatBytes(digest.Sum(nil), len(password))
+
+	digest = variant.digest()
+	for range 16 + int(a[0]) {
+		digest.Write(salt)
+	}
+	s := repeatBytes(digest.Sum(nil), len(salt))
+
+	// Rounds
+	c := a
+	for round := range rounds {
+		digest = variant.digest()
+		if round&1 != 0 {
+			digest.Write(p)
+		} else {
+			digest.Write(c)
+		}
+		if round%3 != 0 {
+			digest.Write(s)
+		}
+		if round%7 != 0 {
+			digest.Write(p)
+		}
+		if round&1 != 0 {
+			digest.Write(c)
+		} else {
+			digest.Write(p)
+		}
+		c = digest.Sum(nil)
+	}
+
+	// Encoding of the bytes of the final digest, three at a time in the order of the variant
+	var checksum strings.Builder
+	for _, group := range variant.order {
+		var value uint32
+		for _, index := range group {
+			value = value<<8 | uint32(c[index])
+		}
+		for range len(group) + 1 {
+			checksum.WriteByte(shaCryptAlphabet[value&0x3f])
+			value >>= 6
+		}
+	}
+
+	return checksum.String()
+}
+
+// repeatBytes repeats a sequence of bytes up to the given length.
+func repeatBytes(sequence []byte, length int) []byte {
+	repeated := make([]byte, 0, length)
+	for len(repeated) < length {
+		repeated = append(repeated, sequence[:min(len(sequence), length-len(repeated))]...)
+	}
+	return repeated
+}
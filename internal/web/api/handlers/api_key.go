@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"net/http"
+
+	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler defines the interface for API-key-related HTTP handlers.
+// @title APIKeyHandler Interface
+// @description Interface for handling API-key-related HTTP requests.
+type APIKeyHandler interface {
+	GetAll(c *gin.Context)
+	GetUserKeys(c *gin.Context)
+	CreateServiceAccountKey(c *gin.Context)
+	Revoke(c *gin.Context)
+	GetOwn(c *gin.Context)
+	CreateOwn(c *gin.Context)
+	RevokeOwn(c *gin.Context)
+}
+
+// APIKeyHandlerImplementation handles HTTP requests for managing the API keys and personal access tokens.
+type APIKeyHandlerImplementation struct {
+	apiKeyService services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new instance of the APIKeyHandlerImplementation.
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandlerImplementation {
+	return &APIKeyHandlerImplementation{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// GetAll retrieves a page of the API keys of every user.
+// @Summary Get all API keys
+// @Description Get a page of the API keys and personal access tokens of every user, filtered with parameters such as user_id=, name~= (contains), expires_before= and last_used_before=
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.APIKey
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /api-keys [get]
+func (handler *APIKeyHandlerImplementation) GetAll(c *gin.Context) {
+	listQuery, ok := bindListQuery(c, repositories.APIKeyListFields)
+	if !ok {
+		return
+	}
+
+	apiKeys, pageInfo, err := handler.apiKeyService.GetAll(listQuery)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+// GetUserKeys retrieves the API keys of a user.
+// @Summary Get the API keys of a user
+// @Description Get the API keys of a service account, or the personal access tokens of a user
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {array} models.APIKey
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users/{id}/api-keys [get]
+func (handler *APIKeyHandlerImplementation) GetUserKeys(c *gin.Context) {
+	uid, ok := bindID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	apiKeys, err := handler.apiKeyService.GetUserKeys(uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+// CreateServiceAccountKey creates an API key for a service account.
+// @Summary Create an API key for a service account
+// @Description Create an API key authenticating as a service account, restricted to the given scopes. The key is only returned by this request.
+// @Tags api-keys
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service account ID"
+// @Param name formData string true "Name describing what the key is used for"
+// @Param scopes formData []string true "Permissions the key is restricted to" collectionFormat(multi)
+// @Param expires_at formData string false "RFC 3339 expiration date (default API_KEY_DEFAULT_TTL from now)"
+// @Success 201 {object} dtos.APIKeyTokenDTO
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users/{id}/api-keys [post]
+func (handler *APIKeyHandlerImplementation) CreateServiceAccountKey(c *gin.Context) {
+	uid, ok := bindID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	var apiKeyDTO dtos.CreateAPIKeyDTO
+	if err := c.ShouldBind(&apiKeyDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	apiKey, err := handler.apiKeyService.CreateServiceAccountKey(requestContext(c), uid, &apiKeyDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiKey)
+}
+
+// Revoke revokes any API key.
+// @Summary Revoke an API key
+// @Description Revoke an API key or a personal access token of any user, it stops working immediately
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /api-keys/{id} [delete]
+func (handler *APIKeyHandlerImplementation) Revoke(c *gin.Context) {
+	kid, ok := bindID(c, "id", "API key")
+	if !ok {
+		return
+	}
+
+	if err := handler.apiKeyService.Revoke(requestContext(c), kid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetOwn retrieves the personal access tokens of the authenticated user.
+// @Summary Get my personal access tokens
+// @Description Get the personal access tokens of the authenticated user
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.APIKey
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /me/api-keys [get]
+func (handler *APIKeyHandlerImplementation) GetOwn(c *gin.Context) {
+	apiKeys, err := handler.apiKeyService.GetUserKeys(c.GetUint("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+// CreateOwn creates a personal access token for the authenticated user.
+// @Summary Create a personal access token
+// @Description Create a token authenticating as the authenticated user, restricted to the given scopes, for scripts and CI. The token is only returned by this request.
+// @Tags me
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param name formData string true "Name describing what the token is used for"
+// @Param scopes formData []string true "Permissions the token is restricted to" collectionFormat(multi)
+// @Param expires_at formData string false "RFC 3339 expiration date (default API_KEY_DEFAULT_TTL from now)"
+// @Success 201 {object} dtos.APIKeyTokenDTO
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Router /me/api-keys [post]
+func (handler *APIKeyHandlerImplementation) CreateOwn(c *gin.Context) {
+	var apiKeyDTO dtos.CreateAPIKeyDTO
+	if err := c.ShouldBind(&apiKeyDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	apiKey, err := handler.apiKeyService.Create(requestContext(c), c.GetUint("userID"), &apiKeyDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiKey)
+}
+
+// RevokeOwn revokes a personal access token of the authenticated user.
+// @Summary Revoke a personal access token
+// @Description Revoke one of the authenticated user's personal access tokens, it stops working immediately
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /me/api-keys/{id} [delete]
+func (handler *APIKeyHandlerImplementation) RevokeOwn(c *gin.Context) {
+	kid, ok := bindID(c, "id", "API key")
+	if !ok {
+		return
+	}
+
+	if err := handler.apiKeyService.RevokeUserKey(requestContext(c), c.GetUint("userID"), kid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
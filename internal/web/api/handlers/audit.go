@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler defines the interface for audit-log-related HTTP handlers.
+// @title AuditHandler Interface
+// @description Interface for handling audit-log-related HTTP requests.
+type AuditHandler interface {
+	GetAll(c *gin.Context)
+}
+
+// AuditHandlerImplementation handles HTTP requests for reading the audit log.
+type AuditHandlerImplementation struct {
+	auditService services.AuditService
+}
+
+// NewAuditHandler creates a new instance of the AuditHandlerImplementation.
+func NewAuditHandler(auditService services.AuditService) *AuditHandlerImplementation {
+	return &AuditHandlerImplementation{
+		auditService: auditService,
+	}
+}
+
+// GetAll retrieves a page of audit events.
+// @Summary Get the audit log
+// @Description Get a page of audit events, filtered with parameters such as action=, actor_id=, target_type=, target_id=, ip=, created_after= and created_before=
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.AuditEvent
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /audit [get]
+func (handler *AuditHandlerImplementation) GetAll(c *gin.Context) {
+	listQuery, ok := bindListQuery(c, repositories.AuditEventListFields)
+	if !ok {
+		return
+	}
+
+	auditEvents, pageInfo, err := handler.auditService.GetAll(listQuery)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, auditEvents)
+}
@@ -13,7 +13,13 @@ import (
 // @description Interface for handling user-authentication-related HTTP requests.
 type AuthHandler interface {
 	Login(c *gin.Context)
+	EnrollMFA(c *gin.Context)
+	VerifyMFA(c *gin.Context)
+	RenewPassword(c *gin.Context)
+	Refresh(c *gin.Context)
 	Signup(c *gin.Context)
+	Logout(c *gin.Context)
+	RevokeUserSessions(c *gin.Context)
 }
 
 // UserHandlerImplementation handles HTTP requests for CRUD operations against the user model.
@@ -30,30 +36,156 @@ func NewAuthHandler(authService services.AuthService) *AuthHandlerImplementation
 
 // Login authenticates a user.
 // @Summary Authenticate a user
-// @Description Authenticate a user with their username and password
+// @Description Authenticate a user with their username and password. Users with a second factor, or required to enroll one, get a challenge to answer through /auth/mfa/verify instead of the tokens.
 // @Tags auth
 // @Accept mpfd
 // @Produce json
 // @Param name formData string true "Username"
 // @Param password formData string true "Password"
-// @Success 200 {object} gin.H "JWT Token"
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
+// @Success 200 {object} dtos.TokenDTO "JWT and refresh tokens"
+// @Success 202 {object} dtos.MFAChallengeDTO "Second factor required"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Email address isn't verified, or password expired with the password_token allowing to renew it"
+// @Failure 429 {object} dtos.ProblemDTO "Too many failed attempts"
 // @Router /auth/login [post]
 func (handler *AuthHandlerImplementation) Login(c *gin.Context) {
 	var loginDTO dtos.LoginDTO
 	if err := c.ShouldBind(&loginDTO); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindError(err))
 		return
 	}
 
-	token, err := handler.authService.Login(&loginDTO)
+	tokens, challenge, err := handler.authService.Login(requestContext(c), &loginDTO)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	if challenge != nil {
+		c.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RenewPassword replaces an expired password during a login.
+// @Summary Replace an expired password during a login
+// @Description Replace the expired password of a user with the token returned by the login, and go on with the login. Users with a second factor, or required to enroll one, get a challenge to answer through /auth/mfa/verify instead of the tokens.
+// @Tags auth
+// @Accept mpfd
+// @Produce json
+// @Param password_token formData string true "Password token returned by the login"
+// @Param password formData string true "New password"
+// @Param password_confirmation formData string true "New password confirmation"
+// @Success 200 {object} dtos.TokenDTO "JWT and refresh tokens"
+// @Success 202 {object} dtos.MFAChallengeDTO "Second factor required"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request, or password failing the password policy along with its violations"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Router /auth/password/renew [post]
+func (handler *AuthHandlerImplementation) RenewPassword(c *gin.Context) {
+	var renewPasswordDTO dtos.RenewPasswordDTO
+	if err := c.ShouldBind(&renewPasswordDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	tokens, challenge, err := handler.authService.RenewPassword(requestContext(c), &renewPasswordDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if challenge != nil {
+		c.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// EnrollMFA starts the TOTP enrollment required to complete a login.
+// @Summary Enroll in TOTP during a login
+// @Description Generate the TOTP secret of a user whose login challenge requires an enrollment. The login is then completed through /auth/mfa/verify with a code of the authenticator app.
+// @Tags auth
+// @Accept mpfd
+// @Produce json
+// @Param mfa_token formData string true "Challenge token returned by the login"
+// @Success 200 {object} dtos.TOTPEnrollmentDTO "TOTP secret and otpauth URI"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Router /auth/mfa/enroll [post]
+func (handler *AuthHandlerImplementation) EnrollMFA(c *gin.Context) {
+	var mfaEnrollDTO dtos.MFAEnrollDTO
+	if err := c.ShouldBind(&mfaEnrollDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	enrollment, err := handler.authService.EnrollMFA(requestContext(c), &mfaEnrollDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// VerifyMFA completes a login with a second factor.
+// @Summary Complete a login with a second factor
+// @Description Answer a login challenge with a TOTP code or a recovery code. When the challenge required an enrollment, the TOTP is activated and the recovery codes are returned along with the tokens.
+// @Tags auth
+// @Accept mpfd
+// @Produce json
+// @Param mfa_token formData string true "Challenge token returned by the login"
+// @Param code formData string true "TOTP code or recovery code"
+// @Success 200 {object} dtos.TokenDTO "JWT and refresh tokens"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 429 {object} dtos.ProblemDTO "Too many failed attempts"
+// @Router /auth/mfa/verify [post]
+func (handler *AuthHandlerImplementation) VerifyMFA(c *gin.Context) {
+	var mfaVerifyDTO dtos.MFAVerifyDTO
+	if err := c.ShouldBind(&mfaVerifyDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	tokens, err := handler.authService.VerifyMFA(requestContext(c), &mfaVerifyDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh exchanges a refresh token for new tokens.
+// @Summary Refresh the authentication tokens
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token can only be used once.
+// @Tags auth
+// @Accept mpfd
+// @Produce json
+// @Param refresh_token formData string true "Refresh token"
+// @Success 200 {object} dtos.TokenDTO "JWT and refresh tokens"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Router /auth/refresh [post]
+func (handler *AuthHandlerImplementation) Refresh(c *gin.Context) {
+	var refreshDTO dtos.RefreshDTO
+	if err := c.ShouldBind(&refreshDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	tokens, err := handler.authService.Refresh(requestContext(c), &refreshDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
 }
 
 // Signup creates a new user.
@@ -67,20 +199,80 @@ func (handler *AuthHandlerImplementation) Login(c *gin.Context) {
 // @Param password formData string true "Password"
 // @Param password_confirmation formData string true "Password confirmation"
 // @Success 201
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 429 {object} dtos.ProblemDTO "Too many attempts"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
 // @Router /auth/signup [post]
 func (handler *AuthHandlerImplementation) Signup(c *gin.Context) {
 	var signupDTO dtos.SignupDTO
 	if err := c.ShouldBind(&signupDTO); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindError(err))
 		return
 	}
 
-	if err := handler.authService.Signup(&signupDTO); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := handler.authService.Signup(requestContext(c), &signupDTO); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.Status(http.StatusCreated)
 }
+
+// Logout revokes the current session.
+// @Summary Log out
+// @Description Revoke the access token used for the request and, when provided, the associated refresh token
+// @Tags auth
+// @Accept mpfd
+// @Security BearerAuth
+// @Param refresh_token formData string false "Refresh token"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /auth/logout [post]
+func (handler *AuthHandlerImplementation) Logout(c *gin.Context) {
+	var logoutDTO dtos.LogoutDTO
+	if err := c.ShouldBind(&logoutDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*services.AccessTokenClaims)
+	if !ok {
+		c.Error(services.NewUnauthorizedError(services.CodeInvalidToken, "invalid token claims"))
+		return
+	}
+
+	if err := handler.authService.Logout(requestContext(c), claims, &logoutDTO); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeUserSessions revokes every session of a user.
+// @Summary Revoke all sessions of a user
+// @Description Invalidate every access token and refresh token issued to a user
+// @Tags users
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users/{id}/sessions [delete]
+func (handler *AuthHandlerImplementation) RevokeUserSessions(c *gin.Context) {
+	uid, ok := bindID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	if err := handler.authService.RevokeSessions(requestContext(c), uid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/bulk"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// BulkHandler defines the interface for bulk-import-and-export-related HTTP handlers.
+// @title BulkHandler Interface
+// @description Interface for handling bulk import and export HTTP requests.
+type BulkHandler interface {
+	Import(c *gin.Context)
+	Export(c *gin.Context)
+}
+
+// BulkHandlerImplementation handles HTTP requests for importing and exporting users, groups and memberships in bulk.
+type BulkHandlerImplementation struct {
+	bulkService services.BulkService
+}
+
+// NewBulkHandler creates a new instance of the BulkHandlerImplementation.
+func NewBulkHandler(bulkService services.BulkService) *BulkHandlerImplementation {
+	return &BulkHandlerImplementation{
+		bulkService: bulkService,
+	}
+}
+
+// Import imports users, groups and memberships from a CSV or JSON file.
+// @Summary Import users, groups and memberships
+// @Description Import users, groups and memberships from a JSON file holding groups and users, or from a CSV file holding users with the name, email, password, password_hash, service_account and groups columns, groups being separated by semicolons. Every row is validated, and the report tells what happened to each of them.
+// @Tags directory
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV or JSON file"
+// @Param format formData string false "Format of the file, guessed from its extension by default" Enums(csv, json)
+// @Param dry_run formData bool false "Only validate the rows, without importing them"
+// @Param atomic formData bool false "Import every row or none of them"
+// @Success 200 {object} dtos.ImportReportDTO
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /directory/import [post]
+func (handler *BulkHandlerImplementation) Import(c *gin.Context) {
+	var importDTO dtos.ImportOptionsDTO
+	if err := c.ShouldBind(&importDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+	if importDTO.Format == "" {
+		importDTO.Format = bulk.FormatOf(importDTO.File.Filename)
+	}
+
+	file, err := importDTO.File.Open()
+	if err != nil {
+		c.Error(services.NewFieldValidationError(services.CodeInvalidRequest, "file", "the file can't be read: %v", err))
+		return
+	}
+	defer file.Close()
+
+	directory, err := bulk.Decode(file, importDTO.Format)
+	if err != nil {
+		c.Error(services.NewFieldValidationError(services.CodeInvalidRequest, "file", "%v", err))
+		return
+	}
+
+	report, err := handler.bulkService.Import(requestContext(c), directory, importDTO.DryRun, importDTO.Atomic)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Export exports every user, group and membership to a CSV or JSON file.
+// @Summary Export users, groups and memberships
+// @Description Export every user, group and membership as a JSON file that can be imported back, or as a CSV file holding the users and their groups. Password hashes are left out unless asked for.
+// @Tags directory
+// @Produce json
+// @Produce text/csv
+// @Security BearerAuth
+// @Param format query string false "Format of the file (default json)" Enums(csv, json)
+// @Param include_password_hashes query bool false "Include the password hashes of the users"
+// @Success 200 {object} dtos.DirectoryDTO
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /directory/export [get]
+func (handler *BulkHandlerImplementation) Export(c *gin.Context) {
+	var exportDTO dtos.ExportOptionsDTO
+	if err := c.ShouldBindQuery(&exportDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+	if exportDTO.Format == "" {
+		exportDTO.Format = bulk.FormatJSON
+	}
+
+	directory, err := handler.bulkService.Export(requestContext(c), exportDTO.IncludePasswordHashes)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var file bytes.Buffer
+	if err := bulk.Encode(&file, exportDTO.Format, directory); err != nil {
+		c.Error(err)
+		return
+	}
+
+	contentType := "application/json"
+	if exportDTO.Format == bulk.FormatCSV {
+		contentType = "text/csv"
+	}
+	c.Header("Content-Disposition", `attachment; filename="gods-export.`+exportDTO.Format+`"`)
+	c.Data(http.StatusOK, contentType, file.Bytes())
+}
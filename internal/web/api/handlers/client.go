@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"net/http"
+
+	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientHandler defines the interface for OpenID-Connect-client-related HTTP handlers.
+// @title ClientHandler Interface
+// @description Interface for handling OpenID-Connect-client-related HTTP requests.
+type ClientHandler interface {
+	Get(c *gin.Context)
+	GetAll(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	RotateSecret(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+// ClientHandlerImplementation handles HTTP requests for CRUD operations against the client model.
+type ClientHandlerImplementation struct {
+	clientService services.ClientService
+}
+
+// NewClientHandler creates a new instance of the ClientHandlerImplementation.
+func NewClientHandler(clientService services.ClientService) *ClientHandlerImplementation {
+	return &ClientHandlerImplementation{
+		clientService: clientService,
+	}
+}
+
+// Get retrieves a client by ID.
+// @Summary Get a client by ID
+// @Description Get details of an OpenID Connect client by its ID
+// @Tags clients
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Success 200 {object} models.Client
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /clients/{id} [get]
+func (handler *ClientHandlerImplementation) Get(c *gin.Context) {
+	cid, ok := bindID(c, "id", "client")
+	if !ok {
+		return
+	}
+
+	client, err := handler.clientService.Get(cid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, client)
+}
+
+// GetAll retrieves a page of clients.
+// @Summary Get all clients
+// @Description Get a page of OpenID Connect clients, filtered with parameters such as name=, name~= (contains), client_id=, created_after= and created_before=
+// @Tags clients
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.Client
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /clients [get]
+func (handler *ClientHandlerImplementation) GetAll(c *gin.Context) {
+	listQuery, ok := bindListQuery(c, repositories.ClientListFields)
+	if !ok {
+		return
+	}
+
+	clients, pageInfo, err := handler.clientService.GetAll(listQuery)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, clients)
+}
+
+// Create registers a new client.
+// @Summary Register a new client
+// @Description Register an OpenID Connect client. The secret of confidential clients is only returned by this request.
+// @Tags clients
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param name formData string true "Client name"
+// @Param redirect_uris formData []string false "Redirect URIs, required for the authorization_code grant" collectionFormat(multi)
+// @Param grant_types formData []string false "Grant types, authorization_code and/or client_credentials (default authorization_code)" collectionFormat(multi)
+// @Param scopes formData []string false "Scopes the client can request (default openid, profile, email and groups)" collectionFormat(multi)
+// @Param public formData bool false "Public client, without secret and required to use PKCE"
+// @Success 201 {object} dtos.ClientSecretDTO
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Router /clients [post]
+func (handler *ClientHandlerImplementation) Create(c *gin.Context) {
+	var clientDTO dtos.CreateClientDTO
+	if err := c.ShouldBind(&clientDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	client, err := handler.clientService.Create(requestContext(c), &clientDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, client)
+}
+
+// Update updates an existing client.
+// @Summary Update an existing client
+// @Description Update an OpenID Connect client, the lists provided replace the current ones
+// @Tags clients
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Param name formData string false "Client name"
+// @Param redirect_uris formData []string false "Redirect URIs" collectionFormat(multi)
+// @Param grant_types formData []string false "Grant types" collectionFormat(multi)
+// @Param scopes formData []string false "Scopes the client can request" collectionFormat(multi)
+// @Success 200 {object} models.Client
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /clients/{id} [put]
+func (handler *ClientHandlerImplementation) Update(c *gin.Context) {
+	cid, ok := bindID(c, "id", "client")
+	if !ok {
+		return
+	}
+
+	var clientDTO dtos.UpdateClientDTO
+	if err := c.ShouldBind(&clientDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	client, err := handler.clientService.Get(cid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := handler.clientService.Update(requestContext(c), client, &clientDTO); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, client)
+}
+
+// RotateSecret replaces the secret of a client.
+// @Summary Rotate the secret of a client
+// @Description Generate a new secret for a confidential OpenID Connect client, the previous one stops working immediately
+// @Tags clients
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Success 200 {object} dtos.ClientSecretDTO
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /clients/{id}/secret [post]
+func (handler *ClientHandlerImplementation) RotateSecret(c *gin.Context) {
+	cid, ok := bindID(c, "id", "client")
+	if !ok {
+		return
+	}
+
+	client, err := handler.clientService.RotateSecret(requestContext(c), cid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, client)
+}
+
+// Delete removes a client.
+// @Summary Delete a client
+// @Description Remove an OpenID Connect client
+// @Tags clients
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /clients/{id} [delete]
+func (handler *ClientHandlerImplementation) Delete(c *gin.Context) {
+	cid, ok := bindID(c, "id", "client")
+	if !ok {
+		return
+	}
+
+	if err := handler.clientService.Delete(requestContext(c), cid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
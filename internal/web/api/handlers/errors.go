@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterFieldNames makes the validation errors name the fields as the clients send them, after their form or
+// JSON names, rather than after the fields of the DTOs.
+func RegisterFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"form", "json"} {
+			if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+// bindError converts the error of a request binding into a ValidationError listing the invalid fields.
+func bindError(err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return services.NewValidationError(services.CodeInvalidRequest, err.Error())
+	}
+
+	fields := make([]services.FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, services.FieldError{
+			Field:   fieldErr.Field(),
+			Code:    fieldErr.Tag(),
+			Message: fieldMessage(fieldErr),
+		})
+	}
+
+	return services.NewValidationError(services.CodeInvalidRequest, "the request has invalid fields", fields...)
+}
+
+// fieldMessage describes the validation rule a field fails.
+func fieldMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fieldErr.Field())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", fieldErr.Field())
+	case "url":
+		return fmt.Sprintf("%s must be a valid URL", fieldErr.Field())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", fieldErr.Field(), fieldErr.Param())
+	default:
+		return fmt.Sprintf("%s is invalid", fieldErr.Field())
+	}
+}
+
+// bindID parses an ID from the path of the request. It adds a ValidationError to the context and returns false when
+// the ID is invalid.
+func bindID(c *gin.Context, param string, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.Error(services.NewFieldValidationError(services.CodeInvalidRequest, param, "invalid %s ID", name))
+		return 0, false
+	}
+
+	return uint(id), true
+}
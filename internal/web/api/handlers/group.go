@@ -2,9 +2,9 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
 	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
 	"github.com/Nokeni/GODS/internal/web/api/services"
 	"github.com/Nokeni/GODS/internal/web/common/dtos"
 	"github.com/gin-gonic/gin"
@@ -19,6 +19,9 @@ type GroupHandler interface {
 	Create(c *gin.Context)
 	Update(c *gin.Context)
 	Delete(c *gin.Context)
+	GetAllDeleted(c *gin.Context)
+	Restore(c *gin.Context)
+	Purge(c *gin.Context)
 }
 
 // GroupHandlerImplementation handles HTTP requests for operations against the user's groups.
@@ -41,46 +44,57 @@ func NewGroupHandler(groupService services.GroupService) *GroupHandlerImplementa
 // @Security BearerAuth
 // @Param id path int true "Group ID"
 // @Success 200 {object} models.Group
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /groups/{id} [get]
 func (handler *GroupHandlerImplementation) Get(c *gin.Context) {
-	id := c.Param("id")
-
-	// Convert id from string to uint
-	gid, err := strconv.ParseUint(id, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+	gid, ok := bindID(c, "id", "group")
+	if !ok {
 		return
 	}
 
-	group, err := handler.groupService.Get(uint(gid))
+	group, err := handler.groupService.Get(gid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, group)
 }
 
-// GetAll retrieves all groups.
+// GetAll retrieves a page of groups.
 // @Summary Get all groups
-// @Description Get a list of all groups
+// @Description Get a page of groups, filtered with parameters such as name=, name~= (contains), description~=, created_after= and created_before=
 // @Tags groups
 // @Produce json
 // @Security BearerAuth
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
 // @Success 200 {array} models.Group
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
 // @Router /groups [get]
 func (handler *GroupHandlerImplementation) GetAll(c *gin.Context) {
-	groups, err := handler.groupService.GetAll()
+	listQuery, ok := bindListQuery(c, repositories.GroupListFields)
+	if !ok {
+		return
+	}
+
+	groups, pageInfo, err := handler.groupService.GetAll(listQuery)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	setPageHeaders(c, pageInfo)
 	c.JSON(http.StatusOK, groups)
 }
 
@@ -94,20 +108,21 @@ func (handler *GroupHandlerImplementation) GetAll(c *gin.Context) {
 // @Param name formData string true "Group name"
 // @Param description formData string false "Group description"
 // @Success 200 {object} models.Group
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 409 {object} dtos.ProblemDTO "Name already taken"
 // @Router /groups [post]
 func (handler *GroupHandlerImplementation) Create(c *gin.Context) {
 	var groupDTO dtos.CreateGroupDTO
 	if err := c.ShouldBind(&groupDTO); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindError(err))
 		return
 	}
 
-	group, err := handler.groupService.Create(&groupDTO)
+	group, err := handler.groupService.Create(requestContext(c), &groupDTO)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -125,34 +140,32 @@ func (handler *GroupHandlerImplementation) Create(c *gin.Context) {
 // @Param name formData string false "Group name"
 // @Param description formData string false "Group description"
 // @Success 200 {object} models.Group
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 409 {object} dtos.ProblemDTO "Name already taken"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /groups/{id} [put]
 func (handler *GroupHandlerImplementation) Update(c *gin.Context) {
-	id := c.Param("id")
-
-	// Convert id from string to uint
-	gid, err := strconv.ParseUint(id, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+	gid, ok := bindID(c, "id", "group")
+	if !ok {
 		return
 	}
 
 	var groupDTO dtos.UpdateGroupDTO
 	if err := c.ShouldBind(&groupDTO); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindError(err))
 		return
 	}
 
-	group, err := handler.groupService.Get(uint(gid))
+	group, err := handler.groupService.Get(gid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	if err := handler.groupService.Update(group, &groupDTO); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := handler.groupService.Update(requestContext(c), group, &groupDTO); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -166,22 +179,105 @@ func (handler *GroupHandlerImplementation) Update(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path int true "Group ID"
 // @Success 204
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /groups/{id} [delete]
 func (handler *GroupHandlerImplementation) Delete(c *gin.Context) {
-	id := c.Param("id")
+	gid, ok := bindID(c, "id", "group")
+	if !ok {
+		return
+	}
+
+	if err := handler.groupService.Delete(requestContext(c), gid); err != nil {
+		c.Error(err)
+		return
+	}
 
-	// Convert id from string to uint
-	gid, err := strconv.ParseUint(id, 10, 32)
+	c.Status(http.StatusNoContent)
+}
+
+// GetAllDeleted retrieves a page of deleted groups.
+// @Summary Get deleted groups
+// @Description Get a page of the deleted groups, which can be restored until they're purged, filtered with parameters such as name=, name~= (contains), deleted_after= and deleted_before=
+// @Tags groups
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.Group
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /groups/deleted [get]
+func (handler *GroupHandlerImplementation) GetAllDeleted(c *gin.Context) {
+	listQuery, ok := bindListQuery(c, repositories.DeletedGroupListFields)
+	if !ok {
+		return
+	}
+
+	groups, pageInfo, err := handler.groupService.GetAllDeleted(listQuery)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, groups)
+}
+
+// Restore undeletes a deleted group.
+// @Summary Restore a deleted group
+// @Description Undelete a deleted group, along with its memberships, subgroups and roles
+// @Tags groups
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Success 200 {object} models.Group
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /groups/{id}/restore [post]
+func (handler *GroupHandlerImplementation) Restore(c *gin.Context) {
+	gid, ok := bindID(c, "id", "group")
+	if !ok {
+		return
+	}
+
+	group, err := handler.groupService.Restore(requestContext(c), gid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// Purge permanently removes a deleted group.
+// @Summary Purge a deleted group
+// @Description Permanently remove a deleted group and its memberships, subgroups and roles, which frees their name. Only deleted groups can be purged.
+// @Tags groups
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /groups/{id}/purge [delete]
+func (handler *GroupHandlerImplementation) Purge(c *gin.Context) {
+	gid, ok := bindID(c, "id", "group")
+	if !ok {
 		return
 	}
 
-	if err := handler.groupService.Delete(uint(gid)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := handler.groupService.Purge(requestContext(c), gid); err != nil {
+		c.Error(err)
 		return
 	}
 
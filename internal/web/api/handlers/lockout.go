@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// LockoutHandler defines the interface for lockout-related HTTP handlers.
+// @title LockoutHandler Interface
+// @description Interface for handling lockout-related HTTP requests.
+type LockoutHandler interface {
+	GetAll(c *gin.Context)
+	Unlock(c *gin.Context)
+}
+
+// LockoutHandlerImplementation handles HTTP requests for managing the locked accounts and IPs.
+type LockoutHandlerImplementation struct {
+	lockoutService services.LockoutService
+}
+
+// NewLockoutHandler creates a new instance of the LockoutHandlerImplementation.
+func NewLockoutHandler(lockoutService services.LockoutService) *LockoutHandlerImplementation {
+	return &LockoutHandlerImplementation{
+		lockoutService: lockoutService,
+	}
+}
+
+// GetAll retrieves the locked accounts and IPs.
+// @Summary Get the lockouts
+// @Description Get the accounts and the login, signup and email IPs currently locked after too many attempts
+// @Tags lockouts
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.LoginAttempt
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /lockouts [get]
+func (handler *LockoutHandlerImplementation) GetAll(c *gin.Context) {
+	loginAttempts, err := handler.lockoutService.GetLocked()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loginAttempts)
+}
+
+// Unlock lifts a lockout.
+// @Summary Unlock an account or an IP
+// @Description Lift the lockout of an account or of a login, signup or email IP and reset its failed attempts counter
+// @Tags lockouts
+// @Produce json
+// @Security BearerAuth
+// @Param scope path string true "Lockout scope (account, ip, signup or email)"
+// @Param value path string true "Account name or IP"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Router /lockouts/{scope}/{value} [delete]
+func (handler *LockoutHandlerImplementation) Unlock(c *gin.Context) {
+	key := services.LockoutKey{Scope: c.Param("scope"), Value: c.Param("value")}
+	if err := handler.lockoutService.Unlock(requestContext(c), key); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+
+	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// MeHandler defines the interface for the authenticated user's self-service HTTP handlers.
+// @title MeHandler Interface
+// @description Interface for handling HTTP requests of users against their own account.
+type MeHandler interface {
+	Get(c *gin.Context)
+	Update(c *gin.Context)
+	ChangePassword(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+// MeHandlerImplementation handles HTTP requests of the authenticated user against their own account.
+type MeHandlerImplementation struct {
+	userService services.UserService
+}
+
+// NewMeHandler creates a new instance of the MeHandlerImplementation.
+func NewMeHandler(userService services.UserService) *MeHandlerImplementation {
+	return &MeHandlerImplementation{
+		userService: userService,
+	}
+}
+
+// Get retrieves the authenticated user.
+// @Summary Get the authenticated user
+// @Description Get the profile of the authenticated user with their groups
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.User
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /me [get]
+func (handler *MeHandlerImplementation) Get(c *gin.Context) {
+	user, err := handler.userService.Get(c.GetUint("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// Update modifies the authenticated user's profile.
+// @Summary Update the authenticated user
+// @Description Update the name and email of the authenticated user
+// @Tags me
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param name formData string false "Username"
+// @Param email formData string false "Email"
+// @Success 200 {object} models.User
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 409 {object} dtos.ProblemDTO "Name already taken"
+// @Router /me [patch]
+func (handler *MeHandlerImplementation) Update(c *gin.Context) {
+	var profileDTO dtos.UpdateProfileDTO
+	if err := c.ShouldBind(&profileDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	user, err := handler.userService.Get(c.GetUint("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := handler.userService.Update(requestContext(c), user, &dtos.UpdateUserDTO{Name: profileDTO.Name, Email: profileDTO.Email}); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword modifies the authenticated user's password.
+// @Summary Change the authenticated user's password
+// @Description Change the password of the authenticated user, who must provide their current password
+// @Tags me
+// @Accept mpfd
+// @Security BearerAuth
+// @Param current_password formData string true "Current password"
+// @Param password formData string true "New password"
+// @Param password_confirmation formData string true "New password confirmation"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /me/password [put]
+func (handler *MeHandlerImplementation) ChangePassword(c *gin.Context) {
+	var changePasswordDTO dtos.ChangePasswordDTO
+	if err := c.ShouldBind(&changePasswordDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	user, err := handler.userService.Get(c.GetUint("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := handler.userService.ChangePassword(requestContext(c), user, &changePasswordDTO); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Delete removes the authenticated user.
+// @Summary Delete the authenticated user
+// @Description Remove the account of the authenticated user from the system
+// @Tags me
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /me [delete]
+func (handler *MeHandlerImplementation) Delete(c *gin.Context) {
+	if err := handler.userService.Delete(requestContext(c), c.GetUint("userID")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
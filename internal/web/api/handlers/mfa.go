@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// MFAHandler defines the interface for two-factor-authentication-related HTTP handlers.
+// @title MFAHandler Interface
+// @description Interface for handling two-factor-authentication-related HTTP requests.
+type MFAHandler interface {
+	EnrollTOTP(c *gin.Context)
+	ActivateTOTP(c *gin.Context)
+	DisableTOTP(c *gin.Context)
+	RegenerateRecoveryCodes(c *gin.Context)
+	ResetUserMFA(c *gin.Context)
+}
+
+// MFAHandlerImplementation handles HTTP requests for managing the users' second factor.
+type MFAHandlerImplementation struct {
+	mfaService services.MFAService
+}
+
+// NewMFAHandler creates a new instance of the MFAHandlerImplementation.
+func NewMFAHandler(mfaService services.MFAService) *MFAHandlerImplementation {
+	return &MFAHandlerImplementation{
+		mfaService: mfaService,
+	}
+}
+
+// EnrollTOTP starts the TOTP enrollment of the authenticated user.
+// @Summary Enroll in TOTP
+// @Description Generate a TOTP secret for the authenticated user. It only takes effect once verified with a code of the authenticator app.
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dtos.TOTPEnrollmentDTO "TOTP secret and otpauth URI"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Router /me/mfa/totp [post]
+func (handler *MFAHandlerImplementation) EnrollTOTP(c *gin.Context) {
+	enrollment, err := handler.mfaService.EnrollTOTP(requestContext(c), c.GetUint("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ActivateTOTP verifies the TOTP enrollment of the authenticated user.
+// @Summary Verify the TOTP enrollment
+// @Description Enable the TOTP of the authenticated user with a code of the authenticator app and get the one-time recovery codes
+// @Tags me
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param code formData string true "TOTP code"
+// @Success 200 {object} dtos.RecoveryCodesDTO "Recovery codes, only shown once"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Router /me/mfa/totp/verify [post]
+func (handler *MFAHandlerImplementation) ActivateTOTP(c *gin.Context) {
+	var totpCodeDTO dtos.TOTPCodeDTO
+	if err := c.ShouldBind(&totpCodeDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	recoveryCodes, err := handler.mfaService.ActivateTOTP(requestContext(c), c.GetUint("userID"), totpCodeDTO.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recoveryCodes)
+}
+
+// DisableTOTP disables the TOTP of the authenticated user.
+// @Summary Disable TOTP
+// @Description Disable the TOTP of the authenticated user, unless their groups require a second factor
+// @Tags me
+// @Accept mpfd
+// @Security BearerAuth
+// @Param code formData string true "TOTP code or recovery code"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Router /me/mfa/totp [delete]
+func (handler *MFAHandlerImplementation) DisableTOTP(c *gin.Context) {
+	var totpCodeDTO dtos.TOTPCodeDTO
+	if err := c.ShouldBind(&totpCodeDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	if err := handler.mfaService.DisableTOTP(requestContext(c), c.GetUint("userID"), totpCodeDTO.Code); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the authenticated user.
+// @Summary Regenerate the recovery codes
+// @Description Replace the recovery codes of the authenticated user, the previous ones can't be used anymore
+// @Tags me
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param code formData string true "TOTP code or recovery code"
+// @Success 200 {object} dtos.RecoveryCodesDTO "Recovery codes, only shown once"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Router /me/mfa/recovery-codes [post]
+func (handler *MFAHandlerImplementation) RegenerateRecoveryCodes(c *gin.Context) {
+	var totpCodeDTO dtos.TOTPCodeDTO
+	if err := c.ShouldBind(&totpCodeDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	recoveryCodes, err := handler.mfaService.RegenerateRecoveryCodes(requestContext(c), c.GetUint("userID"), totpCodeDTO.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recoveryCodes)
+}
+
+// ResetUserMFA removes the second factor of a user.
+// @Summary Reset the second factor of a user
+// @Description Remove the TOTP and the recovery codes of a user who lost them. Users whose groups require a second factor will have to enroll again at their next login.
+// @Tags users
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users/{id}/mfa [delete]
+func (handler *MFAHandlerImplementation) ResetUserMFA(c *gin.Context) {
+	uid, ok := bindID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	if err := handler.mfaService.Reset(requestContext(c), uid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// ssoCookieName is the name of the cookie holding the session of the authorization endpoint.
+const ssoCookieName = "gods_sso"
+
+//go:embed templates
+var templatesFS embed.FS
+
+// authorizeTemplate is the login page of the authorization endpoint.
+var authorizeTemplate = template.Must(template.ParseFS(templatesFS, "templates/authorize.html"))
+
+// authorizePage is the data of the login page of the authorization endpoint.
+type authorizePage struct {
+	Client  *models.Client
+	Request *dtos.AuthorizeDTO
+	Name    string
+	Error   string
+}
+
+// OIDCHandler defines the interface for the OpenID Connect provider HTTP handlers.
+// @title OIDCHandler Interface
+// @description Interface for handling the OpenID Connect provider HTTP requests.
+type OIDCHandler interface {
+	Discovery(c *gin.Context)
+	Authorize(c *gin.Context)
+	AuthorizeLogin(c *gin.Context)
+	Token(c *gin.Context)
+	UserInfo(c *gin.Context)
+}
+
+// OIDCHandlerImplementation handles the OpenID Connect provider HTTP requests.
+type OIDCHandlerImplementation struct {
+	oidcService services.OIDCService
+	authService services.AuthService
+}
+
+// NewOIDCHandler creates a new instance of the OIDCHandlerImplementation.
+func NewOIDCHandler(oidcService services.OIDCService, authService services.AuthService) *OIDCHandlerImplementation {
+	return &OIDCHandlerImplementation{
+		oidcService: oidcService,
+		authService: authService,
+	}
+}
+
+// Discovery returns the OpenID Connect discovery document.
+// @Summary OpenID Connect discovery
+// @Description Get the OpenID Connect provider configuration
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} dtos.DiscoveryDTO
+// @Router /.well-known/openid-configuration [get]
+func (handler *OIDCHandlerImplementation) Discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, handler.oidcService.Discovery())
+}
+
+// Authorize starts an authorization code flow.
+// @Summary Authorization endpoint
+// @Description Start the authorization code flow. Users logged in to GODS are sent back to the client right away, the others get a login page.
+// @Tags oidc
+// @Produce html
+// @Param response_type query string true "code"
+// @Param client_id query string true "Client identifier"
+// @Param redirect_uri query string false "Registered redirect URI, optional when the client has only one"
+// @Param scope query string true "Space-separated scopes, including openid"
+// @Param state query string false "Opaque value sent back to the client"
+// @Param nonce query string false "Value included in the ID token"
+// @Param code_challenge query string false "PKCE challenge, required for public clients"
+// @Param code_challenge_method query string false "S256 or plain"
+// @Param prompt query string false "login to force a new login, none to fail instead of showing the login page"
+// @Success 200 "Login page"
+// @Success 302 "Redirection to the client"
+// @Failure 400 "Invalid client or redirect URI"
+// @Router /oauth2/authorize [get]
+func (handler *OIDCHandlerImplementation) Authorize(c *gin.Context) {
+	var authorizeDTO dtos.AuthorizeDTO
+	if err := c.ShouldBindQuery(&authorizeDTO); err != nil {
+		handler.renderAuthorize(c, http.StatusBadRequest, &authorizePage{Error: err.Error()})
+		return
+	}
+
+	client, err := handler.oidcService.CheckAuthorizeRequest(&authorizeDTO)
+	if err != nil {
+		handler.authorizeError(c, &authorizeDTO, err)
+		return
+	}
+
+	if authorizeDTO.Prompt != "login" {
+		if sessionToken, err := c.Cookie(ssoCookieName); err == nil {
+			if user, authTime, err := handler.oidcService.GetSession(sessionToken); err == nil {
+				handler.authorize(c, &authorizeDTO, user, authTime)
+				return
+			}
+		}
+		if authorizeDTO.Prompt == "none" {
+			handler.authorizeError(c, &authorizeDTO, &services.OAuthError{Code: services.OAuthLoginRequired, Description: "the user isn't logged in"})
+			return
+		}
+	}
+
+	handler.renderAuthorize(c, http.StatusOK, &authorizePage{Client: client, Request: &authorizeDTO})
+}
+
+// AuthorizeLogin logs a user in from the login page of the authorization endpoint.
+// @Summary Authorization endpoint login
+// @Description Log in from the login page of the authorization endpoint and get sent back to the client
+// @Tags oidc
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param name formData string true "Username"
+// @Param password formData string true "Password"
+// @Param code formData string false "TOTP code or recovery code"
+// @Success 302 "Redirection to the client"
+// @Failure 400 "Invalid client or redirect URI"
+// @Failure 401 "Login page with the error"
+// @Failure 429 "Login page with the error"
+// @Router /oauth2/authorize [post]
+func (handler *OIDCHandlerImplementation) AuthorizeLogin(c *gin.Context) {
+	var authorizeLoginDTO dtos.AuthorizeLoginDTO
+	if err := c.ShouldBind(&authorizeLoginDTO); err != nil {
+		handler.renderAuthorize(c, http.StatusBadRequest, &authorizePage{Error: err.Error()})
+		return
+	}
+
+	authorizeDTO := &authorizeLoginDTO.AuthorizeDTO
+	client, err := handler.oidcService.CheckAuthorizeRequest(authorizeDTO)
+	if err != nil {
+		handler.authorizeError(c, authorizeDTO, err)
+		return
+	}
+
+	loginDTO := &dtos.LoginDTO{Name: authorizeLoginDTO.Name, Password: authorizeLoginDTO.Password}
+	user, err := handler.authService.Authenticate(requestContext(c), loginDTO, authorizeLoginDTO.Code)
+	if err != nil {
+		status := http.StatusUnauthorized
+		var lockedError *services.LockedError
+		if errors.As(err, &lockedError) {
+			status = http.StatusTooManyRequests
+		}
+		handler.renderAuthorize(c, status, &authorizePage{Client: client, Request: authorizeDTO, Name: authorizeLoginDTO.Name, Error: err.Error()})
+		return
+	}
+
+	sessionToken, err := handler.oidcService.CreateSession(user)
+	if err != nil {
+		handler.renderAuthorize(c, http.StatusInternalServerError, &authorizePage{Error: serverError(c, err)})
+		return
+	}
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoCookieName, sessionToken, 0, "/oauth2", "", secure, true)
+
+	handler.authorize(c, authorizeDTO, user, time.Now())
+}
+
+// Token exchanges an authorization code or client credentials for tokens.
+// @Summary Token endpoint
+// @Description Exchange an authorization code, or the credentials of a client for the client_credentials grant, for tokens. Clients authenticate with HTTP Basic or with the client_id and client_secret parameters.
+// @Tags oidc
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code or client_credentials"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI the code was sent to"
+// @Param code_verifier formData string false "PKCE verifier"
+// @Param client_id formData string false "Client identifier"
+// @Param client_secret formData string false "Client secret"
+// @Param scope formData string false "Space-separated scopes of the client_credentials grant"
+// @Success 200 {object} dtos.OIDCTokenDTO
+// @Failure 400 {object} gin.H "OAuth2 error"
+// @Failure 401 {object} gin.H "Invalid client"
+// @Router /oauth2/token [post]
+func (handler *OIDCHandlerImplementation) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var tokenRequestDTO dtos.TokenRequestDTO
+	if err := c.ShouldBind(&tokenRequestDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.OAuthInvalidRequest, "error_description": err.Error()})
+		return
+	}
+
+	// The credentials of HTTP Basic are form-encoded (RFC 6749 section 2.3.1)
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		tokenRequestDTO.ClientID, _ = url.QueryUnescape(clientID)
+		tokenRequestDTO.ClientSecret, _ = url.QueryUnescape(clientSecret)
+	}
+
+	tokens, err := handler.oidcService.Token(requestContext(c), &tokenRequestDTO)
+	if err != nil {
+		var oauthError *services.OAuthError
+		if !errors.As(err, &oauthError) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": serverError(c, err)})
+			return
+		}
+
+		status := http.StatusBadRequest
+		if oauthError.Code == services.OAuthInvalidClient {
+			status = http.StatusUnauthorized
+			c.Header("WWW-Authenticate", `Basic realm="GODS"`)
+		}
+		c.JSON(status, gin.H{"error": oauthError.Code, "error_description": oauthError.Description})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// UserInfo returns the claims of the user an access token was issued to.
+// @Summary UserInfo endpoint
+// @Description Get the claims of the user an OpenID Connect access token was issued to, as allowed by its scopes
+// @Tags oidc
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} gin.H "Invalid token"
+// @Router /oauth2/userinfo [get]
+func (handler *OIDCHandlerImplementation) UserInfo(c *gin.Context) {
+	accessToken, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		c.Header("WWW-Authenticate", `Bearer realm="GODS"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": services.OAuthInvalidToken, "error_description": "missing access token"})
+		return
+	}
+
+	userInfo, err := handler.oidcService.UserInfo(accessToken)
+	if err != nil {
+		var oauthError *services.OAuthError
+		if !errors.As(err, &oauthError) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": serverError(c, err)})
+			return
+		}
+		c.Header("WWW-Authenticate", `Bearer realm="GODS", error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": services.OAuthInvalidToken, "error_description": oauthError.Description})
+		return
+	}
+
+	c.JSON(http.StatusOK, userInfo)
+}
+
+// authorize issues an authorization code and sends the user back to the client.
+func (handler *OIDCHandlerImplementation) authorize(c *gin.Context, authorizeDTO *dtos.AuthorizeDTO, user *models.User, authTime time.Time) {
+	redirectURL, err := handler.oidcService.Authorize(requestContext(c), authorizeDTO, user, authTime)
+	if err != nil {
+		handler.authorizeError(c, authorizeDTO, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// authorizeError sends the OAuth2 errors back to the client, and shows the other ones to the user
+// since the client or its redirect URI can't be trusted.
+func (handler *OIDCHandlerImplementation) authorizeError(c *gin.Context, authorizeDTO *dtos.AuthorizeDTO, err error) {
+	var oauthError *services.OAuthError
+	if errors.As(err, &oauthError) {
+		c.Redirect(http.StatusFound, handler.oidcService.AuthorizeErrorURL(authorizeDTO, oauthError))
+		return
+	}
+
+	handler.renderAuthorize(c, http.StatusBadRequest, &authorizePage{Error: err.Error()})
+}
+
+// serverError logs an unexpected error and returns the description shown in its place, so that the details of
+// the internal errors aren't disclosed.
+func serverError(c *gin.Context, err error) string {
+	log.Printf("%s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+	return "an internal error occurred"
+}
+
+// renderAuthorize renders the login page of the authorization endpoint, which mustn't be framed by other sites.
+func (handler *OIDCHandlerImplementation) renderAuthorize(c *gin.Context, status int, page *authorizePage) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := authorizeTemplate.Execute(c.Writer, page); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"github.com/gin-gonic/gin"
+)
+
+// bindListQuery parses the pagination, sorting and filtering query parameters of the request.
+// It adds a ValidationError to the context and returns false when they're invalid.
+func bindListQuery(c *gin.Context, fields query.Fields) (*query.ListQuery, bool) {
+	listQuery, err := query.Parse(c.Request.URL.Query(), fields)
+	if err != nil {
+		c.Error(services.NewValidationError(services.CodeInvalidRequest, err.Error()))
+		return nil, false
+	}
+
+	return listQuery, true
+}
+
+// setPageHeaders adds the total count, the next cursor and the links to the other pages to the response headers.
+func setPageHeaders(c *gin.Context, pageInfo *query.PageInfo) {
+	c.Header("X-Total-Count", strconv.FormatInt(pageInfo.Total, 10))
+
+	if pageInfo.NextCursor != "" {
+		c.Header("X-Next-Cursor", pageInfo.NextCursor)
+	}
+
+	if links := pageInfo.Links(c.Request.URL); links != "" {
+		c.Header("Link", links)
+	}
+}
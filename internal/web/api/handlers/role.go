@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"net/http"
+
+	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// RoleHandler defines the interface for role-related HTTP handlers.
+// @title RoleHandler Interface
+// @description Interface for handling role-related and permission-related HTTP requests.
+type RoleHandler interface {
+	Get(c *gin.Context)
+	GetAll(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	GetPermissions(c *gin.Context)
+	AddPermissionToRole(c *gin.Context)
+	RemovePermissionFromRole(c *gin.Context)
+	AddRoleToGroup(c *gin.Context)
+	RemoveRoleFromGroup(c *gin.Context)
+}
+
+// RoleHandlerImplementation handles HTTP requests for operations against the roles and their permissions.
+type RoleHandlerImplementation struct {
+	roleService services.RoleService
+}
+
+// NewRoleHandler creates a new instance of the RoleHandlerImplementation.
+func NewRoleHandler(roleService services.RoleService) *RoleHandlerImplementation {
+	return &RoleHandlerImplementation{
+		roleService: roleService,
+	}
+}
+
+// Get retrieves a role by ID.
+// @Summary Get a role by ID
+// @Description Get details of a role, its permissions and its groups by its ID
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} models.Role
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /roles/{id} [get]
+func (handler *RoleHandlerImplementation) Get(c *gin.Context) {
+	rid, ok := bindID(c, "id", "role")
+	if !ok {
+		return
+	}
+
+	role, err := handler.roleService.Get(rid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// GetAll retrieves all roles.
+// @Summary Get all roles
+// @Description Get a list of all roles with their permissions
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Role
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /roles [get]
+func (handler *RoleHandlerImplementation) GetAll(c *gin.Context) {
+	roles, err := handler.roleService.GetAll()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// Create creates a new role.
+// @Summary Create a new role
+// @Description Create a new role in the system
+// @Tags roles
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param name formData string true "Role name"
+// @Param description formData string false "Role description"
+// @Success 201 {object} models.Role
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /roles [post]
+func (handler *RoleHandlerImplementation) Create(c *gin.Context) {
+	var roleDTO dtos.CreateRoleDTO
+	if err := c.ShouldBind(&roleDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	role, err := handler.roleService.Create(requestContext(c), &roleDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// Update updates an existing role.
+// @Summary Update an existing role
+// @Description Update an existing role in the system
+// @Tags roles
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param name formData string false "Role name"
+// @Param description formData string false "Role description"
+// @Success 200 {object} models.Role
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /roles/{id} [put]
+func (handler *RoleHandlerImplementation) Update(c *gin.Context) {
+	rid, ok := bindID(c, "id", "role")
+	if !ok {
+		return
+	}
+
+	var roleDTO dtos.UpdateRoleDTO
+	if err := c.ShouldBind(&roleDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	role, err := handler.roleService.Get(rid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := handler.roleService.Update(requestContext(c), role, &roleDTO); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Delete removes a role.
+// @Summary Delete a role
+// @Description Remove a role from the system
+// @Tags roles
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /roles/{id} [delete]
+func (handler *RoleHandlerImplementation) Delete(c *gin.Context) {
+	rid, ok := bindID(c, "id", "role")
+	if !ok {
+		return
+	}
+
+	if err := handler.roleService.Delete(requestContext(c), rid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPermissions retrieves all permissions.
+// @Summary Get all permissions
+// @Description Get a list of all the permissions that can be granted to roles
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Permission
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /permissions [get]
+func (handler *RoleHandlerImplementation) GetPermissions(c *gin.Context) {
+	permissions, err := handler.roleService.GetPermissions()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+// AddPermissionToRole grants a permission to a role.
+// @Summary Grant a permission to a role
+// @Description Grant a permission to a role by their IDs
+// @Tags roles
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param permissionId path int true "Permission ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /roles/{id}/permissions/{permissionId} [post]
+func (handler *RoleHandlerImplementation) AddPermissionToRole(c *gin.Context) {
+	rid, ok := bindID(c, "id", "role")
+	if !ok {
+		return
+	}
+
+	pid, ok := bindID(c, "permissionId", "permission")
+	if !ok {
+		return
+	}
+
+	if err := handler.roleService.AddPermissionToRole(requestContext(c), rid, pid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemovePermissionFromRole revokes a permission from a role.
+// @Summary Revoke a permission from a role
+// @Description Revoke a permission from a role by their IDs
+// @Tags roles
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param permissionId path int true "Permission ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /roles/{id}/permissions/{permissionId} [delete]
+func (handler *RoleHandlerImplementation) RemovePermissionFromRole(c *gin.Context) {
+	rid, ok := bindID(c, "id", "role")
+	if !ok {
+		return
+	}
+
+	pid, ok := bindID(c, "permissionId", "permission")
+	if !ok {
+		return
+	}
+
+	if err := handler.roleService.RemovePermissionFromRole(requestContext(c), rid, pid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddRoleToGroup grants a role to a group.
+// @Summary Grant a role to a group
+// @Description Grant a role to a group by their IDs, giving its permissions to every member of the group
+// @Tags roles
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param groupId path int true "Group ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /roles/{id}/groups/{groupId} [post]
+func (handler *RoleHandlerImplementation) AddRoleToGroup(c *gin.Context) {
+	rid, ok := bindID(c, "id", "role")
+	if !ok {
+		return
+	}
+
+	gid, ok := bindID(c, "groupId", "group")
+	if !ok {
+		return
+	}
+
+	if err := handler.roleService.AddRoleToGroup(requestContext(c), rid, gid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveRoleFromGroup revokes a role from a group.
+// @Summary Revoke a role from a group
+// @Description Revoke a role from a group by their IDs
+// @Tags roles
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param groupId path int true "Group ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /roles/{id}/groups/{groupId} [delete]
+func (handler *RoleHandlerImplementation) RemoveRoleFromGroup(c *gin.Context) {
+	rid, ok := bindID(c, "id", "role")
+	if !ok {
+		return
+	}
+
+	gid, ok := bindID(c, "groupId", "group")
+	if !ok {
+		return
+	}
+
+	if err := handler.roleService.RemoveRoleFromGroup(requestContext(c), rid, gid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,655 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"github.com/Nokeni/GODS/internal/web/common/scim"
+	"github.com/gin-gonic/gin"
+)
+
+// scimContentType is the media type of the SCIM requests and responses.
+const scimContentType = "application/scim+json"
+
+// SCIMHandler defines the interface for the SCIM 2.0 provisioning HTTP handlers.
+// @title SCIMHandler Interface
+// @description Interface for handling the SCIM 2.0 provisioning HTTP requests.
+type SCIMHandler interface {
+	ServiceProviderConfig(c *gin.Context)
+	ResourceTypes(c *gin.Context)
+	ResourceType(c *gin.Context)
+	Schemas(c *gin.Context)
+	Schema(c *gin.Context)
+	GetUsers(c *gin.Context)
+	GetUser(c *gin.Context)
+	CreateUser(c *gin.Context)
+	ReplaceUser(c *gin.Context)
+	PatchUser(c *gin.Context)
+	DeleteUser(c *gin.Context)
+	GetGroups(c *gin.Context)
+	GetGroup(c *gin.Context)
+	CreateGroup(c *gin.Context)
+	ReplaceGroup(c *gin.Context)
+	PatchGroup(c *gin.Context)
+	DeleteGroup(c *gin.Context)
+}
+
+// SCIMHandlerImplementation handles the SCIM 2.0 provisioning HTTP requests.
+type SCIMHandlerImplementation struct {
+	scimService services.SCIMService
+}
+
+// NewSCIMHandler creates a new instance of the SCIMHandlerImplementation.
+func NewSCIMHandler(scimService services.SCIMService) *SCIMHandlerImplementation {
+	return &SCIMHandlerImplementation{
+		scimService: scimService,
+	}
+}
+
+// ServiceProviderConfig returns the SCIM features supported by GODS.
+// @Summary SCIM service provider configuration
+// @Description Get the SCIM features supported by GODS
+// @Tags scim
+// @Produce json
+// @Success 200 {object} dtos.SCIMServiceProviderConfigDTO
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (handler *SCIMHandlerImplementation) ServiceProviderConfig(c *gin.Context) {
+	scimJSON(c, http.StatusOK, handler.scimService.ServiceProviderConfig())
+}
+
+// ResourceTypes returns the types of the SCIM resources.
+// @Summary SCIM resource types
+// @Description Get the types of the SCIM resources, User and Group
+// @Tags scim
+// @Produce json
+// @Success 200 {object} dtos.SCIMListResponseDTO
+// @Router /scim/v2/ResourceTypes [get]
+func (handler *SCIMHandlerImplementation) ResourceTypes(c *gin.Context) {
+	resourceTypes := handler.scimService.ResourceTypes()
+	resources := make([]any, len(resourceTypes))
+	for i, resourceType := range resourceTypes {
+		resources[i] = resourceType
+	}
+	scimJSON(c, http.StatusOK, scimListResponse(resources, len(resources), 1))
+}
+
+// ResourceType returns a type of SCIM resources.
+// @Summary SCIM resource type
+// @Description Get a type of SCIM resources by name
+// @Tags scim
+// @Produce json
+// @Param id path string true "Resource type name"
+// @Success 200 {object} dtos.SCIMResourceTypeDTO
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Router /scim/v2/ResourceTypes/{id} [get]
+func (handler *SCIMHandlerImplementation) ResourceType(c *gin.Context) {
+	for _, resourceType := range handler.scimService.ResourceTypes() {
+		if resourceType.ID == c.Param("id") {
+			scimJSON(c, http.StatusOK, resourceType)
+			return
+		}
+	}
+	scimErrorJSON(c, http.StatusNotFound, "", "resource type not found")
+}
+
+// Schemas returns the schemas of the SCIM resources.
+// @Summary SCIM schemas
+// @Description Get the schemas of the SCIM resources, restricted to the attributes GODS stores
+// @Tags scim
+// @Produce json
+// @Success 200 {object} dtos.SCIMListResponseDTO
+// @Router /scim/v2/Schemas [get]
+func (handler *SCIMHandlerImplementation) Schemas(c *gin.Context) {
+	schemas := handler.scimService.Schemas()
+	resources := make([]any, len(schemas))
+	for i, schema := range schemas {
+		resources[i] = schema
+	}
+	scimJSON(c, http.StatusOK, scimListResponse(resources, len(resources), 1))
+}
+
+// Schema returns a schema of the SCIM resources.
+// @Summary SCIM schema
+// @Description Get a schema of the SCIM resources by URN
+// @Tags scim
+// @Produce json
+// @Param id path string true "Schema URN"
+// @Success 200 {object} dtos.SCIMSchemaDTO
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Router /scim/v2/Schemas/{id} [get]
+func (handler *SCIMHandlerImplementation) Schema(c *gin.Context) {
+	for _, schema := range handler.scimService.Schemas() {
+		if schema.ID == c.Param("id") {
+			scimJSON(c, http.StatusOK, schema)
+			return
+		}
+	}
+	scimErrorJSON(c, http.StatusNotFound, "", "schema not found")
+}
+
+// GetUsers retrieves a page of the SCIM users.
+// @Summary Get SCIM users
+// @Description Get a page of the users matching a SCIM filter, such as userName eq "bob"
+// @Tags scim
+// @Produce json
+// @Security BearerAuth
+// @Param filter query string false "SCIM filter"
+// @Param startIndex query int false "1-based index of the first result (default 1)"
+// @Param count query int false "Maximum number of results (default and max 500)"
+// @Param attributes query string false "Comma-separated attributes to return"
+// @Param excludedAttributes query string false "Comma-separated attributes not to return"
+// @Success 200 {object} dtos.SCIMListResponseDTO
+// @Failure 400 {object} dtos.SCIMErrorDTO "Bad request"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Users [get]
+func (handler *SCIMHandlerImplementation) GetUsers(c *gin.Context) {
+	startIndex, count, ok := scimPagination(c)
+	if !ok {
+		return
+	}
+
+	users, total, err := handler.scimService.GetUsers(c.Query("filter"), startIndex, count)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	resources := make([]any, len(users))
+	for i, user := range users {
+		resources[i] = user
+	}
+	scimList(c, resources, total, startIndex)
+}
+
+// GetUser retrieves a SCIM user.
+// @Summary Get SCIM user
+// @Description Get a user by ID
+// @Tags scim
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param attributes query string false "Comma-separated attributes to return"
+// @Param excludedAttributes query string false "Comma-separated attributes not to return"
+// @Success 200 {object} dtos.SCIMUserDTO
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Users/{id} [get]
+func (handler *SCIMHandlerImplementation) GetUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+
+	user, err := handler.scimService.GetUser(id)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	scimResource(c, http.StatusOK, user)
+}
+
+// CreateUser provisions a SCIM user.
+// @Summary Create SCIM user
+// @Description Provision a user. Users provisioned without a password get a random one.
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user body dtos.SCIMUserDTO true "User"
+// @Success 201 {object} dtos.SCIMUserDTO
+// @Header 201 {string} Location "URL of the user"
+// @Failure 400 {object} dtos.SCIMErrorDTO "Bad request"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 409 {object} dtos.SCIMErrorDTO "Conflict"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Users [post]
+func (handler *SCIMHandlerImplementation) CreateUser(c *gin.Context) {
+	var userDTO dtos.SCIMUserDTO
+	if !bindSCIM(c, &userDTO) {
+		return
+	}
+
+	user, err := handler.scimService.CreateUser(requestContext(c), &userDTO)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	c.Header("Location", user.Meta.Location)
+	scimResource(c, http.StatusCreated, user)
+}
+
+// ReplaceUser replaces a SCIM user.
+// @Summary Replace SCIM user
+// @Description Replace the attributes of a user. Setting active to false deletes the user.
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param user body dtos.SCIMUserDTO true "User"
+// @Success 200 {object} dtos.SCIMUserDTO
+// @Failure 400 {object} dtos.SCIMErrorDTO "Bad request"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Failure 409 {object} dtos.SCIMErrorDTO "Conflict"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Users/{id} [put]
+func (handler *SCIMHandlerImplementation) ReplaceUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var userDTO dtos.SCIMUserDTO
+	if !bindSCIM(c, &userDTO) {
+		return
+	}
+
+	user, err := handler.scimService.ReplaceUser(requestContext(c), id, &userDTO)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	scimResource(c, http.StatusOK, user)
+}
+
+// PatchUser modifies a SCIM user.
+// @Summary Patch SCIM user
+// @Description Modify the attributes of a user with PATCH operations. Setting active to false deletes the user.
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param patch body dtos.SCIMPatchDTO true "PATCH operations"
+// @Success 200 {object} dtos.SCIMUserDTO
+// @Failure 400 {object} dtos.SCIMErrorDTO "Bad request"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Failure 409 {object} dtos.SCIMErrorDTO "Conflict"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Users/{id} [patch]
+func (handler *SCIMHandlerImplementation) PatchUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var patchDTO dtos.SCIMPatchDTO
+	if !bindSCIM(c, &patchDTO) {
+		return
+	}
+
+	user, err := handler.scimService.PatchUser(requestContext(c), id, &patchDTO)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	scimResource(c, http.StatusOK, user)
+}
+
+// DeleteUser deprovisions a SCIM user.
+// @Summary Delete SCIM user
+// @Description Deprovision a user
+// @Tags scim
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 204 "No content"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Users/{id} [delete]
+func (handler *SCIMHandlerImplementation) DeleteUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+
+	if err := handler.scimService.DeleteUser(requestContext(c), id); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetGroups retrieves a page of the SCIM groups.
+// @Summary Get SCIM groups
+// @Description Get a page of the groups matching a SCIM filter, such as displayName eq "admins"
+// @Tags scim
+// @Produce json
+// @Security BearerAuth
+// @Param filter query string false "SCIM filter"
+// @Param startIndex query int false "1-based index of the first result (default 1)"
+// @Param count query int false "Maximum number of results (default and max 500)"
+// @Param attributes query string false "Comma-separated attributes to return"
+// @Param excludedAttributes query string false "Comma-separated attributes not to return, such as members"
+// @Success 200 {object} dtos.SCIMListResponseDTO
+// @Failure 400 {object} dtos.SCIMErrorDTO "Bad request"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Groups [get]
+func (handler *SCIMHandlerImplementation) GetGroups(c *gin.Context) {
+	startIndex, count, ok := scimPagination(c)
+	if !ok {
+		return
+	}
+
+	groups, total, err := handler.scimService.GetGroups(c.Query("filter"), startIndex, count)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	resources := make([]any, len(groups))
+	for i, group := range groups {
+		resources[i] = group
+	}
+	scimList(c, resources, total, startIndex)
+}
+
+// GetGroup retrieves a SCIM group.
+// @Summary Get SCIM group
+// @Description Get a group by ID, along with its members
+// @Tags scim
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Param attributes query string false "Comma-separated attributes to return"
+// @Param excludedAttributes query string false "Comma-separated attributes not to return, such as members"
+// @Success 200 {object} dtos.SCIMGroupDTO
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Groups/{id} [get]
+func (handler *SCIMHandlerImplementation) GetGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+
+	group, err := handler.scimService.GetGroup(id)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	scimResource(c, http.StatusOK, group)
+}
+
+// CreateGroup provisions a SCIM group.
+// @Summary Create SCIM group
+// @Description Provision a group along with its members, which can only be users
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param group body dtos.SCIMGroupDTO true "Group"
+// @Success 201 {object} dtos.SCIMGroupDTO
+// @Header 201 {string} Location "URL of the group"
+// @Failure 400 {object} dtos.SCIMErrorDTO "Bad request"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 409 {object} dtos.SCIMErrorDTO "Conflict"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Groups [post]
+func (handler *SCIMHandlerImplementation) CreateGroup(c *gin.Context) {
+	var groupDTO dtos.SCIMGroupDTO
+	if !bindSCIM(c, &groupDTO) {
+		return
+	}
+
+	group, err := handler.scimService.CreateGroup(requestContext(c), &groupDTO)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	c.Header("Location", group.Meta.Location)
+	scimResource(c, http.StatusCreated, group)
+}
+
+// ReplaceGroup replaces a SCIM group.
+// @Summary Replace SCIM group
+// @Description Replace the name and the members of a group
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Param group body dtos.SCIMGroupDTO true "Group"
+// @Success 200 {object} dtos.SCIMGroupDTO
+// @Failure 400 {object} dtos.SCIMErrorDTO "Bad request"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Failure 409 {object} dtos.SCIMErrorDTO "Conflict"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Groups/{id} [put]
+func (handler *SCIMHandlerImplementation) ReplaceGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var groupDTO dtos.SCIMGroupDTO
+	if !bindSCIM(c, &groupDTO) {
+		return
+	}
+
+	group, err := handler.scimService.ReplaceGroup(requestContext(c), id, &groupDTO)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	scimResource(c, http.StatusOK, group)
+}
+
+// PatchGroup modifies a SCIM group.
+// @Summary Patch SCIM group
+// @Description Modify the name and the members of a group with PATCH operations, such as removing members[value eq "2"]
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Param patch body dtos.SCIMPatchDTO true "PATCH operations"
+// @Success 200 {object} dtos.SCIMGroupDTO
+// @Failure 400 {object} dtos.SCIMErrorDTO "Bad request"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Failure 409 {object} dtos.SCIMErrorDTO "Conflict"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Groups/{id} [patch]
+func (handler *SCIMHandlerImplementation) PatchGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var patchDTO dtos.SCIMPatchDTO
+	if !bindSCIM(c, &patchDTO) {
+		return
+	}
+
+	group, err := handler.scimService.PatchGroup(requestContext(c), id, &patchDTO)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	scimResource(c, http.StatusOK, group)
+}
+
+// DeleteGroup deprovisions a SCIM group.
+// @Summary Delete SCIM group
+// @Description Deprovision a group
+// @Tags scim
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Success 204 "No content"
+// @Failure 401 {object} dtos.SCIMErrorDTO "Unauthorized"
+// @Failure 403 {object} dtos.SCIMErrorDTO "Forbidden"
+// @Failure 404 {object} dtos.SCIMErrorDTO "Not found"
+// @Failure 500 {object} dtos.SCIMErrorDTO "Internal server error"
+// @Router /scim/v2/Groups/{id} [delete]
+func (handler *SCIMHandlerImplementation) DeleteGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+
+	if err := handler.scimService.DeleteGroup(requestContext(c), id); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// scimID parses the ID of the requested resource, responding with a 404 error when it's invalid.
+func scimID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		scimErrorJSON(c, http.StatusNotFound, "", "resource not found")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// scimPagination parses the startIndex and count query parameters, responding with a 400 error when they're invalid.
+func scimPagination(c *gin.Context) (int, int, bool) {
+	startIndex, count := 1, query.MaxLimit
+	for name, value := range map[string]*int{"startIndex": &startIndex, "count": &count} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			scimErrorJSON(c, http.StatusBadRequest, services.SCIMInvalidValue, "invalid "+name)
+			return 0, 0, false
+		}
+		*value = parsed
+	}
+
+	// Out of range values are interpreted as the closest valid ones, as required by RFC 7644
+	return max(startIndex, 1), min(max(count, 0), query.MaxLimit), true
+}
+
+// bindSCIM decodes a SCIM request body, responding with a 400 error when it's invalid.
+func bindSCIM(c *gin.Context, dest any) bool {
+	if err := json.NewDecoder(c.Request.Body).Decode(dest); err != nil {
+		scimErrorJSON(c, http.StatusBadRequest, services.SCIMInvalidSyntax, err.Error())
+		return false
+	}
+	return true
+}
+
+// scimList responds with a page of resources, projected on the requested attributes.
+func scimList(c *gin.Context, resources []any, total int, startIndex int) {
+	for i, resource := range resources {
+		projected, err := project(c, resource)
+		if err != nil {
+			scimError(c, err)
+			return
+		}
+		resources[i] = projected
+	}
+	scimJSON(c, http.StatusOK, scimListResponse(resources, total, startIndex))
+}
+
+// scimResource responds with a resource, projected on the requested attributes.
+func scimResource(c *gin.Context, status int, resource any) {
+	projected, err := project(c, resource)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, status, projected)
+}
+
+// project projects a resource on the attributes requested with the attributes and excludedAttributes query parameters.
+func project(c *gin.Context, resource any) (map[string]any, error) {
+	object, err := scim.ToResource(resource)
+	if err != nil {
+		return nil, err
+	}
+	return scim.Project(object, scim.SplitAttributes(c.Query("attributes")), scim.SplitAttributes(c.Query("excludedAttributes"))), nil
+}
+
+// scimListResponse creates a list response from a page of resources.
+func scimListResponse(resources []any, total int, startIndex int) *dtos.SCIMListResponseDTO {
+	return &dtos.SCIMListResponseDTO{
+		Schemas:      []string{dtos.SCIMSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// scimError responds with a SCIM error, mapping the errors of the services onto the SCIM statuses and types. The other
+// errors are internal server errors, whose details are only logged.
+func scimError(c *gin.Context, err error) {
+	var scimErr *services.SCIMError
+	if errors.As(err, &scimErr) {
+		scimErrorJSON(c, scimErr.Status, scimErr.Type, scimErr.Detail)
+		return
+	}
+	var (
+		notFoundErr   *services.NotFoundError
+		conflictErr   *services.ConflictError
+		validationErr *services.ValidationError
+		policyErr     *services.PasswordPolicyError
+	)
+	switch {
+	case errors.As(err, &notFoundErr):
+		scimErrorJSON(c, http.StatusNotFound, "", err.Error())
+	case errors.As(err, &conflictErr):
+		scimErrorJSON(c, http.StatusConflict, services.SCIMUniqueness, err.Error())
+	case errors.As(err, &validationErr), errors.As(err, &policyErr):
+		scimErrorJSON(c, http.StatusBadRequest, services.SCIMInvalidValue, err.Error())
+	default:
+		log.Printf("%s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+		scimErrorJSON(c, http.StatusInternalServerError, "", "an internal error occurred")
+	}
+}
+
+// scimErrorJSON responds with a SCIM error.
+func scimErrorJSON(c *gin.Context, status int, scimType string, detail string) {
+	scimJSON(c, status, &dtos.SCIMErrorDTO{
+		Schemas:  []string{dtos.SCIMSchemaError},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	})
+}
+
+// scimJSON responds with a SCIM JSON body.
+func scimJSON(c *gin.Context, status int, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(status, scimContentType, data)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	_ "github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// SigningKeyHandler defines the interface for signing key-related HTTP handlers.
+// @title SigningKeyHandler Interface
+// @description Interface for handling signing key-related HTTP requests.
+type SigningKeyHandler interface {
+	GetAll(c *gin.Context)
+	Rotate(c *gin.Context)
+	JWKS(c *gin.Context)
+}
+
+// SigningKeyHandlerImplementation handles HTTP requests for managing the keys signing the tokens.
+type SigningKeyHandlerImplementation struct {
+	keyStoreService services.KeyStoreService
+}
+
+// NewSigningKeyHandler creates a new instance of the SigningKeyHandlerImplementation.
+func NewSigningKeyHandler(keyStoreService services.KeyStoreService) *SigningKeyHandlerImplementation {
+	return &SigningKeyHandlerImplementation{
+		keyStoreService: keyStoreService,
+	}
+}
+
+// GetAll retrieves the signing keys.
+// @Summary Get the signing keys
+// @Description Get the active key signing the tokens and the retired keys still verifying them, the newest first
+// @Tags signing-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.SigningKey
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /signing-keys [get]
+func (handler *SigningKeyHandlerImplementation) GetAll(c *gin.Context) {
+	signingKeys, err := handler.keyStoreService.GetAll()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, signingKeys)
+}
+
+// Rotate replaces the active signing key.
+// @Summary Rotate the signing key
+// @Description Generate a new key signing the tokens. The previous key is retired and keeps verifying the tokens it signed until JWT_KEY_RETENTION elapses.
+// @Tags signing-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} models.SigningKey
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /signing-keys/rotate [post]
+func (handler *SigningKeyHandlerImplementation) Rotate(c *gin.Context) {
+	signingKey, err := handler.keyStoreService.Rotate(requestContext(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, signingKey)
+}
+
+// JWKS returns the public keys verifying the tokens.
+// @Summary JSON Web Key Set
+// @Description Get the public keys verifying the tokens issued by GODS, selected by the kid header of the tokens. Clients may cache the set but must fetch it again when they meet an unknown kid, since the keys are rotated. The same keys sign the API and OIDC access tokens, which have the at+jwt typ header, and tokens that mustn't be accepted as access tokens, such as the two-factor challenges: consumers of the set must check the typ header, along with the audience.
+// @Tags signing-keys
+// @Produce json
+// @Success 200 {object} dtos.JSONWebKeySetDTO
+// @Router /.well-known/jwks.json [get]
+func (handler *SigningKeyHandlerImplementation) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, handler.keyStoreService.JWKS())
+}
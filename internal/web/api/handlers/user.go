@@ -2,9 +2,9 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
 	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
 	"github.com/Nokeni/GODS/internal/web/api/services"
 	"github.com/Nokeni/GODS/internal/web/common/dtos"
 	"github.com/gin-gonic/gin"
@@ -17,8 +17,12 @@ type UserHandler interface {
 	Get(c *gin.Context)
 	GetAll(c *gin.Context)
 	Create(c *gin.Context)
+	CreateServiceAccount(c *gin.Context)
 	Update(c *gin.Context)
 	Delete(c *gin.Context)
+	GetAllDeleted(c *gin.Context)
+	Restore(c *gin.Context)
+	Purge(c *gin.Context)
 }
 
 // UserHandlerImplementation handles HTTP requests for CRUD operations against the user model.
@@ -41,46 +45,57 @@ func NewUserHandler(userService services.UserService) *UserHandlerImplementation
 // @Security BearerAuth
 // @Param id path int true "User ID"
 // @Success 200 {object} models.User
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /users/{id} [get]
 func (handler *UserHandlerImplementation) Get(c *gin.Context) {
-	id := c.Param("id")
-
-	// Convert id from string to uint
-	uid, err := strconv.ParseUint(id, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	uid, ok := bindID(c, "id", "user")
+	if !ok {
 		return
 	}
 
-	user, err := handler.userService.Get(uint(uid))
+	user, err := handler.userService.Get(uid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, user)
 }
 
-// GetAll retrieves all users.
+// GetAll retrieves a page of users.
 // @Summary Get all users
-// @Description Get a list of all users
+// @Description Get a page of users, filtered with parameters such as name=, name~= (contains), email=, created_after= and created_before=
 // @Tags users
 // @Produce json
 // @Security BearerAuth
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
 // @Success 200 {array} models.User
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
 // @Router /users [get]
 func (handler *UserHandlerImplementation) GetAll(c *gin.Context) {
-	users, err := handler.userService.GetAll()
+	listQuery, ok := bindListQuery(c, repositories.UserListFields)
+	if !ok {
+		return
+	}
+
+	users, pageInfo, err := handler.userService.GetAll(listQuery)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	setPageHeaders(c, pageInfo)
 	c.JSON(http.StatusOK, users)
 }
 
@@ -96,20 +111,52 @@ func (handler *UserHandlerImplementation) GetAll(c *gin.Context) {
 // @Param password formData string true "Password"
 // @Param password_confirmation formData string true "Password confirmation"
 // @Success 201 {object} models.User
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 409 {object} dtos.ProblemDTO "Name already taken"
 // @Router /users [post]
 func (handler *UserHandlerImplementation) Create(c *gin.Context) {
 	var userDTO dtos.CreateUserDTO
 	if err := c.ShouldBind(&userDTO); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindError(err))
+		return
+	}
+
+	user, err := handler.userService.Create(requestContext(c), &userDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// CreateServiceAccount adds a new service account.
+// @Summary Create a new service account
+// @Description Create a non-human user for automation. Service accounts have no password, they get their permissions from their groups and authenticate with the API keys created for them.
+// @Tags users
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param name formData string true "Username"
+// @Param email formData string false "Contact email"
+// @Success 201 {object} models.User
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 409 {object} dtos.ProblemDTO "Name already taken"
+// @Router /service-accounts [post]
+func (handler *UserHandlerImplementation) CreateServiceAccount(c *gin.Context) {
+	var serviceAccountDTO dtos.CreateServiceAccountDTO
+	if err := c.ShouldBind(&serviceAccountDTO); err != nil {
+		c.Error(bindError(err))
 		return
 	}
 
-	user, err := handler.userService.Create(&userDTO)
+	user, err := handler.userService.CreateServiceAccount(requestContext(c), &serviceAccountDTO)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -127,35 +174,34 @@ func (handler *UserHandlerImplementation) Create(c *gin.Context) {
 // @Param name formData string false "Username"
 // @Param email formData string false "Email"
 // @Param password formData string false "Password"
+// @Param disabled formData bool false "Whether the user is disabled, which ends their sessions"
 // @Success 200 {object} models.User
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 409 {object} dtos.ProblemDTO "Name already taken"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /users/{id} [put]
 func (handler *UserHandlerImplementation) Update(c *gin.Context) {
-	id := c.Param("id")
-
-	// Convert id from string to uint
-	uid, err := strconv.ParseUint(id, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	uid, ok := bindID(c, "id", "user")
+	if !ok {
 		return
 	}
 
 	var userDTO dtos.UpdateUserDTO
 	if err := c.ShouldBind(&userDTO); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindError(err))
 		return
 	}
 
-	user, err := handler.userService.Get(uint(uid))
+	user, err := handler.userService.Get(uid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	if err := handler.userService.Update(user, &userDTO); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := handler.userService.Update(requestContext(c), user, &userDTO); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -169,22 +215,105 @@ func (handler *UserHandlerImplementation) Update(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path int true "User ID"
 // @Success 204
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /users/{id} [delete]
 func (handler *UserHandlerImplementation) Delete(c *gin.Context) {
-	id := c.Param("id")
+	uid, ok := bindID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	if err := handler.userService.Delete(requestContext(c), uid); err != nil {
+		c.Error(err)
+		return
+	}
 
-	// Convert id from string to uint
-	uid, err := strconv.ParseUint(id, 10, 32)
+	c.Status(http.StatusNoContent)
+}
+
+// GetAllDeleted retrieves a page of deleted users.
+// @Summary Get deleted users
+// @Description Get a page of the deleted users, which can be restored until they're purged, filtered with parameters such as name=, name~= (contains), deleted_after= and deleted_before=
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.User
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /users/deleted [get]
+func (handler *UserHandlerImplementation) GetAllDeleted(c *gin.Context) {
+	listQuery, ok := bindListQuery(c, repositories.DeletedUserListFields)
+	if !ok {
+		return
+	}
+
+	users, pageInfo, err := handler.userService.GetAllDeleted(listQuery)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, users)
+}
+
+// Restore undeletes a deleted user.
+// @Summary Restore a deleted user
+// @Description Undelete a deleted user, along with their memberships
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users/{id}/restore [post]
+func (handler *UserHandlerImplementation) Restore(c *gin.Context) {
+	uid, ok := bindID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	user, err := handler.userService.Restore(requestContext(c), uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// Purge permanently removes a deleted user.
+// @Summary Purge a deleted user
+// @Description Permanently remove a deleted user and their memberships, which frees their name. Only deleted users can be purged.
+// @Tags users
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users/{id}/purge [delete]
+func (handler *UserHandlerImplementation) Purge(c *gin.Context) {
+	uid, ok := bindID(c, "id", "user")
+	if !ok {
 		return
 	}
 
-	if err := handler.userService.Delete(uint(uid)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := handler.userService.Purge(requestContext(c), uid); err != nil {
+		c.Error(err)
 		return
 	}
 
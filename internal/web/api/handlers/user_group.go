@@ -2,9 +2,9 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
 	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
 	"github.com/Nokeni/GODS/internal/web/api/services"
 	"github.com/gin-gonic/gin"
 )
@@ -17,6 +17,11 @@ type UserGroupHandler interface {
 	RemoveUserFromGroup(c *gin.Context)
 	GetUserGroups(c *gin.Context)
 	GetGroupUsers(c *gin.Context)
+	AddGroupToGroup(c *gin.Context)
+	RemoveGroupFromGroup(c *gin.Context)
+	GetSubgroups(c *gin.Context)
+	GetEffectiveUserGroups(c *gin.Context)
+	GetEffectiveGroupUsers(c *gin.Context)
 }
 
 // UserGroupImplementation handles HTTP requests for operations against the user's groups.
@@ -39,29 +44,24 @@ func NewUserGroupHandler(userGroupService services.UserGroupService) *UserGroupI
 // @Param userId path int true "User ID"
 // @Param groupId path int true "Group ID"
 // @Success 204
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /users-groups/{groupId}/users/{userId} [post]
 func (handler *UserGroupImplementation) AddUserToGroup(c *gin.Context) {
-	userId := c.Param("userId")
-	groupId := c.Param("groupId")
-
-	// Convert userId and groupId from string to uint
-	uid, err := strconv.ParseUint(userId, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	uid, ok := bindID(c, "userId", "user")
+	if !ok {
 		return
 	}
 
-	gid, err := strconv.ParseUint(groupId, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+	gid, ok := bindID(c, "groupId", "group")
+	if !ok {
 		return
 	}
 
-	if err := handler.userGroupService.AddUserToGroup(uint(uid), uint(gid)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := handler.userGroupService.AddUserToGroup(requestContext(c), uid, gid); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -76,93 +76,295 @@ func (handler *UserGroupImplementation) AddUserToGroup(c *gin.Context) {
 // @Param userId path int true "User ID"
 // @Param groupId path int true "Group ID"
 // @Success 204
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /users-groups/{groupId}/users/{userId} [delete]
 func (handler *UserGroupImplementation) RemoveUserFromGroup(c *gin.Context) {
-	userId := c.Param("userId")
-	groupId := c.Param("groupId")
-
-	// Convert userId and groupId from string to uint
-	uid, err := strconv.ParseUint(userId, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	uid, ok := bindID(c, "userId", "user")
+	if !ok {
 		return
 	}
 
-	gid, err := strconv.ParseUint(groupId, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+	gid, ok := bindID(c, "groupId", "group")
+	if !ok {
 		return
 	}
 
-	if err := handler.userGroupService.RemoveUserFromGroup(uint(uid), uint(gid)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := handler.userGroupService.RemoveUserFromGroup(requestContext(c), uid, gid); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
-// GetUserGroups retrieves all groups for a user.
+// GetUserGroups retrieves a page of the groups of a user.
 // @Summary Get all groups for a user
-// @Description Get a list of all groups that a user belongs to by their ID
+// @Description Get a page of the groups that a user belongs to by their ID, filtered like the list of groups
 // @Tags user_group
 // @Produce json
 // @Security BearerAuth
 // @Param userId path int true "User ID"
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
 // @Success 200 {array} models.Group
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /users-groups/users/{userId} [get]
 func (handler *UserGroupImplementation) GetUserGroups(c *gin.Context) {
-	userId := c.Param("userId")
+	uid, ok := bindID(c, "userId", "user")
+	if !ok {
+		return
+	}
 
-	// Convert userId from string to uint
-	uid, err := strconv.ParseUint(userId, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	listQuery, ok := bindListQuery(c, repositories.GroupListFields)
+	if !ok {
 		return
 	}
 
-	groups, err := handler.userGroupService.GetUserGroups(uint(uid))
+	groups, pageInfo, err := handler.userGroupService.GetUserGroups(uid, listQuery)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	setPageHeaders(c, pageInfo)
 	c.JSON(http.StatusOK, groups)
 }
 
-// GetGroupUsers retrieves all users for a group.
+// GetGroupUsers retrieves a page of the users of a group.
 // @Summary Get all users for a group
-// @Description Get a list of all users that belong to a group by its ID
+// @Description Get a page of the users that belong to a group by its ID, filtered like the list of users
 // @Tags user_group
 // @Produce json
 // @Security BearerAuth
 // @Param groupId path int true "Group ID"
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
 // @Success 200 {array} models.User
-// @Failure 400 {object} gin.H "Bad request"
-// @Failure 401 {object} gin.H "Unauthorized"
-// @Failure 500 {object} gin.H "Internal server error"
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
 // @Router /users-groups/{groupId}/users [get]
 func (handler *UserGroupImplementation) GetGroupUsers(c *gin.Context) {
-	groupId := c.Param("groupId")
+	gid, ok := bindID(c, "groupId", "group")
+	if !ok {
+		return
+	}
+
+	listQuery, ok := bindListQuery(c, repositories.UserListFields)
+	if !ok {
+		return
+	}
+
+	users, pageInfo, err := handler.userGroupService.GetGroupUsers(gid, listQuery)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, users)
+}
+
+// AddGroupToGroup nests a group in another one.
+// @Summary Nest a group in a group
+// @Description Nest a group in another one by their IDs, the members of the subgroup becoming members of the group too. A group can't be nested in itself or in one of its subgroups.
+// @Tags user_group
+// @Security BearerAuth
+// @Param subgroupId path int true "Subgroup ID"
+// @Param groupId path int true "Group ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users-groups/{groupId}/groups/{subgroupId} [post]
+func (handler *UserGroupImplementation) AddGroupToGroup(c *gin.Context) {
+	sgid, ok := bindID(c, "subgroupId", "subgroup")
+	if !ok {
+		return
+	}
+
+	gid, ok := bindID(c, "groupId", "group")
+	if !ok {
+		return
+	}
+
+	if err := handler.userGroupService.AddGroupToGroup(requestContext(c), sgid, gid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveGroupFromGroup removes a group nested in another one.
+// @Summary Remove a group from a group
+// @Description Remove a group nested in another one by their IDs
+// @Tags user_group
+// @Security BearerAuth
+// @Param subgroupId path int true "Subgroup ID"
+// @Param groupId path int true "Group ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users-groups/{groupId}/groups/{subgroupId} [delete]
+func (handler *UserGroupImplementation) RemoveGroupFromGroup(c *gin.Context) {
+	sgid, ok := bindID(c, "subgroupId", "subgroup")
+	if !ok {
+		return
+	}
+
+	gid, ok := bindID(c, "groupId", "group")
+	if !ok {
+		return
+	}
+
+	if err := handler.userGroupService.RemoveGroupFromGroup(requestContext(c), sgid, gid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetSubgroups retrieves a page of the groups nested in a group.
+// @Summary Get all subgroups of a group
+// @Description Get a page of the groups directly nested in a group by its ID, filtered like the list of groups
+// @Tags user_group
+// @Produce json
+// @Security BearerAuth
+// @Param groupId path int true "Group ID"
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.Group
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users-groups/{groupId}/groups [get]
+func (handler *UserGroupImplementation) GetSubgroups(c *gin.Context) {
+	gid, ok := bindID(c, "groupId", "group")
+	if !ok {
+		return
+	}
+
+	listQuery, ok := bindListQuery(c, repositories.GroupListFields)
+	if !ok {
+		return
+	}
+
+	groups, pageInfo, err := handler.userGroupService.GetSubgroups(gid, listQuery)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, groups)
+}
+
+// GetEffectiveUserGroups retrieves a page of the groups a user belongs to, directly or through nested groups.
+// @Summary Get all effective groups for a user
+// @Description Get a page of the groups that a user belongs to by their ID, directly or through nested groups, filtered like the list of groups
+// @Tags user_group
+// @Produce json
+// @Security BearerAuth
+// @Param userId path int true "User ID"
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.Group
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users-groups/users/{userId}/effective [get]
+func (handler *UserGroupImplementation) GetEffectiveUserGroups(c *gin.Context) {
+	uid, ok := bindID(c, "userId", "user")
+	if !ok {
+		return
+	}
+
+	listQuery, ok := bindListQuery(c, repositories.GroupListFields)
+	if !ok {
+		return
+	}
 
-	// Convert groupId from string to uint
-	gid, err := strconv.ParseUint(groupId, 10, 32)
+	groups, pageInfo, err := handler.userGroupService.GetEffectiveUserGroups(uid, listQuery)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, groups)
+}
+
+// GetEffectiveGroupUsers retrieves a page of the users belonging to a group, directly or through nested groups.
+// @Summary Get all effective users for a group
+// @Description Get a page of the users that belong to a group by its ID, directly or through nested groups, filtered like the list of users
+// @Tags user_group
+// @Produce json
+// @Security BearerAuth
+// @Param groupId path int true "Group ID"
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.User
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /users-groups/{groupId}/users/effective [get]
+func (handler *UserGroupImplementation) GetEffectiveGroupUsers(c *gin.Context) {
+	gid, ok := bindID(c, "groupId", "group")
+	if !ok {
+		return
+	}
+
+	listQuery, ok := bindListQuery(c, repositories.UserListFields)
+	if !ok {
 		return
 	}
 
-	users, err := handler.userGroupService.GetGroupUsers(uint(gid))
+	users, pageInfo, err := handler.userGroupService.GetEffectiveGroupUsers(gid, listQuery)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	setPageHeaders(c, pageInfo)
 	c.JSON(http.StatusOK, users)
 }
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// VerificationHandler defines the interface for the HTTP handlers of the flows relying on emails sent to users.
+// @title VerificationHandler Interface
+// @description Interface for handling the email verification and password reset HTTP requests.
+type VerificationHandler interface {
+	SendEmailVerification(c *gin.Context)
+	ResendEmailVerification(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+}
+
+// VerificationHandlerImplementation handles the email verification and password reset HTTP requests.
+type VerificationHandlerImplementation struct {
+	verificationService services.VerificationService
+}
+
+// NewVerificationHandler creates a new instance of the VerificationHandlerImplementation.
+func NewVerificationHandler(verificationService services.VerificationService) *VerificationHandlerImplementation {
+	return &VerificationHandlerImplementation{
+		verificationService: verificationService,
+	}
+}
+
+// SendEmailVerification sends a verification link to the authenticated user.
+// @Summary Send a verification email
+// @Description Send a new verification link to the email of the authenticated user
+// @Tags me
+// @Security BearerAuth
+// @Success 202
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Router /me/email/verification [post]
+func (handler *VerificationHandlerImplementation) SendEmailVerification(c *gin.Context) {
+	if err := handler.verificationService.SendEmailVerification(requestContext(c), c.GetUint("userID")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ResendEmailVerification sends a verification link to the unverified users having an email.
+// @Summary Resend a verification email
+// @Description Send a new verification link to the unverified users having the given email. The response doesn't tell whether such users exist.
+// @Tags auth
+// @Accept mpfd
+// @Param email formData string true "Email"
+// @Success 202
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 429 {object} dtos.ProblemDTO "Too many attempts"
+// @Router /auth/verify-email/resend [post]
+func (handler *VerificationHandlerImplementation) ResendEmailVerification(c *gin.Context) {
+	var emailDTO dtos.EmailDTO
+	if err := c.ShouldBind(&emailDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	if err := handler.verificationService.ResendEmailVerification(requestContext(c), &emailDTO); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// VerifyEmail verifies the email of a user.
+// @Summary Verify an email address
+// @Description Mark the email of a user as verified with the token sent to it. The link sent by email opens this endpoint with a GET request.
+// @Tags auth
+// @Accept mpfd
+// @Param token formData string true "Verification token"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Router /auth/verify-email [post]
+func (handler *VerificationHandlerImplementation) VerifyEmail(c *gin.Context) {
+	var verifyEmailDTO dtos.VerifyEmailDTO
+	if err := c.ShouldBind(&verifyEmailDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	if err := handler.verificationService.VerifyEmail(requestContext(c), &verifyEmailDTO); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ForgotPassword sends a password reset token to the users having an email.
+// @Summary Request a password reset
+// @Description Send a single-use password reset token to the users having the given email. The response doesn't tell whether such users exist.
+// @Tags auth
+// @Accept mpfd
+// @Param email formData string true "Email"
+// @Success 202
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 429 {object} dtos.ProblemDTO "Too many attempts"
+// @Router /auth/forgot-password [post]
+func (handler *VerificationHandlerImplementation) ForgotPassword(c *gin.Context) {
+	var emailDTO dtos.EmailDTO
+	if err := c.ShouldBind(&emailDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	if err := handler.verificationService.ForgotPassword(requestContext(c), &emailDTO); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword replaces the password of a user.
+// @Summary Reset a password
+// @Description Replace the password of a user with the token sent by email. Every session of the user is revoked.
+// @Tags auth
+// @Accept mpfd
+// @Param token formData string true "Password reset token"
+// @Param password formData string true "New password"
+// @Param password_confirmation formData string true "New password confirmation"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Router /auth/reset-password [post]
+func (handler *VerificationHandlerImplementation) ResetPassword(c *gin.Context) {
+	var resetPasswordDTO dtos.ResetPasswordDTO
+	if err := c.ShouldBind(&resetPasswordDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	if err := handler.verificationService.ResetPassword(requestContext(c), &resetPasswordDTO); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"net/http"
+
+	_ "github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler defines the interface for webhook-related HTTP handlers.
+// @title WebhookHandler Interface
+// @description Interface for handling webhook-related HTTP requests.
+type WebhookHandler interface {
+	Get(c *gin.Context)
+	GetAll(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	RotateSecret(c *gin.Context)
+	Delete(c *gin.Context)
+	Ping(c *gin.Context)
+	GetDeliveries(c *gin.Context)
+	Redeliver(c *gin.Context)
+}
+
+// WebhookHandlerImplementation handles HTTP requests for CRUD operations against the webhook model and its deliveries.
+type WebhookHandlerImplementation struct {
+	webhookService services.WebhookService
+}
+
+// NewWebhookHandler creates a new instance of the WebhookHandlerImplementation.
+func NewWebhookHandler(webhookService services.WebhookService) *WebhookHandlerImplementation {
+	return &WebhookHandlerImplementation{
+		webhookService: webhookService,
+	}
+}
+
+// Get retrieves a webhook by ID.
+// @Summary Get a webhook by ID
+// @Description Get details of a webhook by its ID
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /webhooks/{id} [get]
+func (handler *WebhookHandlerImplementation) Get(c *gin.Context) {
+	wid, ok := bindID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	webhook, err := handler.webhookService.Get(wid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// GetAll retrieves a page of webhooks.
+// @Summary Get all webhooks
+// @Description Get a page of webhooks, filtered with parameters such as name=, name~= (contains), url~=, created_after= and created_before=
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.Webhook
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Router /webhooks [get]
+func (handler *WebhookHandlerImplementation) GetAll(c *gin.Context) {
+	listQuery, ok := bindListQuery(c, repositories.WebhookListFields)
+	if !ok {
+		return
+	}
+
+	webhooks, pageInfo, err := handler.webhookService.GetAll(listQuery)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// Create registers a new webhook.
+// @Summary Register a new webhook
+// @Description Register an endpoint notified of the user, group and membership lifecycle events. Events are posted as JSON with the X-GODS-Event and X-GODS-Delivery headers, and signed with the X-GODS-Signature header "t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>">". The signing secret is only returned by this request.
+// @Tags webhooks
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param name formData string true "Webhook name"
+// @Param url formData string true "HTTP or HTTPS URL the events are posted to"
+// @Param events formData []string false "Events to receive, every event by default" collectionFormat(multi)
+// @Success 201 {object} dtos.WebhookSecretDTO
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Router /webhooks [post]
+func (handler *WebhookHandlerImplementation) Create(c *gin.Context) {
+	var webhookDTO dtos.CreateWebhookDTO
+	if err := c.ShouldBind(&webhookDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	webhook, err := handler.webhookService.Create(requestContext(c), &webhookDTO)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// Update updates an existing webhook.
+// @Summary Update an existing webhook
+// @Description Update a webhook, the list of events provided replaces the current one
+// @Tags webhooks
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param name formData string false "Webhook name"
+// @Param url formData string false "HTTP or HTTPS URL the events are posted to"
+// @Param events formData []string false "Events to receive" collectionFormat(multi)
+// @Param disabled formData bool false "Stop sending events to the webhook"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /webhooks/{id} [put]
+func (handler *WebhookHandlerImplementation) Update(c *gin.Context) {
+	wid, ok := bindID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	var webhookDTO dtos.UpdateWebhookDTO
+	if err := c.ShouldBind(&webhookDTO); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	webhook, err := handler.webhookService.Get(wid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := handler.webhookService.Update(requestContext(c), webhook, &webhookDTO); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// RotateSecret replaces the secret of a webhook.
+// @Summary Rotate the secret of a webhook
+// @Description Generate a new signing secret for a webhook, the events sent from then on are signed with it
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} dtos.WebhookSecretDTO
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /webhooks/{id}/secret [post]
+func (handler *WebhookHandlerImplementation) RotateSecret(c *gin.Context) {
+	wid, ok := bindID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	webhook, err := handler.webhookService.RotateSecret(requestContext(c), wid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// Delete removes a webhook.
+// @Summary Delete a webhook
+// @Description Remove a webhook, its pending deliveries are abandoned
+// @Tags webhooks
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 204
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /webhooks/{id} [delete]
+func (handler *WebhookHandlerImplementation) Delete(c *gin.Context) {
+	wid, ok := bindID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	if err := handler.webhookService.Delete(requestContext(c), wid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Ping sends a test event to a webhook.
+// @Summary Ping a webhook
+// @Description Queue a ping event for a webhook, whatever the events it receives, to check it's reachable. The result appears in the deliveries of the webhook.
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /webhooks/{id}/ping [post]
+func (handler *WebhookHandlerImplementation) Ping(c *gin.Context) {
+	wid, ok := bindID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	delivery, err := handler.webhookService.Ping(wid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// GetDeliveries retrieves a page of the deliveries of a webhook.
+// @Summary Get the deliveries of a webhook
+// @Description Get a page of the deliveries of a webhook, with their payload, attempts and last response, filtered with parameters such as event=, event_id=, status=, created_after= and created_before=
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param limit query int false "Maximum number of results (default 50, max 500)"
+// @Param offset query int false "Number of results to skip"
+// @Param cursor query string false "Cursor returned in the X-Next-Cursor header, empty to start cursor pagination"
+// @Param sort query string false "Comma-separated fields to sort on, prefixed by - for descending order"
+// @Success 200 {array} models.WebhookDelivery
+// @Header 200 {integer} X-Total-Count "Number of results matching the filters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Header 200 {string} Link "Links to the next, previous and first pages"
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 500 {object} dtos.ProblemDTO "Internal server error"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /webhooks/{id}/deliveries [get]
+func (handler *WebhookHandlerImplementation) GetDeliveries(c *gin.Context) {
+	wid, ok := bindID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	listQuery, ok := bindListQuery(c, repositories.WebhookDeliveryListFields)
+	if !ok {
+		return
+	}
+
+	deliveries, pageInfo, err := handler.webhookService.GetDeliveries(wid, listQuery)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// Redeliver sends the event of a past delivery again.
+// @Summary Redeliver an event
+// @Description Queue a new delivery of the payload of a past delivery, with the same event ID so that the webhook can tell it already received it
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} dtos.ProblemDTO "Bad request"
+// @Failure 401 {object} dtos.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dtos.ProblemDTO "Forbidden"
+// @Failure 404 {object} dtos.ProblemDTO "Not found"
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (handler *WebhookHandlerImplementation) Redeliver(c *gin.Context) {
+	wid, ok := bindID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+	did, ok := bindID(c, "deliveryId", "delivery")
+	if !ok {
+		return
+	}
+
+	delivery, err := handler.webhookService.Redeliver(requestContext(c), wid, did)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
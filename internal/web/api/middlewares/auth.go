@@ -1,55 +1,76 @@
 package middlewares
 
 import (
-	"fmt"
-	"net/http"
+	"strings"
 
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
-	"github.com/spf13/viper"
 )
 
-// AuthMiddleware checks if the user is authenticated.
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware checks if the user is authenticated, with a JWT or with an API key.
+func AuthMiddleware(authService services.AuthService, apiKeyService services.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the token from the Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			c.Error(services.NewUnauthorizedError(services.CodeAuthenticationRequired, "authorization header is required"))
 			c.Abort()
 			return
 		}
 
 		// Extract the token from the header
-		tokenString := authHeader[len("Bearer "):]
+		tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
+		if !found {
+			c.Error(services.NewUnauthorizedError(services.CodeAuthenticationRequired, "invalid authorization header"))
+			c.Abort()
+			return
+		}
 
-		// Parse the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the algorithm
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		// API keys are told apart from the JWTs by their prefix
+		if strings.HasPrefix(tokenString, models.APIKeyPrefix) {
+			apiKey, err := apiKeyService.Authenticate(tokenString, c.ClientIP())
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
 			}
-			// Return the secret key
-			return []byte(viper.GetString("JWT_KEY")), nil
-		})
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+
+			// Set the key's owner and the key itself, whose scopes restrict the permissions
+			c.Set("userID", apiKey.UserID)
+			c.Set("apiKey", apiKey)
+
+			c.Next()
 			return
 		}
 
-		// Get the claims from the token
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		// Parse the token and check it hasn't been revoked
+		claims, err := authService.ValidateToken(tokenString)
+		if err != nil {
+			c.Error(err)
 			c.Abort()
 			return
 		}
 
-		// Set the user's ID in the context
-		c.Set("userID", claims["UserID"])
+		// Set the user's ID and the token claims in the context
+		c.Set("userID", claims.UserID)
+		c.Set("claims", claims)
 
 		// Continue to the next handler
 		c.Next()
 	}
 }
+
+// RequireSession refuses the requests authenticated with an API key, for the endpoints managing the account itself
+// such as its password, second factor and API keys.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("apiKey"); exists {
+			c.Error(services.NewForbiddenError(services.CodeSessionRequired, "this endpoint can't be used with an API key"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
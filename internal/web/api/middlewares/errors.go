@@ -0,0 +1,110 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ProblemDetails renders the error added to the context by the handlers and the middlewares as problem details,
+// unless a response has already been written.
+func ProblemDetails() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		problem := newProblem(c, c.Errors.Last().Err)
+		c.Header("Content-Type", dtos.ProblemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// SCIMErrors renders the error added to the context as a SCIM error, for the SCIM endpoints sharing the
+// authentication and permission middlewares of the API.
+func SCIMErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		problem := newProblem(c, c.Errors.Last().Err)
+		data, err := json.Marshal(&dtos.SCIMErrorDTO{
+			Schemas: []string{dtos.SCIMSchemaError},
+			Status:  strconv.Itoa(problem.Status),
+			Detail:  problem.Detail,
+		})
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Data(problem.Status, "application/scim+json", data)
+	}
+}
+
+// newProblem maps an error onto its status and code. Errors that aren't caused by the client are logged and
+// reported without their details, which could leak internals.
+func newProblem(c *gin.Context, err error) *dtos.ProblemDTO {
+	problem := &dtos.ProblemDTO{
+		Type:     "about:blank",
+		Detail:   err.Error(),
+		Instance: c.Request.URL.Path,
+	}
+
+	var (
+		validationErr   *services.ValidationError
+		policyErr       *services.PasswordPolicyError
+		unauthorizedErr *services.UnauthorizedError
+		expiredErr      *services.PasswordExpiredError
+		forbiddenErr    *services.ForbiddenError
+		notFoundErr     *services.NotFoundError
+		conflictErr     *services.ConflictError
+		lockedErr       *services.LockedError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		problem.Status, problem.Code = http.StatusBadRequest, validationErr.Code
+		for _, field := range validationErr.Fields {
+			problem.Errors = append(problem.Errors, dtos.FieldErrorDTO{Field: field.Field, Code: field.Code, Message: field.Message})
+		}
+	case errors.As(err, &policyErr):
+		problem.Status, problem.Code = http.StatusBadRequest, services.CodePasswordPolicy
+		for _, violation := range policyErr.Violations {
+			problem.Errors = append(problem.Errors, dtos.FieldErrorDTO{Field: "password", Code: violation.Rule, Message: violation.Message})
+		}
+	case errors.As(err, &unauthorizedErr):
+		problem.Status, problem.Code = http.StatusUnauthorized, unauthorizedErr.Code
+	case errors.As(err, &expiredErr):
+		problem.Status, problem.Code = http.StatusForbidden, services.CodePasswordExpired
+		problem.PasswordToken = expiredErr.PasswordToken
+	case errors.As(err, &forbiddenErr):
+		problem.Status, problem.Code = http.StatusForbidden, forbiddenErr.Code
+	case errors.As(err, &notFoundErr):
+		problem.Status, problem.Code = http.StatusNotFound, notFoundErr.Code
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		problem.Status, problem.Code, problem.Detail = http.StatusNotFound, services.CodeNotFound, "resource not found"
+	case errors.As(err, &conflictErr):
+		problem.Status, problem.Code = http.StatusConflict, conflictErr.Code
+	case errors.As(err, &lockedErr):
+		problem.Status, problem.Code = http.StatusTooManyRequests, services.CodeTooManyAttempts
+		c.Header("Retry-After", strconv.Itoa(int(time.Until(lockedErr.Until).Seconds())+1))
+	default:
+		log.Printf("%s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+		problem.Status, problem.Code, problem.Detail = http.StatusInternalServerError, services.CodeInternal, "an internal error occurred"
+	}
+	problem.Title = http.StatusText(problem.Status)
+
+	return problem
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"slices"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission returns a factory of middlewares checking if the authenticated user has been granted a permission.
+// Requests authenticated with an API key also need the permission to be one of the key's scopes.
+func RequirePermission(roleService services.RoleService) func(permission string) gin.HandlerFunc {
+	return func(permission string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			// Get the user from the context
+			userID, exists := c.Get("userID")
+			if !exists {
+				c.Error(services.NewUnauthorizedError(services.CodeAuthenticationRequired, "user not authenticated"))
+				c.Abort()
+				return
+			}
+
+			uid, ok := userID.(uint)
+			if !ok {
+				c.Error(services.NewUnauthorizedError(services.CodeAuthenticationRequired, "invalid user ID"))
+				c.Abort()
+				return
+			}
+
+			// Check if one of the user's groups has a role granting the permission
+			granted, err := roleService.HasPermission(uid, permission)
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			if !granted {
+				c.Error(services.NewForbiddenError(services.CodePermissionDenied, "you do not have permission to access this resource"))
+				c.Abort()
+				return
+			}
+			if apiKey, exists := c.Get("apiKey"); exists && !slices.Contains(apiKey.(*models.APIKey).Scopes, permission) {
+				c.Error(services.NewForbiddenError(services.CodeInsufficientScope, "the API key doesn't have the %s scope", permission))
+				c.Abort()
+				return
+			}
+
+			// Continue to the next handler
+			c.Next()
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix starts every API key, telling them apart from the JWTs in the Authorization header.
+const APIKeyPrefix = "gods_"
+
+// APIKey is a model that represents a long-lived token authenticating automation: a personal access token
+// created by a user for themselves, or an API key created by an admin for a service account.
+// The permissions of a key are the permissions of its owner restricted to its scopes.
+type APIKey struct {
+	gorm.Model
+	UserID     uint       `gorm:"not null;index"`                        // UserID is the ID of the user the key authenticates as.
+	Name       string     `gorm:"size:255;not null"`                     // Name describes what the key is used for.
+	Prefix     string     `gorm:"size:16;not null"`                      // Prefix is the beginning of the key, identifying it without revealing it.
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"` // TokenHash is the SHA-256 hash of the key.
+	Scopes     []string   `gorm:"serializer:json;type:text;not null"`    // Scopes are the permissions the key is restricted to.
+	ExpiresAt  time.Time  `gorm:"not null"`                              // ExpiresAt is the key's expiration date.
+	LastUsedAt *time.Time // LastUsedAt is the date the key was last used, nil if it never was.
+	LastUsedIP string     `gorm:"size:64"` // LastUsedIP is the client IP of the last request authenticated by the key.
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditUserCreate           = "user.create"
+	AuditUserUpdate           = "user.update"
+	AuditUserPasswordChange   = "user.password_change"
+	AuditUserPasswordRehash   = "user.password_rehash"
+	AuditUserDelete           = "user.delete"
+	AuditUserRestore          = "user.restore"
+	AuditUserPurge            = "user.purge"
+	AuditUserEmailVerify      = "user.email_verify"
+	AuditUserMFAEnable        = "user.mfa_enable"
+	AuditUserMFADisable       = "user.mfa_disable"
+	AuditUserMFAReset         = "user.mfa_reset"
+	AuditUserRecoveryCodes    = "user.recovery_codes"
+	AuditGroupCreate          = "group.create"
+	AuditGroupUpdate          = "group.update"
+	AuditGroupDelete          = "group.delete"
+	AuditGroupRestore         = "group.restore"
+	AuditGroupPurge           = "group.purge"
+	AuditMembershipAdd        = "membership.add"
+	AuditMembershipRemove     = "membership.remove"
+	AuditRoleCreate           = "role.create"
+	AuditRoleUpdate           = "role.update"
+	AuditRoleDelete           = "role.delete"
+	AuditRolePermissionAdd    = "role.permission_add"
+	AuditRolePermissionRemove = "role.permission_remove"
+	AuditRoleGroupAdd         = "role.group_add"
+	AuditRoleGroupRemove      = "role.group_remove"
+	AuditClientCreate         = "client.create"
+	AuditClientUpdate         = "client.update"
+	AuditClientDelete         = "client.delete"
+	AuditClientSecretRotate   = "client.secret_rotate"
+	AuditSigningKeyRotate     = "signing_key.rotate"
+	AuditAPIKeyCreate         = "api_key.create"
+	AuditAPIKeyRevoke         = "api_key.revoke"
+	AuditOIDCAuthorize        = "oidc.authorize"
+	AuditOIDCClientToken      = "oidc.client_token"
+	AuditAuthLogin            = "auth.login"
+	AuditAuthLoginFailed      = "auth.login_failed"
+	AuditAuthMFAFailed        = "auth.mfa_failed"
+	AuditAuthRecoveryCodeUse  = "auth.recovery_code_use"
+	AuditAuthSignup           = "auth.signup"
+	AuditAuthLogout           = "auth.logout"
+	AuditAuthRefreshReuse     = "auth.refresh_reuse"
+	AuditAuthSessionsRevoke   = "auth.sessions_revoke"
+	AuditAuthPasswordForgot   = "auth.password_forgot"
+	AuditAuthPasswordReset    = "auth.password_reset"
+	AuditAuthLockout          = "auth.lockout"
+	AuditAuthUnlock           = "auth.unlock"
+	AuditDirectoryImport      = "directory.import"
+	AuditDirectoryExport      = "directory.export"
+	AuditWebhookCreate        = "webhook.create"
+	AuditWebhookUpdate        = "webhook.update"
+	AuditWebhookDelete        = "webhook.delete"
+	AuditWebhookSecretRotate  = "webhook.secret_rotate"
+	AuditWebhookRedeliver     = "webhook.redeliver"
+)
+
+// AuditEvent is a model that represents an entry of the append-only audit log.
+type AuditEvent struct {
+	ID         uint            `gorm:"primarykey"`             // ID is the event's ID.
+	CreatedAt  time.Time       `gorm:"not null;index"`         // CreatedAt is the date of the event.
+	ActorID    *uint           `gorm:"index"`                  // ActorID is the ID of the authenticated user who performed the action, if any.
+	Action     string          `gorm:"size:64;not null;index"` // Action is the performed action, such as "user.create".
+	TargetType string          `gorm:"size:64;index"`          // TargetType is the type of the resource the action was performed on.
+	TargetID   *uint           `gorm:"index"`                  // TargetID is the ID of the resource the action was performed on.
+	Details    string          // Details is a free-form description of the event, such as the name used in a failed login.
+	Changes    json.RawMessage // Changes is the JSON diff of the target's fields, as {"field": {"before": ..., "after": ...}}.
+	IP         string          `gorm:"size:64"` // IP is the client IP of the request.
+	UserAgent  string          // UserAgent is the user agent of the request.
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuthorizationCode is a model that represents a code issued by the authorization endpoint, to be exchanged for tokens.
+type AuthorizationCode struct {
+	gorm.Model
+	CodeHash            string     `gorm:"size:64;not null;uniqueIndex"` // CodeHash is the SHA-256 hash of the code.
+	ClientID            string     `gorm:"size:64;not null;index"`       // ClientID is the identifier of the client the code was issued to.
+	UserID              uint       `gorm:"not null;index"`               // UserID is the ID of the user who authorized the client.
+	TokenVersion        uint       `gorm:"not null;default:0"`           // TokenVersion is the token version of the user when they authorized the client.
+	RedirectURI         string     `gorm:"not null"`                     // RedirectURI is the URI the code was sent to.
+	Scope               string     // Scope is the space-separated list of granted scopes.
+	Nonce               string     // Nonce is the value to include in the ID token, as sent by the client.
+	CodeChallenge       string     `gorm:"size:128"` // CodeChallenge is the PKCE challenge sent by the client.
+	CodeChallengeMethod string     `gorm:"size:16"`  // CodeChallengeMethod is the PKCE challenge method, S256 or plain.
+	AuthTime            time.Time  `gorm:"not null"` // AuthTime is the date the user authenticated.
+	ExpiresAt           time.Time  `gorm:"not null"` // ExpiresAt is the code's expiration date.
+	UsedAt              *time.Time // UsedAt is the date the code was exchanged.
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Grant types a client can use.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// Client is a model that represents an application relying on GODS as its OpenID Connect provider.
+type Client struct {
+	gorm.Model
+	ClientID     string   `gorm:"size:64;not null;uniqueIndex"` // ClientID is the public identifier of the client.
+	SecretHash   string   `json:"-"`                            // SecretHash is the SHA-256 hash of the client secret, empty for public clients.
+	Name         string   `gorm:"size:255;not null"`            // Name is the client's name.
+	RedirectURIs []string `gorm:"serializer:json;type:text"`    // RedirectURIs is the list of URIs the authorization codes can be sent to.
+	GrantTypes   []string `gorm:"serializer:json;type:text"`    // GrantTypes is the list of grant types the client can use.
+	Scopes       []string `gorm:"serializer:json;type:text"`    // Scopes is the list of scopes the client can request.
+	Public       bool     `gorm:"not null;default:false"`       // Public is true for the clients that can't keep a secret, which must use PKCE.
+}
@@ -7,7 +7,9 @@ import (
 // Group is a model that represents a group of users.
 type Group struct {
 	gorm.Model
-	Name        string  `gorm:"not null;unique"` // Name is the group's name
-	Description string  // Description is the group's description
-	Users       []*User `gorm:"many2many:user_groups;"` // Users is the list of users that belongs to the group
+	Name        string   `gorm:"size:255;not null;unique"` // Name is the group's name
+	Description string   // Description is the group's description
+	Users       []*User  `gorm:"many2many:user_groups;"`                                                     // Users is the list of users that belongs to the group
+	Subgroups   []*Group `gorm:"many2many:group_subgroups;joinForeignKey:GroupID;joinReferences:SubgroupID"` // Subgroups is the list of groups nested in the group, whose members are members of the group too
+	Roles       []*Role  `gorm:"many2many:group_roles;"`                                                     // Roles is the list of roles granted to the group
 }
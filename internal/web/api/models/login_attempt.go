@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Scopes the failed attempts are counted against.
+const (
+	LockoutScopeAccount = "account"
+	LockoutScopeIP      = "ip"
+	LockoutScopeSignup  = "signup"
+	LockoutScopeEmail   = "email"
+)
+
+// LoginAttempt tracks the failed attempts counted against an account, an IP or a signup source.
+// It isn't a database model, it's kept by a LoginAttemptRepository, in memory by default.
+type LoginAttempt struct {
+	Scope         string    // Scope is what the attempts are counted against, such as "account" or "ip".
+	Value         string    // Value is the account name or the IP the attempts are counted against.
+	Failures      int       // Failures is the number of consecutive failed attempts.
+	LastFailureAt time.Time // LastFailureAt is the date of the last failed attempt.
+	LockedUntil   time.Time // LockedUntil is the date until which attempts are refused.
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PasswordHistory is a model that represents a former password of a user, which can't be reused.
+type PasswordHistory struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"not null"`       // CreatedAt is the date the password was replaced.
+	UserID    uint      `gorm:"not null;index"` // UserID is the ID of the user the password belonged to.
+	Hash      string    `gorm:"not null"`       // Hash is the hash of the password.
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Permissions checked by the API routes and the admin console.
+const (
+	PermissionUsersRead        = "users:read"
+	PermissionUsersWrite       = "users:write"
+	PermissionGroupsRead       = "groups:read"
+	PermissionGroupsWrite      = "groups:write"
+	PermissionMembershipsRead  = "memberships:read"
+	PermissionMembershipsWrite = "memberships:write"
+	PermissionRolesRead        = "roles:read"
+	PermissionRolesWrite       = "roles:write"
+	PermissionAuditRead        = "audit:read"
+	PermissionClientsRead      = "clients:read"
+	PermissionClientsWrite     = "clients:write"
+	PermissionAPIKeysRead      = "api_keys:read"
+	PermissionAPIKeysWrite     = "api_keys:write"
+	PermissionSigningKeysRead  = "signing_keys:read"
+	PermissionSigningKeysWrite = "signing_keys:write"
+	PermissionLockoutsRead     = "lockouts:read"
+	PermissionLockoutsWrite    = "lockouts:write"
+	PermissionLDAPSearch       = "ldap:search"
+	PermissionDirectoryImport  = "directory:import"
+	PermissionDirectoryExport  = "directory:export"
+	PermissionWebhooksRead     = "webhooks:read"
+	PermissionWebhooksWrite    = "webhooks:write"
+	PermissionConsoleAccess    = "console:access"
+)
+
+// DefaultPermissions is the list of permissions created at startup and granted to the admin role.
+var DefaultPermissions = []Permission{
+	{Name: PermissionUsersRead, Description: "List and read users"},
+	{Name: PermissionUsersWrite, Description: "Create, update and delete users"},
+	{Name: PermissionGroupsRead, Description: "List and read groups"},
+	{Name: PermissionGroupsWrite, Description: "Create, update and delete groups"},
+	{Name: PermissionMembershipsRead, Description: "List the members of groups"},
+	{Name: PermissionMembershipsWrite, Description: "Add and remove the members of groups"},
+	{Name: PermissionRolesRead, Description: "List and read roles and permissions"},
+	{Name: PermissionRolesWrite, Description: "Create, update and delete roles and grant them to groups"},
+	{Name: PermissionAuditRead, Description: "Read the audit log"},
+	{Name: PermissionClientsRead, Description: "List and read OpenID Connect clients"},
+	{Name: PermissionClientsWrite, Description: "Register, update and delete OpenID Connect clients"},
+	{Name: PermissionAPIKeysRead, Description: "List the API keys and personal access tokens of every user"},
+	{Name: PermissionAPIKeysWrite, Description: "Create API keys for service accounts and revoke any API key"},
+	{Name: PermissionSigningKeysRead, Description: "List the keys signing the tokens"},
+	{Name: PermissionSigningKeysWrite, Description: "Rotate the keys signing the tokens"},
+	{Name: PermissionLockoutsRead, Description: "List the locked accounts and IPs"},
+	{Name: PermissionLockoutsWrite, Description: "Unlock the locked accounts and IPs"},
+	{Name: PermissionLDAPSearch, Description: "Search the users and groups over LDAP"},
+	{Name: PermissionDirectoryImport, Description: "Import users, groups and memberships in bulk"},
+	{Name: PermissionDirectoryExport, Description: "Export every user, group and membership, password hashes included"},
+	{Name: PermissionWebhooksRead, Description: "List the webhooks and their deliveries"},
+	{Name: PermissionWebhooksWrite, Description: "Register, update and delete webhooks and redeliver their events"},
+	{Name: PermissionConsoleAccess, Description: "Log in to the admin console, whose pages also need the permissions of the API endpoints"},
+}
+
+// Permission is a model that represents the permission to perform an action.
+type Permission struct {
+	gorm.Model
+	Name        string  `gorm:"size:255;not null;unique"` // Name is the permission's name, such as "users:write".
+	Description string  // Description is the permission's description.
+	Roles       []*Role `gorm:"many2many:role_permissions;"` // Roles is the list of roles granting the permission.
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a model that represents a one-time code replacing a TOTP code when the authenticator is lost.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `gorm:"not null;index"`         // UserID is the ID of the user the code was issued to.
+	CodeHash string     `gorm:"size:64;not null;index"` // CodeHash is the SHA-256 hash of the code.
+	UsedAt   *time.Time // UsedAt is the date the code was used.
+}
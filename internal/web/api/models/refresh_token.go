@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a model that represents a refresh token issued to a user.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"not null;index"`               // UserID is the ID of the user the token was issued to.
+	FamilyID  string     `gorm:"size:64;not null;index"`       // FamilyID identifies the chain of rotated tokens the token belongs to.
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"` // TokenHash is the SHA-256 hash of the token.
+	ExpiresAt time.Time  `gorm:"not null"`                     // ExpiresAt is the token's expiration date.
+	UsedAt    *time.Time // UsedAt is the date the token was exchanged for a new one.
+	RevokedAt *time.Time // RevokedAt is the date the token was revoked.
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RevokedToken is a model that represents an access token revoked before its expiration.
+type RevokedToken struct {
+	gorm.Model
+	TokenID   string    `gorm:"size:64;not null;uniqueIndex"` // TokenID is the revoked token's unique identifier (jti claim).
+	ExpiresAt time.Time `gorm:"not null;index"`               // ExpiresAt is the revoked token's expiration date, after which the entry can be discarded.
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Role is a model that represents a set of permissions granted to groups.
+type Role struct {
+	gorm.Model
+	Name        string        `gorm:"size:255;not null;unique"` // Name is the role's name.
+	Description string        // Description is the role's description.
+	Permissions []*Permission `gorm:"many2many:role_permissions;"` // Permissions is the list of permissions granted by the role.
+	Groups      []*Group      `gorm:"many2many:group_roles;"`      // Groups is the list of groups the role is granted to.
+}
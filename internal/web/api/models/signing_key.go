@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Algorithms of the signing keys.
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is a model that represents a key of the keystore signing the JWTs issued by GODS.
+// Only the newest key signs tokens, the retired ones keep verifying the tokens they signed until they expire.
+type SigningKey struct {
+	gorm.Model
+	KeyID      string     `gorm:"size:64;not null;uniqueIndex"` // KeyID is the kid header of the tokens signed by the key.
+	Algorithm  string     `gorm:"size:16;not null"`             // Algorithm is the JWS algorithm of the key, such as "RS256".
+	PrivateKey string     `gorm:"type:text;not null" json:"-"`  // PrivateKey is the PKCS #8 private key, PEM-encoded or encrypted.
+	Encrypted  bool       `gorm:"not null;default:false"`       // Encrypted tells whether the private key is encrypted with JWT_KEYSTORE_SECRET.
+	RetiredAt  *time.Time // RetiredAt is the date the key stopped signing tokens, nil for the active key.
+	ExpiresAt  *time.Time `gorm:"index"` // ExpiresAt is the date a retired key stops verifying tokens.
+}
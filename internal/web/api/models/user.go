@@ -1,53 +1,24 @@
 package models
 
 import (
-	"errors"
-	"regexp"
+	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 // User is a model that represents a user.
 type User struct {
 	gorm.Model
-	Name     string   `gorm:"not null;unique"`        // Name is the user's name.
-	Email    string   `gorm:"not null"`               // Email is the user's email.
-	Password string   `gorm:"not null"`               // Password is the user's password.
-	Groups   []*Group `gorm:"many2many:user_groups;"` // Groups is the list of groups the user belongs to.
-}
-
-// ValidatePasswordStrength checks if the password meets the required strength criteria using regex.
-func ValidatePasswordStrength(password string) error {
-	// Check for at least one uppercase letter
-	if !regexp.MustCompile(`[A-Z]`).MatchString(password) {
-		return errors.New("password must contain at least one uppercase letter")
-	}
-	// Check for at least one lowercase letter
-	if !regexp.MustCompile(`[a-z]`).MatchString(password) {
-		return errors.New("password must contain at least one lowercase letter")
-	}
-	// Check for at least one digit
-	if !regexp.MustCompile(`\d`).MatchString(password) {
-		return errors.New("password must contain at least one digit")
-	}
-	// Check for at least one special character
-	if !regexp.MustCompile(`[@$!%*?&]`).MatchString(password) {
-		return errors.New("password must contain at least one special character")
-	}
-	// Check for minimum length of 8
-	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters long")
-	}
-	return nil
-}
-
-// HashPassword hashes the provided password using bcrypt.
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-
-	return string(hashedPassword), nil
+	Name              string     `gorm:"size:255;not null;unique"` // Name is the user's name.
+	Email             string     `gorm:"not null"`                 // Email is the user's email.
+	EmailVerifiedAt   *time.Time // EmailVerifiedAt is the date the user proved they own their email, nil until then.
+	Password          string     `gorm:"not null" json:"-"` // Password is the user's password.
+	PasswordChangedAt *time.Time // PasswordChangedAt is the date the password was last changed, from which its maximum age is counted.
+	TokenVersion      uint       `gorm:"not null;default:0" json:"-"` // TokenVersion is incremented to invalidate every token issued to the user.
+	TOTPSecret        string     `gorm:"size:64" json:"-"`            // TOTPSecret is the base32-encoded TOTP secret, set from the enrollment on.
+	TOTPEnabled       bool       `gorm:"not null;default:false"`      // TOTPEnabled is true once the TOTP enrollment has been verified.
+	TOTPLastCounter   int64      `gorm:"not null;default:0" json:"-"` // TOTPLastCounter is the time step of the last accepted code, so that codes can't be replayed.
+	ServiceAccount    bool       `gorm:"not null;default:false"`      // ServiceAccount is true for the non-human users, which only authenticate with API keys.
+	Disabled          bool       `gorm:"not null;default:false"`      // Disabled is true for the deactivated users, who can't authenticate until they're enabled again.
+	Groups            []*Group   `gorm:"many2many:user_groups;"`      // Groups is the list of groups the user belongs to.
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Purposes of the verification tokens.
+const (
+	VerificationPurposeEmail         = "email_verification"
+	VerificationPurposePasswordReset = "password_reset"
+)
+
+// VerificationToken is a model that represents a single-use token sent by email to a user.
+type VerificationToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"not null;index"`               // UserID is the ID of the user the token was sent to.
+	Purpose   string     `gorm:"size:32;not null;index"`       // Purpose is what the token allows, such as "password_reset".
+	Email     string     `gorm:"not null"`                     // Email is the address the token was sent to.
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"` // TokenHash is the SHA-256 hash of the token.
+	ExpiresAt time.Time  `gorm:"not null"`                     // ExpiresAt is the token's expiration date.
+	UsedAt    *time.Time // UsedAt is the date the token was used, or superseded by a newer one.
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Events sent to the webhooks.
+const (
+	WebhookUserCreated       = "user.created"
+	WebhookUserUpdated       = "user.updated"
+	WebhookUserDeleted       = "user.deleted"
+	WebhookUserRestored      = "user.restored"
+	WebhookGroupCreated      = "group.created"
+	WebhookGroupUpdated      = "group.updated"
+	WebhookGroupDeleted      = "group.deleted"
+	WebhookGroupRestored     = "group.restored"
+	WebhookMembershipAdded   = "membership.added"
+	WebhookMembershipRemoved = "membership.removed"
+	WebhookPing              = "ping" // WebhookPing is only sent on demand, to test a webhook.
+)
+
+// WebhookEvents are the events webhooks can subscribe to.
+var WebhookEvents = []string{
+	WebhookUserCreated,
+	WebhookUserUpdated,
+	WebhookUserDeleted,
+	WebhookUserRestored,
+	WebhookGroupCreated,
+	WebhookGroupUpdated,
+	WebhookGroupDeleted,
+	WebhookGroupRestored,
+	WebhookMembershipAdded,
+	WebhookMembershipRemoved,
+}
+
+// Statuses of the webhook deliveries.
+const (
+	DeliveryPending   = "pending"   // DeliveryPending is the status of the deliveries waiting for their first or next attempt.
+	DeliverySucceeded = "succeeded" // DeliverySucceeded is the status of the deliveries acknowledged with a 2xx response.
+	DeliveryFailed    = "failed"    // DeliveryFailed is the status of the deliveries that ran out of attempts.
+)
+
+// Webhook is a model that represents an endpoint notified of the identity lifecycle events.
+type Webhook struct {
+	gorm.Model
+	Name     string   `gorm:"size:255;not null"`         // Name is the webhook's name.
+	URL      string   `gorm:"not null"`                  // URL is the endpoint the events are posted to.
+	Secret   string   `gorm:"size:64;not null" json:"-"` // Secret is the key of the HMAC signatures of the payloads.
+	Events   []string `gorm:"serializer:json;type:text"` // Events is the list of events the webhook receives, every event when empty.
+	Disabled bool     `gorm:"not null;default:false"`    // Disabled is true for the webhooks that don't receive events anymore.
+}
+
+// Receives tells if the webhook subscribed to an event.
+func (webhook *Webhook) Receives(event string) bool {
+	if webhook.Disabled {
+		return false
+	}
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, subscribed := range webhook.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a model that represents the sending of an event to a webhook, along with its attempts.
+type WebhookDelivery struct {
+	ID             uint            `gorm:"primarykey"`             // ID is the delivery's ID.
+	CreatedAt      time.Time       `gorm:"not null"`               // CreatedAt is the date the event was queued.
+	UpdatedAt      time.Time       `gorm:"not null"`               // UpdatedAt is the date of the last change of the delivery.
+	WebhookID      uint            `gorm:"not null;index"`         // WebhookID is the ID of the webhook the event is sent to.
+	EventID        string          `gorm:"size:64;not null;index"` // EventID identifies the event, it's shared by its redeliveries.
+	Event          string          `gorm:"size:64;not null;index"` // Event is the type of the event, such as "user.created".
+	Payload        json.RawMessage `gorm:"not null"`               // Payload is the JSON body posted to the webhook.
+	Status         string          `gorm:"size:16;not null;index"` // Status is either pending, succeeded or failed.
+	Attempts       int             `gorm:"not null;default:0"`     // Attempts is the number of times the payload has been posted.
+	NextAttemptAt  *time.Time      `gorm:"index"`                  // NextAttemptAt is the date of the next attempt of the pending deliveries.
+	LastAttemptAt  *time.Time      // LastAttemptAt is the date of the last attempt.
+	ResponseStatus int             // ResponseStatus is the HTTP status of the last response, 0 if there wasn't any.
+	ResponseBody   string          `gorm:"type:text"` // ResponseBody is the beginning of the body of the last response.
+	LastError      string          // LastError is the reason of the last failed attempt.
+}
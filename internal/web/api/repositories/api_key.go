@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository defines the methods for interacting with the API key data.
+type APIKeyRepository interface {
+	Get(id uint) (*models.APIKey, error)
+	GetByHash(tokenHash string) (*models.APIKey, error)
+	GetAll(listQuery *query.ListQuery) ([]*models.APIKey, *query.PageInfo, error)
+	GetUserKeys(userID uint) ([]*models.APIKey, error)
+	Create(apiKey *models.APIKey) error
+	MarkUsed(id uint, date time.Time, ip string) error
+	Delete(id uint) error
+	DeleteUserKeys(userID uint) error
+}
+
+// APIKeyListFields are the fields API keys can be filtered and sorted on.
+var APIKeyListFields = query.Fields{
+	"id":           {Column: "id", Type: query.Number},
+	"user_id":      {Column: "user_id", Type: query.Number},
+	"name":         {Column: "name", Type: query.String},
+	"prefix":       {Column: "prefix", Type: query.String},
+	"expires_at":   {Column: "expires_at", Type: query.Time},
+	"last_used_at": {Column: "last_used_at", Type: query.Time},
+	"created_at":   {Column: "created_at", Type: query.Time},
+}
+
+// APIKeyRepositoryImplementation is an implementation of the APIKeyRepository using Gorm.
+type APIKeyRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewAPIKeyRepository(database *gorm.DB) APIKeyRepository {
+	return &APIKeyRepositoryImplementation{database: database}
+}
+
+// Get retrieves an API key by ID.
+func (repo *APIKeyRepositoryImplementation) Get(id uint) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	if err := repo.database.First(&apiKey, id).Error; err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// GetByHash retrieves an API key by its hash.
+func (repo *APIKeyRepositoryImplementation) GetByHash(tokenHash string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	if err := repo.database.Where("token_hash = ?", tokenHash).First(&apiKey).Error; err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// GetAll retrieves a page of API keys.
+func (repo *APIKeyRepositoryImplementation) GetAll(listQuery *query.ListQuery) ([]*models.APIKey, *query.PageInfo, error) {
+	var apiKeys []*models.APIKey
+	pageInfo, err := query.Find(repo.database.Model(&models.APIKey{}), listQuery, &apiKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return apiKeys, pageInfo, nil
+}
+
+// GetUserKeys retrieves the API keys of a user, the newest first.
+func (repo *APIKeyRepositoryImplementation) GetUserKeys(userID uint) ([]*models.APIKey, error) {
+	var apiKeys []*models.APIKey
+	if err := repo.database.Where("user_id = ?", userID).Order("created_at DESC").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// Create adds a new API key.
+func (repo *APIKeyRepositoryImplementation) Create(apiKey *models.APIKey) error {
+	return repo.database.Create(apiKey).Error
+}
+
+// MarkUsed records the date and the client IP of the last use of an API key.
+func (repo *APIKeyRepositoryImplementation) MarkUsed(id uint, date time.Time, ip string) error {
+	return repo.database.Model(&models.APIKey{}).Where("id = ?", id).
+		UpdateColumns(map[string]any{"last_used_at": date, "last_used_ip": ip}).Error
+}
+
+// Delete revokes an API key by ID.
+func (repo *APIKeyRepositoryImplementation) Delete(id uint) error {
+	return repo.database.Delete(&models.APIKey{}, id).Error
+}
+
+// DeleteUserKeys revokes every API key of a user.
+func (repo *APIKeyRepositoryImplementation) DeleteUserKeys(userID uint) error {
+	return repo.database.Where("user_id = ?", userID).Delete(&models.APIKey{}).Error
+}
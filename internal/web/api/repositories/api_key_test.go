@@ -0,0 +1,68 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"gorm.io/gorm"
+)
+
+func TestAPIKeyRepository(t *testing.T) {
+	apiKeyRepository := repositories.NewAPIKeyRepository(dbtest.Open(t))
+
+	apiKey := &models.APIKey{UserID: 1, Name: "ci", Prefix: "gods_abc", TokenHash: "hash", Scopes: []string{"users:read"}, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := apiKeyRepository.Create(apiKey); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	got, err := apiKeyRepository.GetByHash("hash")
+	if err != nil || got.ID != apiKey.ID || len(got.Scopes) != 1 || got.Scopes[0] != "users:read" {
+		t.Fatalf("GetByHash() = %v, %v, want the key with its scopes", got, err)
+	}
+
+	usedAt := time.Now().Truncate(time.Second)
+	if err := apiKeyRepository.MarkUsed(apiKey.ID, usedAt, "192.0.2.1"); err != nil {
+		t.Fatalf("MarkUsed() error = %v", err)
+	}
+	if got, err := apiKeyRepository.Get(apiKey.ID); err != nil || got.LastUsedAt == nil || !got.LastUsedAt.Equal(usedAt) || got.LastUsedIP != "192.0.2.1" {
+		t.Errorf("Get() after MarkUsed() = %v, %v, want the last use recorded", got, err)
+	}
+
+	if keys, err := apiKeyRepository.GetUserKeys(1); err != nil || len(keys) != 1 {
+		t.Errorf("GetUserKeys() = %v, %v, want the key", keys, err)
+	}
+
+	if err := apiKeyRepository.Delete(apiKey.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := apiKeyRepository.GetByHash("hash"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetByHash() of a revoked key error = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestAPIKeyRepositoryDeleteUserKeys(t *testing.T) {
+	apiKeyRepository := repositories.NewAPIKeyRepository(dbtest.Open(t))
+
+	for userID, tokenHashes := range map[uint][]string{1: {"one", "two"}, 2: {"three"}} {
+		for _, tokenHash := range tokenHashes {
+			apiKey := &models.APIKey{UserID: userID, Name: "ci", Prefix: "gods_abc", TokenHash: tokenHash, ExpiresAt: time.Now().Add(time.Hour)}
+			if err := apiKeyRepository.Create(apiKey); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+		}
+	}
+
+	if err := apiKeyRepository.DeleteUserKeys(1); err != nil {
+		t.Fatalf("DeleteUserKeys() error = %v", err)
+	}
+	if keys, err := apiKeyRepository.GetUserKeys(1); err != nil || len(keys) != 0 {
+		t.Errorf("GetUserKeys() of the user = %v, %v, want none", keys, err)
+	}
+	if keys, err := apiKeyRepository.GetUserKeys(2); err != nil || len(keys) != 1 {
+		t.Errorf("GetUserKeys() of another user = %v, %v, want their key", keys, err)
+	}
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"gorm.io/gorm"
+)
+
+// AuditEventRepository defines the methods for interacting with the audit log. Events can't be modified nor deleted.
+type AuditEventRepository interface {
+	GetAll(listQuery *query.ListQuery) ([]*models.AuditEvent, *query.PageInfo, error)
+	Create(auditEvent *models.AuditEvent) error
+}
+
+// AuditEventListFields are the fields audit events can be filtered and sorted on.
+var AuditEventListFields = query.Fields{
+	"id":          {Column: "id", Type: query.Number},
+	"actor_id":    {Column: "actor_id", Type: query.Number},
+	"action":      {Column: "action", Type: query.String},
+	"target_type": {Column: "target_type", Type: query.String},
+	"target_id":   {Column: "target_id", Type: query.Number},
+	"details":     {Column: "details", Type: query.String},
+	"ip":          {Column: "ip", Type: query.String},
+	"created_at":  {Column: "created_at", Type: query.Time},
+}
+
+// AuditEventRepositoryImplementation is an implementation of the AuditEventRepository using Gorm.
+type AuditEventRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewAuditEventRepository(database *gorm.DB) AuditEventRepository {
+	return &AuditEventRepositoryImplementation{database: database}
+}
+
+// GetAll retrieves a page of audit events.
+func (repo *AuditEventRepositoryImplementation) GetAll(listQuery *query.ListQuery) ([]*models.AuditEvent, *query.PageInfo, error) {
+	var auditEvents []*models.AuditEvent
+	pageInfo, err := query.Find(repo.database.Model(&models.AuditEvent{}), listQuery, &auditEvents)
+	if err != nil {
+		return nil, nil, err
+	}
+	return auditEvents, pageInfo, nil
+}
+
+// Create adds a new audit event.
+func (repo *AuditEventRepositoryImplementation) Create(auditEvent *models.AuditEvent) error {
+	return repo.database.Create(auditEvent).Error
+}
@@ -0,0 +1,56 @@
+package repositories_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+)
+
+func TestAuditEventRepository(t *testing.T) {
+	auditEventRepository := repositories.NewAuditEventRepository(dbtest.Open(t))
+
+	actorID, targetID := uint(1), uint(2)
+	start := time.Now().Add(-time.Hour)
+	for _, auditEvent := range []*models.AuditEvent{
+		{CreatedAt: start, ActorID: &actorID, Action: "user.create", TargetType: "user", TargetID: &targetID, Changes: json.RawMessage(`{"name":{"after":"bob"}}`)},
+		{CreatedAt: start.Add(time.Minute), Action: "auth.login_failed", Details: "name=bob", IP: "192.0.2.1"},
+		{CreatedAt: start.Add(2 * time.Minute), ActorID: &actorID, Action: "user.delete", TargetType: "user", TargetID: &targetID},
+	} {
+		if err := auditEventRepository.Create(auditEvent); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		filters   []query.Filter
+		wantTotal int64
+	}{
+		{name: "every event", wantTotal: 3},
+		{name: "by actor", filters: []query.Filter{{Column: "actor_id", Operator: "=", Value: int64(actorID)}}, wantTotal: 2},
+		{name: "by action", filters: []query.Filter{{Column: "action", Operator: "=", Value: "auth.login_failed"}}, wantTotal: 1},
+		{name: "by date", filters: []query.Filter{{Column: "created_at", Operator: ">", Value: start.Add(30 * time.Second)}}, wantTotal: 2},
+		{name: "by details", filters: []query.Filter{{Column: "details", Operator: "~", Value: "BOB"}}, wantTotal: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, pageInfo, err := auditEventRepository.GetAll(&query.ListQuery{Limit: 10, Filters: test.filters})
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+			if pageInfo.Total != test.wantTotal {
+				t.Errorf("GetAll() total = %d, want %d", pageInfo.Total, test.wantTotal)
+			}
+		})
+	}
+
+	auditEvents, _, err := auditEventRepository.GetAll(&query.ListQuery{Limit: 1, Sorts: []query.Sort{{Column: "created_at", Descending: true}}})
+	if err != nil || len(auditEvents) != 1 || auditEvents[0].Action != "user.delete" {
+		t.Errorf("GetAll() of the latest event = %v, %v, want user.delete", auditEvents, err)
+	}
+}
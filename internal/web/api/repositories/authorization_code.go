@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// AuthorizationCodeRepository defines the methods for interacting with the authorization code data.
+type AuthorizationCodeRepository interface {
+	GetByHash(codeHash string) (*models.AuthorizationCode, error)
+	Create(authorizationCode *models.AuthorizationCode) error
+	MarkUsed(id uint, date time.Time) (bool, error)
+}
+
+// AuthorizationCodeRepositoryImplementation is an implementation of the AuthorizationCodeRepository using Gorm.
+type AuthorizationCodeRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewAuthorizationCodeRepository(database *gorm.DB) AuthorizationCodeRepository {
+	return &AuthorizationCodeRepositoryImplementation{database: database}
+}
+
+// GetByHash retrieves an authorization code by its hash.
+func (repo *AuthorizationCodeRepositoryImplementation) GetByHash(codeHash string) (*models.AuthorizationCode, error) {
+	var authorizationCode models.AuthorizationCode
+	if err := repo.database.Where("code_hash = ?", codeHash).First(&authorizationCode).Error; err != nil {
+		return nil, err
+	}
+	return &authorizationCode, nil
+}
+
+// Create adds a new authorization code.
+func (repo *AuthorizationCodeRepositoryImplementation) Create(authorizationCode *models.AuthorizationCode) error {
+	return repo.database.Create(authorizationCode).Error
+}
+
+// MarkUsed marks an authorization code as used, and returns false if it already was: only one of concurrent
+// exchanges of a code can succeed.
+func (repo *AuthorizationCodeRepositoryImplementation) MarkUsed(id uint, date time.Time) (bool, error) {
+	result := repo.database.Model(&models.AuthorizationCode{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", date)
+	return result.RowsAffected == 1, result.Error
+}
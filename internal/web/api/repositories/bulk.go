@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// ImportGroup is a group of a bulk import, created unless it already exists, along with the names of the
+// groups to nest in it.
+type ImportGroup struct {
+	Group     *models.Group
+	Exists    bool
+	Subgroups []string
+	Err       error // Err is set when the group couldn't be imported.
+}
+
+// ImportUser is a user of a bulk import, along with the names of the groups to add them to.
+type ImportUser struct {
+	User   *models.User
+	Groups []string
+	Err    error // Err is set when the user couldn't be imported.
+}
+
+// BulkRepository defines the methods for importing users and groups in bulk.
+type BulkRepository interface {
+	Import(groups []*ImportGroup, users []*ImportUser, atomic bool) error
+}
+
+// BulkRepositoryImplementation is an implementation of the BulkRepository using Gorm.
+type BulkRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewBulkRepository(database *gorm.DB) BulkRepository {
+	return &BulkRepositoryImplementation{database: database}
+}
+
+// Import creates the groups, then nests them, then creates the users and adds them to their groups. Each group
+// and user is imported in its own transaction, so that a failure doesn't leave it half imported, and sets its Err.
+// Groups and users fail when one of the groups to nest them in or to add them to can't be found.
+// Atomic imports run in a single transaction and stop at the first failure, which is returned, rolling back
+// everything.
+func (repo *BulkRepositoryImplementation) Import(groups []*ImportGroup, users []*ImportUser, atomic bool) error {
+	importAll := func(database *gorm.DB) error {
+		for _, group := range groups {
+			if !group.Exists {
+				group.Err = database.Transaction(func(tx *gorm.DB) error {
+					return tx.Create(group.Group).Error
+				})
+			} else {
+				group.Err = database.Where("name = ?", group.Group.Name).First(group.Group).Error
+			}
+			if group.Err != nil && atomic {
+				return group.Err
+			}
+		}
+
+		for _, group := range groups {
+			if group.Err != nil || len(group.Subgroups) == 0 {
+				continue
+			}
+			group.Err = database.Transaction(func(tx *gorm.DB) error {
+				var subgroups []*models.Group
+				if err := tx.Where("name IN ?", group.Subgroups).Find(&subgroups).Error; err != nil {
+					return err
+				}
+				if err := checkFound(group.Subgroups, subgroups); err != nil {
+					return err
+				}
+				return tx.Model(group.Group).Association("Subgroups").Append(subgroups)
+			})
+			if group.Err != nil && atomic {
+				return group.Err
+			}
+		}
+
+		for _, user := range users {
+			user.Err = database.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(user.User).Error; err != nil {
+					return err
+				}
+				if len(user.Groups) == 0 {
+					return nil
+				}
+				var groups []*models.Group
+				if err := tx.Where("name IN ?", user.Groups).Find(&groups).Error; err != nil {
+					return err
+				}
+				if err := checkFound(user.Groups, groups); err != nil {
+					return err
+				}
+				return tx.Model(user.User).Association("Groups").Append(groups)
+			})
+			if user.Err != nil && atomic {
+				return user.Err
+			}
+		}
+
+		return nil
+	}
+
+	if atomic {
+		return repo.database.Transaction(importAll)
+	}
+	return importAll(repo.database)
+}
+
+// checkFound returns an error naming the groups that weren't found, such as the groups that failed to be imported.
+func checkFound(names []string, groups []*models.Group) error {
+	var missing []string
+	for _, name := range names {
+		if !slices.ContainsFunc(groups, func(group *models.Group) bool { return group.Name == name }) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("groups not found: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repositories_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+)
+
+func TestBulkRepositoryImport(t *testing.T) {
+	database := dbtest.Open(t)
+	bulkRepository := repositories.NewBulkRepository(database)
+	userRepository := repositories.NewUserRepository(database)
+	groupRepository := repositories.NewGroupRepository(database)
+	userGroupRepository := repositories.NewUserGroupRepository(database)
+
+	createGroup(t, groupRepository, "existing")
+	createUser(t, userRepository, "taken")
+
+	groups := []*repositories.ImportGroup{
+		{Group: &models.Group{Name: "staff"}, Subgroups: []string{"engineers"}},
+		{Group: &models.Group{Name: "engineers"}},
+		{Group: &models.Group{Name: "existing"}, Exists: true},
+	}
+	users := []*repositories.ImportUser{
+		{User: &models.User{Name: "alice", Email: "alice@example.com", Password: "hash"}, Groups: []string{"engineers", "existing"}},
+		{User: &models.User{Name: "taken", Email: "taken@example.com", Password: "hash"}, Groups: []string{"staff"}},
+		{User: &models.User{Name: "bob", Email: "bob@example.com", Password: "hash"}, Groups: []string{"staff", "missing"}},
+	}
+	if err := bulkRepository.Import(groups, users, false); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	for _, group := range groups {
+		if group.Err != nil || group.Group.ID == 0 {
+			t.Errorf("group %s = %d, %v, want imported", group.Group.Name, group.Group.ID, group.Err)
+		}
+	}
+	if users[0].Err != nil {
+		t.Errorf("user alice error = %v", users[0].Err)
+	}
+	// A failed user is reported without stopping the import
+	if users[1].Err == nil {
+		t.Error("user taken was imported over an existing user")
+	}
+	// A user whose groups aren't all found isn't imported
+	if users[2].Err == nil {
+		t.Error("user bob was imported without one of their groups")
+	}
+	if _, err := userRepository.GetByName("bob"); err == nil {
+		t.Error("user bob was kept after failing to be added to their groups")
+	}
+
+	subgroups, _, err := userGroupRepository.GetSubgroups(groups[0].Group.ID, &query.ListQuery{Limit: 10})
+	if err != nil || !reflect.DeepEqual(names(subgroups), []string{"engineers"}) {
+		t.Errorf("subgroups of staff = %v, %v, want [engineers]", names(subgroups), err)
+	}
+	memberships, _, err := userGroupRepository.GetUserGroups(users[0].User.ID, &query.ListQuery{Limit: 10, Sorts: []query.Sort{{Column: "name"}}})
+	if err != nil || !reflect.DeepEqual(names(memberships), []string{"engineers", "existing"}) {
+		t.Errorf("groups of alice = %v, %v, want [engineers existing]", names(memberships), err)
+	}
+}
+
+func TestBulkRepositoryAtomicImport(t *testing.T) {
+	database := dbtest.Open(t)
+	bulkRepository := repositories.NewBulkRepository(database)
+	userRepository := repositories.NewUserRepository(database)
+	groupRepository := repositories.NewGroupRepository(database)
+
+	createUser(t, userRepository, "taken")
+
+	groups := []*repositories.ImportGroup{{Group: &models.Group{Name: "staff"}}}
+	users := []*repositories.ImportUser{
+		{User: &models.User{Name: "alice", Email: "alice@example.com", Password: "hash"}, Groups: []string{"staff"}},
+		{User: &models.User{Name: "taken", Email: "taken@example.com", Password: "hash"}},
+	}
+	if err := bulkRepository.Import(groups, users, true); err == nil {
+		t.Fatal("atomic Import() with a failing user succeeded")
+	}
+
+	// Nothing of a failed atomic import is kept
+	if _, err := groupRepository.GetByName("staff"); err == nil {
+		t.Error("group staff was kept after a failed atomic import")
+	}
+	if _, err := userRepository.GetByName("alice"); err == nil {
+		t.Error("user alice was kept after a failed atomic import")
+	}
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"gorm.io/gorm"
+)
+
+// ClientRepository defines the methods for interacting with the OpenID Connect client data.
+type ClientRepository interface {
+	Get(id uint) (*models.Client, error)
+	GetByClientID(clientID string) (*models.Client, error)
+	GetAll(listQuery *query.ListQuery) ([]*models.Client, *query.PageInfo, error)
+	Create(client *models.Client) error
+	Update(client *models.Client) error
+	Delete(id uint) error
+}
+
+// ClientListFields are the fields clients can be filtered and sorted on.
+var ClientListFields = query.Fields{
+	"id":         {Column: "id", Type: query.Number},
+	"client_id":  {Column: "client_id", Type: query.String},
+	"name":       {Column: "name", Type: query.String},
+	"created_at": {Column: "created_at", Type: query.Time},
+	"updated_at": {Column: "updated_at", Type: query.Time},
+}
+
+// ClientRepositoryImplementation is an implementation of the ClientRepository using Gorm.
+type ClientRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewClientRepository(database *gorm.DB) ClientRepository {
+	return &ClientRepositoryImplementation{database: database}
+}
+
+// Get retrieves a client by ID.
+func (repo *ClientRepositoryImplementation) Get(id uint) (*models.Client, error) {
+	var client models.Client
+	if err := repo.database.First(&client, id).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// GetByClientID retrieves a client by its public identifier.
+func (repo *ClientRepositoryImplementation) GetByClientID(clientID string) (*models.Client, error) {
+	var client models.Client
+	if err := repo.database.Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// GetAll retrieves a page of clients.
+func (repo *ClientRepositoryImplementation) GetAll(listQuery *query.ListQuery) ([]*models.Client, *query.PageInfo, error) {
+	var clients []*models.Client
+	pageInfo, err := query.Find(repo.database.Model(&models.Client{}), listQuery, &clients)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clients, pageInfo, nil
+}
+
+// Create adds a new client.
+func (repo *ClientRepositoryImplementation) Create(client *models.Client) error {
+	return repo.database.Create(client).Error
+}
+
+// Update modifies an existing client.
+func (repo *ClientRepositoryImplementation) Update(client *models.Client) error {
+	return repo.database.Save(client).Error
+}
+
+// Delete removes a client by ID.
+func (repo *ClientRepositoryImplementation) Delete(id uint) error {
+	return repo.database.Delete(&models.Client{}, id).Error
+}
@@ -0,0 +1,55 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"gorm.io/gorm"
+)
+
+func TestClientAndAuthorizationCodeRepositories(t *testing.T) {
+	database := dbtest.Open(t)
+	clientRepository := repositories.NewClientRepository(database)
+	authorizationCodeRepository := repositories.NewAuthorizationCodeRepository(database)
+
+	client := &models.Client{ClientID: "app", Name: "App", RedirectURIs: []string{"https://app.example.com/callback"}, GrantTypes: []string{models.GrantTypeAuthorizationCode}}
+	if err := clientRepository.Create(client); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	got, err := clientRepository.GetByClientID("app")
+	if err != nil || len(got.RedirectURIs) != 1 || got.RedirectURIs[0] != "https://app.example.com/callback" {
+		t.Fatalf("GetByClientID() = %v, %v, want the client with its redirect URIs", got, err)
+	}
+	if err := clientRepository.Create(&models.Client{ClientID: "app", Name: "Other"}); err == nil {
+		t.Error("Create() of a duplicate client ID succeeded")
+	}
+
+	code := &models.AuthorizationCode{CodeHash: "code", ClientID: "app", UserID: 1, RedirectURI: "https://app.example.com/callback", AuthTime: time.Now(), ExpiresAt: time.Now().Add(time.Minute)}
+	if err := authorizationCodeRepository.Create(code); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got, err := authorizationCodeRepository.GetByHash("code"); err != nil || got.ClientID != "app" || got.UsedAt != nil {
+		t.Errorf("GetByHash() = %v, %v, want the unused code", got, err)
+	}
+	// A code can only be used once
+	if used, err := authorizationCodeRepository.MarkUsed(code.ID, time.Now()); err != nil || !used {
+		t.Errorf("MarkUsed() = %v, %v, want true", used, err)
+	}
+	if used, err := authorizationCodeRepository.MarkUsed(code.ID, time.Now()); err != nil || used {
+		t.Errorf("second MarkUsed() = %v, %v, want false", used, err)
+	}
+	if got, err := authorizationCodeRepository.GetByHash("code"); err != nil || got.UsedAt == nil {
+		t.Errorf("GetByHash() = %v, %v, want the used code", got, err)
+	}
+
+	if err := clientRepository.Delete(client.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := clientRepository.GetByClientID("app"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetByClientID() of a deleted client error = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
@@ -1,7 +1,10 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
 	"gorm.io/gorm"
 )
 
@@ -9,10 +12,35 @@ import (
 type GroupRepository interface {
 	Get(id uint) (*models.Group, error)
 	GetByName(name string) (*models.Group, error)
-	GetAll() ([]*models.Group, error)
+	GetAll(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	GetAllWithUsers(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
 	Create(group *models.Group) error
 	Update(group *models.Group) error
 	Delete(id uint) error
+	GetDeleted(id uint) (*models.Group, error)
+	GetDeletedByName(name string) (*models.Group, error)
+	GetAllDeleted(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	GetDeletedBefore(date time.Time) ([]*models.Group, error)
+	Restore(id uint) error
+	Purge(id uint) error
+}
+
+// GroupListFields are the fields groups can be filtered and sorted on.
+var GroupListFields = query.Fields{
+	"id":          {Column: "id", Type: query.Number},
+	"name":        {Column: "name", Type: query.String},
+	"description": {Column: "description", Type: query.String},
+	"created_at":  {Column: "created_at", Type: query.Time},
+	"updated_at":  {Column: "updated_at", Type: query.Time},
+}
+
+// DeletedGroupListFields are the fields deleted groups can be filtered and sorted on.
+var DeletedGroupListFields = query.Fields{
+	"id":          {Column: "id", Type: query.Number},
+	"name":        {Column: "name", Type: query.String},
+	"description": {Column: "description", Type: query.String},
+	"created_at":  {Column: "created_at", Type: query.Time},
+	"deleted_at":  {Column: "deleted_at", Type: query.Time},
 }
 
 // GroupRepositoryImplementation is an implementation of the GroupRepository using Gorm.
@@ -42,13 +70,24 @@ func (repo *GroupRepositoryImplementation) GetByName(name string) (*models.Group
 	return &group, nil
 }
 
-// GetAll retrieves all groups.
-func (repo *GroupRepositoryImplementation) GetAll() ([]*models.Group, error) {
+// GetAll retrieves a page of groups.
+func (repo *GroupRepositoryImplementation) GetAll(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
 	var groups []*models.Group
-	if err := repo.database.Find(&groups).Error; err != nil {
-		return nil, err
+	pageInfo, err := query.Find(repo.database.Model(&models.Group{}), listQuery, &groups)
+	if err != nil {
+		return nil, nil, err
 	}
-	return groups, nil
+	return groups, pageInfo, nil
+}
+
+// GetAllWithUsers retrieves a page of groups along with their users and subgroups.
+func (repo *GroupRepositoryImplementation) GetAllWithUsers(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	var groups []*models.Group
+	pageInfo, err := query.Find(repo.database.Model(&models.Group{}).Preload("Users").Preload("Subgroups"), listQuery, &groups)
+	if err != nil {
+		return nil, nil, err
+	}
+	return groups, pageInfo, nil
 }
 
 // Create adds a new group.
@@ -61,7 +100,66 @@ func (repo *GroupRepositoryImplementation) Update(group *models.Group) error {
 	return repo.database.Save(group).Error
 }
 
-// Delete removes a group by ID.
+// Delete removes a group by ID. The group is only soft-deleted, along with its memberships which are kept for
+// a restoration, until purged.
 func (repo *GroupRepositoryImplementation) Delete(id uint) error {
 	return repo.database.Delete(&models.Group{}, id).Error
 }
+
+// GetDeleted retrieves a deleted group by ID.
+func (repo *GroupRepositoryImplementation) GetDeleted(id uint) (*models.Group, error) {
+	var group models.Group
+	if err := repo.database.Unscoped().Where("deleted_at IS NOT NULL").First(&group, id).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GetDeletedByName retrieves a deleted group by name.
+func (repo *GroupRepositoryImplementation) GetDeletedByName(name string) (*models.Group, error) {
+	var group models.Group
+	if err := repo.database.Unscoped().Where("name = ? AND deleted_at IS NOT NULL", name).First(&group).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GetAllDeleted retrieves a page of deleted groups.
+func (repo *GroupRepositoryImplementation) GetAllDeleted(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	var groups []*models.Group
+	pageInfo, err := query.Find(repo.database.Unscoped().Model(&models.Group{}).Where("deleted_at IS NOT NULL"), listQuery, &groups)
+	if err != nil {
+		return nil, nil, err
+	}
+	return groups, pageInfo, nil
+}
+
+// GetDeletedBefore retrieves the groups deleted before a date.
+func (repo *GroupRepositoryImplementation) GetDeletedBefore(date time.Time) ([]*models.Group, error) {
+	var groups []*models.Group
+	if err := repo.database.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", date).Order("id").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// Restore undeletes a deleted group, whose members, subgroups and roles are effective again.
+func (repo *GroupRepositoryImplementation) Restore(id uint) error {
+	return repo.database.Unscoped().Model(&models.Group{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil).Error
+}
+
+// Purge permanently removes a deleted group, along with its memberships, its nestings and its role grants.
+func (repo *GroupRepositoryImplementation) Purge(id uint) error {
+	return repo.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_groups WHERE group_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM group_subgroups WHERE group_id = ? OR subgroup_id = ?", id, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM group_roles WHERE group_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("deleted_at IS NOT NULL").Delete(&models.Group{}, id).Error
+	})
+}
@@ -0,0 +1,88 @@
+package repositories_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"gorm.io/gorm"
+)
+
+func TestGroupRepositoryCRUD(t *testing.T) {
+	groupRepository := repositories.NewGroupRepository(dbtest.Open(t))
+
+	staff := createGroup(t, groupRepository, "staff")
+	got, err := groupRepository.GetByName("staff")
+	if err != nil || got.ID != staff.ID {
+		t.Fatalf("GetByName() = %v, %v, want staff", got, err)
+	}
+
+	got.Description = "Everyone"
+	if err := groupRepository.Update(got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got, err := groupRepository.Get(staff.ID); err != nil || got.Description != "Everyone" {
+		t.Errorf("Get() after Update() = %v, %v, want the new description", got, err)
+	}
+
+	if err := groupRepository.Create(&models.Group{Name: "staff"}); err == nil {
+		t.Error("Create() of a duplicate name succeeded")
+	}
+
+	groups, pageInfo, err := groupRepository.GetAll(&query.ListQuery{Limit: 10, Filters: []query.Filter{{Column: "description", Operator: "~", Value: "every"}}})
+	if err != nil || pageInfo.Total != 1 || !reflect.DeepEqual(names(groups), []string{"staff"}) {
+		t.Errorf("GetAll() = %v, %v, want [staff]", names(groups), err)
+	}
+}
+
+func TestGroupRepositoryDeleteRestorePurge(t *testing.T) {
+	database := dbtest.Open(t)
+	userRepository := repositories.NewUserRepository(database)
+	groupRepository := repositories.NewGroupRepository(database)
+	userGroupRepository := repositories.NewUserGroupRepository(database)
+
+	alice := createUser(t, userRepository, "alice")
+	staff := createGroup(t, groupRepository, "staff")
+	if err := userGroupRepository.AddUserToGroup(alice.ID, staff.ID); err != nil {
+		t.Fatalf("AddUserToGroup() error = %v", err)
+	}
+
+	if err := groupRepository.Delete(staff.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := groupRepository.Get(staff.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Get() of a deleted group error = %v, want gorm.ErrRecordNotFound", err)
+	}
+	if groupIDs, err := userGroupRepository.GetEffectiveGroupIDs(alice.ID); err != nil || len(groupIDs) != 0 {
+		t.Errorf("GetEffectiveGroupIDs() with a deleted group = %v, %v, want none", groupIDs, err)
+	}
+	if deleted, err := groupRepository.GetDeletedByName("staff"); err != nil || deleted.ID != staff.ID {
+		t.Errorf("GetDeletedByName() = %v, %v, want staff", deleted, err)
+	}
+
+	// The memberships are kept while the group is deleted
+	if err := groupRepository.Restore(staff.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if groupIDs, err := userGroupRepository.GetEffectiveGroupIDs(alice.ID); err != nil || !reflect.DeepEqual(groupIDs, []uint{staff.ID}) {
+		t.Errorf("GetEffectiveGroupIDs() with a restored group = %v, %v, want [%d]", groupIDs, err, staff.ID)
+	}
+
+	if err := groupRepository.Delete(staff.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := groupRepository.Purge(staff.ID); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if _, err := groupRepository.GetDeleted(staff.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetDeleted() of a purged group error = %v, want gorm.ErrRecordNotFound", err)
+	}
+	var memberships int64
+	if err := database.Table("user_groups").Where("group_id = ?", staff.ID).Count(&memberships).Error; err != nil || memberships != 0 {
+		t.Errorf("memberships of a purged group = %d, %v, want 0", memberships, err)
+	}
+}
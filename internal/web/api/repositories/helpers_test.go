@@ -0,0 +1,53 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+)
+
+// createUser adds a user named after its argument.
+func createUser(t *testing.T, userRepository repositories.UserRepository, name string) *models.User {
+	t.Helper()
+
+	user := &models.User{Name: name, Email: name + "@example.com", Password: "hash"}
+	if err := userRepository.Create(user); err != nil {
+		t.Fatalf("Create(%s) error = %v", name, err)
+	}
+	return user
+}
+
+// createGroup adds a group named after its argument.
+func createGroup(t *testing.T, groupRepository repositories.GroupRepository, name string) *models.Group {
+	t.Helper()
+
+	group := &models.Group{Name: name}
+	if err := groupRepository.Create(group); err != nil {
+		t.Fatalf("Create(%s) error = %v", name, err)
+	}
+	return group
+}
+
+// names returns the names of users or groups.
+func names[T interface{ *models.User | *models.Group }](records []T) []string {
+	result := make([]string, 0, len(records))
+	for _, record := range records {
+		switch record := any(record).(type) {
+		case *models.User:
+			result = append(result, record.Name)
+		case *models.Group:
+			result = append(result, record.Name)
+		}
+	}
+	return result
+}
+
+// idSet returns the set of a list of IDs.
+func idSet(ids []uint) map[uint]bool {
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
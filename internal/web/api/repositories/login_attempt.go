@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+)
+
+// LoginAttemptRepository defines the methods for interacting with the failed attempts counters.
+// The default implementation keeps them in memory, a shared store can implement it to count attempts across instances.
+type LoginAttemptRepository interface {
+	Get(scope string, value string) (*models.LoginAttempt, error)
+	GetLocked(now time.Time) ([]*models.LoginAttempt, error)
+	Increment(scope string, value string, now time.Time) (int, error)
+	Lock(scope string, value string, until time.Time) error
+	Delete(scope string, value string) error
+	DeleteOlderThan(date time.Time) error
+}
+
+// InMemoryLoginAttemptRepository is an implementation of the LoginAttemptRepository keeping the counters in memory.
+type InMemoryLoginAttemptRepository struct {
+	mutex    sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func NewInMemoryLoginAttemptRepository() LoginAttemptRepository {
+	return &InMemoryLoginAttemptRepository{attempts: map[string]models.LoginAttempt{}}
+}
+
+// Get retrieves the counter of a scope and value, a new one if there is none.
+func (repo *InMemoryLoginAttemptRepository) Get(scope string, value string) (*models.LoginAttempt, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	loginAttempt, ok := repo.attempts[scope+":"+value]
+	if !ok {
+		return &models.LoginAttempt{Scope: scope, Value: value}, nil
+	}
+	return &loginAttempt, nil
+}
+
+// GetLocked retrieves the counters that are locked at the given date.
+func (repo *InMemoryLoginAttemptRepository) GetLocked(now time.Time) ([]*models.LoginAttempt, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	locked := []*models.LoginAttempt{}
+	for _, loginAttempt := range repo.attempts {
+		if loginAttempt.LockedUntil.After(now) {
+			locked = append(locked, &loginAttempt)
+		}
+	}
+	return locked, nil
+}
+
+// Increment counts a failure at the given date against a scope and value, creating their counter if needed,
+// and returns the number of failures including it.
+func (repo *InMemoryLoginAttemptRepository) Increment(scope string, value string, now time.Time) (int, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	key := scope + ":" + value
+	loginAttempt, ok := repo.attempts[key]
+	if !ok {
+		loginAttempt = models.LoginAttempt{Scope: scope, Value: value}
+	}
+	loginAttempt.Failures++
+	loginAttempt.LastFailureAt = now
+	repo.attempts[key] = loginAttempt
+	return loginAttempt.Failures, nil
+}
+
+// Lock locks the counter of a scope and value until the given date, unless it's already locked for longer.
+// Counters reset in the meantime aren't locked.
+func (repo *InMemoryLoginAttemptRepository) Lock(scope string, value string, until time.Time) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	key := scope + ":" + value
+	loginAttempt, ok := repo.attempts[key]
+	if ok && until.After(loginAttempt.LockedUntil) {
+		loginAttempt.LockedUntil = until
+		repo.attempts[key] = loginAttempt
+	}
+	return nil
+}
+
+// Delete removes the counter of a scope and value.
+func (repo *InMemoryLoginAttemptRepository) Delete(scope string, value string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	delete(repo.attempts, scope+":"+value)
+	return nil
+}
+
+// DeleteOlderThan removes the unlocked counters whose last failure happened before the given date.
+func (repo *InMemoryLoginAttemptRepository) DeleteOlderThan(date time.Time) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	for key, loginAttempt := range repo.attempts {
+		if loginAttempt.LastFailureAt.Before(date) && loginAttempt.LockedUntil.Before(date) {
+			delete(repo.attempts, key)
+		}
+	}
+	return nil
+}
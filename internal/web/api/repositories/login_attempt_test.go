@@ -0,0 +1,70 @@
+package repositories_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+)
+
+func TestInMemoryLoginAttemptRepositoryIncrement(t *testing.T) {
+	loginAttemptRepository := repositories.NewInMemoryLoginAttemptRepository()
+
+	// Concurrent failures must all be counted
+	const failures = 50
+	now := time.Now()
+	var wg sync.WaitGroup
+	for range failures {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := loginAttemptRepository.Increment("account", "alice", now); err != nil {
+				t.Errorf("Increment() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count, err := loginAttemptRepository.Increment("account", "alice", now); err != nil || count != failures+1 {
+		t.Errorf("Increment() = %d, %v, want %d", count, err, failures+1)
+	}
+	if count, err := loginAttemptRepository.Increment("ip", "alice", now); err != nil || count != 1 {
+		t.Errorf("Increment() of another scope = %d, %v, want 1", count, err)
+	}
+}
+
+func TestInMemoryLoginAttemptRepositoryLock(t *testing.T) {
+	loginAttemptRepository := repositories.NewInMemoryLoginAttemptRepository()
+
+	now := time.Now()
+	if _, err := loginAttemptRepository.Increment("account", "alice", now); err != nil {
+		t.Fatalf("Increment() error = %v", err)
+	}
+	if err := loginAttemptRepository.Lock("account", "alice", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	// A shorter lock doesn't shorten the current one
+	if err := loginAttemptRepository.Lock("account", "alice", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	// Counters reset in the meantime aren't locked
+	if err := loginAttemptRepository.Lock("account", "bob", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	locked, err := loginAttemptRepository.GetLocked(now)
+	if err != nil {
+		t.Fatalf("GetLocked() error = %v", err)
+	}
+	if len(locked) != 1 || locked[0].Value != "alice" || !locked[0].LockedUntil.Equal(now.Add(time.Hour)) {
+		t.Errorf("GetLocked() = %v, want alice locked for an hour", locked)
+	}
+
+	if err := loginAttemptRepository.Delete("account", "alice"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if loginAttempt, err := loginAttemptRepository.Get("account", "alice"); err != nil || loginAttempt.Failures != 0 {
+		t.Errorf("Get() after Delete() = %v, %v, want a new counter", loginAttempt, err)
+	}
+}
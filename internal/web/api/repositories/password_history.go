@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// PasswordHistoryRepository defines the methods for interacting with the password history data.
+type PasswordHistoryRepository interface {
+	GetLatest(userID uint, limit int) ([]*models.PasswordHistory, error)
+	Create(passwordHistory *models.PasswordHistory) error
+	DeleteAllButLatest(userID uint, keep int) error
+}
+
+// PasswordHistoryRepositoryImplementation is an implementation of the PasswordHistoryRepository using Gorm.
+type PasswordHistoryRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewPasswordHistoryRepository(database *gorm.DB) PasswordHistoryRepository {
+	return &PasswordHistoryRepositoryImplementation{database: database}
+}
+
+// GetLatest retrieves the most recently replaced passwords of a user.
+func (repo *PasswordHistoryRepositoryImplementation) GetLatest(userID uint, limit int) ([]*models.PasswordHistory, error) {
+	var passwordHistories []*models.PasswordHistory
+	if err := repo.database.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&passwordHistories).Error; err != nil {
+		return nil, err
+	}
+	return passwordHistories, nil
+}
+
+// Create adds a replaced password to the history of its user.
+func (repo *PasswordHistoryRepositoryImplementation) Create(passwordHistory *models.PasswordHistory) error {
+	return repo.database.Create(passwordHistory).Error
+}
+
+// DeleteAllButLatest removes the former passwords of a user, except the given number of most recent ones.
+// The IDs to keep are read first, MySQL not supporting LIMIT in IN subqueries.
+func (repo *PasswordHistoryRepositoryImplementation) DeleteAllButLatest(userID uint, keep int) error {
+	var latest []uint
+	if err := repo.database.Model(&models.PasswordHistory{}).Where("user_id = ?", userID).Order("id DESC").Limit(keep).Pluck("id", &latest).Error; err != nil {
+		return err
+	}
+	if len(latest) == 0 {
+		return repo.database.Where("user_id = ?", userID).Delete(&models.PasswordHistory{}).Error
+	}
+	return repo.database.Where("user_id = ? AND id NOT IN ?", userID, latest).Delete(&models.PasswordHistory{}).Error
+}
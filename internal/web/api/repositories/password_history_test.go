@@ -0,0 +1,46 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+)
+
+func TestPasswordHistoryRepository(t *testing.T) {
+	passwordHistoryRepository := repositories.NewPasswordHistoryRepository(dbtest.Open(t))
+
+	for _, passwordHistory := range []*models.PasswordHistory{
+		{UserID: 1, Hash: "first"},
+		{UserID: 1, Hash: "second"},
+		{UserID: 2, Hash: "other"},
+		{UserID: 1, Hash: "third"},
+	} {
+		if err := passwordHistoryRepository.Create(passwordHistory); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	latest, err := passwordHistoryRepository.GetLatest(1, 2)
+	if err != nil || len(latest) != 2 || latest[0].Hash != "third" || latest[1].Hash != "second" {
+		t.Fatalf("GetLatest() = %v, %v, want third and second", latest, err)
+	}
+
+	if err := passwordHistoryRepository.DeleteAllButLatest(1, 1); err != nil {
+		t.Fatalf("DeleteAllButLatest() error = %v", err)
+	}
+	if latest, err := passwordHistoryRepository.GetLatest(1, 10); err != nil || len(latest) != 1 || latest[0].Hash != "third" {
+		t.Errorf("GetLatest() after DeleteAllButLatest() = %v, %v, want third", latest, err)
+	}
+	if latest, err := passwordHistoryRepository.GetLatest(2, 10); err != nil || len(latest) != 1 {
+		t.Errorf("GetLatest() of another user = %v, %v, want their password", latest, err)
+	}
+
+	if err := passwordHistoryRepository.DeleteAllButLatest(1, 0); err != nil {
+		t.Fatalf("DeleteAllButLatest() keeping none error = %v", err)
+	}
+	if latest, err := passwordHistoryRepository.GetLatest(1, 10); err != nil || len(latest) != 0 {
+		t.Errorf("GetLatest() after DeleteAllButLatest() keeping none = %v, %v, want none", latest, err)
+	}
+}
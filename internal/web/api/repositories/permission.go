@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// PermissionRepository defines the methods for interacting with the permission data.
+type PermissionRepository interface {
+	Get(id uint) (*models.Permission, error)
+	GetByName(name string) (*models.Permission, error)
+	GetAll() ([]*models.Permission, error)
+	GetGroupsPermissions(groupIDs []uint) ([]string, error)
+	Create(permission *models.Permission) error
+}
+
+// PermissionRepositoryImplementation is an implementation of the PermissionRepository using Gorm.
+type PermissionRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewPermissionRepository(database *gorm.DB) PermissionRepository {
+	return &PermissionRepositoryImplementation{database: database}
+}
+
+// Get retrieves a permission by ID.
+func (repo *PermissionRepositoryImplementation) Get(id uint) (*models.Permission, error) {
+	var permission models.Permission
+	if err := repo.database.First(&permission, id).Error; err != nil {
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// GetByName retrieves a permission by name.
+func (repo *PermissionRepositoryImplementation) GetByName(name string) (*models.Permission, error) {
+	var permission models.Permission
+	if err := repo.database.Where("name = ?", name).First(&permission).Error; err != nil {
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// GetAll retrieves all permissions.
+func (repo *PermissionRepositoryImplementation) GetAll() ([]*models.Permission, error) {
+	var permissions []*models.Permission
+	if err := repo.database.Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// GetGroupsPermissions retrieves the names of the permissions granted to a set of groups through their roles.
+func (repo *PermissionRepositoryImplementation) GetGroupsPermissions(groupIDs []uint) ([]string, error) {
+	var names []string
+	if len(groupIDs) == 0 {
+		return names, nil
+	}
+
+	if err := repo.database.Model(&models.Permission{}).
+		Distinct().
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id AND roles.deleted_at IS NULL").
+		Joins("JOIN group_roles ON group_roles.role_id = roles.id").
+		Where("group_roles.group_id IN ?", groupIDs).
+		Pluck("permissions.name", &names).Error; err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// Create adds a new permission.
+func (repo *PermissionRepositoryImplementation) Create(permission *models.Permission) error {
+	return repo.database.Create(permission).Error
+}
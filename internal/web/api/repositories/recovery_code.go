@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository defines the methods for interacting with the recovery code data.
+type RecoveryCodeRepository interface {
+	GetUnused(userID uint, codeHash string) (*models.RecoveryCode, error)
+	MarkUsed(id uint, date time.Time) (bool, error)
+	Replace(userID uint, recoveryCodes []*models.RecoveryCode) error
+	DeleteUserCodes(userID uint) error
+}
+
+// RecoveryCodeRepositoryImplementation is an implementation of the RecoveryCodeRepository using Gorm.
+type RecoveryCodeRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewRecoveryCodeRepository(database *gorm.DB) RecoveryCodeRepository {
+	return &RecoveryCodeRepositoryImplementation{database: database}
+}
+
+// GetUnused retrieves a recovery code of a user by its hash, if it hasn't been used yet.
+func (repo *RecoveryCodeRepositoryImplementation) GetUnused(userID uint, codeHash string) (*models.RecoveryCode, error) {
+	var recoveryCode models.RecoveryCode
+	if err := repo.database.Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).First(&recoveryCode).Error; err != nil {
+		return nil, err
+	}
+	return &recoveryCode, nil
+}
+
+// MarkUsed marks a recovery code as used, and returns false if it already was: only one of concurrent
+// logins with a code can succeed.
+func (repo *RecoveryCodeRepositoryImplementation) MarkUsed(id uint, date time.Time) (bool, error) {
+	result := repo.database.Model(&models.RecoveryCode{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", date)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Replace deletes the recovery codes of a user and adds the new ones.
+func (repo *RecoveryCodeRepositoryImplementation) Replace(userID uint, recoveryCodes []*models.RecoveryCode) error {
+	return repo.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(recoveryCodes).Error
+	})
+}
+
+// DeleteUserCodes removes every recovery code of a user.
+func (repo *RecoveryCodeRepositoryImplementation) DeleteUserCodes(userID uint) error {
+	return repo.database.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
@@ -0,0 +1,50 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"gorm.io/gorm"
+)
+
+func TestRecoveryCodeRepository(t *testing.T) {
+	recoveryCodeRepository := repositories.NewRecoveryCodeRepository(dbtest.Open(t))
+
+	if err := recoveryCodeRepository.Replace(1, []*models.RecoveryCode{{UserID: 1, CodeHash: "old"}}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if err := recoveryCodeRepository.Replace(1, []*models.RecoveryCode{{UserID: 1, CodeHash: "one"}, {UserID: 1, CodeHash: "two"}}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if _, err := recoveryCodeRepository.GetUnused(1, "old"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetUnused() of a replaced code error = %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	recoveryCode, err := recoveryCodeRepository.GetUnused(1, "one")
+	if err != nil {
+		t.Fatalf("GetUnused() error = %v", err)
+	}
+	if used, err := recoveryCodeRepository.MarkUsed(recoveryCode.ID, time.Now()); err != nil || !used {
+		t.Fatalf("MarkUsed() = %v, %v, want true", used, err)
+	}
+	if used, err := recoveryCodeRepository.MarkUsed(recoveryCode.ID, time.Now()); err != nil || used {
+		t.Errorf("MarkUsed() of a used code = %v, %v, want false", used, err)
+	}
+	if _, err := recoveryCodeRepository.GetUnused(1, "one"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetUnused() of a used code error = %v, want gorm.ErrRecordNotFound", err)
+	}
+	if _, err := recoveryCodeRepository.GetUnused(2, "two"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetUnused() of another user's code error = %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	if err := recoveryCodeRepository.DeleteUserCodes(1); err != nil {
+		t.Fatalf("DeleteUserCodes() error = %v", err)
+	}
+	if _, err := recoveryCodeRepository.GetUnused(1, "two"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetUnused() of a deleted code error = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository defines the methods for interacting with the refresh token data.
+type RefreshTokenRepository interface {
+	GetByHash(tokenHash string) (*models.RefreshToken, error)
+	Create(refreshToken *models.RefreshToken) error
+	MarkUsed(id uint, date time.Time) (bool, error)
+	RevokeFamily(familyID string) error
+	RevokeUserTokens(userID uint) error
+}
+
+// RefreshTokenRepositoryImplementation is an implementation of the RefreshTokenRepository using Gorm.
+type RefreshTokenRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewRefreshTokenRepository(database *gorm.DB) RefreshTokenRepository {
+	return &RefreshTokenRepositoryImplementation{database: database}
+}
+
+// GetByHash retrieves a refresh token by its hash.
+func (repo *RefreshTokenRepositoryImplementation) GetByHash(tokenHash string) (*models.RefreshToken, error) {
+	var refreshToken models.RefreshToken
+	if err := repo.database.Where("token_hash = ?", tokenHash).First(&refreshToken).Error; err != nil {
+		return nil, err
+	}
+	return &refreshToken, nil
+}
+
+// Create adds a new refresh token.
+func (repo *RefreshTokenRepositoryImplementation) Create(refreshToken *models.RefreshToken) error {
+	return repo.database.Create(refreshToken).Error
+}
+
+// MarkUsed marks a refresh token as used, and returns false if it already was: only one of concurrent
+// exchanges of a token can succeed.
+func (repo *RefreshTokenRepositoryImplementation) MarkUsed(id uint, date time.Time) (bool, error) {
+	result := repo.database.Model(&models.RefreshToken{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", date)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeFamily revokes every token of a refresh token family.
+func (repo *RefreshTokenRepositoryImplementation) RevokeFamily(familyID string) error {
+	return repo.database.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserTokens revokes every refresh token issued to a user.
+func (repo *RefreshTokenRepositoryImplementation) RevokeUserTokens(userID uint) error {
+	return repo.database.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
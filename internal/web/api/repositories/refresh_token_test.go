@@ -0,0 +1,63 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+)
+
+func TestRefreshTokenRepositoryRevocation(t *testing.T) {
+	refreshTokenRepository := repositories.NewRefreshTokenRepository(dbtest.Open(t))
+
+	expiresAt := time.Now().Add(time.Hour)
+	for _, refreshToken := range []*models.RefreshToken{
+		{UserID: 1, FamilyID: "a", TokenHash: "a1", ExpiresAt: expiresAt},
+		{UserID: 1, FamilyID: "a", TokenHash: "a2", ExpiresAt: expiresAt},
+		{UserID: 1, FamilyID: "b", TokenHash: "b1", ExpiresAt: expiresAt},
+		{UserID: 2, FamilyID: "c", TokenHash: "c1", ExpiresAt: expiresAt},
+	} {
+		if err := refreshTokenRepository.Create(refreshToken); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	if err := refreshTokenRepository.Create(&models.RefreshToken{UserID: 1, FamilyID: "a", TokenHash: "a1", ExpiresAt: expiresAt}); err == nil {
+		t.Error("Create() of a duplicate hash succeeded")
+	}
+
+	revoked := func(tokenHash string) bool {
+		refreshToken, err := refreshTokenRepository.GetByHash(tokenHash)
+		if err != nil {
+			t.Fatalf("GetByHash(%s) error = %v", tokenHash, err)
+		}
+		return refreshToken.RevokedAt != nil
+	}
+
+	// A token can only be exchanged once
+	b1, err := refreshTokenRepository.GetByHash("b1")
+	if err != nil {
+		t.Fatalf("GetByHash() error = %v", err)
+	}
+	if used, err := refreshTokenRepository.MarkUsed(b1.ID, time.Now()); err != nil || !used {
+		t.Errorf("MarkUsed() = %v, %v, want true", used, err)
+	}
+	if used, err := refreshTokenRepository.MarkUsed(b1.ID, time.Now()); err != nil || used {
+		t.Errorf("second MarkUsed() = %v, %v, want false", used, err)
+	}
+
+	if err := refreshTokenRepository.RevokeFamily("a"); err != nil {
+		t.Fatalf("RevokeFamily() error = %v", err)
+	}
+	if !revoked("a1") || !revoked("a2") || revoked("b1") || revoked("c1") {
+		t.Error("RevokeFamily() didn't revoke exactly the tokens of the family")
+	}
+
+	if err := refreshTokenRepository.RevokeUserTokens(1); err != nil {
+		t.Fatalf("RevokeUserTokens() error = %v", err)
+	}
+	if !revoked("b1") || revoked("c1") {
+		t.Error("RevokeUserTokens() didn't revoke exactly the tokens of the user")
+	}
+}
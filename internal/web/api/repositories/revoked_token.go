@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// RevokedTokenRepository defines the methods for interacting with the revoked token data.
+type RevokedTokenRepository interface {
+	IsRevoked(tokenID string) (bool, error)
+	Create(revokedToken *models.RevokedToken) error
+	DeleteExpired() error
+}
+
+// RevokedTokenRepositoryImplementation is an implementation of the RevokedTokenRepository using Gorm.
+type RevokedTokenRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewRevokedTokenRepository(database *gorm.DB) RevokedTokenRepository {
+	return &RevokedTokenRepositoryImplementation{database: database}
+}
+
+// IsRevoked checks if a token has been revoked.
+func (repo *RevokedTokenRepositoryImplementation) IsRevoked(tokenID string) (bool, error) {
+	var count int64
+	if err := repo.database.Model(&models.RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Create adds a new revoked token.
+func (repo *RevokedTokenRepositoryImplementation) Create(revokedToken *models.RevokedToken) error {
+	return repo.database.Create(revokedToken).Error
+}
+
+// DeleteExpired removes the revoked tokens that have expired anyway.
+func (repo *RevokedTokenRepositoryImplementation) DeleteExpired() error {
+	return repo.database.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error
+}
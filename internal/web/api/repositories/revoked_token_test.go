@@ -0,0 +1,30 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+)
+
+func TestRevokedTokenRepository(t *testing.T) {
+	revokedTokenRepository := repositories.NewRevokedTokenRepository(dbtest.Open(t))
+
+	if err := revokedTokenRepository.Create(&models.RevokedToken{TokenID: "live", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := revokedTokenRepository.Create(&models.RevokedToken{TokenID: "expired", ExpiresAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := revokedTokenRepository.DeleteExpired(); err != nil {
+		t.Fatalf("DeleteExpired() error = %v", err)
+	}
+
+	for tokenID, want := range map[string]bool{"live": true, "expired": false, "unknown": false} {
+		if got, err := revokedTokenRepository.IsRevoked(tokenID); err != nil || got != want {
+			t.Errorf("IsRevoked(%s) = %v, %v, want %v", tokenID, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// RoleRepository defines the methods for interacting with the role data.
+type RoleRepository interface {
+	Get(id uint) (*models.Role, error)
+	GetByName(name string) (*models.Role, error)
+	GetAll() ([]*models.Role, error)
+	Create(role *models.Role) error
+	Update(role *models.Role) error
+	Delete(id uint) error
+	AddPermissionToRole(roleID uint, permissionID uint) error
+	RemovePermissionFromRole(roleID uint, permissionID uint) error
+	AddRoleToGroup(roleID uint, groupID uint) error
+	RemoveRoleFromGroup(roleID uint, groupID uint) error
+}
+
+// RoleRepositoryImplementation is an implementation of the RoleRepository using Gorm.
+type RoleRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewRoleRepository(database *gorm.DB) RoleRepository {
+	return &RoleRepositoryImplementation{database: database}
+}
+
+// Get retrieves a role by ID.
+func (repo *RoleRepositoryImplementation) Get(id uint) (*models.Role, error) {
+	var role models.Role
+	if err := repo.database.Preload("Permissions").Preload("Groups").First(&role, id).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetByName retrieves a role by name.
+func (repo *RoleRepositoryImplementation) GetByName(name string) (*models.Role, error) {
+	var role models.Role
+	if err := repo.database.Where("name = ?", name).Preload("Permissions").Preload("Groups").First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetAll retrieves all roles.
+func (repo *RoleRepositoryImplementation) GetAll() ([]*models.Role, error) {
+	var roles []*models.Role
+	if err := repo.database.Preload("Permissions").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// Create adds a new role.
+func (repo *RoleRepositoryImplementation) Create(role *models.Role) error {
+	return repo.database.Create(role).Error
+}
+
+// Update modifies an existing role.
+func (repo *RoleRepositoryImplementation) Update(role *models.Role) error {
+	return repo.database.Save(role).Error
+}
+
+// Delete removes a role by ID.
+func (repo *RoleRepositoryImplementation) Delete(id uint) error {
+	return repo.database.Delete(&models.Role{}, id).Error
+}
+
+// AddPermissionToRole grants a permission to a role.
+func (repo *RoleRepositoryImplementation) AddPermissionToRole(roleID uint, permissionID uint) error {
+	role := &models.Role{}
+	permission := &models.Permission{}
+
+	if err := repo.database.First(role, roleID).Error; err != nil {
+		return err
+	}
+	if err := repo.database.First(permission, permissionID).Error; err != nil {
+		return err
+	}
+
+	return repo.database.Model(role).Association("Permissions").Append(permission)
+}
+
+// RemovePermissionFromRole revokes a permission from a role.
+func (repo *RoleRepositoryImplementation) RemovePermissionFromRole(roleID uint, permissionID uint) error {
+	role := &models.Role{}
+	permission := &models.Permission{}
+
+	if err := repo.database.First(role, roleID).Error; err != nil {
+		return err
+	}
+	if err := repo.database.First(permission, permissionID).Error; err != nil {
+		return err
+	}
+
+	return repo.database.Model(role).Association("Permissions").Delete(permission)
+}
+
+// AddRoleToGroup grants a role to a group.
+func (repo *RoleRepositoryImplementation) AddRoleToGroup(roleID uint, groupID uint) error {
+	role := &models.Role{}
+	group := &models.Group{}
+
+	if err := repo.database.First(role, roleID).Error; err != nil {
+		return err
+	}
+	if err := repo.database.First(group, groupID).Error; err != nil {
+		return err
+	}
+
+	return repo.database.Model(role).Association("Groups").Append(group)
+}
+
+// RemoveRoleFromGroup revokes a role from a group.
+func (repo *RoleRepositoryImplementation) RemoveRoleFromGroup(roleID uint, groupID uint) error {
+	role := &models.Role{}
+	group := &models.Group{}
+
+	if err := repo.database.First(role, roleID).Error; err != nil {
+		return err
+	}
+	if err := repo.database.First(group, groupID).Error; err != nil {
+		return err
+	}
+
+	return repo.database.Model(role).Association("Groups").Delete(group)
+}
@@ -0,0 +1,111 @@
+package repositories_test
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"gorm.io/gorm"
+)
+
+func TestRoleRepositoryPermissions(t *testing.T) {
+	database := dbtest.Open(t)
+	groupRepository := repositories.NewGroupRepository(database)
+	roleRepository := repositories.NewRoleRepository(database)
+	permissionRepository := repositories.NewPermissionRepository(database)
+
+	staff := createGroup(t, groupRepository, "staff")
+	admins := createGroup(t, groupRepository, "admins")
+
+	var permissions []*models.Permission
+	for _, name := range []string{"users:read", "users:write", "groups:read"} {
+		permission := &models.Permission{Name: name}
+		if err := permissionRepository.Create(permission); err != nil {
+			t.Fatalf("Create(%s) error = %v", name, err)
+		}
+		permissions = append(permissions, permission)
+	}
+	if err := permissionRepository.Create(&models.Permission{Name: "users:read"}); err == nil {
+		t.Error("Create() of a duplicate permission succeeded")
+	}
+	if got, err := permissionRepository.GetByName("users:write"); err != nil || got.ID != permissions[1].ID {
+		t.Errorf("GetByName() = %v, %v, want users:write", got, err)
+	}
+
+	reader := &models.Role{Name: "reader"}
+	writer := &models.Role{Name: "writer"}
+	for _, role := range []*models.Role{reader, writer} {
+		if err := roleRepository.Create(role); err != nil {
+			t.Fatalf("Create(%s) error = %v", role.Name, err)
+		}
+	}
+	for _, grant := range []struct {
+		role       *models.Role
+		permission *models.Permission
+	}{{reader, permissions[0]}, {reader, permissions[2]}, {writer, permissions[1]}} {
+		if err := roleRepository.AddPermissionToRole(grant.role.ID, grant.permission.ID); err != nil {
+			t.Fatalf("AddPermissionToRole() error = %v", err)
+		}
+	}
+	if err := roleRepository.AddRoleToGroup(reader.ID, staff.ID); err != nil {
+		t.Fatalf("AddRoleToGroup() error = %v", err)
+	}
+	if err := roleRepository.AddRoleToGroup(writer.ID, admins.ID); err != nil {
+		t.Fatalf("AddRoleToGroup() error = %v", err)
+	}
+
+	role, err := roleRepository.GetByName("reader")
+	if err != nil || len(role.Permissions) != 2 || len(role.Groups) != 1 {
+		t.Errorf("GetByName() = %v, %v, want 2 permissions and 1 group", role, err)
+	}
+
+	tests := []struct {
+		name     string
+		groupIDs []uint
+		want     []string
+	}{
+		{name: "no group", groupIDs: nil, want: []string{}},
+		{name: "one group", groupIDs: []uint{staff.ID}, want: []string{"groups:read", "users:read"}},
+		{name: "several groups", groupIDs: []uint{staff.ID, admins.ID}, want: []string{"groups:read", "users:read", "users:write"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := permissionRepository.GetGroupsPermissions(test.groupIDs)
+			if err != nil {
+				t.Fatalf("GetGroupsPermissions() error = %v", err)
+			}
+			sort.Strings(got)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("GetGroupsPermissions() = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// Revoking a permission or deleting a role takes the permissions away
+	if err := roleRepository.RemovePermissionFromRole(reader.ID, permissions[2].ID); err != nil {
+		t.Fatalf("RemovePermissionFromRole() error = %v", err)
+	}
+	if got, err := permissionRepository.GetGroupsPermissions([]uint{staff.ID}); err != nil || !slices.Equal(got, []string{"users:read"}) {
+		t.Errorf("GetGroupsPermissions() after RemovePermissionFromRole() = %v, %v, want [users:read]", got, err)
+	}
+	if err := roleRepository.Delete(writer.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got, err := permissionRepository.GetGroupsPermissions([]uint{admins.ID}); err != nil || len(got) != 0 {
+		t.Errorf("GetGroupsPermissions() of a deleted role = %v, %v, want none", got, err)
+	}
+	if _, err := roleRepository.Get(writer.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Get() of a deleted role error = %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	if err := roleRepository.RemoveRoleFromGroup(reader.ID, staff.ID); err != nil {
+		t.Fatalf("RemoveRoleFromGroup() error = %v", err)
+	}
+	if got, err := permissionRepository.GetGroupsPermissions([]uint{staff.ID}); err != nil || len(got) != 0 {
+		t.Errorf("GetGroupsPermissions() after RemoveRoleFromGroup() = %v, %v, want none", got, err)
+	}
+}
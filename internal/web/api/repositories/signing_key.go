@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// SigningKeyRepository defines the methods for interacting with the signing key data.
+type SigningKeyRepository interface {
+	GetAll() ([]*models.SigningKey, error)
+	Create(signingKey *models.SigningKey) error
+	Update(signingKey *models.SigningKey) error
+	DeleteExpired(date time.Time) error
+}
+
+// SigningKeyRepositoryImplementation is an implementation of the SigningKeyRepository using Gorm.
+type SigningKeyRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewSigningKeyRepository(database *gorm.DB) SigningKeyRepository {
+	return &SigningKeyRepositoryImplementation{database: database}
+}
+
+// GetAll retrieves every signing key, the newest first.
+func (repo *SigningKeyRepositoryImplementation) GetAll() ([]*models.SigningKey, error) {
+	var signingKeys []*models.SigningKey
+	if err := repo.database.Order("created_at DESC, id DESC").Find(&signingKeys).Error; err != nil {
+		return nil, err
+	}
+	return signingKeys, nil
+}
+
+// Create adds a new signing key.
+func (repo *SigningKeyRepositoryImplementation) Create(signingKey *models.SigningKey) error {
+	return repo.database.Create(signingKey).Error
+}
+
+// Update modifies an existing signing key.
+func (repo *SigningKeyRepositoryImplementation) Update(signingKey *models.SigningKey) error {
+	return repo.database.Save(signingKey).Error
+}
+
+// DeleteExpired permanently removes the retired keys that no longer verify tokens at the given date.
+func (repo *SigningKeyRepositoryImplementation) DeleteExpired(date time.Time) error {
+	return repo.database.Unscoped().Where("expires_at IS NOT NULL AND expires_at < ?", date).Delete(&models.SigningKey{}).Error
+}
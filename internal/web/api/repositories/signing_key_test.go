@@ -0,0 +1,37 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+)
+
+func TestSigningKeyRepository(t *testing.T) {
+	signingKeyRepository := repositories.NewSigningKeyRepository(dbtest.Open(t))
+
+	now := time.Now()
+	expired, retired := now.Add(-time.Hour), now.Add(time.Hour)
+	for _, signingKey := range []*models.SigningKey{
+		{KeyID: "expired", Algorithm: models.SigningAlgorithmRS256, PrivateKey: "key", RetiredAt: &expired, ExpiresAt: &expired},
+		{KeyID: "retired", Algorithm: models.SigningAlgorithmRS256, PrivateKey: "key", RetiredAt: &now, ExpiresAt: &retired},
+		{KeyID: "active", Algorithm: models.SigningAlgorithmEdDSA, PrivateKey: "key"},
+	} {
+		if err := signingKeyRepository.Create(signingKey); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	if err := signingKeyRepository.DeleteExpired(now); err != nil {
+		t.Fatalf("DeleteExpired() error = %v", err)
+	}
+	signingKeys, err := signingKeyRepository.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if len(signingKeys) != 2 || signingKeys[0].KeyID != "active" || signingKeys[1].KeyID != "retired" {
+		t.Errorf("GetAll() after DeleteExpired() = %v, want active then retired", signingKeys)
+	}
+}
@@ -1,7 +1,10 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
 	"gorm.io/gorm"
 )
 
@@ -9,10 +12,37 @@ import (
 type UserRepository interface {
 	Get(id uint) (*models.User, error)
 	GetByName(name string) (*models.User, error)
-	GetAll() ([]*models.User, error)
+	GetAllByEmail(email string) ([]*models.User, error)
+	GetAll(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error)
+	GetAllWithGroups(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error)
 	Create(user *models.User) error
 	Update(user *models.User) error
+	AdvanceTOTPCounter(id uint, counter int64) (bool, error)
 	Delete(id uint) error
+	GetDeleted(id uint) (*models.User, error)
+	GetDeletedByName(name string) (*models.User, error)
+	GetAllDeleted(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error)
+	GetDeletedBefore(date time.Time) ([]*models.User, error)
+	Restore(id uint) error
+	Purge(id uint) error
+}
+
+// UserListFields are the fields users can be filtered and sorted on.
+var UserListFields = query.Fields{
+	"id":         {Column: "id", Type: query.Number},
+	"name":       {Column: "name", Type: query.String},
+	"email":      {Column: "email", Type: query.String},
+	"created_at": {Column: "created_at", Type: query.Time},
+	"updated_at": {Column: "updated_at", Type: query.Time},
+}
+
+// DeletedUserListFields are the fields deleted users can be filtered and sorted on.
+var DeletedUserListFields = query.Fields{
+	"id":         {Column: "id", Type: query.Number},
+	"name":       {Column: "name", Type: query.String},
+	"email":      {Column: "email", Type: query.String},
+	"created_at": {Column: "created_at", Type: query.Time},
+	"deleted_at": {Column: "deleted_at", Type: query.Time},
 }
 
 // UserRepositoryImplementation is an implementation of the UserRepository using Gorm.
@@ -42,15 +72,35 @@ func (repo *UserRepositoryImplementation) GetByName(name string) (*models.User,
 	return &user, nil
 }
 
-// GetAll retrieves all users.
-func (repo *UserRepositoryImplementation) GetAll() ([]*models.User, error) {
+// GetAllByEmail retrieves the users having the given email, which isn't unique.
+func (repo *UserRepositoryImplementation) GetAllByEmail(email string) ([]*models.User, error) {
 	var users []*models.User
-	if err := repo.database.Find(&users).Error; err != nil {
+	if err := repo.database.Where("email = ?", email).Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
+// GetAll retrieves a page of users.
+func (repo *UserRepositoryImplementation) GetAll(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error) {
+	var users []*models.User
+	pageInfo, err := query.Find(repo.database.Model(&models.User{}), listQuery, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, pageInfo, nil
+}
+
+// GetAllWithGroups retrieves a page of users along with their groups.
+func (repo *UserRepositoryImplementation) GetAllWithGroups(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error) {
+	var users []*models.User
+	pageInfo, err := query.Find(repo.database.Model(&models.User{}).Preload("Groups"), listQuery, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, pageInfo, nil
+}
+
 // Create adds a new user.
 func (repo *UserRepositoryImplementation) Create(user *models.User) error {
 	return repo.database.Create(user).Error
@@ -61,7 +111,72 @@ func (repo *UserRepositoryImplementation) Update(user *models.User) error {
 	return repo.database.Save(user).Error
 }
 
-// Delete removes a user by ID.
+// AdvanceTOTPCounter records the time step of the last TOTP code accepted for a user, and returns false if a code
+// of the same or a later time step already was: only one of concurrent logins with a code can succeed.
+func (repo *UserRepositoryImplementation) AdvanceTOTPCounter(id uint, counter int64) (bool, error) {
+	result := repo.database.Model(&models.User{}).Where("id = ? AND totp_last_counter < ?", id, counter).Update("totp_last_counter", counter)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Delete removes a user by ID. The user is only soft-deleted, along with their memberships which are kept for
+// a restoration, until purged.
 func (repo *UserRepositoryImplementation) Delete(id uint) error {
 	return repo.database.Delete(&models.User{}, id).Error
 }
+
+// GetDeleted retrieves a deleted user by ID.
+func (repo *UserRepositoryImplementation) GetDeleted(id uint) (*models.User, error) {
+	var user models.User
+	if err := repo.database.Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetDeletedByName retrieves a deleted user by username.
+func (repo *UserRepositoryImplementation) GetDeletedByName(name string) (*models.User, error) {
+	var user models.User
+	if err := repo.database.Unscoped().Where("name = ? AND deleted_at IS NOT NULL", name).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetAllDeleted retrieves a page of deleted users.
+func (repo *UserRepositoryImplementation) GetAllDeleted(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error) {
+	var users []*models.User
+	pageInfo, err := query.Find(repo.database.Unscoped().Model(&models.User{}).Where("deleted_at IS NOT NULL"), listQuery, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, pageInfo, nil
+}
+
+// GetDeletedBefore retrieves the users deleted before a date.
+func (repo *UserRepositoryImplementation) GetDeletedBefore(date time.Time) ([]*models.User, error) {
+	var users []*models.User
+	if err := repo.database.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", date).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Restore undeletes a deleted user, whose memberships are effective again.
+func (repo *UserRepositoryImplementation) Restore(id uint) error {
+	return repo.database.Unscoped().Model(&models.User{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil).Error
+}
+
+// Purge permanently removes a deleted user, along with their memberships and everything issued to them.
+func (repo *UserRepositoryImplementation) Purge(id uint) error {
+	return repo.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_groups WHERE user_id = ?", id).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{&models.APIKey{}, &models.RefreshToken{}, &models.RecoveryCode{}, &models.VerificationToken{}, &models.AuthorizationCode{}, &models.PasswordHistory{}} {
+			if err := tx.Unscoped().Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Where("deleted_at IS NOT NULL").Delete(&models.User{}, id).Error
+	})
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
 	"gorm.io/gorm"
 )
 
@@ -9,8 +10,15 @@ import (
 type UserGroupRepository interface {
 	AddUserToGroup(userID uint, groupID uint) error
 	RemoveUserFromGroup(userID uint, groupID uint) error
-	GetUserGroups(userID uint) ([]*models.Group, error)
-	GetGroupUsers(groupID uint) ([]*models.User, error)
+	GetUserGroups(userID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	GetGroupUsers(groupID uint, listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error)
+	AddGroupToGroup(subgroupID uint, groupID uint) error
+	RemoveGroupFromGroup(subgroupID uint, groupID uint) error
+	GetSubgroups(groupID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	GetDescendantGroupIDs(groupID uint) ([]uint, error)
+	GetEffectiveGroupIDs(userID uint) ([]uint, error)
+	GetEffectiveUserGroups(userID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	GetEffectiveGroupUsers(groupID uint, listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error)
 }
 
 // UserGroupRepository is an implementation of the UserGroupRepository using Gorm.
@@ -52,20 +60,159 @@ func (repo *UserGroupRepositoryImplementation) RemoveUserFromGroup(userID uint,
 	return repo.database.Model(user).Association("Groups").Delete(group)
 }
 
-// GetUserGroups retrieves all groups for a user.
-func (repo *UserGroupRepositoryImplementation) GetUserGroups(userID uint) ([]*models.Group, error) {
-	var user models.User
-	if err := repo.database.Preload("Groups").First(&user, userID).Error; err != nil {
-		return nil, err
+// GetUserGroups retrieves a page of the groups of a user.
+func (repo *UserGroupRepositoryImplementation) GetUserGroups(userID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	if err := repo.database.First(&models.User{}, userID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var groups []*models.Group
+	memberships := repo.database.Table("user_groups").Select("group_id").Where("user_id = ?", userID)
+	pageInfo, err := query.Find(repo.database.Model(&models.Group{}).Where("id IN (?)", memberships), listQuery, &groups)
+	if err != nil {
+		return nil, nil, err
+	}
+	return groups, pageInfo, nil
+}
+
+// GetGroupUsers retrieves a page of the users of a group.
+func (repo *UserGroupRepositoryImplementation) GetGroupUsers(groupID uint, listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error) {
+	if err := repo.database.First(&models.Group{}, groupID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var users []*models.User
+	memberships := repo.database.Table("user_groups").Select("user_id").Where("group_id = ?", groupID)
+	pageInfo, err := query.Find(repo.database.Model(&models.User{}).Where("id IN (?)", memberships), listQuery, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, pageInfo, nil
+}
+
+// AddGroupToGroup nests a group in another one.
+func (repo *UserGroupRepositoryImplementation) AddGroupToGroup(subgroupID uint, groupID uint) error {
+	subgroup := &models.Group{}
+	group := &models.Group{}
+
+	if err := repo.database.First(subgroup, subgroupID).Error; err != nil {
+		return err
+	}
+	if err := repo.database.First(group, groupID).Error; err != nil {
+		return err
+	}
+
+	return repo.database.Model(group).Association("Subgroups").Append(subgroup)
+}
+
+// RemoveGroupFromGroup removes a group nested in another one.
+func (repo *UserGroupRepositoryImplementation) RemoveGroupFromGroup(subgroupID uint, groupID uint) error {
+	subgroup := &models.Group{}
+	group := &models.Group{}
+
+	if err := repo.database.First(subgroup, subgroupID).Error; err != nil {
+		return err
+	}
+	if err := repo.database.First(group, groupID).Error; err != nil {
+		return err
 	}
-	return user.Groups, nil
+
+	return repo.database.Model(group).Association("Subgroups").Delete(subgroup)
+}
+
+// GetSubgroups retrieves a page of the groups directly nested in a group.
+func (repo *UserGroupRepositoryImplementation) GetSubgroups(groupID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	if err := repo.database.First(&models.Group{}, groupID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var groups []*models.Group
+	nestings := repo.database.Table("group_subgroups").Select("subgroup_id").Where("group_id = ?", groupID)
+	pageInfo, err := query.Find(repo.database.Model(&models.Group{}).Where("id IN (?)", nestings), listQuery, &groups)
+	if err != nil {
+		return nil, nil, err
+	}
+	return groups, pageInfo, nil
+}
+
+// GetDescendantGroupIDs retrieves the IDs of a group and of the groups nested in it, at any depth.
+func (repo *UserGroupRepositoryImplementation) GetDescendantGroupIDs(groupID uint) ([]uint, error) {
+	return repo.walk([]uint{groupID}, "group_id", "subgroup_id")
 }
 
-// GetGroupUsers retrieves all users for a group.
-func (repo *UserGroupRepositoryImplementation) GetGroupUsers(groupID uint) ([]*models.User, error) {
-	var group models.Group
-	if err := repo.database.Preload("Users").First(&group, groupID).Error; err != nil {
+// GetEffectiveGroupIDs retrieves the IDs of the groups a user belongs to, directly or through nested groups.
+func (repo *UserGroupRepositoryImplementation) GetEffectiveGroupIDs(userID uint) ([]uint, error) {
+	var groupIDs []uint
+	memberships := repo.database.Table("user_groups").Select("group_id").Where("user_id = ?", userID)
+	if err := repo.database.Model(&models.Group{}).Where("id IN (?)", memberships).Pluck("id", &groupIDs).Error; err != nil {
 		return nil, err
 	}
-	return group.Users, nil
+	return repo.walk(groupIDs, "subgroup_id", "group_id")
+}
+
+// GetEffectiveUserGroups retrieves a page of the groups a user belongs to, directly or through nested groups.
+func (repo *UserGroupRepositoryImplementation) GetEffectiveUserGroups(userID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	if err := repo.database.First(&models.User{}, userID).Error; err != nil {
+		return nil, nil, err
+	}
+	groupIDs, err := repo.GetEffectiveGroupIDs(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var groups []*models.Group
+	pageInfo, err := query.Find(repo.database.Model(&models.Group{}).Where("id IN ?", groupIDs), listQuery, &groups)
+	if err != nil {
+		return nil, nil, err
+	}
+	return groups, pageInfo, nil
+}
+
+// GetEffectiveGroupUsers retrieves a page of the users belonging to a group, directly or through nested groups.
+func (repo *UserGroupRepositoryImplementation) GetEffectiveGroupUsers(groupID uint, listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error) {
+	if err := repo.database.First(&models.Group{}, groupID).Error; err != nil {
+		return nil, nil, err
+	}
+	groupIDs, err := repo.GetDescendantGroupIDs(groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var users []*models.User
+	memberships := repo.database.Table("user_groups").Select("user_id").Where("group_id IN ?", groupIDs)
+	pageInfo, err := query.Find(repo.database.Model(&models.User{}).Where("id IN (?)", memberships), listQuery, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, pageInfo, nil
+}
+
+// walk follows the nestings of groups from a set of groups, one level at a time, and returns the IDs of the groups
+// reached along with the starting ones. It goes down to the subgroups from group_id to subgroup_id, and up to the
+// parent groups the other way around. Deleted groups stop the walk, and groups already reached aren't
+// followed again so that it ends even if the nestings were to form a cycle.
+func (repo *UserGroupRepositoryImplementation) walk(groupIDs []uint, from string, to string) ([]uint, error) {
+	reached := make(map[uint]bool, len(groupIDs))
+	for _, groupID := range groupIDs {
+		reached[groupID] = true
+	}
+
+	for frontier := groupIDs; len(frontier) > 0; {
+		var next []uint
+		nestings := repo.database.Table("group_subgroups").Select(to).Where(from+" IN ?", frontier)
+		if err := repo.database.Model(&models.Group{}).Where("id IN (?)", nestings).Pluck("id", &next).Error; err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for _, groupID := range next {
+			if !reached[groupID] {
+				reached[groupID] = true
+				groupIDs = append(groupIDs, groupID)
+				frontier = append(frontier, groupID)
+			}
+		}
+	}
+
+	return groupIDs, nil
 }
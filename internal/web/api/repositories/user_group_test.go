@@ -0,0 +1,119 @@
+package repositories_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+)
+
+func TestUserGroupRepositoryMemberships(t *testing.T) {
+	database := dbtest.Open(t)
+	userRepository := repositories.NewUserRepository(database)
+	groupRepository := repositories.NewGroupRepository(database)
+	userGroupRepository := repositories.NewUserGroupRepository(database)
+
+	alice := createUser(t, userRepository, "alice")
+	bob := createUser(t, userRepository, "bob")
+	staff := createGroup(t, groupRepository, "staff")
+	admins := createGroup(t, groupRepository, "admins")
+
+	for _, membership := range []struct{ userID, groupID uint }{{alice.ID, staff.ID}, {bob.ID, staff.ID}, {alice.ID, admins.ID}} {
+		if err := userGroupRepository.AddUserToGroup(membership.userID, membership.groupID); err != nil {
+			t.Fatalf("AddUserToGroup() error = %v", err)
+		}
+	}
+	// Adding a member twice is harmless
+	if err := userGroupRepository.AddUserToGroup(alice.ID, staff.ID); err != nil {
+		t.Fatalf("AddUserToGroup() of a member error = %v", err)
+	}
+
+	users, pageInfo, err := userGroupRepository.GetGroupUsers(staff.ID, &query.ListQuery{Limit: 10})
+	if err != nil || pageInfo.Total != 2 || !reflect.DeepEqual(names(users), []string{"alice", "bob"}) {
+		t.Errorf("GetGroupUsers() = %v, %v, want [alice bob]", names(users), err)
+	}
+	groups, _, err := userGroupRepository.GetUserGroups(alice.ID, &query.ListQuery{Limit: 10, Sorts: []query.Sort{{Column: "name"}}})
+	if err != nil || !reflect.DeepEqual(names(groups), []string{"admins", "staff"}) {
+		t.Errorf("GetUserGroups() = %v, %v, want [admins staff]", names(groups), err)
+	}
+
+	if err := userGroupRepository.RemoveUserFromGroup(alice.ID, staff.ID); err != nil {
+		t.Fatalf("RemoveUserFromGroup() error = %v", err)
+	}
+	users, _, err = userGroupRepository.GetGroupUsers(staff.ID, &query.ListQuery{Limit: 10})
+	if err != nil || !reflect.DeepEqual(names(users), []string{"bob"}) {
+		t.Errorf("GetGroupUsers() after RemoveUserFromGroup() = %v, %v, want [bob]", names(users), err)
+	}
+
+	if err := userGroupRepository.AddUserToGroup(alice.ID, staff.ID+100); err == nil {
+		t.Error("AddUserToGroup() to a missing group succeeded")
+	}
+}
+
+func TestUserGroupRepositoryNestedGroups(t *testing.T) {
+	database := dbtest.Open(t)
+	userRepository := repositories.NewUserRepository(database)
+	groupRepository := repositories.NewGroupRepository(database)
+	userGroupRepository := repositories.NewUserGroupRepository(database)
+
+	// everyone > staff > engineers, alice being an engineer and bob a member of staff
+	alice := createUser(t, userRepository, "alice")
+	bob := createUser(t, userRepository, "bob")
+	everyone := createGroup(t, groupRepository, "everyone")
+	staff := createGroup(t, groupRepository, "staff")
+	engineers := createGroup(t, groupRepository, "engineers")
+	if err := userGroupRepository.AddGroupToGroup(staff.ID, everyone.ID); err != nil {
+		t.Fatalf("AddGroupToGroup() error = %v", err)
+	}
+	if err := userGroupRepository.AddGroupToGroup(engineers.ID, staff.ID); err != nil {
+		t.Fatalf("AddGroupToGroup() error = %v", err)
+	}
+	if err := userGroupRepository.AddUserToGroup(alice.ID, engineers.ID); err != nil {
+		t.Fatalf("AddUserToGroup() error = %v", err)
+	}
+	if err := userGroupRepository.AddUserToGroup(bob.ID, staff.ID); err != nil {
+		t.Fatalf("AddUserToGroup() error = %v", err)
+	}
+
+	subgroups, _, err := userGroupRepository.GetSubgroups(everyone.ID, &query.ListQuery{Limit: 10})
+	if err != nil || !reflect.DeepEqual(names(subgroups), []string{"staff"}) {
+		t.Errorf("GetSubgroups() = %v, %v, want [staff]", names(subgroups), err)
+	}
+
+	descendants, err := userGroupRepository.GetDescendantGroupIDs(everyone.ID)
+	if err != nil || !reflect.DeepEqual(idSet(descendants), idSet([]uint{everyone.ID, staff.ID, engineers.ID})) {
+		t.Errorf("GetDescendantGroupIDs() = %v, %v, want every group", descendants, err)
+	}
+
+	effective, err := userGroupRepository.GetEffectiveGroupIDs(alice.ID)
+	if err != nil || !reflect.DeepEqual(idSet(effective), idSet([]uint{everyone.ID, staff.ID, engineers.ID})) {
+		t.Errorf("GetEffectiveGroupIDs() = %v, %v, want every group", effective, err)
+	}
+
+	groups, _, err := userGroupRepository.GetEffectiveUserGroups(bob.ID, &query.ListQuery{Limit: 10, Sorts: []query.Sort{{Column: "name"}}})
+	if err != nil || !reflect.DeepEqual(names(groups), []string{"everyone", "staff"}) {
+		t.Errorf("GetEffectiveUserGroups() = %v, %v, want [everyone staff]", names(groups), err)
+	}
+
+	users, pageInfo, err := userGroupRepository.GetEffectiveGroupUsers(everyone.ID, &query.ListQuery{Limit: 10})
+	if err != nil || pageInfo.Total != 2 {
+		t.Errorf("GetEffectiveGroupUsers() = %v, %v, want alice and bob", names(users), err)
+	}
+
+	// A cycle doesn't make the walks loop forever
+	if err := userGroupRepository.AddGroupToGroup(everyone.ID, engineers.ID); err != nil {
+		t.Fatalf("AddGroupToGroup() error = %v", err)
+	}
+	if descendants, err := userGroupRepository.GetDescendantGroupIDs(staff.ID); err != nil || len(descendants) != 3 {
+		t.Errorf("GetDescendantGroupIDs() with a cycle = %v, %v, want the 3 groups", descendants, err)
+	}
+
+	if err := userGroupRepository.RemoveGroupFromGroup(engineers.ID, staff.ID); err != nil {
+		t.Fatalf("RemoveGroupFromGroup() error = %v", err)
+	}
+	if effective, err := userGroupRepository.GetEffectiveGroupIDs(alice.ID); err != nil || !reflect.DeepEqual(effective, []uint{engineers.ID}) {
+		t.Errorf("GetEffectiveGroupIDs() after RemoveGroupFromGroup() = %v, %v, want [%d]", effective, err, engineers.ID)
+	}
+}
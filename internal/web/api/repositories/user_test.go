@@ -0,0 +1,356 @@
+package repositories_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"gorm.io/gorm"
+)
+
+func TestUserRepositoryCRUD(t *testing.T) {
+	userRepository := repositories.NewUserRepository(dbtest.Open(t))
+
+	alice := createUser(t, userRepository, "alice")
+	if alice.ID == 0 {
+		t.Fatal("Create() didn't set the ID")
+	}
+
+	got, err := userRepository.GetByName("alice")
+	if err != nil {
+		t.Fatalf("GetByName() error = %v", err)
+	}
+	if got.ID != alice.ID || got.Email != "alice@example.com" {
+		t.Errorf("GetByName() = %d %s, want %d alice@example.com", got.ID, got.Email, alice.ID)
+	}
+
+	got.Email = "alice@example.org"
+	if err := userRepository.Update(got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got, err := userRepository.Get(alice.ID); err != nil || got.Email != "alice@example.org" {
+		t.Errorf("Get() after Update() = %v, %v, want alice@example.org", got, err)
+	}
+
+	if _, err := userRepository.Get(alice.ID + 100); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Get() of a missing user error = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestUserRepositoryAdvanceTOTPCounter(t *testing.T) {
+	userRepository := repositories.NewUserRepository(dbtest.Open(t))
+	alice := createUser(t, userRepository, "alice")
+
+	if advanced, err := userRepository.AdvanceTOTPCounter(alice.ID, 10); err != nil || !advanced {
+		t.Fatalf("AdvanceTOTPCounter() = %v, %v, want true", advanced, err)
+	}
+	for _, counter := range []int64{10, 9} {
+		if advanced, err := userRepository.AdvanceTOTPCounter(alice.ID, counter); err != nil || advanced {
+			t.Errorf("AdvanceTOTPCounter(%d) after 10 = %v, %v, want false", counter, advanced, err)
+		}
+	}
+	if got, err := userRepository.Get(alice.ID); err != nil || got.TOTPLastCounter != 10 {
+		t.Errorf("Get() after AdvanceTOTPCounter() = %v, %v, want the counter 10", got, err)
+	}
+}
+
+func TestUserRepositoryUniqueName(t *testing.T) {
+	userRepository := repositories.NewUserRepository(dbtest.Open(t))
+
+	createUser(t, userRepository, "alice")
+	if err := userRepository.Create(&models.User{Name: "alice", Email: "other@example.com", Password: "hash"}); err == nil {
+		t.Error("Create() of a duplicate name succeeded")
+	}
+}
+
+func TestUserRepositoryGetAllByEmail(t *testing.T) {
+	userRepository := repositories.NewUserRepository(dbtest.Open(t))
+
+	createUser(t, userRepository, "alice")
+	if err := userRepository.Create(&models.User{Name: "alice2", Email: "alice@example.com", Password: "hash"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	createUser(t, userRepository, "bob")
+
+	users, err := userRepository.GetAllByEmail("alice@example.com")
+	if err != nil {
+		t.Fatalf("GetAllByEmail() error = %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("GetAllByEmail() = %v, want alice and alice2", names(users))
+	}
+}
+
+func TestUserRepositoryGetAll(t *testing.T) {
+	userRepository := repositories.NewUserRepository(dbtest.Open(t))
+	for _, name := range []string{"carol", "alice", "bob", "dave", "admin"} {
+		createUser(t, userRepository, name)
+	}
+
+	tests := []struct {
+		name      string
+		listQuery *query.ListQuery
+		want      []string
+		wantTotal int64
+	}{
+		{
+			name:      "first page",
+			listQuery: &query.ListQuery{Limit: 2},
+			want:      []string{"carol", "alice"},
+			wantTotal: 5,
+		},
+		{
+			name:      "offset",
+			listQuery: &query.ListQuery{Limit: 2, Offset: 2},
+			want:      []string{"bob", "dave"},
+			wantTotal: 5,
+		},
+		{
+			name:      "sorted by name",
+			listQuery: &query.ListQuery{Limit: 10, Sorts: []query.Sort{{Column: "name"}}},
+			want:      []string{"admin", "alice", "bob", "carol", "dave"},
+			wantTotal: 5,
+		},
+		{
+			name:      "sorted by name descending",
+			listQuery: &query.ListQuery{Limit: 2, Sorts: []query.Sort{{Column: "name", Descending: true}}},
+			want:      []string{"dave", "carol"},
+			wantTotal: 5,
+		},
+		{
+			name:      "equality filter",
+			listQuery: &query.ListQuery{Limit: 10, Filters: []query.Filter{{Column: "name", Operator: "=", Value: "bob"}}},
+			want:      []string{"bob"},
+			wantTotal: 1,
+		},
+		{
+			name:      "case-insensitive contains filter",
+			listQuery: &query.ListQuery{Limit: 10, Filters: []query.Filter{{Column: "name", Operator: "~", Value: "A"}}},
+			want:      []string{"carol", "alice", "dave", "admin"},
+			wantTotal: 4,
+		},
+		{
+			name:      "case-insensitive starts with filter",
+			listQuery: &query.ListQuery{Limit: 10, Filters: []query.Filter{{Column: "name", Operator: "^", Value: "A"}}},
+			want:      []string{"alice", "admin"},
+			wantTotal: 2,
+		},
+		{
+			name:      "case-insensitive equality filter",
+			listQuery: &query.ListQuery{Limit: 10, Filters: []query.Filter{{Column: "email", Operator: "~=", Value: "Bob@Example.com"}}},
+			want:      []string{"bob"},
+			wantTotal: 1,
+		},
+		{
+			name:      "no results",
+			listQuery: &query.ListQuery{Limit: 0},
+			want:      []string{},
+			wantTotal: 5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			users, pageInfo, err := userRepository.GetAll(test.listQuery)
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+			if got := names(users); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("GetAll() = %v, want %v", got, test.want)
+			}
+			if pageInfo.Total != test.wantTotal {
+				t.Errorf("GetAll() total = %d, want %d", pageInfo.Total, test.wantTotal)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryGetAllEscapesWildcards(t *testing.T) {
+	userRepository := repositories.NewUserRepository(dbtest.Open(t))
+	for _, name := range []string{"a_b", "axb", "100%", "1000", `c\d`, "cd"} {
+		createUser(t, userRepository, name)
+	}
+
+	tests := []struct {
+		name     string
+		operator string
+		value    string
+		want     []string
+	}{
+		{name: "underscore", operator: "~", value: "_", want: []string{"a_b"}},
+		{name: "percent", operator: "~", value: "0%", want: []string{"100%"}},
+		{name: "backslash", operator: "~", value: `\`, want: []string{`c\d`}},
+		{name: "starts with an underscore", operator: "^", value: "a_", want: []string{"a_b"}},
+		{name: "starts with a percent", operator: "^", value: "%", want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			users, _, err := userRepository.GetAll(&query.ListQuery{Limit: 10, Filters: []query.Filter{{Column: "name", Operator: test.operator, Value: test.value}}})
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+			if got := names(users); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("GetAll() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryGetAllWithoutQuery(t *testing.T) {
+	userRepository := repositories.NewUserRepository(dbtest.Open(t))
+	for _, name := range []string{"carol", "alice", "bob"} {
+		createUser(t, userRepository, name)
+	}
+
+	users, pageInfo, err := userRepository.GetAll(nil)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	got := names(users)
+	if !reflect.DeepEqual(got, []string{"carol", "alice", "bob"}) || pageInfo.Total != 3 {
+		t.Errorf("GetAll() = %v, total %d, want every user", got, pageInfo.Total)
+	}
+}
+
+func TestUserRepositoryGetAllWithGroups(t *testing.T) {
+	database := dbtest.Open(t)
+	userRepository := repositories.NewUserRepository(database)
+	userGroupRepository := repositories.NewUserGroupRepository(database)
+
+	staff := createGroup(t, repositories.NewGroupRepository(database), "staff")
+	for _, name := range []string{"alice", "bob", "albert"} {
+		user := createUser(t, userRepository, name)
+		if err := userGroupRepository.AddUserToGroup(user.ID, staff.ID); err != nil {
+			t.Fatalf("AddUserToGroup() error = %v", err)
+		}
+	}
+
+	users, pageInfo, err := userRepository.GetAllWithGroups(&query.ListQuery{Limit: 1, Offset: 1, Filters: []query.Filter{{Column: "name", Operator: "^", Value: "al"}}})
+	if err != nil {
+		t.Fatalf("GetAllWithGroups() error = %v", err)
+	}
+	if got := names(users); !reflect.DeepEqual(got, []string{"albert"}) || pageInfo.Total != 2 {
+		t.Fatalf("GetAllWithGroups() = %v, total %d, want [albert] out of 2", got, pageInfo.Total)
+	}
+	if !reflect.DeepEqual(names(users[0].Groups), []string{"staff"}) {
+		t.Errorf("GetAllWithGroups() groups = %v, want [staff]", names(users[0].Groups))
+	}
+}
+
+func TestUserRepositoryGetAllCursor(t *testing.T) {
+	userRepository := repositories.NewUserRepository(dbtest.Open(t))
+	for _, name := range []string{"alice", "bob", "carol"} {
+		createUser(t, userRepository, name)
+	}
+
+	page, pageInfo, err := userRepository.GetAll(&query.ListQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if pageInfo.NextCursor == "" {
+		t.Fatal("GetAll() of a full page returned no cursor")
+	}
+
+	cursor := page[len(page)-1].ID
+	page, pageInfo, err = userRepository.GetAll(&query.ListQuery{Limit: 2, Cursor: &cursor})
+	if err != nil {
+		t.Fatalf("GetAll() after the cursor error = %v", err)
+	}
+	if got := names(page); !reflect.DeepEqual(got, []string{"carol"}) {
+		t.Errorf("GetAll() after the cursor = %v, want [carol]", got)
+	}
+	if pageInfo.NextCursor != "" {
+		t.Errorf("GetAll() of the last page returned cursor %q", pageInfo.NextCursor)
+	}
+}
+
+func TestUserRepositorySoftDelete(t *testing.T) {
+	userRepository := repositories.NewUserRepository(dbtest.Open(t))
+	alice := createUser(t, userRepository, "alice")
+	createUser(t, userRepository, "bob")
+
+	if err := userRepository.Delete(alice.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := userRepository.Get(alice.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Get() of a deleted user error = %v, want gorm.ErrRecordNotFound", err)
+	}
+	if users, _, err := userRepository.GetAll(nil); err != nil || !reflect.DeepEqual(names(users), []string{"bob"}) {
+		t.Errorf("GetAll() = %v, %v, want [bob]", names(users), err)
+	}
+
+	deleted, err := userRepository.GetDeleted(alice.ID)
+	if err != nil || deleted.Name != "alice" {
+		t.Fatalf("GetDeleted() = %v, %v, want alice", deleted, err)
+	}
+	if deleted, err := userRepository.GetDeletedByName("alice"); err != nil || deleted.ID != alice.ID {
+		t.Errorf("GetDeletedByName() = %v, %v, want alice", deleted, err)
+	}
+	if deleted, _, err := userRepository.GetAllDeleted(&query.ListQuery{Limit: 10}); err != nil || !reflect.DeepEqual(names(deleted), []string{"alice"}) {
+		t.Errorf("GetAllDeleted() = %v, %v, want [alice]", names(deleted), err)
+	}
+
+	if deleted, err := userRepository.GetDeletedBefore(time.Now().Add(time.Hour)); err != nil || len(deleted) != 1 {
+		t.Errorf("GetDeletedBefore() of the future = %v, %v, want alice", names(deleted), err)
+	}
+	if deleted, err := userRepository.GetDeletedBefore(time.Now().Add(-time.Hour)); err != nil || len(deleted) != 0 {
+		t.Errorf("GetDeletedBefore() of the past = %v, %v, want none", names(deleted), err)
+	}
+
+	if err := userRepository.Restore(alice.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got, err := userRepository.Get(alice.ID); err != nil || got.Name != "alice" {
+		t.Errorf("Get() of a restored user = %v, %v, want alice", got, err)
+	}
+	if _, err := userRepository.GetDeleted(alice.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetDeleted() of a restored user error = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestUserRepositoryPurge(t *testing.T) {
+	database := dbtest.Open(t)
+	userRepository := repositories.NewUserRepository(database)
+	groupRepository := repositories.NewGroupRepository(database)
+	userGroupRepository := repositories.NewUserGroupRepository(database)
+	refreshTokenRepository := repositories.NewRefreshTokenRepository(database)
+
+	alice := createUser(t, userRepository, "alice")
+	group := createGroup(t, groupRepository, "staff")
+	if err := userGroupRepository.AddUserToGroup(alice.ID, group.ID); err != nil {
+		t.Fatalf("AddUserToGroup() error = %v", err)
+	}
+	if err := refreshTokenRepository.Create(&models.RefreshToken{UserID: alice.ID, FamilyID: "family", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Create() of a refresh token error = %v", err)
+	}
+
+	// Active users can't be purged
+	if err := userRepository.Purge(alice.ID); err != nil {
+		t.Fatalf("Purge() of an active user error = %v", err)
+	}
+	if _, err := userRepository.Get(alice.ID); err != nil {
+		t.Fatalf("Purge() removed an active user: %v", err)
+	}
+
+	if err := userRepository.Delete(alice.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := userRepository.Purge(alice.ID); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if _, err := userRepository.GetDeleted(alice.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetDeleted() of a purged user error = %v, want gorm.ErrRecordNotFound", err)
+	}
+	if _, err := refreshTokenRepository.GetByHash("hash"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetByHash() of a purged user's token error = %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	// The name of a purged user is free again
+	createUser(t, userRepository, "alice")
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"gorm.io/gorm"
+)
+
+// VerificationTokenRepository defines the methods for interacting with the verification token data.
+type VerificationTokenRepository interface {
+	GetByHash(purpose string, tokenHash string) (*models.VerificationToken, error)
+	Create(verificationToken *models.VerificationToken) error
+	MarkUsed(id uint, date time.Time) (bool, error)
+	InvalidateUserTokens(userID uint, purpose string) error
+}
+
+// VerificationTokenRepositoryImplementation is an implementation of the VerificationTokenRepository using Gorm.
+type VerificationTokenRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewVerificationTokenRepository(database *gorm.DB) VerificationTokenRepository {
+	return &VerificationTokenRepositoryImplementation{database: database}
+}
+
+// GetByHash retrieves a verification token of the given purpose by its hash.
+func (repo *VerificationTokenRepositoryImplementation) GetByHash(purpose string, tokenHash string) (*models.VerificationToken, error) {
+	var verificationToken models.VerificationToken
+	if err := repo.database.Where("purpose = ? AND token_hash = ?", purpose, tokenHash).First(&verificationToken).Error; err != nil {
+		return nil, err
+	}
+	return &verificationToken, nil
+}
+
+// Create adds a new verification token.
+func (repo *VerificationTokenRepositoryImplementation) Create(verificationToken *models.VerificationToken) error {
+	return repo.database.Create(verificationToken).Error
+}
+
+// MarkUsed marks a verification token as used, and returns false if it already was: only one of concurrent
+// uses of a token can succeed.
+func (repo *VerificationTokenRepositoryImplementation) MarkUsed(id uint, date time.Time) (bool, error) {
+	result := repo.database.Model(&models.VerificationToken{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", date)
+	return result.RowsAffected == 1, result.Error
+}
+
+// InvalidateUserTokens marks every unused token of the given purpose sent to a user as used.
+func (repo *VerificationTokenRepositoryImplementation) InvalidateUserTokens(userID uint, purpose string) error {
+	return repo.database.Model(&models.VerificationToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
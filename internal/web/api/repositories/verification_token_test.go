@@ -0,0 +1,50 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"gorm.io/gorm"
+)
+
+func TestVerificationTokenRepository(t *testing.T) {
+	verificationTokenRepository := repositories.NewVerificationTokenRepository(dbtest.Open(t))
+
+	expiresAt := time.Now().Add(time.Hour)
+	for _, verificationToken := range []*models.VerificationToken{
+		{UserID: 1, Purpose: models.VerificationPurposeEmail, Email: "a@example.com", TokenHash: "email", ExpiresAt: expiresAt},
+		{UserID: 1, Purpose: models.VerificationPurposePasswordReset, Email: "a@example.com", TokenHash: "reset", ExpiresAt: expiresAt},
+	} {
+		if err := verificationTokenRepository.Create(verificationToken); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// Tokens are only found for their purpose
+	if _, err := verificationTokenRepository.GetByHash(models.VerificationPurposePasswordReset, "email"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetByHash() with another purpose error = %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	if err := verificationTokenRepository.InvalidateUserTokens(1, models.VerificationPurposeEmail); err != nil {
+		t.Fatalf("InvalidateUserTokens() error = %v", err)
+	}
+	if token, err := verificationTokenRepository.GetByHash(models.VerificationPurposeEmail, "email"); err != nil || token.UsedAt == nil {
+		t.Errorf("GetByHash() of an invalidated token = %v, %v, want it used", token, err)
+	}
+	token, err := verificationTokenRepository.GetByHash(models.VerificationPurposePasswordReset, "reset")
+	if err != nil || token.UsedAt != nil {
+		t.Fatalf("GetByHash() of a token of another purpose = %v, %v, want it unused", token, err)
+	}
+
+	// A token can only be used once
+	if used, err := verificationTokenRepository.MarkUsed(token.ID, time.Now()); err != nil || !used {
+		t.Errorf("MarkUsed() = %v, %v, want true", used, err)
+	}
+	if used, err := verificationTokenRepository.MarkUsed(token.ID, time.Now()); err != nil || used {
+		t.Errorf("second MarkUsed() = %v, %v, want false", used, err)
+	}
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository defines the methods for interacting with the webhook data.
+type WebhookRepository interface {
+	Get(id uint) (*models.Webhook, error)
+	GetAll(listQuery *query.ListQuery) ([]*models.Webhook, *query.PageInfo, error)
+	GetEnabled() ([]*models.Webhook, error)
+	Create(webhook *models.Webhook) error
+	Update(webhook *models.Webhook) error
+	Delete(id uint) error
+}
+
+// WebhookListFields are the fields webhooks can be filtered and sorted on.
+var WebhookListFields = query.Fields{
+	"id":         {Column: "id", Type: query.Number},
+	"name":       {Column: "name", Type: query.String},
+	"url":        {Column: "url", Type: query.String},
+	"created_at": {Column: "created_at", Type: query.Time},
+	"updated_at": {Column: "updated_at", Type: query.Time},
+}
+
+// WebhookRepositoryImplementation is an implementation of the WebhookRepository using Gorm.
+type WebhookRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewWebhookRepository(database *gorm.DB) WebhookRepository {
+	return &WebhookRepositoryImplementation{database: database}
+}
+
+// Get retrieves a webhook by ID.
+func (repo *WebhookRepositoryImplementation) Get(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := repo.database.First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetAll retrieves a page of webhooks.
+func (repo *WebhookRepositoryImplementation) GetAll(listQuery *query.ListQuery) ([]*models.Webhook, *query.PageInfo, error) {
+	var webhooks []*models.Webhook
+	pageInfo, err := query.Find(repo.database.Model(&models.Webhook{}), listQuery, &webhooks)
+	if err != nil {
+		return nil, nil, err
+	}
+	return webhooks, pageInfo, nil
+}
+
+// GetEnabled retrieves every webhook that receives events.
+func (repo *WebhookRepositoryImplementation) GetEnabled() ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	if err := repo.database.Where("disabled = ?", false).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// Create adds a new webhook.
+func (repo *WebhookRepositoryImplementation) Create(webhook *models.Webhook) error {
+	return repo.database.Create(webhook).Error
+}
+
+// Update modifies an existing webhook.
+func (repo *WebhookRepositoryImplementation) Update(webhook *models.Webhook) error {
+	return repo.database.Save(webhook).Error
+}
+
+// Delete removes a webhook by ID.
+func (repo *WebhookRepositoryImplementation) Delete(id uint) error {
+	return repo.database.Delete(&models.Webhook{}, id).Error
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"gorm.io/gorm"
+)
+
+// WebhookDeliveryRepository defines the methods for interacting with the webhook delivery queue and history.
+type WebhookDeliveryRepository interface {
+	Get(webhookID uint, id uint) (*models.WebhookDelivery, error)
+	GetAll(webhookID uint, listQuery *query.ListQuery) ([]*models.WebhookDelivery, *query.PageInfo, error)
+	Create(deliveries []*models.WebhookDelivery) error
+	Update(delivery *models.WebhookDelivery) error
+	Claim(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+}
+
+// WebhookDeliveryListFields are the fields webhook deliveries can be filtered and sorted on.
+var WebhookDeliveryListFields = query.Fields{
+	"id":              {Column: "id", Type: query.Number},
+	"event_id":        {Column: "event_id", Type: query.String},
+	"event":           {Column: "event", Type: query.String},
+	"status":          {Column: "status", Type: query.String},
+	"attempts":        {Column: "attempts", Type: query.Number},
+	"response_status": {Column: "response_status", Type: query.Number},
+	"created_at":      {Column: "created_at", Type: query.Time},
+	"last_attempt_at": {Column: "last_attempt_at", Type: query.Time},
+}
+
+// WebhookDeliveryRepositoryImplementation is an implementation of the WebhookDeliveryRepository using Gorm.
+type WebhookDeliveryRepositoryImplementation struct {
+	database *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(database *gorm.DB) WebhookDeliveryRepository {
+	return &WebhookDeliveryRepositoryImplementation{database: database}
+}
+
+// Get retrieves a delivery of a webhook by ID.
+func (repo *WebhookDeliveryRepositoryImplementation) Get(webhookID uint, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := repo.database.Where("webhook_id = ?", webhookID).First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetAll retrieves a page of the deliveries of a webhook.
+func (repo *WebhookDeliveryRepositoryImplementation) GetAll(webhookID uint, listQuery *query.ListQuery) ([]*models.WebhookDelivery, *query.PageInfo, error) {
+	var deliveries []*models.WebhookDelivery
+	pageInfo, err := query.Find(repo.database.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID), listQuery, &deliveries)
+	if err != nil {
+		return nil, nil, err
+	}
+	return deliveries, pageInfo, nil
+}
+
+// Create queues new deliveries.
+func (repo *WebhookDeliveryRepositoryImplementation) Create(deliveries []*models.WebhookDelivery) error {
+	return repo.database.Create(deliveries).Error
+}
+
+// Update modifies an existing delivery.
+func (repo *WebhookDeliveryRepositoryImplementation) Update(delivery *models.WebhookDelivery) error {
+	return repo.database.Save(delivery).Error
+}
+
+// Claim retrieves the pending deliveries whose next attempt is due, the oldest first, and postpones their next
+// attempt by the lease so that no other worker picks them up meanwhile. A delivery is only claimed if it's still due
+// when postponed, which makes claiming safe across several instances.
+func (repo *WebhookDeliveryRepositoryImplementation) Claim(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	var due []*models.WebhookDelivery
+	if err := repo.database.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).Order("next_attempt_at").Limit(limit).Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	leasedUntil := now.Add(lease)
+	claimed := make([]*models.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		result := repo.database.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", delivery.ID, models.DeliveryPending, now).
+			Update("next_attempt_at", leasedUntil)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			delivery.NextAttemptAt = &leasedUntil
+			claimed = append(claimed, delivery)
+		}
+	}
+	return claimed, nil
+}
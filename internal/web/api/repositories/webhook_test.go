@@ -0,0 +1,95 @@
+package repositories_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"gorm.io/gorm"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	webhookRepository := repositories.NewWebhookRepository(dbtest.Open(t))
+
+	enabled := &models.Webhook{Name: "crm", URL: "https://crm.example.com/hook", Secret: "secret", Events: []string{models.WebhookUserCreated}}
+	disabled := &models.Webhook{Name: "old", URL: "https://old.example.com/hook", Secret: "secret", Disabled: true}
+	for _, webhook := range []*models.Webhook{enabled, disabled} {
+		if err := webhookRepository.Create(webhook); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	webhooks, err := webhookRepository.GetEnabled()
+	if err != nil || len(webhooks) != 1 || webhooks[0].ID != enabled.ID {
+		t.Fatalf("GetEnabled() = %v, %v, want the enabled webhook", webhooks, err)
+	}
+	if len(webhooks[0].Events) != 1 || webhooks[0].Events[0] != models.WebhookUserCreated {
+		t.Errorf("GetEnabled() events = %v, want [%s]", webhooks[0].Events, models.WebhookUserCreated)
+	}
+
+	if err := webhookRepository.Delete(disabled.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := webhookRepository.Get(disabled.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Get() of a deleted webhook error = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestWebhookDeliveryRepositoryClaim(t *testing.T) {
+	deliveryRepository := repositories.NewWebhookDeliveryRepository(dbtest.Open(t))
+
+	now := time.Now().Truncate(time.Second)
+	due, later := now.Add(-time.Minute), now.Add(time.Minute)
+	deliveries := []*models.WebhookDelivery{
+		{WebhookID: 1, EventID: "due", Event: models.WebhookUserCreated, Payload: json.RawMessage(`{}`), Status: models.DeliveryPending, NextAttemptAt: &due},
+		{WebhookID: 1, EventID: "later", Event: models.WebhookUserCreated, Payload: json.RawMessage(`{}`), Status: models.DeliveryPending, NextAttemptAt: &later},
+		{WebhookID: 1, EventID: "done", Event: models.WebhookUserCreated, Payload: json.RawMessage(`{}`), Status: models.DeliverySucceeded, NextAttemptAt: &due},
+		{WebhookID: 2, EventID: "other", Event: models.WebhookGroupCreated, Payload: json.RawMessage(`{"id":1}`), Status: models.DeliveryPending, NextAttemptAt: &due},
+	}
+	if err := deliveryRepository.Create(deliveries); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	claimed, err := deliveryRepository.Claim(now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if len(claimed) != 2 {
+		t.Fatalf("Claim() = %d deliveries, want the 2 due ones", len(claimed))
+	}
+	for _, delivery := range claimed {
+		if delivery.EventID != "due" && delivery.EventID != "other" {
+			t.Errorf("Claim() claimed %s, which isn't due", delivery.EventID)
+		}
+	}
+
+	// Claimed deliveries are leased, they can't be claimed again until the lease ends
+	if claimed, err := deliveryRepository.Claim(now, time.Minute, 10); err != nil || len(claimed) != 0 {
+		t.Errorf("second Claim() = %d deliveries, %v, want none", len(claimed), err)
+	}
+	if claimed, err := deliveryRepository.Claim(now.Add(2*time.Minute), time.Minute, 10); err != nil || len(claimed) != 3 {
+		t.Errorf("Claim() after the lease = %d deliveries, %v, want 3", len(claimed), err)
+	}
+
+	got, err := deliveryRepository.Get(2, deliveries[3].ID)
+	if err != nil || string(got.Payload) != `{"id":1}` {
+		t.Fatalf("Get() = %v, %v, want the delivery with its payload", got, err)
+	}
+	if _, err := deliveryRepository.Get(1, deliveries[3].ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Get() through another webhook error = %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	got.Status, got.Attempts = models.DeliveryFailed, 8
+	if err := deliveryRepository.Update(got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	history, pageInfo, err := deliveryRepository.GetAll(2, &query.ListQuery{Limit: 10, Filters: []query.Filter{{Column: "status", Operator: "=", Value: models.DeliveryFailed}}})
+	if err != nil || pageInfo.Total != 1 || history[0].Attempts != 8 {
+		t.Errorf("GetAll() of the failed deliveries = %v, %v, want the updated delivery", history, err)
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/Nokeni/GODS/internal/web/api/handlers"
+	"github.com/Nokeni/GODS/internal/web/api/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -10,42 +11,162 @@ func RegisterAPIRoutes(
 	userHandler handlers.UserHandler,
 	groupHandler handlers.GroupHandler,
 	userGroupHandler handlers.UserGroupHandler,
+	roleHandler handlers.RoleHandler,
+	meHandler handlers.MeHandler,
+	mfaHandler handlers.MFAHandler,
 	authHandler handlers.AuthHandler,
+	verificationHandler handlers.VerificationHandler,
+	auditHandler handlers.AuditHandler,
+	lockoutHandler handlers.LockoutHandler,
+	clientHandler handlers.ClientHandler,
+	signingKeyHandler handlers.SigningKeyHandler,
+	apiKeyHandler handlers.APIKeyHandler,
+	bulkHandler handlers.BulkHandler,
+	webhookHandler handlers.WebhookHandler,
 	authMiddleware gin.HandlerFunc,
-	adminMiddleware gin.HandlerFunc,
+	requireSession gin.HandlerFunc,
+	requirePermission func(permission string) gin.HandlerFunc,
+	problemDetails gin.HandlerFunc,
 ) {
-	api := router.Group("/api")
+	api := router.Group("/api", problemDetails)
 	{
-		userRoutes := api.Group("/users", authMiddleware, adminMiddleware)
+		userRoutes := api.Group("/users", authMiddleware)
 		{
-			userRoutes.GET("/", userHandler.GetAll)
-			userRoutes.GET("/:id", userHandler.Get)
-			userRoutes.POST("/", userHandler.Create)
-			userRoutes.PUT("/:id", userHandler.Update)
-			userRoutes.DELETE("/:id", userHandler.Delete)
+			userRoutes.GET("/", requirePermission(models.PermissionUsersRead), userHandler.GetAll)
+			userRoutes.GET("/deleted", requirePermission(models.PermissionUsersRead), userHandler.GetAllDeleted)
+			userRoutes.GET("/:id", requirePermission(models.PermissionUsersRead), userHandler.Get)
+			userRoutes.POST("/", requirePermission(models.PermissionUsersWrite), userHandler.Create)
+			userRoutes.PUT("/:id", requirePermission(models.PermissionUsersWrite), userHandler.Update)
+			userRoutes.DELETE("/:id", requirePermission(models.PermissionUsersWrite), userHandler.Delete)
+			userRoutes.POST("/:id/restore", requirePermission(models.PermissionUsersWrite), userHandler.Restore)
+			userRoutes.DELETE("/:id/purge", requirePermission(models.PermissionUsersWrite), userHandler.Purge)
+			userRoutes.DELETE("/:id/sessions", requirePermission(models.PermissionUsersWrite), authHandler.RevokeUserSessions)
+			userRoutes.DELETE("/:id/mfa", requirePermission(models.PermissionUsersWrite), mfaHandler.ResetUserMFA)
+			userRoutes.GET("/:id/api-keys", requirePermission(models.PermissionAPIKeysRead), apiKeyHandler.GetUserKeys)
+			userRoutes.POST("/:id/api-keys", requirePermission(models.PermissionAPIKeysWrite), apiKeyHandler.CreateServiceAccountKey)
 		}
 
-		groupRoutes := api.Group("/groups", authMiddleware, adminMiddleware)
+		api.POST("/service-accounts", authMiddleware, requirePermission(models.PermissionUsersWrite), userHandler.CreateServiceAccount)
+
+		apiKeyRoutes := api.Group("/api-keys", authMiddleware)
+		{
+			apiKeyRoutes.GET("/", requirePermission(models.PermissionAPIKeysRead), apiKeyHandler.GetAll)
+			apiKeyRoutes.DELETE("/:id", requirePermission(models.PermissionAPIKeysWrite), apiKeyHandler.Revoke)
+		}
+
+		groupRoutes := api.Group("/groups", authMiddleware)
+		{
+			groupRoutes.GET("/", requirePermission(models.PermissionGroupsRead), groupHandler.GetAll)
+			groupRoutes.GET("/deleted", requirePermission(models.PermissionGroupsRead), groupHandler.GetAllDeleted)
+			groupRoutes.GET("/:id", requirePermission(models.PermissionGroupsRead), groupHandler.Get)
+			groupRoutes.POST("/", requirePermission(models.PermissionGroupsWrite), groupHandler.Create)
+			groupRoutes.PUT("/:id", requirePermission(models.PermissionGroupsWrite), groupHandler.Update)
+			groupRoutes.DELETE("/:id", requirePermission(models.PermissionGroupsWrite), groupHandler.Delete)
+			groupRoutes.POST("/:id/restore", requirePermission(models.PermissionGroupsWrite), groupHandler.Restore)
+			groupRoutes.DELETE("/:id/purge", requirePermission(models.PermissionGroupsWrite), groupHandler.Purge)
+		}
+
+		userGroupRoutes := api.Group("/users-groups", authMiddleware)
+		{
+			userGroupRoutes.POST("/:groupId/users/:userId", requirePermission(models.PermissionMembershipsWrite), userGroupHandler.AddUserToGroup)
+			userGroupRoutes.DELETE("/:groupId/users/:userId", requirePermission(models.PermissionMembershipsWrite), userGroupHandler.RemoveUserFromGroup)
+			userGroupRoutes.GET("/users/:userId", requirePermission(models.PermissionMembershipsRead), userGroupHandler.GetUserGroups)
+			userGroupRoutes.GET("/:groupId/users", requirePermission(models.PermissionMembershipsRead), userGroupHandler.GetGroupUsers)
+			userGroupRoutes.GET("/users/:userId/effective", requirePermission(models.PermissionMembershipsRead), userGroupHandler.GetEffectiveUserGroups)
+			userGroupRoutes.GET("/:groupId/users/effective", requirePermission(models.PermissionMembershipsRead), userGroupHandler.GetEffectiveGroupUsers)
+			userGroupRoutes.POST("/:groupId/groups/:subgroupId", requirePermission(models.PermissionMembershipsWrite), userGroupHandler.AddGroupToGroup)
+			userGroupRoutes.DELETE("/:groupId/groups/:subgroupId", requirePermission(models.PermissionMembershipsWrite), userGroupHandler.RemoveGroupFromGroup)
+			userGroupRoutes.GET("/:groupId/groups", requirePermission(models.PermissionMembershipsRead), userGroupHandler.GetSubgroups)
+		}
+
+		directoryRoutes := api.Group("/directory", authMiddleware)
+		{
+			directoryRoutes.POST("/import", requirePermission(models.PermissionDirectoryImport), bulkHandler.Import)
+			directoryRoutes.GET("/export", requirePermission(models.PermissionDirectoryExport), bulkHandler.Export)
+		}
+
+		roleRoutes := api.Group("/roles", authMiddleware)
+		{
+			roleRoutes.GET("/", requirePermission(models.PermissionRolesRead), roleHandler.GetAll)
+			roleRoutes.GET("/:id", requirePermission(models.PermissionRolesRead), roleHandler.Get)
+			roleRoutes.POST("/", requirePermission(models.PermissionRolesWrite), roleHandler.Create)
+			roleRoutes.PUT("/:id", requirePermission(models.PermissionRolesWrite), roleHandler.Update)
+			roleRoutes.DELETE("/:id", requirePermission(models.PermissionRolesWrite), roleHandler.Delete)
+			roleRoutes.POST("/:id/permissions/:permissionId", requirePermission(models.PermissionRolesWrite), roleHandler.AddPermissionToRole)
+			roleRoutes.DELETE("/:id/permissions/:permissionId", requirePermission(models.PermissionRolesWrite), roleHandler.RemovePermissionFromRole)
+			roleRoutes.POST("/:id/groups/:groupId", requirePermission(models.PermissionRolesWrite), roleHandler.AddRoleToGroup)
+			roleRoutes.DELETE("/:id/groups/:groupId", requirePermission(models.PermissionRolesWrite), roleHandler.RemoveRoleFromGroup)
+		}
+
+		api.GET("/permissions", authMiddleware, requirePermission(models.PermissionRolesRead), roleHandler.GetPermissions)
+
+		api.GET("/audit", authMiddleware, requirePermission(models.PermissionAuditRead), auditHandler.GetAll)
+
+		signingKeyRoutes := api.Group("/signing-keys", authMiddleware)
+		{
+			signingKeyRoutes.GET("/", requirePermission(models.PermissionSigningKeysRead), signingKeyHandler.GetAll)
+			signingKeyRoutes.POST("/rotate", requirePermission(models.PermissionSigningKeysWrite), signingKeyHandler.Rotate)
+		}
+
+		lockoutRoutes := api.Group("/lockouts", authMiddleware)
+		{
+			lockoutRoutes.GET("/", requirePermission(models.PermissionLockoutsRead), lockoutHandler.GetAll)
+			lockoutRoutes.DELETE("/:scope/:value", requirePermission(models.PermissionLockoutsWrite), lockoutHandler.Unlock)
+		}
+
+		clientRoutes := api.Group("/clients", authMiddleware)
+		{
+			clientRoutes.GET("/", requirePermission(models.PermissionClientsRead), clientHandler.GetAll)
+			clientRoutes.GET("/:id", requirePermission(models.PermissionClientsRead), clientHandler.Get)
+			clientRoutes.POST("/", requirePermission(models.PermissionClientsWrite), clientHandler.Create)
+			clientRoutes.PUT("/:id", requirePermission(models.PermissionClientsWrite), clientHandler.Update)
+			clientRoutes.DELETE("/:id", requirePermission(models.PermissionClientsWrite), clientHandler.Delete)
+			clientRoutes.POST("/:id/secret", requirePermission(models.PermissionClientsWrite), clientHandler.RotateSecret)
+		}
+
+		webhookRoutes := api.Group("/webhooks", authMiddleware)
 		{
-			groupRoutes.GET("/", groupHandler.GetAll)
-			groupRoutes.GET("/:id", groupHandler.Get)
-			groupRoutes.POST("/", groupHandler.Create)
-			groupRoutes.PUT("/:id", groupHandler.Update)
-			groupRoutes.DELETE("/:id", groupHandler.Delete)
+			webhookRoutes.GET("/", requirePermission(models.PermissionWebhooksRead), webhookHandler.GetAll)
+			webhookRoutes.GET("/:id", requirePermission(models.PermissionWebhooksRead), webhookHandler.Get)
+			webhookRoutes.POST("/", requirePermission(models.PermissionWebhooksWrite), webhookHandler.Create)
+			webhookRoutes.PUT("/:id", requirePermission(models.PermissionWebhooksWrite), webhookHandler.Update)
+			webhookRoutes.DELETE("/:id", requirePermission(models.PermissionWebhooksWrite), webhookHandler.Delete)
+			webhookRoutes.POST("/:id/secret", requirePermission(models.PermissionWebhooksWrite), webhookHandler.RotateSecret)
+			webhookRoutes.POST("/:id/ping", requirePermission(models.PermissionWebhooksWrite), webhookHandler.Ping)
+			webhookRoutes.GET("/:id/deliveries", requirePermission(models.PermissionWebhooksRead), webhookHandler.GetDeliveries)
+			webhookRoutes.POST("/:id/deliveries/:deliveryId/redeliver", requirePermission(models.PermissionWebhooksWrite), webhookHandler.Redeliver)
 		}
 
-		userGroupRoutes := api.Group("/users-groups", authMiddleware, adminMiddleware)
+		meRoutes := api.Group("/me", authMiddleware)
 		{
-			userGroupRoutes.POST("/:groupId/users/:userId", userGroupHandler.AddUserToGroup)
-			userGroupRoutes.DELETE("/:groupId/users/:userId", userGroupHandler.RemoveUserFromGroup)
-			userGroupRoutes.GET("/users/:userId", userGroupHandler.GetUserGroups)
-			userGroupRoutes.GET("/:groupId/users", userGroupHandler.GetGroupUsers)
+			meRoutes.GET("", meHandler.Get)
+			meRoutes.PATCH("", requireSession, meHandler.Update)
+			meRoutes.DELETE("", requireSession, meHandler.Delete)
+			meRoutes.PUT("/password", requireSession, meHandler.ChangePassword)
+			meRoutes.POST("/email/verification", requireSession, verificationHandler.SendEmailVerification)
+			meRoutes.POST("/mfa/totp", requireSession, mfaHandler.EnrollTOTP)
+			meRoutes.POST("/mfa/totp/verify", requireSession, mfaHandler.ActivateTOTP)
+			meRoutes.DELETE("/mfa/totp", requireSession, mfaHandler.DisableTOTP)
+			meRoutes.POST("/mfa/recovery-codes", requireSession, mfaHandler.RegenerateRecoveryCodes)
+			meRoutes.GET("/api-keys", requireSession, apiKeyHandler.GetOwn)
+			meRoutes.POST("/api-keys", requireSession, apiKeyHandler.CreateOwn)
+			meRoutes.DELETE("/api-keys/:id", requireSession, apiKeyHandler.RevokeOwn)
 		}
 
 		authRoutes := api.Group("/auth")
 		{
 			authRoutes.POST("/login", authHandler.Login)
+			authRoutes.POST("/mfa/enroll", authHandler.EnrollMFA)
+			authRoutes.POST("/mfa/verify", authHandler.VerifyMFA)
+			authRoutes.POST("/password/renew", authHandler.RenewPassword)
+			authRoutes.POST("/refresh", authHandler.Refresh)
 			authRoutes.POST("/signup", authHandler.Signup)
+			authRoutes.POST("/logout", authMiddleware, requireSession, authHandler.Logout)
+			authRoutes.GET("/verify-email", verificationHandler.VerifyEmail)
+			authRoutes.POST("/verify-email", verificationHandler.VerifyEmail)
+			authRoutes.POST("/verify-email/resend", verificationHandler.ResendEmailVerification)
+			authRoutes.POST("/forgot-password", verificationHandler.ForgotPassword)
+			authRoutes.POST("/reset-password", verificationHandler.ResetPassword)
 		}
 	}
 }
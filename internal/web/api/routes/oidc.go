@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterOIDCRoutes(router *gin.Engine, oidcHandler handlers.OIDCHandler, signingKeyHandler handlers.SigningKeyHandler) {
+	wellKnown := router.Group("/.well-known")
+	{
+		wellKnown.GET("/openid-configuration", oidcHandler.Discovery)
+		wellKnown.GET("/jwks.json", signingKeyHandler.JWKS)
+	}
+
+	oauth2 := router.Group("/oauth2")
+	{
+		oauth2.GET("/authorize", oidcHandler.Authorize)
+		oauth2.POST("/authorize", oidcHandler.AuthorizeLogin)
+		oauth2.POST("/token", oidcHandler.Token)
+		oauth2.GET("/userinfo", oidcHandler.UserInfo)
+		oauth2.POST("/userinfo", oidcHandler.UserInfo)
+	}
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/handlers"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSCIMRoutes(
+	router *gin.Engine,
+	scimHandler handlers.SCIMHandler,
+	authMiddleware gin.HandlerFunc,
+	requirePermission func(permission string) gin.HandlerFunc,
+	scimErrors gin.HandlerFunc,
+) {
+	scim := router.Group("/scim/v2", scimErrors)
+	{
+		// The discovery endpoints describe the service provider to unauthenticated clients
+		scim.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+		scim.GET("/ResourceTypes", scimHandler.ResourceTypes)
+		scim.GET("/ResourceTypes/:id", scimHandler.ResourceType)
+		scim.GET("/Schemas", scimHandler.Schemas)
+		scim.GET("/Schemas/:id", scimHandler.Schema)
+
+		userRoutes := scim.Group("/Users", authMiddleware)
+		{
+			userRoutes.GET("", requirePermission(models.PermissionUsersRead), scimHandler.GetUsers)
+			userRoutes.GET("/:id", requirePermission(models.PermissionUsersRead), scimHandler.GetUser)
+			userRoutes.POST("", requirePermission(models.PermissionUsersWrite), scimHandler.CreateUser)
+			userRoutes.PUT("/:id", requirePermission(models.PermissionUsersWrite), scimHandler.ReplaceUser)
+			userRoutes.PATCH("/:id", requirePermission(models.PermissionUsersWrite), scimHandler.PatchUser)
+			userRoutes.DELETE("/:id", requirePermission(models.PermissionUsersWrite), scimHandler.DeleteUser)
+		}
+
+		// Provisioning groups provisions their memberships too
+		groupRoutes := scim.Group("/Groups", authMiddleware)
+		{
+			groupRoutes.GET("", requirePermission(models.PermissionGroupsRead), scimHandler.GetGroups)
+			groupRoutes.GET("/:id", requirePermission(models.PermissionGroupsRead), scimHandler.GetGroup)
+			groupRoutes.POST("", requirePermission(models.PermissionGroupsWrite), requirePermission(models.PermissionMembershipsWrite), scimHandler.CreateGroup)
+			groupRoutes.PUT("/:id", requirePermission(models.PermissionGroupsWrite), requirePermission(models.PermissionMembershipsWrite), scimHandler.ReplaceGroup)
+			groupRoutes.PATCH("/:id", requirePermission(models.PermissionGroupsWrite), requirePermission(models.PermissionMembershipsWrite), scimHandler.PatchGroup)
+			groupRoutes.DELETE("/:id", requirePermission(models.PermissionGroupsWrite), scimHandler.DeleteGroup)
+		}
+	}
+}
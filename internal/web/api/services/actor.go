@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+)
+
+// Actor describes who performs an operation, as recorded in the audit log.
+type Actor struct {
+	UserID    *uint  // UserID is the ID of the authenticated user, nil for anonymous requests and system operations.
+	IP        string // IP is the client IP of the request.
+	UserAgent string // UserAgent is the user agent of the request.
+}
+
+// actorContextKey is the key of the actor in a context.
+type actorContextKey struct{}
+
+// WithActor returns a copy of the context carrying the actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by the context, or an empty actor for system operations.
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorContextKey{}).(Actor)
+	return actor
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"github.com/spf13/viper"
+)
+
+// apiKeyUsageInterval is the minimum time between two updates of the last use of an API key,
+// sparing a database write on every request.
+const apiKeyUsageInterval = time.Minute
+
+// APIKeyService defines the methods for performing business operations on API keys and personal access tokens.
+type APIKeyService interface {
+	GetAll(listQuery *query.ListQuery) ([]*models.APIKey, *query.PageInfo, error)
+	GetUserKeys(userID uint) ([]*models.APIKey, error)
+	Create(ctx context.Context, userID uint, apiKeyDTO *dtos.CreateAPIKeyDTO) (*dtos.APIKeyTokenDTO, error)
+	CreateServiceAccountKey(ctx context.Context, userID uint, apiKeyDTO *dtos.CreateAPIKeyDTO) (*dtos.APIKeyTokenDTO, error)
+	Revoke(ctx context.Context, id uint) error
+	RevokeUserKey(ctx context.Context, userID uint, id uint) error
+	Authenticate(token string, ip string) (*models.APIKey, error)
+}
+
+// APIKeyServiceImplementation is an implementation of the APIKeyService.
+type APIKeyServiceImplementation struct {
+	apiKeyRepository     repositories.APIKeyRepository
+	userRepository       repositories.UserRepository
+	permissionRepository repositories.PermissionRepository
+	auditService         AuditService
+}
+
+func NewAPIKeyService(
+	apiKeyRepository repositories.APIKeyRepository,
+	userRepository repositories.UserRepository,
+	permissionRepository repositories.PermissionRepository,
+	auditService AuditService,
+) APIKeyService {
+	return &APIKeyServiceImplementation{
+		apiKeyRepository:     apiKeyRepository,
+		userRepository:       userRepository,
+		permissionRepository: permissionRepository,
+		auditService:         auditService,
+	}
+}
+
+// GetAll retrieves a page of the API keys of every user.
+func (service *APIKeyServiceImplementation) GetAll(listQuery *query.ListQuery) ([]*models.APIKey, *query.PageInfo, error) {
+	return service.apiKeyRepository.GetAll(listQuery)
+}
+
+// GetUserKeys retrieves the API keys of a user.
+func (service *APIKeyServiceImplementation) GetUserKeys(userID uint) ([]*models.APIKey, error) {
+	return service.apiKeyRepository.GetUserKeys(userID)
+}
+
+// Create generates a personal access token for a user. The token is only returned by this call.
+func (service *APIKeyServiceImplementation) Create(ctx context.Context, userID uint, apiKeyDTO *dtos.CreateAPIKeyDTO) (*dtos.APIKeyTokenDTO, error) {
+	if strings.TrimSpace(apiKeyDTO.Name) == "" {
+		return nil, NewFieldValidationError(CodeInvalidRequest, "name", "the name is required")
+	}
+	if len(apiKeyDTO.Scopes) == 0 {
+		return nil, NewFieldValidationError(CodeInvalidScope, "scopes", "at least one scope is required")
+	}
+	for _, scope := range apiKeyDTO.Scopes {
+		if _, err := service.permissionRepository.GetByName(scope); err != nil {
+			return nil, NewFieldValidationError(CodeInvalidScope, "scopes", "unknown scope %s", scope)
+		}
+	}
+
+	now := time.Now()
+	expiresAt := apiKeyDTO.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(viper.GetDuration("API_KEY_DEFAULT_TTL"))
+	}
+	if !expiresAt.After(now) {
+		return nil, NewFieldValidationError(CodeInvalidExpiration, "expires_at", "the expiration date must be in the future")
+	}
+	if maxTTL := viper.GetDuration("API_KEY_MAX_TTL"); maxTTL > 0 && expiresAt.After(now.Add(maxTTL)) {
+		return nil, NewFieldValidationError(CodeInvalidExpiration, "expires_at", "API keys can't be valid for more than %s", maxTTL)
+	}
+
+	randomToken, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	token := models.APIKeyPrefix + randomToken
+
+	// Only the hash of the token is persisted, the prefix identifies it in the lists
+	apiKey := &models.APIKey{
+		UserID:    userID,
+		Name:      apiKeyDTO.Name,
+		Prefix:    token[:len(models.APIKeyPrefix)+6],
+		TokenHash: hashToken(token),
+		Scopes:    apiKeyDTO.Scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := service.apiKeyRepository.Create(apiKey); err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAPIKeyCreate, TargetType: "api_key", TargetID: &apiKey.ID, Details: apiKey.Name}, nil, apiKey)
+
+	return &dtos.APIKeyTokenDTO{APIKey: apiKey, Token: token}, nil
+}
+
+// CreateServiceAccountKey generates an API key for a service account. The users create their own personal access tokens.
+func (service *APIKeyServiceImplementation) CreateServiceAccountKey(ctx context.Context, userID uint, apiKeyDTO *dtos.CreateAPIKeyDTO) (*dtos.APIKeyTokenDTO, error) {
+	user, err := service.userRepository.Get(userID)
+	if err != nil {
+		return nil, notFound(err, CodeUserNotFound, "user %d not found", userID)
+	}
+	if !user.ServiceAccount {
+		return nil, NewConflictError(CodeNotServiceAccount, "API keys can only be created for service accounts, users create their own personal access tokens")
+	}
+
+	return service.Create(ctx, userID, apiKeyDTO)
+}
+
+// Revoke revokes an API key.
+func (service *APIKeyServiceImplementation) Revoke(ctx context.Context, id uint) error {
+	apiKey, err := service.apiKeyRepository.Get(id)
+	if err != nil {
+		return notFound(err, CodeAPIKeyNotFound, "API key %d not found", id)
+	}
+
+	if err := service.apiKeyRepository.Delete(id); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAPIKeyRevoke, TargetType: "api_key", TargetID: &apiKey.ID, Details: apiKey.Name}, apiKey, nil)
+
+	return nil
+}
+
+// RevokeUserKey revokes an API key if it belongs to the given user.
+func (service *APIKeyServiceImplementation) RevokeUserKey(ctx context.Context, userID uint, id uint) error {
+	apiKey, err := service.apiKeyRepository.Get(id)
+	if err != nil || apiKey.UserID != userID {
+		return NewNotFoundError(CodeAPIKeyNotFound, "API key %d not found", id)
+	}
+
+	return service.Revoke(ctx, id)
+}
+
+// Authenticate returns the API key matching a token if it hasn't expired and its user still exists,
+// and records its use.
+func (service *APIKeyServiceImplementation) Authenticate(token string, ip string) (*models.APIKey, error) {
+	apiKey, err := service.apiKeyRepository.GetByHash(hashToken(token))
+	if err != nil {
+		return nil, NewUnauthorizedError(CodeInvalidAPIKey, "invalid API key")
+	}
+
+	now := time.Now()
+	if now.After(apiKey.ExpiresAt) {
+		return nil, NewUnauthorizedError(CodeAPIKeyExpired, "API key has expired")
+	}
+	if user, err := service.userRepository.Get(apiKey.UserID); err != nil || user.Disabled {
+		return nil, NewUnauthorizedError(CodeInvalidAPIKey, "invalid API key")
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyUsageInterval || apiKey.LastUsedIP != ip {
+		if err := service.apiKeyRepository.MarkUsed(apiKey.ID, now, ip); err != nil {
+			return nil, err
+		}
+		apiKey.LastUsedAt = &now
+		apiKey.LastUsedIP = ip
+	}
+
+	return apiKey, nil
+}
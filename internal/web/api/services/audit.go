@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+)
+
+// AuditService defines the methods for recording and reading the audit log.
+type AuditService interface {
+	Record(ctx context.Context, event *models.AuditEvent, before any, after any)
+	GetAll(listQuery *query.ListQuery) ([]*models.AuditEvent, *query.PageInfo, error)
+}
+
+// AuditServiceImplementation is an implementation of the AuditService.
+type AuditServiceImplementation struct {
+	auditEventRepository repositories.AuditEventRepository
+}
+
+func NewAuditService(auditEventRepository repositories.AuditEventRepository) AuditService {
+	return &AuditServiceImplementation{auditEventRepository: auditEventRepository}
+}
+
+// ignoredAuditFields are the fields left out of the audit diffs.
+var ignoredAuditFields = map[string]bool{
+	"CreatedAt":   true,
+	"UpdatedAt":   true,
+	"DeletedAt":   true,
+	"Users":       true,
+	"Groups":      true,
+	"Roles":       true,
+	"Permissions": true,
+}
+
+// Record appends an event to the audit log, completed with the actor carried by the context and with the diff
+// between the before and after states of the target, either of which can be nil.
+// Failing to record an event doesn't fail the audited operation, the error is logged instead.
+func (service *AuditServiceImplementation) Record(ctx context.Context, event *models.AuditEvent, before any, after any) {
+	actor := ActorFromContext(ctx)
+	event.ActorID = actor.UserID
+	event.IP = actor.IP
+	event.UserAgent = actor.UserAgent
+
+	changes, err := diff(before, after)
+	if err != nil {
+		log.Printf("failed to compute audit diff of %s: %v", event.Action, err)
+	}
+	event.Changes = changes
+
+	if err := service.auditEventRepository.Create(event); err != nil {
+		log.Printf("failed to record audit event %s: %v", event.Action, err)
+	}
+}
+
+// GetAll retrieves a page of audit events.
+func (service *AuditServiceImplementation) GetAll(listQuery *query.ListQuery) ([]*models.AuditEvent, *query.PageInfo, error) {
+	return service.auditEventRepository.GetAll(listQuery)
+}
+
+// diff returns the JSON diff of the fields that differ between two states of a model.
+func diff(before any, after any) (json.RawMessage, error) {
+	if before == nil && after == nil {
+		return nil, nil
+	}
+
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]map[string]any{}
+	for field, value := range afterFields {
+		if previous, ok := beforeFields[field]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[field] = map[string]any{"before": beforeFields[field], "after": value}
+		}
+	}
+	for field, previous := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			changes[field] = map[string]any{"before": previous, "after": nil}
+		}
+	}
+
+	return json.Marshal(changes)
+}
+
+// auditFields returns the JSON fields of a model, without the ignored ones.
+func auditFields(model any) (map[string]any, error) {
+	fields := map[string]any{}
+	if value := reflect.ValueOf(model); model == nil || (value.Kind() == reflect.Pointer && value.IsNil()) {
+		return fields, nil
+	}
+
+	encoded, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+
+	for field := range ignoredAuditFields {
+		delete(fields, field)
+	}
+	return fields, nil
+}
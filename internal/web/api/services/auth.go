@@ -1,103 +1,688 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
 	"time"
 
+	"github.com/Nokeni/GODS/internal/passhash"
 	"github.com/Nokeni/GODS/internal/web/api/models"
 	"github.com/Nokeni/GODS/internal/web/api/repositories"
 	"github.com/Nokeni/GODS/internal/web/common/dtos"
 	"github.com/golang-jwt/jwt"
 	"github.com/spf13/viper"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthService defines the methods for performing business operations on User's authentication.
 type AuthService interface {
-	Login(loginDTO *dtos.LoginDTO) (string, error)
-	Signup(signupDTO *dtos.SignupDTO) error
+	Login(ctx context.Context, loginDTO *dtos.LoginDTO) (*dtos.TokenDTO, *dtos.MFAChallengeDTO, error)
+	Authenticate(ctx context.Context, loginDTO *dtos.LoginDTO, code string) (*models.User, error)
+	EnrollMFA(ctx context.Context, mfaEnrollDTO *dtos.MFAEnrollDTO) (*dtos.TOTPEnrollmentDTO, error)
+	VerifyMFA(ctx context.Context, mfaVerifyDTO *dtos.MFAVerifyDTO) (*dtos.TokenDTO, error)
+	RenewPassword(ctx context.Context, renewPasswordDTO *dtos.RenewPasswordDTO) (*dtos.TokenDTO, *dtos.MFAChallengeDTO, error)
+	Refresh(ctx context.Context, refreshDTO *dtos.RefreshDTO) (*dtos.TokenDTO, error)
+	Signup(ctx context.Context, signupDTO *dtos.SignupDTO) error
+	ValidateToken(tokenString string) (*AccessTokenClaims, error)
+	Logout(ctx context.Context, claims *AccessTokenClaims, logoutDTO *dtos.LogoutDTO) error
+	RevokeSessions(ctx context.Context, userID uint) error
+}
+
+// AccessTokenClaims represents the claims of the access tokens issued by the AuthService.
+type AccessTokenClaims struct {
+	UserID       uint
+	TokenVersion uint
+	jwt.StandardClaims
+}
+
+// accessTokenType is the typ header of the access tokens. The other tokens signed with the same keys, such as the
+// challenge and password renewal tokens, don't have it and mustn't be accepted as access tokens.
+const accessTokenType = "at+jwt"
+
+// mfaChallengeAudience is the audience of the challenge tokens, which mustn't be accepted as access tokens.
+const mfaChallengeAudience = "mfa"
+
+// MFAChallengeClaims represents the claims of the challenge tokens issued by the AuthService to the users
+// who still have to provide their second factor.
+type MFAChallengeClaims struct {
+	UserID       uint
+	TokenVersion uint
+	jwt.StandardClaims
+}
+
+// passwordRenewalAudience is the audience of the tokens allowing a user to replace their expired password, which
+// mustn't be accepted as access tokens.
+const passwordRenewalAudience = "password_renewal"
+
+// PasswordRenewalClaims represents the claims of the tokens issued by the AuthService to the users whose password
+// has expired, so that they can replace it without logging in.
+type PasswordRenewalClaims struct {
+	UserID       uint
+	TokenVersion uint
+	jwt.StandardClaims
 }
 
 // AuthServiceImplementation is an implementation of the UserService.
 type AuthServiceImplementation struct {
-	userRepository repositories.UserRepository
+	userRepository         repositories.UserRepository
+	refreshTokenRepository repositories.RefreshTokenRepository
+	revokedTokenRepository repositories.RevokedTokenRepository
+	lockoutService         LockoutService
+	mfaService             MFAService
+	passwordPolicyService  PasswordPolicyService
+	passwordHasher         passhash.Hasher
+	verificationService    VerificationService
+	keyStoreService        KeyStoreService
+	auditService           AuditService
+	webhookService         WebhookService
 }
 
-func NewAuthService(userRepository repositories.UserRepository) AuthService {
-	return &AuthServiceImplementation{userRepository: userRepository}
+func NewAuthService(
+	userRepository repositories.UserRepository,
+	refreshTokenRepository repositories.RefreshTokenRepository,
+	revokedTokenRepository repositories.RevokedTokenRepository,
+	lockoutService LockoutService,
+	mfaService MFAService,
+	passwordPolicyService PasswordPolicyService,
+	passwordHasher passhash.Hasher,
+	verificationService VerificationService,
+	keyStoreService KeyStoreService,
+	auditService AuditService,
+	webhookService WebhookService,
+) AuthService {
+	return &AuthServiceImplementation{
+		userRepository:         userRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		revokedTokenRepository: revokedTokenRepository,
+		lockoutService:         lockoutService,
+		mfaService:             mfaService,
+		passwordPolicyService:  passwordPolicyService,
+		passwordHasher:         passwordHasher,
+		verificationService:    verificationService,
+		keyStoreService:        keyStoreService,
+		auditService:           auditService,
+		webhookService:         webhookService,
+	}
+}
+
+// ErrMFACodeRequired is returned by Authenticate when the user has a second factor and no code was provided.
+var ErrMFACodeRequired error = NewUnauthorizedError(CodeMFACodeRequired, "two-factor authentication code required")
+
+// ErrUserDisabled is returned by the login of disabled users.
+var ErrUserDisabled error = NewForbiddenError(CodeUserDisabled, "user is disabled")
+
+// ErrPasswordExpired is returned by Authenticate when the password of the user has reached its maximum age.
+var ErrPasswordExpired error = NewForbiddenError(CodePasswordExpired, "password expired, log in through the API to change it")
+
+// PasswordExpiredError is returned by Login when the password of the user has reached its maximum age. The login
+// goes on through RenewPassword, with the token allowing to replace the password.
+type PasswordExpiredError struct {
+	PasswordToken string
+}
+
+func (err *PasswordExpiredError) Error() string {
+	return "password expired, it has to be changed"
 }
 
 // Login authenticates a user.
-func (service *AuthServiceImplementation) Login(loginDTO *dtos.LoginDTO) (string, error) {
-	user, err := service.userRepository.GetByName(loginDTO.Name)
-	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginDTO.Password)) != nil {
-		return "", errors.New("invalid username or password")
+// Failed logins are counted against the account and the client IP, which are temporarily locked past a threshold.
+// Users with a second factor, or required to enroll one, get a challenge to answer through VerifyMFA instead of tokens.
+// Users whose password has expired get a PasswordExpiredError, and have to replace it through RenewPassword.
+func (service *AuthServiceImplementation) Login(ctx context.Context, loginDTO *dtos.LoginDTO) (*dtos.TokenDTO, *dtos.MFAChallengeDTO, error) {
+	user, err := service.checkCredentials(ctx, loginDTO)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if service.passwordPolicyService.IsExpired(user) {
+		passwordToken, err := service.generatePasswordRenewalToken(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, &PasswordExpiredError{PasswordToken: passwordToken}
 	}
 
-	token, err := generateJWTToken(user)
+	return service.continueLogin(ctx, user)
+}
+
+// RenewPassword replaces the expired password of a user with the token returned by their login, and goes on with the
+// login: users with a second factor still get a challenge.
+func (service *AuthServiceImplementation) RenewPassword(ctx context.Context, renewPasswordDTO *dtos.RenewPasswordDTO) (*dtos.TokenDTO, *dtos.MFAChallengeDTO, error) {
+	user, err := service.parsePasswordRenewalToken(renewPasswordDTO.PasswordToken)
 	if err != nil {
-		return "", err
+		return nil, nil, err
+	}
+
+	// Check if passwords match
+	if renewPasswordDTO.Password != renewPasswordDTO.PasswordConfirmation {
+		return nil, nil, NewFieldValidationError(CodePasswordsMismatch, "password_confirmation", "passwords doesn't match")
+	}
+
+	if err := service.passwordPolicyService.SetPassword(user, renewPasswordDTO.Password); err != nil {
+		return nil, nil, err
+	}
+
+	// Bumping the version invalidates the renewal token and the tokens issued with the previous password
+	user.TokenVersion++
+	if err := service.userRepository.Update(user); err != nil {
+		return nil, nil, err
+	}
+	if err := service.refreshTokenRepository.RevokeUserTokens(user.ID); err != nil {
+		return nil, nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserPasswordChange, TargetType: "user", TargetID: &user.ID, Details: "expired"}, nil, nil)
+
+	return service.continueLogin(ctx, user)
+}
+
+// continueLogin issues the tokens of a user whose password has been verified, or the challenge of their second factor.
+func (service *AuthServiceImplementation) continueLogin(ctx context.Context, user *models.User) (*dtos.TokenDTO, *dtos.MFAChallengeDTO, error) {
+	if user.TOTPEnabled || service.mfaService.IsRequired(user) {
+		mfaToken, err := service.generateMFAChallengeToken(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &dtos.MFAChallengeDTO{
+			MFARequired:        true,
+			MFAToken:           mfaToken,
+			EnrollmentRequired: !user.TOTPEnabled,
+		}, nil
+	}
+
+	tokens, err := service.completeLogin(ctx, user)
+	return tokens, nil, err
+}
+
+// Authenticate checks the credentials of a user in a single step, TOTP code included when they have a second factor,
+// for the login forms that can't run the challenge flow such as the OpenID Connect authorization endpoint.
+func (service *AuthServiceImplementation) Authenticate(ctx context.Context, loginDTO *dtos.LoginDTO, code string) (*models.User, error) {
+	user, err := service.checkCredentials(ctx, loginDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	if service.passwordPolicyService.IsExpired(user) {
+		return nil, ErrPasswordExpired
+	}
+	if !user.TOTPEnabled && service.mfaService.IsRequired(user) {
+		return nil, NewForbiddenError(CodeMFAEnrollmentRequired, "two-factor authentication is required, log in through the API to enroll")
+	}
+	if user.TOTPEnabled {
+		if code == "" {
+			return nil, ErrMFACodeRequired
+		}
+		if err := service.mfaService.Verify(ctx, user, code); err != nil {
+			if err := service.registerMFAFailure(ctx, user); err != nil {
+				return nil, err
+			}
+			return nil, err
+		}
+	}
+
+	if err := service.registerLogin(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// EnrollMFA starts the TOTP enrollment of a user who has to enroll before completing their login.
+func (service *AuthServiceImplementation) EnrollMFA(ctx context.Context, mfaEnrollDTO *dtos.MFAEnrollDTO) (*dtos.TOTPEnrollmentDTO, error) {
+	user, err := service.parseMFAChallengeToken(mfaEnrollDTO.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.mfaService.EnrollTOTP(ctx, user.ID)
+}
+
+// VerifyMFA completes a login with a TOTP code or a recovery code. For users completing their enrollment,
+// the TOTP gets activated and the recovery codes are returned along with the tokens.
+// Failed codes are counted against the account and the client IP like failed passwords.
+func (service *AuthServiceImplementation) VerifyMFA(ctx context.Context, mfaVerifyDTO *dtos.MFAVerifyDTO) (*dtos.TokenDTO, error) {
+	user, err := service.parseMFAChallengeToken(mfaVerifyDTO.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.lockoutService.Check(AccountLockoutKey(user.Name), IPLockoutKey(ActorFromContext(ctx).IP)); err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes *dtos.RecoveryCodesDTO
+	if user.TOTPEnabled {
+		err = service.mfaService.Verify(ctx, user, mfaVerifyDTO.Code)
+	} else {
+		recoveryCodes, err = service.mfaService.ActivateTOTP(ctx, user.ID, mfaVerifyDTO.Code)
+	}
+	if err != nil {
+		if err := service.registerMFAFailure(ctx, user); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	tokens, err := service.completeLogin(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if recoveryCodes != nil {
+		tokens.RecoveryCodes = recoveryCodes.RecoveryCodes
 	}
 
-	return token, nil
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+func (service *AuthServiceImplementation) Refresh(ctx context.Context, refreshDTO *dtos.RefreshDTO) (*dtos.TokenDTO, error) {
+	refreshToken, err := service.refreshTokenRepository.GetByHash(hashToken(refreshDTO.RefreshToken))
+	if err != nil || refreshToken.RevokedAt != nil {
+		return nil, NewUnauthorizedError(CodeInvalidRefreshToken, "invalid refresh token")
+	}
+
+	if refreshToken.UsedAt == nil && time.Now().After(refreshToken.ExpiresAt) {
+		return nil, NewUnauthorizedError(CodeRefreshTokenExpired, "refresh token expired")
+	}
+
+	// Mark the token as used so it can't be exchanged again. A token that has already been rotated, including by
+	// a concurrent exchange, is being replayed: the family is compromised
+	used := false
+	if refreshToken.UsedAt == nil {
+		if used, err = service.refreshTokenRepository.MarkUsed(refreshToken.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if !used {
+		if err := service.refreshTokenRepository.RevokeFamily(refreshToken.FamilyID); err != nil {
+			return nil, err
+		}
+		service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthRefreshReuse, TargetType: "user", TargetID: &refreshToken.UserID}, nil, nil)
+		return nil, NewUnauthorizedError(CodeRefreshTokenReused, "refresh token reuse detected")
+	}
+
+	user, err := service.userRepository.Get(refreshToken.UserID)
+	if err != nil || user.Disabled {
+		if err := service.refreshTokenRepository.RevokeFamily(refreshToken.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, NewUnauthorizedError(CodeInvalidRefreshToken, "invalid refresh token")
+	}
+
+	return service.issueTokens(user, refreshToken.FamilyID)
 }
 
 // Signup creates a new user.
-func (service *AuthServiceImplementation) Signup(signupDTO *dtos.SignupDTO) error {
+// Every signup attempt is counted against the client IP, which is temporarily locked past a threshold.
+func (service *AuthServiceImplementation) Signup(ctx context.Context, signupDTO *dtos.SignupDTO) error {
+	signupKey := SignupLockoutKey(ActorFromContext(ctx).IP)
+	if err := service.lockoutService.Check(signupKey); err != nil {
+		return err
+	}
+	if err := service.lockoutService.RegisterFailure(ctx, signupKey); err != nil {
+		return err
+	}
+
 	// Check if passwords match
 	if signupDTO.Password != signupDTO.PasswordConfirmation {
-		return errors.New("passwords doesn't match")
+		return NewFieldValidationError(CodePasswordsMismatch, "password_confirmation", "passwords doesn't match")
 	}
 
-	// Check if the user already exists
+	// Check if the user already exists, deleted users keeping their name until purged
 	if _, err := service.userRepository.GetByName(signupDTO.Name); err == nil {
-		return errors.New("user already exists")
+		return NewConflictError(CodeUserExists, "user already exists")
+	}
+	if _, err := service.userRepository.GetDeletedByName(signupDTO.Name); err == nil {
+		return NewConflictError(CodeUserExists, "user already exists")
+	}
+
+	// Create the user model, with a password meeting the policy
+	user := &models.User{
+		Name:  signupDTO.Name,
+		Email: signupDTO.Email,
+	}
+	if err := service.passwordPolicyService.SetPassword(user, signupDTO.Password); err != nil {
+		return err
 	}
 
-	// Check the provided password strength
-	if err := models.ValidatePasswordStrength(signupDTO.Password); err != nil {
+	if err := service.userRepository.Create(user); err != nil {
 		return err
 	}
 
-	hashedPassword, err := models.HashPassword(signupDTO.Password)
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthSignup, TargetType: "user", TargetID: &user.ID}, nil, user)
+	service.webhookService.Publish(models.WebhookUserCreated, user)
+
+	// The account is created even if the email can't be sent, the user can ask for a new one
+	if err := service.verificationService.SendEmailVerification(ctx, user.ID); err != nil {
+		log.Printf("failed to send the verification email of user %d: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// ValidateToken parses an access token and checks it hasn't been revoked.
+func (service *AuthServiceImplementation) ValidateToken(tokenString string) (*AccessTokenClaims, error) {
+	claims := &AccessTokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, service.keyStoreService.Keyfunc)
+	if err != nil || !token.Valid || token.Header["typ"] != accessTokenType || claims.Audience != "" {
+		return nil, NewUnauthorizedError(CodeInvalidToken, "invalid token")
+	}
+
+	// Check if the token has been explicitly revoked through a logout
+	revoked, err := service.revokedTokenRepository.IsRevoked(claims.Id)
 	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, NewUnauthorizedError(CodeInvalidToken, "token has been revoked")
+	}
+
+	// Check if the user still exists and hasn't had all of their sessions revoked
+	user, err := service.userRepository.Get(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion {
+		return nil, NewUnauthorizedError(CodeInvalidToken, "token has been revoked")
+	}
+
+	return claims, nil
+}
+
+// Logout revokes the access token and, when provided, the refresh token family of the current session.
+func (service *AuthServiceImplementation) Logout(ctx context.Context, claims *AccessTokenClaims, logoutDTO *dtos.LogoutDTO) error {
+	// Discard the deny-list entries that are no longer needed
+	if err := service.revokedTokenRepository.DeleteExpired(); err != nil {
 		return err
 	}
 
-	// Create the user model
-	user := &models.User{
-		Name:     signupDTO.Name,
-		Email:    signupDTO.Email,
-		Password: hashedPassword,
+	if err := service.revokedTokenRepository.Create(&models.RevokedToken{
+		TokenID:   claims.Id,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}); err != nil {
+		return err
 	}
 
-	return service.userRepository.Create(user)
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthLogout, TargetType: "user", TargetID: &claims.UserID}, nil, nil)
+
+	if logoutDTO.RefreshToken == "" {
+		return nil
+	}
+
+	refreshToken, err := service.refreshTokenRepository.GetByHash(hashToken(logoutDTO.RefreshToken))
+	if err != nil || refreshToken.UserID != claims.UserID {
+		return NewFieldValidationError(CodeInvalidRefreshToken, "refresh_token", "invalid refresh token")
+	}
+
+	return service.refreshTokenRepository.RevokeFamily(refreshToken.FamilyID)
+}
+
+// RevokeSessions invalidates every access token and refresh token issued to a user.
+func (service *AuthServiceImplementation) RevokeSessions(ctx context.Context, userID uint) error {
+	user, err := service.userRepository.Get(userID)
+	if err != nil {
+		return notFound(err, CodeUserNotFound, "user %d not found", userID)
+	}
+
+	// Bumping the version invalidates the access tokens issued with the previous one
+	user.TokenVersion++
+	if err := service.userRepository.Update(user); err != nil {
+		return err
+	}
+
+	if err := service.refreshTokenRepository.RevokeUserTokens(user.ID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthSessionsRevoke, TargetType: "user", TargetID: &user.ID}, nil, nil)
+
+	return nil
+}
+
+// checkCredentials checks the name and password of a user, counting the failures against the account and the client IP.
+func (service *AuthServiceImplementation) checkCredentials(ctx context.Context, loginDTO *dtos.LoginDTO) (*models.User, error) {
+	accountKey := AccountLockoutKey(loginDTO.Name)
+	ipKey := IPLockoutKey(ActorFromContext(ctx).IP)
+	if err := service.lockoutService.Check(accountKey, ipKey); err != nil {
+		return nil, err
+	}
+
+	// Service accounts don't have a password, they authenticate with API keys
+	user, err := service.userRepository.GetByName(loginDTO.Name)
+	var matches bool
+	if err == nil && !user.ServiceAccount {
+		matches, _ = passhash.Verify(user.Password, loginDTO.Password)
+	}
+	if !matches {
+		event := &models.AuditEvent{Action: models.AuditAuthLoginFailed, TargetType: "user", Details: loginDTO.Name}
+		if user != nil {
+			event.TargetID = &user.ID
+		}
+		service.auditService.Record(ctx, event, nil, nil)
+		if err := service.lockoutService.RegisterFailure(ctx, accountKey, ipKey); err != nil {
+			return nil, err
+		}
+		return nil, NewUnauthorizedError(CodeInvalidCredentials, "invalid username or password")
+	}
+
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	if viper.GetBool("AUTH_REQUIRE_VERIFIED_EMAIL") && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	service.rehashPassword(ctx, user, loginDTO.Password)
+
+	return user, nil
 }
 
-// generateJWTToken generates a JWT token for the user.
-func generateJWTToken(user *models.User) (string, error) {
+// rehashPassword replaces the hash of a user's password when it was made with an outdated algorithm or outdated
+// parameters, now that the password is known. The login goes on when it fails, with the hash kept as it is.
+func (service *AuthServiceImplementation) rehashPassword(ctx context.Context, user *models.User, password string) {
+	if !service.passwordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := service.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("failed to rehash the password of user %d: %v", user.ID, err)
+		return
+	}
+
+	previous := user.Password
+	user.Password = hashedPassword
+	if err := service.userRepository.Update(user); err != nil {
+		log.Printf("failed to rehash the password of user %d: %v", user.ID, err)
+		user.Password = previous
+		return
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserPasswordRehash, TargetType: "user", TargetID: &user.ID, Details: service.passwordHasher.Name()}, nil, nil)
+}
+
+// registerMFAFailure records a failed second factor and counts it against the account and the client IP.
+func (service *AuthServiceImplementation) registerMFAFailure(ctx context.Context, user *models.User) error {
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthMFAFailed, TargetType: "user", TargetID: &user.ID}, nil, nil)
+	return service.lockoutService.RegisterFailure(ctx, AccountLockoutKey(user.Name), IPLockoutKey(ActorFromContext(ctx).IP))
+}
+
+// registerLogin resets the failed logins of the user and records their login.
+func (service *AuthServiceImplementation) registerLogin(ctx context.Context, user *models.User) error {
+	// The IP counter isn't reset, a valid account mustn't help guessing the password of others
+	if err := service.lockoutService.RegisterSuccess(AccountLockoutKey(user.Name)); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthLogin, TargetType: "user", TargetID: &user.ID}, nil, nil)
+
+	return nil
+}
+
+// completeLogin records the login of the user and issues their tokens.
+func (service *AuthServiceImplementation) completeLogin(ctx context.Context, user *models.User) (*dtos.TokenDTO, error) {
+	if err := service.registerLogin(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Every login starts a new refresh token family
+	familyID, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return service.issueTokens(user, familyID)
+}
+
+// parseMFAChallengeToken parses a challenge token and returns the user it was issued to.
+func (service *AuthServiceImplementation) parseMFAChallengeToken(tokenString string) (*models.User, error) {
+	claims := &MFAChallengeClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, service.keyStoreService.Keyfunc)
+	if err != nil || !token.Valid || !claims.VerifyAudience(mfaChallengeAudience, true) {
+		return nil, NewUnauthorizedError(CodeInvalidMFAToken, "invalid MFA token")
+	}
+
+	user, err := service.userRepository.Get(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion {
+		return nil, NewUnauthorizedError(CodeInvalidMFAToken, "invalid MFA token")
+	}
+
+	return user, nil
+}
+
+// parsePasswordRenewalToken parses a password renewal token and returns the user it was issued to.
+func (service *AuthServiceImplementation) parsePasswordRenewalToken(tokenString string) (*models.User, error) {
+	claims := &PasswordRenewalClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, service.keyStoreService.Keyfunc)
+	if err != nil || !token.Valid || !claims.VerifyAudience(passwordRenewalAudience, true) {
+		return nil, NewUnauthorizedError(CodeInvalidPasswordToken, "invalid password token")
+	}
+
+	user, err := service.userRepository.Get(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion {
+		return nil, NewUnauthorizedError(CodeInvalidPasswordToken, "invalid password token")
+	}
+
+	return user, nil
+}
+
+// issueTokens generates an access token and a refresh token belonging to the given family.
+func (service *AuthServiceImplementation) issueTokens(user *models.User, familyID string) (*dtos.TokenDTO, error) {
+	accessTokenTTL := viper.GetDuration("JWT_ACCESS_TOKEN_TTL")
+
+	accessToken, err := service.generateJWTToken(user, accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the hash of the refresh token is persisted
+	if err := service.refreshTokenRepository.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(viper.GetDuration("JWT_REFRESH_TOKEN_TTL")),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &dtos.TokenDTO{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// generateJWTToken generates a JWT token for the user, signed with the active key of the keystore.
+func (service *AuthServiceImplementation) generateJWTToken(user *models.User, ttl time.Duration) (string, error) {
 	// Define token expiration time
-	expirationTime := time.Now().Add(24 * time.Hour)
-
-	// Create the JWT claims, which includes the username and expiry time
-	claims := &struct {
-		UserID uint
-		jwt.StandardClaims
-	}{
-		UserID: user.ID,
+	expirationTime := time.Now().Add(ttl)
+
+	// Generate the token's unique identifier, used to revoke it
+	tokenID, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	// Create the JWT claims, which includes the user ID, the user's token version and expiry time
+	claims := &AccessTokenClaims{
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
 		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID,
+			Issuer:    oidcIssuer(),
+			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
 
-	// Declare the token with the algorithm used for signing, and the claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
 	// Create the JWT string
-	tokenString, err := token.SignedString([]byte(viper.GetString("JWT_KEY")))
+	tokenString, err := service.keyStoreService.Sign(claims, accessTokenType)
 	if err != nil {
 		return "", err
 	}
 
 	return tokenString, nil
 }
+
+// generateMFAChallengeToken generates the short-lived token identifying a user whose password has been verified.
+func (service *AuthServiceImplementation) generateMFAChallengeToken(user *models.User) (string, error) {
+	tokenID, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &MFAChallengeClaims{
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID,
+			Audience:  mfaChallengeAudience,
+			ExpiresAt: time.Now().Add(viper.GetDuration("MFA_CHALLENGE_TTL")).Unix(),
+		},
+	}
+
+	return service.keyStoreService.Sign(claims, "")
+}
+
+// generatePasswordRenewalToken generates the short-lived token allowing a user whose password has been verified,
+// but has expired, to replace it.
+func (service *AuthServiceImplementation) generatePasswordRenewalToken(user *models.User) (string, error) {
+	tokenID, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &PasswordRenewalClaims{
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID,
+			Audience:  passwordRenewalAudience,
+			ExpiresAt: time.Now().Add(viper.GetDuration("PASSWORD_RENEWAL_TOKEN_TTL")).Unix(),
+		},
+	}
+
+	return service.keyStoreService.Sign(claims, "")
+}
+
+// generateRandomToken generates a random URL-safe token.
+func generateRandomToken() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 hash of a token.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/golang-jwt/jwt"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// newTestAuthService returns an auth service issuing and validating tokens on a test database, along with a user.
+func newTestAuthService(t *testing.T, database *gorm.DB) (*AuthServiceImplementation, *models.User) {
+	t.Helper()
+
+	viper.Set("JWT_ALGORITHM", models.SigningAlgorithmEdDSA)
+	viper.Set("JWT_KEYSTORE_SECRET", "")
+	viper.Set("JWT_KEY_ROTATION_INTERVAL", "0")
+	viper.Set("JWT_KEY_RETENTION", "1h")
+	viper.Set("MFA_CHALLENGE_TTL", "5m")
+	viper.Set("PASSWORD_RENEWAL_TOKEN_TTL", "5m")
+
+	userRepository := repositories.NewUserRepository(database)
+	auditService := NewAuditService(repositories.NewAuditEventRepository(database))
+	keyStoreService, err := NewKeyStoreService(repositories.NewSigningKeyRepository(database), auditService)
+	if err != nil {
+		t.Fatalf("NewKeyStoreService() error = %v", err)
+	}
+
+	user := &models.User{Name: "alice", Email: "alice@example.com", Password: "hash"}
+	if err := userRepository.Create(user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	service := &AuthServiceImplementation{
+		userRepository:         userRepository,
+		refreshTokenRepository: repositories.NewRefreshTokenRepository(database),
+		revokedTokenRepository: repositories.NewRevokedTokenRepository(database),
+		keyStoreService:        keyStoreService,
+		auditService:           auditService,
+	}
+	return service, user
+}
+
+func TestValidateTokenAcceptsOnlyAccessTokens(t *testing.T) {
+	service, user := newTestAuthService(t, dbtest.Open(t))
+
+	accessToken, err := service.generateJWTToken(user, time.Hour)
+	if err != nil {
+		t.Fatalf("generateJWTToken() error = %v", err)
+	}
+	claims, err := service.ValidateToken(accessToken)
+	if err != nil || claims.UserID != user.ID {
+		t.Fatalf("ValidateToken() = %v, %v, want the claims of the user", claims, err)
+	}
+
+	challengeToken, err := service.generateMFAChallengeToken(user)
+	if err != nil {
+		t.Fatalf("generateMFAChallengeToken() error = %v", err)
+	}
+	renewalToken, err := service.generatePasswordRenewalToken(user)
+	if err != nil {
+		t.Fatalf("generatePasswordRenewalToken() error = %v", err)
+	}
+	// A token carrying the claims of an access token isn't one without the typ header
+	untypedToken, err := service.keyStoreService.Sign(&AccessTokenClaims{
+		UserID:         user.ID,
+		TokenVersion:   user.TokenVersion,
+		StandardClaims: jwt.StandardClaims{Id: "untyped", ExpiresAt: time.Now().Add(time.Hour).Unix()},
+	}, "")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	for name, token := range map[string]string{"challenge": challengeToken, "password renewal": renewalToken, "untyped": untypedToken} {
+		if claims, err := service.ValidateToken(token); err == nil {
+			t.Errorf("ValidateToken() of a %s token = %v, want an error", name, claims)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"slices"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/passhash"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+)
+
+// Statuses of the rows of a bulk import report.
+const (
+	ImportValid    = "valid"    // ImportValid is the status of the rows that a dry run would import.
+	ImportCreated  = "created"  // ImportCreated is the status of the imported rows.
+	ImportExisting = "existing" // ImportExisting is the status of the groups that already existed, whose subgroups are added.
+	ImportInvalid  = "invalid"  // ImportInvalid is the status of the rows that failed the validation, which aren't imported.
+	ImportFailed   = "failed"   // ImportFailed is the status of the rows that couldn't be saved.
+	ImportSkipped  = "skipped"  // ImportSkipped is the status of the valid rows of the atomic imports that weren't applied.
+)
+
+// BulkService defines the methods for importing and exporting users, groups and memberships in bulk.
+type BulkService interface {
+	Import(ctx context.Context, directory *dtos.DirectoryDTO, dryRun bool, atomic bool) (*dtos.ImportReportDTO, error)
+	Export(ctx context.Context, includePasswordHashes bool) (*dtos.DirectoryDTO, error)
+}
+
+// BulkServiceImplementation is an implementation of the BulkService.
+type BulkServiceImplementation struct {
+	bulkRepository        repositories.BulkRepository
+	userRepository        repositories.UserRepository
+	groupRepository       repositories.GroupRepository
+	passwordPolicyService PasswordPolicyService
+	auditService          AuditService
+	webhookService        WebhookService
+}
+
+func NewBulkService(
+	bulkRepository repositories.BulkRepository,
+	userRepository repositories.UserRepository,
+	groupRepository repositories.GroupRepository,
+	passwordPolicyService PasswordPolicyService,
+	auditService AuditService,
+	webhookService WebhookService,
+) BulkService {
+	return &BulkServiceImplementation{
+		bulkRepository:        bulkRepository,
+		userRepository:        userRepository,
+		groupRepository:       groupRepository,
+		passwordPolicyService: passwordPolicyService,
+		auditService:          auditService,
+		webhookService:        webhookService,
+	}
+}
+
+// Import validates every row of a file, then imports the valid ones unless it's a dry run. Atomic imports are only
+// applied if every row is valid, and are rolled back entirely if one of them can't be saved. Existing groups are
+// kept as they are, but the subgroups listed for them are added, while existing users are invalid rows. The names of
+// the deleted users and groups can't be imported until they're restored or purged.
+func (service *BulkServiceImplementation) Import(ctx context.Context, directory *dtos.DirectoryDTO, dryRun bool, atomic bool) (*dtos.ImportReportDTO, error) {
+	existingGroups, _, err := service.groupRepository.GetAllWithUsers(nil)
+	if err != nil {
+		return nil, err
+	}
+	existingUsers, _, err := service.userRepository.GetAllWithGroups(nil)
+	if err != nil {
+		return nil, err
+	}
+	deletedGroups, _, err := service.groupRepository.GetAllDeleted(nil)
+	if err != nil {
+		return nil, err
+	}
+	deletedUsers, _, err := service.userRepository.GetAllDeleted(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The nestings of the groups, by name, to detect the cycles the import would create
+	nestings := make(map[string][]string)
+	for _, group := range existingGroups {
+		for _, subgroup := range group.Subgroups {
+			nestings[group.Name] = append(nestings[group.Name], subgroup.Name)
+		}
+	}
+	knownGroups := make(map[string]bool)
+	for _, group := range existingGroups {
+		knownGroups[group.Name] = true
+	}
+	for _, group := range directory.Groups {
+		knownGroups[group.Name] = true
+	}
+	knownUsers := make(map[string]bool)
+	for _, user := range existingUsers {
+		knownUsers[user.Name] = true
+	}
+	// The IDs of the deleted groups and users, by name, which stays taken until they're purged
+	deletedGroupIDs := make(map[string]uint)
+	for _, group := range deletedGroups {
+		deletedGroupIDs[group.Name] = group.ID
+	}
+	deletedUserIDs := make(map[string]uint)
+	for _, user := range deletedUsers {
+		deletedUserIDs[user.Name] = user.ID
+	}
+
+	report := &dtos.ImportReportDTO{DryRun: dryRun, Atomic: atomic, Rows: []dtos.ImportRowDTO{}}
+	var groups []*repositories.ImportGroup
+	var groupRows []int
+	seenGroups := make(map[string]bool)
+	for i, groupDTO := range directory.Groups {
+		row := dtos.ImportRowDTO{Row: i + 1, Type: "group", Name: groupDTO.Name, Status: ImportValid}
+		exists := slices.ContainsFunc(existingGroups, func(group *models.Group) bool { return group.Name == groupDTO.Name })
+
+		if groupDTO.Name == "" {
+			row.Errors = append(row.Errors, "name is required")
+		} else if seenGroups[groupDTO.Name] {
+			row.Errors = append(row.Errors, "duplicate group")
+		} else if id, ok := deletedGroupIDs[groupDTO.Name]; ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("name belongs to the deleted group %d", id))
+		}
+		seenGroups[groupDTO.Name] = true
+		for _, subgroup := range groupDTO.Subgroups {
+			switch {
+			case !knownGroups[subgroup]:
+				row.Errors = append(row.Errors, fmt.Sprintf("unknown subgroup %q", subgroup))
+			case subgroup == groupDTO.Name || reachable(nestings, subgroup, groupDTO.Name):
+				row.Errors = append(row.Errors, fmt.Sprintf("nesting %q would create a cycle", subgroup))
+			}
+		}
+
+		if len(row.Errors) == 0 {
+			if exists {
+				row.Status = ImportExisting
+			}
+			nestings[groupDTO.Name] = append(nestings[groupDTO.Name], groupDTO.Subgroups...)
+			groups = append(groups, &repositories.ImportGroup{
+				Group:     &models.Group{Name: groupDTO.Name, Description: groupDTO.Description},
+				Exists:    exists,
+				Subgroups: groupDTO.Subgroups,
+			})
+			groupRows = append(groupRows, len(report.Rows))
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	// Only the existing groups and the valid rows can be referenced: the rows nesting an invalid group are
+	// invalid too, which may in turn invalidate the rows nesting them
+	validGroups := make(map[string]bool)
+	for _, group := range existingGroups {
+		validGroups[group.Name] = true
+	}
+	for _, group := range groups {
+		validGroups[group.Group.Name] = true
+	}
+	for dropped := true; dropped; {
+		dropped = false
+		for i := len(groups) - 1; i >= 0; i-- {
+			row := &report.Rows[groupRows[i]]
+			for _, subgroup := range groups[i].Subgroups {
+				if !validGroups[subgroup] {
+					row.Errors = append(row.Errors, fmt.Sprintf("subgroup %q is invalid", subgroup))
+				}
+			}
+			if len(row.Errors) > 0 {
+				if !groups[i].Exists {
+					delete(validGroups, groups[i].Group.Name)
+				}
+				groups = slices.Delete(groups, i, i+1)
+				groupRows = slices.Delete(groupRows, i, i+1)
+				dropped = true
+			}
+		}
+	}
+
+	var users []*repositories.ImportUser
+	var userRows []int
+	seenUsers := make(map[string]bool)
+	for i, userDTO := range directory.Users {
+		row := dtos.ImportRowDTO{Row: i + 1, Type: "user", Name: userDTO.Name, Status: ImportValid}
+
+		user, errs := service.validateUser(&userDTO)
+		row.Errors = errs
+		if knownUsers[userDTO.Name] {
+			row.Errors = append(row.Errors, "user already exists")
+		} else if userDTO.Name != "" && seenUsers[userDTO.Name] {
+			row.Errors = append(row.Errors, "duplicate user")
+		} else if id, ok := deletedUserIDs[userDTO.Name]; ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("name belongs to the deleted user %d", id))
+		}
+		seenUsers[userDTO.Name] = true
+		for _, group := range userDTO.Groups {
+			switch {
+			case !knownGroups[group]:
+				row.Errors = append(row.Errors, fmt.Sprintf("unknown group %q", group))
+			case !validGroups[group]:
+				row.Errors = append(row.Errors, fmt.Sprintf("group %q is invalid", group))
+			}
+		}
+
+		if len(row.Errors) == 0 {
+			users = append(users, &repositories.ImportUser{User: user, Groups: userDTO.Groups})
+			userRows = append(userRows, len(report.Rows))
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	for i := range report.Rows {
+		if len(report.Rows[i].Errors) > 0 {
+			report.Rows[i].Status = ImportInvalid
+			report.Invalid++
+		}
+	}
+	if dryRun {
+		return report, nil
+	}
+	if atomic && report.Invalid > 0 {
+		for i := range report.Rows {
+			if report.Rows[i].Status != ImportInvalid {
+				report.Rows[i].Status = ImportSkipped
+			}
+		}
+		return report, nil
+	}
+
+	importErr := service.bulkRepository.Import(groups, users, atomic)
+	for i, group := range groups {
+		row := &report.Rows[groupRows[i]]
+		row.Status = importStatus(group.Err, importErr)
+		if group.Err != nil {
+			row.Errors = append(row.Errors, group.Err.Error())
+		} else if row.Status == ImportCreated && group.Exists {
+			row.Status = ImportExisting
+		}
+	}
+	for i, user := range users {
+		row := &report.Rows[userRows[i]]
+		row.Status = importStatus(user.Err, importErr)
+		if user.Err != nil {
+			row.Errors = append(row.Errors, user.Err.Error())
+		}
+	}
+	for i := range report.Rows {
+		switch report.Rows[i].Status {
+		case ImportCreated:
+			report.Created++
+		case ImportExisting:
+			report.Existing++
+		case ImportFailed:
+			report.Failed++
+		}
+	}
+	report.Applied = importErr == nil
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditDirectoryImport, Details: fmt.Sprintf("%d created, %d existing, %d invalid, %d failed", report.Created, report.Existing, report.Invalid, report.Failed)}, nil, nil)
+	if report.Applied {
+		service.publish(groups, users)
+	}
+
+	return report, nil
+}
+
+// Export returns every user, group and membership, along with the password hashes of the users when asked to.
+func (service *BulkServiceImplementation) Export(ctx context.Context, includePasswordHashes bool) (*dtos.DirectoryDTO, error) {
+	groups, _, err := service.groupRepository.GetAllWithUsers(nil)
+	if err != nil {
+		return nil, err
+	}
+	users, _, err := service.userRepository.GetAllWithGroups(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	directory := &dtos.DirectoryDTO{
+		Groups: make([]dtos.DirectoryGroupDTO, 0, len(groups)),
+		Users:  make([]dtos.DirectoryUserDTO, 0, len(users)),
+	}
+	for _, group := range groups {
+		groupDTO := dtos.DirectoryGroupDTO{Name: group.Name, Description: group.Description}
+		for _, subgroup := range group.Subgroups {
+			groupDTO.Subgroups = append(groupDTO.Subgroups, subgroup.Name)
+		}
+		directory.Groups = append(directory.Groups, groupDTO)
+	}
+	for _, user := range users {
+		userDTO := dtos.DirectoryUserDTO{Name: user.Name, Email: user.Email, ServiceAccount: user.ServiceAccount}
+		if includePasswordHashes && !user.ServiceAccount {
+			userDTO.PasswordHash = user.Password
+		}
+		for _, group := range user.Groups {
+			userDTO.Groups = append(userDTO.Groups, group.Name)
+		}
+		directory.Users = append(directory.Users, userDTO)
+	}
+
+	details := "without password hashes"
+	if includePasswordHashes {
+		details = "with password hashes"
+	}
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditDirectoryExport, Details: details}, nil, nil)
+
+	return directory, nil
+}
+
+// publish notifies the webhooks of the groups, users and memberships created by an import.
+func (service *BulkServiceImplementation) publish(groups []*repositories.ImportGroup, users []*repositories.ImportUser) {
+	for _, group := range groups {
+		if group.Err == nil && !group.Exists {
+			service.webhookService.Publish(models.WebhookGroupCreated, group.Group)
+		}
+	}
+	for _, group := range groups {
+		if group.Err == nil {
+			for _, subgroup := range group.Group.Subgroups {
+				service.webhookService.Publish(models.WebhookMembershipAdded, map[string]uint{"group_id": group.Group.ID, "subgroup_id": subgroup.ID})
+			}
+		}
+	}
+	for _, user := range users {
+		if user.Err == nil {
+			service.webhookService.Publish(models.WebhookUserCreated, user.User)
+			for _, group := range user.User.Groups {
+				service.webhookService.Publish(models.WebhookMembershipAdded, map[string]uint{"group_id": group.ID, "user_id": user.User.ID})
+			}
+		}
+	}
+}
+
+// validateUser checks the attributes of an imported user and returns the user to create, with a hashed password.
+func (service *BulkServiceImplementation) validateUser(userDTO *dtos.DirectoryUserDTO) (*models.User, []string) {
+	var errs []string
+	if userDTO.Name == "" {
+		errs = append(errs, "name is required")
+	}
+	if userDTO.Email != "" {
+		if address, err := netmail.ParseAddress(userDTO.Email); err != nil || address.Address != userDTO.Email {
+			errs = append(errs, "invalid email")
+		}
+	} else if !userDTO.ServiceAccount {
+		errs = append(errs, "email is required")
+	}
+
+	user := &models.User{Name: userDTO.Name, Email: userDTO.Email, ServiceAccount: userDTO.ServiceAccount}
+	switch {
+	case userDTO.ServiceAccount:
+		if userDTO.Password != "" || userDTO.PasswordHash != "" {
+			errs = append(errs, "service accounts can't have a password")
+		}
+	case userDTO.Password != "" && userDTO.PasswordHash != "":
+		errs = append(errs, "password and password_hash are mutually exclusive")
+	case userDTO.PasswordHash != "":
+		if err := passhash.Validate(userDTO.PasswordHash); err != nil {
+			errs = append(errs, "password_hash isn't a supported hash")
+		}
+		// The maximum age of the imported hashes is counted from the import, those of outdated algorithms are
+		// replaced at the first login
+		now := time.Now()
+		user.Password = userDTO.PasswordHash
+		user.PasswordChangedAt = &now
+	case userDTO.Password != "":
+		var policyErr *PasswordPolicyError
+		if err := service.passwordPolicyService.SetPassword(user, userDTO.Password); errors.As(err, &policyErr) {
+			for _, violation := range policyErr.Violations {
+				errs = append(errs, violation.Message)
+			}
+		} else if err != nil {
+			errs = append(errs, err.Error())
+		}
+	default:
+		errs = append(errs, "password or password_hash is required")
+	}
+
+	return user, errs
+}
+
+// importStatus returns the status of a valid row once imported, given its own error and the one of the import.
+func importStatus(rowErr error, importErr error) string {
+	switch {
+	case rowErr != nil:
+		return ImportFailed
+	case importErr != nil:
+		return ImportSkipped
+	default:
+		return ImportCreated
+	}
+}
+
+// reachable tells if a group is reachable from another one by following the nestings.
+func reachable(nestings map[string][]string, from string, to string) bool {
+	visited := make(map[string]bool)
+	pending := []string{from}
+	for len(pending) > 0 {
+		group := pending[0]
+		pending = pending[1:]
+		if group == to {
+			return true
+		}
+		if !visited[group] {
+			visited[group] = true
+			pending = append(pending, nestings[group]...)
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+)
+
+// defaultClientScopes are the scopes granted to the clients registered without an explicit list.
+var defaultClientScopes = []string{"openid", "profile", "email", "groups"}
+
+// ClientService defines the methods for performing business operations on OpenID Connect clients.
+type ClientService interface {
+	Get(id uint) (*models.Client, error)
+	GetByClientID(clientID string) (*models.Client, error)
+	GetAll(listQuery *query.ListQuery) ([]*models.Client, *query.PageInfo, error)
+	Create(ctx context.Context, clientDTO *dtos.CreateClientDTO) (*dtos.ClientSecretDTO, error)
+	Update(ctx context.Context, client *models.Client, clientDTO *dtos.UpdateClientDTO) error
+	RotateSecret(ctx context.Context, id uint) (*dtos.ClientSecretDTO, error)
+	Delete(ctx context.Context, id uint) error
+	Authenticate(clientID string, clientSecret string) (*models.Client, error)
+}
+
+// ClientServiceImplementation is an implementation of the ClientService.
+type ClientServiceImplementation struct {
+	clientRepository repositories.ClientRepository
+	auditService     AuditService
+}
+
+func NewClientService(clientRepository repositories.ClientRepository, auditService AuditService) ClientService {
+	return &ClientServiceImplementation{
+		clientRepository: clientRepository,
+		auditService:     auditService,
+	}
+}
+
+// Get retrieves a client by ID.
+func (service *ClientServiceImplementation) Get(id uint) (*models.Client, error) {
+	client, err := service.clientRepository.Get(id)
+	if err != nil {
+		return nil, notFound(err, CodeClientNotFound, "client %d not found", id)
+	}
+	return client, nil
+}
+
+// GetByClientID retrieves a client by its public identifier.
+func (service *ClientServiceImplementation) GetByClientID(clientID string) (*models.Client, error) {
+	return service.clientRepository.GetByClientID(clientID)
+}
+
+// GetAll retrieves a page of clients.
+func (service *ClientServiceImplementation) GetAll(listQuery *query.ListQuery) ([]*models.Client, *query.PageInfo, error) {
+	return service.clientRepository.GetAll(listQuery)
+}
+
+// Create registers a new client. Confidential clients get a secret, returned only once.
+func (service *ClientServiceImplementation) Create(ctx context.Context, clientDTO *dtos.CreateClientDTO) (*dtos.ClientSecretDTO, error) {
+	clientID, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &models.Client{
+		ClientID:     clientID[:32],
+		Name:         clientDTO.Name,
+		RedirectURIs: clientDTO.RedirectURIs,
+		GrantTypes:   clientDTO.GrantTypes,
+		Scopes:       clientDTO.Scopes,
+		Public:       clientDTO.Public,
+	}
+	if len(client.GrantTypes) == 0 {
+		client.GrantTypes = []string{models.GrantTypeAuthorizationCode}
+	}
+	if len(client.Scopes) == 0 {
+		client.Scopes = defaultClientScopes
+	}
+	if err := validateClient(client); err != nil {
+		return nil, err
+	}
+
+	var clientSecret string
+	if !client.Public {
+		if clientSecret, err = generateRandomToken(); err != nil {
+			return nil, err
+		}
+		client.SecretHash = hashToken(clientSecret)
+	}
+
+	if err := service.clientRepository.Create(client); err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditClientCreate, TargetType: "client", TargetID: &client.ID}, nil, client)
+
+	return &dtos.ClientSecretDTO{Client: client, ClientSecret: clientSecret}, nil
+}
+
+// Update modifies an existing client.
+func (service *ClientServiceImplementation) Update(ctx context.Context, client *models.Client, clientDTO *dtos.UpdateClientDTO) error {
+	before := *client
+
+	// Update client details depending on provided DTO fields
+	if clientDTO.Name != "" {
+		client.Name = clientDTO.Name
+	}
+	if len(clientDTO.RedirectURIs) > 0 {
+		client.RedirectURIs = clientDTO.RedirectURIs
+	}
+	if len(clientDTO.GrantTypes) > 0 {
+		client.GrantTypes = clientDTO.GrantTypes
+	}
+	if len(clientDTO.Scopes) > 0 {
+		client.Scopes = clientDTO.Scopes
+	}
+	if err := validateClient(client); err != nil {
+		return err
+	}
+
+	if err := service.clientRepository.Update(client); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditClientUpdate, TargetType: "client", TargetID: &client.ID}, &before, client)
+
+	return nil
+}
+
+// RotateSecret replaces the secret of a confidential client, the previous one stops working immediately.
+func (service *ClientServiceImplementation) RotateSecret(ctx context.Context, id uint) (*dtos.ClientSecretDTO, error) {
+	client, err := service.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if client.Public {
+		return nil, NewConflictError(CodePublicClient, "public clients don't have a secret")
+	}
+
+	clientSecret, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	client.SecretHash = hashToken(clientSecret)
+	if err := service.clientRepository.Update(client); err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditClientSecretRotate, TargetType: "client", TargetID: &client.ID}, nil, nil)
+
+	return &dtos.ClientSecretDTO{Client: client, ClientSecret: clientSecret}, nil
+}
+
+// Delete removes a client by ID.
+func (service *ClientServiceImplementation) Delete(ctx context.Context, id uint) error {
+	client, err := service.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if err := service.clientRepository.Delete(id); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditClientDelete, TargetType: "client", TargetID: &client.ID}, client, nil)
+
+	return nil
+}
+
+// Authenticate retrieves a client by its identifier and checks its secret. Public clients have no secret to check.
+func (service *ClientServiceImplementation) Authenticate(clientID string, clientSecret string) (*models.Client, error) {
+	client, err := service.clientRepository.GetByClientID(clientID)
+	if err != nil {
+		return nil, NewUnauthorizedError(CodeInvalidClient, "unknown client")
+	}
+
+	if client.Public {
+		return client, nil
+	}
+	if clientSecret == "" || subtle.ConstantTimeCompare([]byte(hashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, NewUnauthorizedError(CodeInvalidClient, "invalid client credentials")
+	}
+	return client, nil
+}
+
+// validateClient checks the grant types, redirect URIs and scopes of a client.
+func validateClient(client *models.Client) error {
+	for _, grantType := range client.GrantTypes {
+		if grantType != models.GrantTypeAuthorizationCode && grantType != models.GrantTypeClientCredentials {
+			return NewFieldValidationError(CodeInvalidClient, "grant_types", "unsupported grant type: %s", grantType)
+		}
+	}
+	if client.Public && slices.Contains(client.GrantTypes, models.GrantTypeClientCredentials) {
+		return NewFieldValidationError(CodeInvalidClient, "grant_types", "public clients can't use the client_credentials grant")
+	}
+	if slices.Contains(client.GrantTypes, models.GrantTypeAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return NewFieldValidationError(CodeInvalidClient, "redirect_uris", "the authorization_code grant requires at least one redirect URI")
+	}
+
+	for _, redirectURI := range client.RedirectURIs {
+		parsedURI, err := url.Parse(redirectURI)
+		if err != nil || !parsedURI.IsAbs() || parsedURI.Fragment != "" {
+			return NewFieldValidationError(CodeInvalidClient, "redirect_uris", "invalid redirect URI: %s", redirectURI)
+		}
+	}
+
+	for _, scope := range client.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\n") {
+			return NewFieldValidationError(CodeInvalidClient, "scopes", "invalid scope: %q", scope)
+		}
+	}
+
+	return nil
+}
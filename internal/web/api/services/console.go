@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/golang-jwt/jwt"
+	"github.com/spf13/viper"
+)
+
+// consoleSessionAudience is the audience of the session tokens of the admin console, which mustn't be accepted as
+// access tokens.
+const consoleSessionAudience = "console"
+
+// ErrConsoleAccessDenied is returned when a user without the console:access permission logs in to the admin console.
+var ErrConsoleAccessDenied error = NewForbiddenError(CodeConsoleAccessDenied, "you do not have access to the admin console")
+
+// ConsoleSessionClaims represents the claims of the session tokens of the admin console, kept in a cookie.
+type ConsoleSessionClaims struct {
+	UserID       uint
+	TokenVersion uint
+	jwt.StandardClaims
+}
+
+// ConsoleService defines the methods for managing the sessions of the admin console.
+type ConsoleService interface {
+	Login(ctx context.Context, loginDTO *dtos.LoginDTO, code string) (string, error)
+	GetSession(sessionToken string) (*models.User, *ConsoleSessionClaims, error)
+	Logout(ctx context.Context, claims *ConsoleSessionClaims) error
+}
+
+// ConsoleServiceImplementation is an implementation of the ConsoleService.
+type ConsoleServiceImplementation struct {
+	userRepository         repositories.UserRepository
+	revokedTokenRepository repositories.RevokedTokenRepository
+	authService            AuthService
+	roleService            RoleService
+	keyStoreService        KeyStoreService
+	auditService           AuditService
+}
+
+func NewConsoleService(
+	userRepository repositories.UserRepository,
+	revokedTokenRepository repositories.RevokedTokenRepository,
+	authService AuthService,
+	roleService RoleService,
+	keyStoreService KeyStoreService,
+	auditService AuditService,
+) ConsoleService {
+	return &ConsoleServiceImplementation{
+		userRepository:         userRepository,
+		revokedTokenRepository: revokedTokenRepository,
+		authService:            authService,
+		roleService:            roleService,
+		keyStoreService:        keyStoreService,
+		auditService:           auditService,
+	}
+}
+
+// Login authenticates a user, TOTP code included when they have a second factor, and returns the token of their
+// console session. Only the users granted the console:access permission can log in.
+func (service *ConsoleServiceImplementation) Login(ctx context.Context, loginDTO *dtos.LoginDTO, code string) (string, error) {
+	user, err := service.authService.Authenticate(ctx, loginDTO, code)
+	if err != nil {
+		return "", err
+	}
+
+	granted, err := service.roleService.HasPermission(user.ID, models.PermissionConsoleAccess)
+	if err != nil {
+		return "", err
+	}
+	if !granted {
+		return "", ErrConsoleAccessDenied
+	}
+
+	tokenID, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &ConsoleSessionClaims{
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID,
+			Audience:  consoleSessionAudience,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(viper.GetDuration("CONSOLE_SESSION_TTL")).Unix(),
+		},
+	}
+
+	return service.keyStoreService.Sign(claims, "")
+}
+
+// GetSession parses a session token and returns its user. Sessions are invalidated by a logout, along with the other
+// tokens of the user, and as soon as the user loses the console:access permission.
+func (service *ConsoleServiceImplementation) GetSession(sessionToken string) (*models.User, *ConsoleSessionClaims, error) {
+	claims := &ConsoleSessionClaims{}
+	token, err := jwt.ParseWithClaims(sessionToken, claims, service.keyStoreService.Keyfunc)
+	if err != nil || !token.Valid || !claims.VerifyAudience(consoleSessionAudience, true) {
+		return nil, nil, errors.New("invalid session")
+	}
+
+	revoked, err := service.revokedTokenRepository.IsRevoked(claims.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, errors.New("invalid session")
+	}
+
+	user, err := service.userRepository.Get(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion {
+		return nil, nil, errors.New("invalid session")
+	}
+
+	granted, err := service.roleService.HasPermission(user.ID, models.PermissionConsoleAccess)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !granted {
+		return nil, nil, ErrConsoleAccessDenied
+	}
+
+	return user, claims, nil
+}
+
+// Logout revokes a console session.
+func (service *ConsoleServiceImplementation) Logout(ctx context.Context, claims *ConsoleSessionClaims) error {
+	// Discard the deny-list entries that are no longer needed
+	if err := service.revokedTokenRepository.DeleteExpired(); err != nil {
+		return err
+	}
+
+	if err := service.revokedTokenRepository.Create(&models.RevokedToken{
+		TokenID:   claims.Id,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthLogout, TargetType: "user", TargetID: &claims.UserID, Details: "console"}, nil, nil)
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+)
+
+// totpCodeLength is the length of the TOTP code appended to the password of the users binding over LDAP,
+// as LDAP clients can't run the challenge flow.
+const totpCodeLength = 6
+
+// DirectoryService defines the methods exposing the users and groups as a directory, such as over LDAP.
+type DirectoryService interface {
+	Bind(ctx context.Context, name string, password string) (*DirectoryBind, error)
+	CanSearch(bind *DirectoryBind) (bool, error)
+	GetUsers(filters []query.Filter) ([]*models.User, error)
+	GetGroups(filters []query.Filter) ([]*models.Group, error)
+}
+
+// DirectoryBind is the identity a directory client authenticated as.
+type DirectoryBind struct {
+	User   *models.User   // User is the authenticated user.
+	APIKey *models.APIKey // APIKey is the API key the user authenticated with, nil when they used their password.
+}
+
+// DirectoryServiceImplementation is an implementation of the DirectoryService.
+type DirectoryServiceImplementation struct {
+	userRepository      repositories.UserRepository
+	groupRepository     repositories.GroupRepository
+	userGroupRepository repositories.UserGroupRepository
+	authService         AuthService
+	apiKeyService       APIKeyService
+	roleService         RoleService
+	auditService        AuditService
+}
+
+func NewDirectoryService(
+	userRepository repositories.UserRepository,
+	groupRepository repositories.GroupRepository,
+	userGroupRepository repositories.UserGroupRepository,
+	authService AuthService,
+	apiKeyService APIKeyService,
+	roleService RoleService,
+	auditService AuditService,
+) DirectoryService {
+	return &DirectoryServiceImplementation{
+		userRepository:      userRepository,
+		groupRepository:     groupRepository,
+		userGroupRepository: userGroupRepository,
+		authService:         authService,
+		apiKeyService:       apiKeyService,
+		roleService:         roleService,
+		auditService:        auditService,
+	}
+}
+
+// Bind authenticates a user by name. Service accounts bind with one of their API keys as password, and the users
+// having a second factor append their current TOTP code to their password.
+func (service *DirectoryServiceImplementation) Bind(ctx context.Context, name string, password string) (*DirectoryBind, error) {
+	if strings.HasPrefix(password, models.APIKeyPrefix) {
+		return service.bindAPIKey(ctx, name, password)
+	}
+
+	code := ""
+	if user, err := service.userRepository.GetByName(name); err == nil && user.TOTPEnabled && len(password) > totpCodeLength {
+		password, code = password[:len(password)-totpCodeLength], password[len(password)-totpCodeLength:]
+	}
+
+	user, err := service.authService.Authenticate(ctx, &dtos.LoginDTO{Name: name, Password: password}, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DirectoryBind{User: user}, nil
+}
+
+// CanSearch checks if an authenticated client may read the directory.
+func (service *DirectoryServiceImplementation) CanSearch(bind *DirectoryBind) (bool, error) {
+	if bind == nil {
+		return false, nil
+	}
+	if bind.APIKey != nil && !slices.Contains(bind.APIKey.Scopes, models.PermissionLDAPSearch) {
+		return false, nil
+	}
+	return service.roleService.HasPermission(bind.User.ID, models.PermissionLDAPSearch)
+}
+
+// GetUsers retrieves the users matching filters along with the groups they belong to, directly or through nested
+// groups, every user when the filters are nil.
+func (service *DirectoryServiceImplementation) GetUsers(filters []query.Filter) ([]*models.User, error) {
+	if filters == nil {
+		return service.getAllUsers()
+	}
+
+	users, err := findAll(filters, service.userRepository.GetAllWithGroups)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if user.Groups, _, err = service.userGroupRepository.GetEffectiveUserGroups(user.ID, nil); err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+// getAllUsers retrieves every user along with their groups. The nested groups of every user are resolved at once,
+// from the nesting of every group, rather than user by user.
+func (service *DirectoryServiceImplementation) getAllUsers() ([]*models.User, error) {
+	users, _, err := service.userRepository.GetAllWithGroups(nil)
+	if err != nil {
+		return nil, err
+	}
+	groups, _, err := service.groupRepository.GetAllWithUsers(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[uint][]*models.Group)
+	for _, group := range groups {
+		for _, subgroup := range group.Subgroups {
+			parents[subgroup.ID] = append(parents[subgroup.ID], group)
+		}
+	}
+	for _, user := range users {
+		var effective []*models.Group
+		reached := make(map[uint]bool)
+		for pending := slices.Clone(user.Groups); len(pending) > 0; {
+			group := pending[0]
+			pending = pending[1:]
+			if reached[group.ID] {
+				continue
+			}
+			reached[group.ID] = true
+			effective = append(effective, group)
+			pending = append(pending, parents[group.ID]...)
+		}
+		user.Groups = effective
+	}
+	return users, nil
+}
+
+// GetGroups retrieves the groups matching filters along with their users and subgroups, every group when the
+// filters are nil.
+func (service *DirectoryServiceImplementation) GetGroups(filters []query.Filter) ([]*models.Group, error) {
+	if filters == nil {
+		groups, _, err := service.groupRepository.GetAllWithUsers(nil)
+		return groups, err
+	}
+	return findAll(filters, service.groupRepository.GetAllWithUsers)
+}
+
+// findAll retrieves every result matching filters from a paged repository method, one page after the other.
+func findAll[T any](filters []query.Filter, getAll func(listQuery *query.ListQuery) ([]T, *query.PageInfo, error)) ([]T, error) {
+	var results []T
+	listQuery := &query.ListQuery{Limit: query.MaxLimit, Filters: filters}
+	for {
+		page, pageInfo, err := getAll(listQuery)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, page...)
+		if len(page) < listQuery.Limit || int64(len(results)) >= pageInfo.Total {
+			return results, nil
+		}
+		listQuery.Offset += len(page)
+	}
+}
+
+// bindAPIKey authenticates a user with one of their API keys.
+func (service *DirectoryServiceImplementation) bindAPIKey(ctx context.Context, name string, token string) (*DirectoryBind, error) {
+	user, err := service.userRepository.GetByName(name)
+	if err == nil {
+		apiKey, err := service.apiKeyService.Authenticate(token, ActorFromContext(ctx).IP)
+		if err == nil && apiKey.UserID == user.ID {
+			return &DirectoryBind{User: user, APIKey: apiKey}, nil
+		}
+	}
+
+	event := &models.AuditEvent{Action: models.AuditAuthLoginFailed, TargetType: "user", Details: name}
+	if user != nil {
+		event.TargetID = &user.ID
+	}
+	service.auditService.Record(ctx, event, nil, nil)
+
+	return nil, errors.New("invalid username or API key")
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+)
+
+func TestDirectoryGetUsersResolvesNestedGroups(t *testing.T) {
+	database := dbtest.Open(t)
+	userRepository := repositories.NewUserRepository(database)
+	groupRepository := repositories.NewGroupRepository(database)
+	userGroupRepository := repositories.NewUserGroupRepository(database)
+	service := NewDirectoryService(userRepository, groupRepository, userGroupRepository, nil, nil, nil, nil)
+
+	groups := make(map[string]*models.Group)
+	for _, name := range []string{"staff", "engineering", "developers", "sales"} {
+		group := &models.Group{Name: name}
+		if err := groupRepository.Create(group); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		groups[name] = group
+	}
+	// developers ⊂ engineering ⊂ staff
+	for subgroup, group := range map[string]string{"developers": "engineering", "engineering": "staff"} {
+		if err := userGroupRepository.AddGroupToGroup(groups[subgroup].ID, groups[group].ID); err != nil {
+			t.Fatalf("AddGroupToGroup() error = %v", err)
+		}
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if err := userRepository.Create(&models.User{Name: name, Email: name + "@example.com", Password: "hash"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	alice, _ := userRepository.GetByName("alice")
+	if err := userGroupRepository.AddUserToGroup(alice.ID, groups["developers"].ID); err != nil {
+		t.Fatalf("AddUserToGroup() error = %v", err)
+	}
+
+	// Every user, or the filtered ones, get the same groups
+	for _, filters := range [][]query.Filter{nil, {{Column: "name", Operator: "~=", Value: "alice"}}} {
+		users, err := service.GetUsers(filters)
+		if err != nil {
+			t.Fatalf("GetUsers(%v) error = %v", filters, err)
+		}
+		for _, user := range users {
+			var names []string
+			for _, group := range user.Groups {
+				names = append(names, group.Name)
+			}
+			slices.Sort(names)
+			if want := []string{"developers", "engineering", "staff"}; user.Name == "alice" && !slices.Equal(names, want) {
+				t.Errorf("GetUsers(%v) groups of alice = %v, want %v", filters, names, want)
+			}
+			if user.Name == "bob" && len(names) != 0 {
+				t.Errorf("GetUsers(%v) groups of bob = %v, want none", filters, names)
+			}
+		}
+	}
+}
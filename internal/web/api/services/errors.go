@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// The codes of the errors reported to the clients. They're part of the API: clients can rely on them, unlike on
+// the messages, so they must never change.
+const (
+	CodeInternal           = "internal_error"
+	CodeInvalidRequest     = "invalid_request"
+	CodeNotFound           = "not_found"
+	CodeTooManyAttempts    = "too_many_attempts"
+	CodePasswordPolicy     = "password_policy"
+	CodePasswordsMismatch  = "passwords_mismatch"
+	CodeInvalidPassword    = "invalid_password"
+	CodeInvalidMFACode     = "invalid_mfa_code"
+	CodeInvalidScope       = "invalid_scope"
+	CodeInvalidExpiration  = "invalid_expiration"
+	CodeInvalidClient      = "invalid_client"
+	CodeInvalidWebhook     = "invalid_webhook"
+	CodeInvalidVerifyToken = "invalid_verification_token"
+
+	CodeUserNotFound            = "user_not_found"
+	CodeGroupNotFound           = "group_not_found"
+	CodeRoleNotFound            = "role_not_found"
+	CodePermissionNotFound      = "permission_not_found"
+	CodeClientNotFound          = "client_not_found"
+	CodeAPIKeyNotFound          = "api_key_not_found"
+	CodeWebhookNotFound         = "webhook_not_found"
+	CodeWebhookDeliveryNotFound = "webhook_delivery_not_found"
+
+	CodeUserExists          = "user_exists"
+	CodeGroupExists         = "group_exists"
+	CodeRoleExists          = "role_exists"
+	CodePermissionExists    = "permission_exists"
+	CodeDeletedNameConflict = "deleted_name_conflict"
+	CodeGroupCycle          = "group_cycle"
+	CodeTOTPEnabled         = "totp_already_enabled"
+	CodeTOTPNotEnabled      = "totp_not_enabled"
+	CodeTOTPNotEnrolled     = "totp_not_enrolled"
+	CodeEmailVerified       = "email_already_verified"
+	CodeServiceAccount      = "service_account"
+	CodeNotServiceAccount   = "not_service_account"
+	CodePublicClient        = "public_client"
+	CodeWebhookDisabled     = "webhook_disabled"
+
+	CodeAuthenticationRequired = "authentication_required"
+	CodeInvalidCredentials     = "invalid_credentials"
+	CodeInvalidToken           = "invalid_token"
+	CodeInvalidAPIKey          = "invalid_api_key"
+	CodeAPIKeyExpired          = "api_key_expired"
+	CodeInvalidRefreshToken    = "invalid_refresh_token"
+	CodeRefreshTokenReused     = "refresh_token_reused"
+	CodeRefreshTokenExpired    = "refresh_token_expired"
+	CodeInvalidMFAToken        = "invalid_mfa_token"
+	CodeInvalidPasswordToken   = "invalid_password_token"
+	CodeMFACodeRequired        = "mfa_code_required"
+
+	CodePermissionDenied      = "permission_denied"
+	CodeInsufficientScope     = "insufficient_scope"
+	CodeSessionRequired       = "session_required"
+	CodeEmailNotVerified      = "email_not_verified"
+	CodePasswordExpired       = "password_expired"
+	CodeMFARequired           = "mfa_required"
+	CodeConsoleAccessDenied   = "console_access_denied"
+	CodeMFAEnrollmentRequired = "mfa_enrollment_required"
+	CodeUserDisabled          = "user_disabled"
+)
+
+// NotFoundError is returned when the resource a client asked for doesn't exist.
+type NotFoundError struct {
+	Code    string
+	Message string
+}
+
+func (err *NotFoundError) Error() string {
+	return err.Message
+}
+
+// NewNotFoundError creates a NotFoundError with a formatted message.
+func NewNotFoundError(code string, format string, args ...any) *NotFoundError {
+	return &NotFoundError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ConflictError is returned when a request conflicts with the current state of a resource, such as a name already
+// taken or a second factor already enabled.
+type ConflictError struct {
+	Code    string
+	Message string
+}
+
+func (err *ConflictError) Error() string {
+	return err.Message
+}
+
+// NewConflictError creates a ConflictError with a formatted message.
+func NewConflictError(code string, format string, args ...any) *ConflictError {
+	return &ConflictError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// FieldError is an invalid field of a request.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// ValidationError is returned when a request is invalid, with the invalid fields when they're known.
+type ValidationError struct {
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (err *ValidationError) Error() string {
+	return err.Message
+}
+
+// NewValidationError creates a ValidationError with the invalid fields of a request.
+func NewValidationError(code string, message string, fields ...FieldError) *ValidationError {
+	return &ValidationError{Code: code, Message: message, Fields: fields}
+}
+
+// NewFieldValidationError creates a ValidationError about a single field, with a formatted message.
+func NewFieldValidationError(code string, field string, format string, args ...any) *ValidationError {
+	message := fmt.Sprintf(format, args...)
+	return NewValidationError(code, message, FieldError{Field: field, Code: code, Message: message})
+}
+
+// UnauthorizedError is returned when a request isn't authenticated, or when its credentials or tokens are invalid.
+type UnauthorizedError struct {
+	Code    string
+	Message string
+}
+
+func (err *UnauthorizedError) Error() string {
+	return err.Message
+}
+
+// NewUnauthorizedError creates an UnauthorizedError with a formatted message.
+func NewUnauthorizedError(code string, format string, args ...any) *UnauthorizedError {
+	return &UnauthorizedError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ForbiddenError is returned when an authenticated user isn't allowed to do what they asked.
+type ForbiddenError struct {
+	Code    string
+	Message string
+}
+
+func (err *ForbiddenError) Error() string {
+	return err.Message
+}
+
+// NewForbiddenError creates a ForbiddenError with a formatted message.
+func NewForbiddenError(code string, format string, args ...any) *ForbiddenError {
+	return &ForbiddenError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// notFound replaces the error of a repository that didn't find a record with a NotFoundError, and returns the
+// other errors as they are.
+func notFound(err error, code string, format string, args ...any) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NewNotFoundError(code, format, args...)
+	}
+	return err
+}
@@ -1,47 +1,67 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"time"
 
 	"github.com/Nokeni/GODS/internal/web/api/models"
 	"github.com/Nokeni/GODS/internal/web/api/repositories"
 	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/common/query"
 )
 
 // GroupService defines the methods for performing business operations on Groups.
 type GroupService interface {
 	Get(id uint) (*models.Group, error)
-	GetAll() ([]*models.Group, error)
-	Create(groupDTO *dtos.CreateGroupDTO) (*models.Group, error)
-	Update(group *models.Group, groupDTO *dtos.UpdateGroupDTO) error
-	Delete(id uint) error
+	GetAll(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	Create(ctx context.Context, groupDTO *dtos.CreateGroupDTO) (*models.Group, error)
+	Update(ctx context.Context, group *models.Group, groupDTO *dtos.UpdateGroupDTO) error
+	Delete(ctx context.Context, id uint) error
+	GetAllDeleted(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	Restore(ctx context.Context, id uint) (*models.Group, error)
+	Purge(ctx context.Context, id uint) error
+	PurgeDeletedBefore(ctx context.Context, date time.Time) (int, error)
 }
 
 // GroupServiceImplementation is an implementation of the GroupService.
 type GroupServiceImplementation struct {
 	groupRepository repositories.GroupRepository
+	auditService    AuditService
+	webhookService  WebhookService
 }
 
-func NewGroupService(groupRepository repositories.GroupRepository) GroupService {
-	return &GroupServiceImplementation{groupRepository: groupRepository}
+func NewGroupService(groupRepository repositories.GroupRepository, auditService AuditService, webhookService WebhookService) GroupService {
+	return &GroupServiceImplementation{
+		groupRepository: groupRepository,
+		auditService:    auditService,
+		webhookService:  webhookService,
+	}
 }
 
 // Get retrieves a group by ID.
 func (service *GroupServiceImplementation) Get(id uint) (*models.Group, error) {
-	return service.groupRepository.Get(id)
+	group, err := service.groupRepository.Get(id)
+	if err != nil {
+		return nil, notFound(err, CodeGroupNotFound, "group %d not found", id)
+	}
+	return group, nil
 }
 
-// GetAll retrieves all groups.
-func (service *GroupServiceImplementation) GetAll() ([]*models.Group, error) {
-	return service.groupRepository.GetAll()
+// GetAll retrieves a page of groups.
+func (service *GroupServiceImplementation) GetAll(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	return service.groupRepository.GetAll(listQuery)
 }
 
 // Create adds a new group.
-func (service *GroupServiceImplementation) Create(groupDTO *dtos.CreateGroupDTO) (*models.Group, error) {
+func (service *GroupServiceImplementation) Create(ctx context.Context, groupDTO *dtos.CreateGroupDTO) (*models.Group, error) {
 	// Check if the group already exists
 	group, err := service.groupRepository.GetByName(groupDTO.Name)
 	if err == nil {
-		return group, errors.New("group already exists")
+		return group, NewConflictError(CodeGroupExists, "group already exists")
+	}
+	if err := service.checkDeletedName(groupDTO.Name); err != nil {
+		return nil, err
 	}
 
 	// Create the group model
@@ -50,15 +70,28 @@ func (service *GroupServiceImplementation) Create(groupDTO *dtos.CreateGroupDTO)
 		Description: groupDTO.Description,
 	}
 
-	err = service.groupRepository.Create(group)
+	if err := service.groupRepository.Create(group); err != nil {
+		return group, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditGroupCreate, TargetType: "group", TargetID: &group.ID}, nil, group)
+	service.webhookService.Publish(models.WebhookGroupCreated, group)
 
-	return group, err
+	return group, nil
 }
 
 // Update modifies an existing group.
-func (service *GroupServiceImplementation) Update(group *models.Group, groupDTO *dtos.UpdateGroupDTO) error {
+func (service *GroupServiceImplementation) Update(ctx context.Context, group *models.Group, groupDTO *dtos.UpdateGroupDTO) error {
+	before := *group
+
 	// Update group details depending on provided DTO fields
-	if groupDTO.Name != "" {
+	if groupDTO.Name != "" && groupDTO.Name != group.Name {
+		if _, err := service.groupRepository.GetByName(groupDTO.Name); err == nil {
+			return NewConflictError(CodeGroupExists, "group already exists")
+		}
+		if err := service.checkDeletedName(groupDTO.Name); err != nil {
+			return err
+		}
 		group.Name = groupDTO.Name
 	}
 
@@ -66,10 +99,101 @@ func (service *GroupServiceImplementation) Update(group *models.Group, groupDTO
 		group.Description = groupDTO.Description
 	}
 
-	return service.groupRepository.Update(group)
+	if err := service.groupRepository.Update(group); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditGroupUpdate, TargetType: "group", TargetID: &group.ID}, &before, group)
+	service.webhookService.Publish(models.WebhookGroupUpdated, group)
+
+	return nil
 }
 
 // Delete removes a group by ID.
-func (service *GroupServiceImplementation) Delete(id uint) error {
-	return service.groupRepository.Delete(id)
+func (service *GroupServiceImplementation) Delete(ctx context.Context, id uint) error {
+	group, err := service.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if err := service.groupRepository.Delete(id); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditGroupDelete, TargetType: "group", TargetID: &group.ID}, group, nil)
+	service.webhookService.Publish(models.WebhookGroupDeleted, group)
+
+	return nil
+}
+
+// GetAllDeleted retrieves a page of deleted groups.
+func (service *GroupServiceImplementation) GetAllDeleted(listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	return service.groupRepository.GetAllDeleted(listQuery)
+}
+
+// Restore undeletes a deleted group, along with its members, subgroups and roles.
+func (service *GroupServiceImplementation) Restore(ctx context.Context, id uint) (*models.Group, error) {
+	group, err := service.groupRepository.GetDeleted(id)
+	if err != nil {
+		return nil, notFound(err, CodeGroupNotFound, "deleted group %d not found", id)
+	}
+
+	if err := service.groupRepository.Restore(group.ID); err != nil {
+		return nil, err
+	}
+
+	restored, err := service.groupRepository.Get(group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditGroupRestore, TargetType: "group", TargetID: &restored.ID, Details: restored.Name}, nil, nil)
+	service.webhookService.Publish(models.WebhookGroupRestored, restored)
+
+	return restored, nil
+}
+
+// Purge permanently removes a deleted group. Groups have to be deleted before being purged.
+func (service *GroupServiceImplementation) Purge(ctx context.Context, id uint) error {
+	group, err := service.groupRepository.GetDeleted(id)
+	if err != nil {
+		return notFound(err, CodeGroupNotFound, "deleted group %d not found", id)
+	}
+
+	return service.purge(ctx, group, "")
+}
+
+// PurgeDeletedBefore permanently removes the groups deleted before a date, and returns how many were purged.
+func (service *GroupServiceImplementation) PurgeDeletedBefore(ctx context.Context, date time.Time) (int, error) {
+	groups, err := service.groupRepository.GetDeletedBefore(date)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, group := range groups {
+		if err := service.purge(ctx, group, "retention"); err != nil {
+			return i, err
+		}
+	}
+
+	return len(groups), nil
+}
+
+// purge permanently removes a deleted group and records it, with the reason of the purge if it wasn't requested.
+func (service *GroupServiceImplementation) purge(ctx context.Context, group *models.Group, reason string) error {
+	if err := service.groupRepository.Purge(group.ID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditGroupPurge, TargetType: "group", TargetID: &group.ID, Details: reason}, group, nil)
+
+	return nil
+}
+
+// checkDeletedName returns ErrDeletedNameConflict if a deleted group has the name.
+func (service *GroupServiceImplementation) checkDeletedName(name string) error {
+	if group, err := service.groupRepository.GetDeletedByName(name); err == nil {
+		return fmt.Errorf("%w: restore or purge the deleted group %d first", ErrDeletedNameConflict, group.ID)
+	}
+	return nil
 }
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/golang-jwt/jwt"
+	"github.com/spf13/viper"
+)
+
+// KeyStoreService defines the methods for signing the JWTs issued by GODS and verifying them.
+type KeyStoreService interface {
+	Sign(claims jwt.Claims, tokenType string) (string, error)
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	JWKS() *dtos.JSONWebKeySetDTO
+	GetAll() ([]*models.SigningKey, error)
+	Rotate(ctx context.Context) (*models.SigningKey, error)
+}
+
+// keyReloadInterval is the minimum time between two reloads of the keystore caused by unknown key IDs,
+// which show up when another instance rotated the keys.
+const keyReloadInterval = 10 * time.Second
+
+// loadedKey is a signing key along with its decoded private key.
+type loadedKey struct {
+	model      *models.SigningKey
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+}
+
+// KeyStoreServiceImplementation is an implementation of the KeyStoreService.
+// The keys are cached in memory and reloaded from the database when they're rotated.
+type KeyStoreServiceImplementation struct {
+	signingKeyRepository repositories.SigningKeyRepository
+	auditService         AuditService
+	mutex                sync.RWMutex
+	keys                 []*loadedKey // keys are the keys still verifying tokens, the newest first.
+	active               *loadedKey   // active is the key signing the tokens.
+	loadedAt             time.Time
+}
+
+// NewKeyStoreService loads the signing keys, generating the first one or rotating it right away
+// when it's due or doesn't match JWT_ALGORITHM anymore.
+func NewKeyStoreService(signingKeyRepository repositories.SigningKeyRepository, auditService AuditService) (KeyStoreService, error) {
+	service := &KeyStoreServiceImplementation{
+		signingKeyRepository: signingKeyRepository,
+		auditService:         auditService,
+	}
+
+	if _, err := service.rotate(context.Background(), false); err != nil {
+		return nil, fmt.Errorf("failed to load the signing keys: %v", err)
+	}
+
+	return service, nil
+}
+
+// Sign signs the claims with the active key, which is rotated first once JWT_KEY_ROTATION_INTERVAL has elapsed.
+func (service *KeyStoreServiceImplementation) Sign(claims jwt.Claims, tokenType string) (string, error) {
+	service.mutex.RLock()
+	due := service.rotationDue()
+	service.mutex.RUnlock()
+	if due {
+		if _, err := service.rotate(context.Background(), false); err != nil {
+			return "", err
+		}
+	}
+
+	service.mutex.RLock()
+	key := service.active
+	service.mutex.RUnlock()
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.model.KeyID
+	if tokenType != "" {
+		token.Header["typ"] = tokenType
+	}
+	return token.SignedString(key.privateKey)
+}
+
+// Keyfunc returns the public key verifying a token, chosen by its kid header, for jwt.Parse.
+// The retired keys keep verifying the tokens they signed until they expire.
+func (service *KeyStoreServiceImplementation) Keyfunc(token *jwt.Token) (interface{}, error) {
+	keyID, _ := token.Header["kid"].(string)
+	key := service.verificationKey(keyID)
+	if key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+
+	// The algorithm is tied to the key, tokens can't pick another one
+	if token.Method.Alg() != key.model.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.privateKey.Public(), nil
+}
+
+// JWKS returns the public keys verifying the tokens as a JSON Web Key Set, the active key first.
+func (service *KeyStoreServiceImplementation) JWKS() *dtos.JSONWebKeySetDTO {
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+
+	now := time.Now()
+	jwks := &dtos.JSONWebKeySetDTO{Keys: []dtos.JSONWebKeyDTO{}}
+	for _, key := range service.keys {
+		if key.model.ExpiresAt == nil || now.Before(*key.model.ExpiresAt) {
+			jwks.Keys = append(jwks.Keys, key.jwk())
+		}
+	}
+	return jwks
+}
+
+// GetAll retrieves every signing key, the newest first.
+func (service *KeyStoreServiceImplementation) GetAll() ([]*models.SigningKey, error) {
+	return service.signingKeyRepository.GetAll()
+}
+
+// Rotate generates a new active key using JWT_ALGORITHM and retires the previous one.
+func (service *KeyStoreServiceImplementation) Rotate(ctx context.Context) (*models.SigningKey, error) {
+	return service.rotate(ctx, true)
+}
+
+// rotate reloads the keys and, when forced or due, generates a new active key. Retired keys are kept
+// for JWT_KEY_RETENTION and deleted once expired.
+func (service *KeyStoreServiceImplementation) rotate(ctx context.Context, force bool) (*models.SigningKey, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	// Another instance, or a concurrent call, may have rotated the keys already
+	if err := service.load(); err != nil {
+		return nil, err
+	}
+	if !force && !service.rotationDue() {
+		return service.active.model, nil
+	}
+
+	algorithm := viper.GetString("JWT_ALGORITHM")
+	privateKey, err := generatePrivateKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := keyIDOf(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	encodedKey, encrypted, err := encodePrivateKey(keyID, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey := &models.SigningKey{KeyID: keyID, Algorithm: algorithm, PrivateKey: encodedKey, Encrypted: encrypted}
+	if err := service.signingKeyRepository.Create(signingKey); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(keyRetention())
+	for _, key := range service.keys {
+		if key.model.RetiredAt == nil {
+			key.model.RetiredAt = &now
+			key.model.ExpiresAt = &expiresAt
+			if err := service.signingKeyRepository.Update(key.model); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := service.signingKeyRepository.DeleteExpired(now); err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditSigningKeyRotate, TargetType: "signing_key", TargetID: &signingKey.ID, Details: algorithm + " " + keyID}, nil, nil)
+
+	if err := service.load(); err != nil {
+		return nil, err
+	}
+	return signingKey, nil
+}
+
+// verificationKey returns the key with the given ID if it still verifies tokens,
+// reloading the keys when it's unknown.
+func (service *KeyStoreServiceImplementation) verificationKey(keyID string) *loadedKey {
+	service.mutex.RLock()
+	key, loadedAt := service.findKey(keyID), service.loadedAt
+	service.mutex.RUnlock()
+
+	if key == nil && time.Since(loadedAt) >= keyReloadInterval {
+		service.mutex.Lock()
+		if err := service.load(); err == nil {
+			key = service.findKey(keyID)
+		}
+		service.mutex.Unlock()
+	}
+
+	if key == nil || (key.model.ExpiresAt != nil && time.Now().After(*key.model.ExpiresAt)) {
+		return nil
+	}
+	return key
+}
+
+// findKey returns the loaded key with the given ID, the caller must hold the mutex.
+func (service *KeyStoreServiceImplementation) findKey(keyID string) *loadedKey {
+	for _, key := range service.keys {
+		if key.model.KeyID == keyID {
+			return key
+		}
+	}
+	return nil
+}
+
+// load reads the keys still verifying tokens from the database, the caller must hold the mutex for writing.
+func (service *KeyStoreServiceImplementation) load() error {
+	signingKeys, err := service.signingKeyRepository.GetAll()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	keys := make([]*loadedKey, 0, len(signingKeys))
+	var active *loadedKey
+	for _, signingKey := range signingKeys {
+		if signingKey.ExpiresAt != nil && now.After(*signingKey.ExpiresAt) {
+			continue
+		}
+
+		privateKey, err := decodePrivateKey(signingKey)
+		if err != nil {
+			return fmt.Errorf("failed to decode the signing key %s: %v", signingKey.KeyID, err)
+		}
+		method, err := signingMethod(signingKey.Algorithm)
+		if err != nil {
+			return err
+		}
+
+		key := &loadedKey{model: signingKey, method: method, privateKey: privateKey}
+		keys = append(keys, key)
+		if active == nil && signingKey.RetiredAt == nil {
+			active = key
+		}
+	}
+
+	service.keys = keys
+	service.active = active
+	service.loadedAt = now
+	return nil
+}
+
+// rotationDue tells whether the active key must be replaced, the caller must hold the mutex.
+func (service *KeyStoreServiceImplementation) rotationDue() bool {
+	if service.active == nil || service.active.model.Algorithm != viper.GetString("JWT_ALGORITHM") {
+		return true
+	}
+
+	interval := viper.GetDuration("JWT_KEY_ROTATION_INTERVAL")
+	return interval > 0 && time.Since(service.active.model.CreatedAt) >= interval
+}
+
+// jwk returns the public key of a signing key as a JSON Web Key.
+func (key *loadedKey) jwk() dtos.JSONWebKeyDTO {
+	jwk := dtos.JSONWebKeyDTO{
+		Use:       "sig",
+		KeyID:     key.model.KeyID,
+		Algorithm: key.model.Algorithm,
+	}
+
+	switch publicKey := key.privateKey.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+
+	return jwk
+}
+
+// keyRetention returns how long the retired keys keep verifying tokens, which is never shorter
+// than the lifetime of the tokens they signed.
+func keyRetention() time.Duration {
+	retention := viper.GetDuration("JWT_KEY_RETENTION")
+	for _, setting := range []string{
+		"JWT_ACCESS_TOKEN_TTL", "MFA_CHALLENGE_TTL", "PASSWORD_RENEWAL_TOKEN_TTL", "CONSOLE_SESSION_TTL",
+		"OIDC_ACCESS_TOKEN_TTL", "OIDC_ID_TOKEN_TTL", "OIDC_SESSION_TTL",
+	} {
+		retention = max(retention, viper.GetDuration(setting))
+	}
+	return retention
+}
+
+// signingMethod returns the JWT signing method of an algorithm.
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case models.SigningAlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	case models.SigningAlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q, expected RS256 or EdDSA", algorithm)
+	}
+}
+
+// generatePrivateKey generates a new private key for an algorithm.
+func generatePrivateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case models.SigningAlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case models.SigningAlgorithmEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q, expected RS256 or EdDSA", algorithm)
+	}
+}
+
+// keyIDOf identifies a key with the thumbprint of its public key.
+func keyIDOf(privateKey crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return "", err
+	}
+	thumbprint := sha256.Sum256(der)
+
+	return base64.RawURLEncoding.EncodeToString(thumbprint[:])[:16], nil
+}
+
+// encodePrivateKey encodes a private key to be stored, encrypting it when JWT_KEYSTORE_SECRET is set.
+func encodePrivateKey(keyID string, privateKey crypto.Signer) (string, bool, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", false, err
+	}
+
+	aead, err := keystoreCipher()
+	if err != nil {
+		return "", false, err
+	}
+	if aead == nil {
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), false, nil
+	}
+
+	// The key ID is authenticated along with the key, so that keys can't be swapped in the database
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", false, err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, der, []byte(keyID))), true, nil
+}
+
+// decodePrivateKey decodes the private key of a stored signing key.
+func decodePrivateKey(signingKey *models.SigningKey) (crypto.Signer, error) {
+	var der []byte
+	if signingKey.Encrypted {
+		aead, err := keystoreCipher()
+		if err != nil {
+			return nil, err
+		}
+		if aead == nil {
+			return nil, errors.New("the key is encrypted but JWT_KEYSTORE_SECRET isn't set")
+		}
+
+		sealed, err := base64.StdEncoding.DecodeString(signingKey.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(sealed) < aead.NonceSize() {
+			return nil, errors.New("the encrypted key is truncated")
+		}
+		der, err = aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(signingKey.KeyID))
+		if err != nil {
+			return nil, errors.New("the key can't be decrypted with JWT_KEYSTORE_SECRET")
+		}
+	} else {
+		block, _ := pem.Decode([]byte(signingKey.PrivateKey))
+		if block == nil {
+			return nil, errors.New("no PEM data found")
+		}
+		der = block.Bytes
+	}
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := parsedKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported key type")
+	}
+	return privateKey, nil
+}
+
+// keystoreCipher returns the cipher encrypting the private keys at rest, nil when JWT_KEYSTORE_SECRET isn't set.
+func keystoreCipher() (cipher.AEAD, error) {
+	secret := viper.GetString("JWT_KEYSTORE_SECRET")
+	if secret == "" {
+		return nil, nil
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
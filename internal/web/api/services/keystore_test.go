@@ -0,0 +1,213 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/golang-jwt/jwt"
+	"github.com/spf13/viper"
+)
+
+// setKeystoreSettings configures the keystore for a test.
+func setKeystoreSettings(t *testing.T, algorithm string, secret string) {
+	t.Helper()
+
+	viper.Set("JWT_ALGORITHM", algorithm)
+	viper.Set("JWT_KEYSTORE_SECRET", secret)
+	viper.Set("JWT_KEY_ROTATION_INTERVAL", "0")
+	viper.Set("JWT_KEY_RETENTION", "1h")
+}
+
+// newKeyStore loads a keystore from the signing keys of a repository.
+func newKeyStore(t *testing.T, signingKeyRepository repositories.SigningKeyRepository, auditService services.AuditService) services.KeyStoreService {
+	t.Helper()
+
+	keyStore, err := services.NewKeyStoreService(signingKeyRepository, auditService)
+	if err != nil {
+		t.Fatalf("NewKeyStoreService() error = %v", err)
+	}
+	return keyStore
+}
+
+// signToken signs a token expiring in an hour.
+func signToken(t *testing.T, keyStore services.KeyStoreService) string {
+	t.Helper()
+
+	token, err := keyStore.Sign(&jwt.StandardClaims{Subject: "1", ExpiresAt: time.Now().Add(time.Hour).Unix()}, "")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	return token
+}
+
+// verifies checks if a keystore verifies a token.
+func verifies(keyStore services.KeyStoreService, token string) bool {
+	parsed, err := jwt.ParseWithClaims(token, &jwt.StandardClaims{}, keyStore.Keyfunc)
+	return err == nil && parsed.Valid
+}
+
+func TestKeyStoreSignAndRotate(t *testing.T) {
+	database := dbtest.Open(t)
+	signingKeyRepository := repositories.NewSigningKeyRepository(database)
+	auditService := services.NewAuditService(repositories.NewAuditEventRepository(database))
+	setKeystoreSettings(t, models.SigningAlgorithmEdDSA, "")
+
+	keyStore := newKeyStore(t, signingKeyRepository, auditService)
+	token := signToken(t, keyStore)
+	if !verifies(keyStore, token) {
+		t.Fatal("Keyfunc() doesn't verify a token of the active key")
+	}
+
+	rotated, err := keyStore.Rotate(context.Background())
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	newToken := signToken(t, keyStore)
+	if parsed, err := jwt.Parse(newToken, keyStore.Keyfunc); err != nil || parsed.Header["kid"] != rotated.KeyID {
+		t.Errorf("Sign() after Rotate() = %v, %v, want a token of the key %s", parsed, err, rotated.KeyID)
+	}
+	// The retired key keeps verifying the tokens it signed, and is still published
+	if !verifies(keyStore, token) || !verifies(keyStore, newToken) {
+		t.Error("Keyfunc() doesn't verify the tokens of the active and retired keys")
+	}
+	if jwks := keyStore.JWKS(); len(jwks.Keys) != 2 || jwks.Keys[0].KeyID != rotated.KeyID {
+		t.Errorf("JWKS() = %v, want the active key then the retired one", jwks.Keys)
+	}
+
+	// Another instance sharing the database knows the keys
+	other := newKeyStore(t, signingKeyRepository, auditService)
+	if !verifies(other, token) || !verifies(other, newToken) {
+		t.Error("another keystore doesn't verify the tokens of the shared keys")
+	}
+
+	// Once expired, the retired key doesn't verify tokens nor is published anymore
+	signingKeys, err := signingKeyRepository.GetAll()
+	if err != nil || len(signingKeys) != 2 || signingKeys[1].RetiredAt == nil {
+		t.Fatalf("GetAll() = %v, %v, want the active and retired keys", signingKeys, err)
+	}
+	expired := time.Now().Add(-time.Minute)
+	signingKeys[1].ExpiresAt = &expired
+	if err := signingKeyRepository.Update(signingKeys[1]); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	other = newKeyStore(t, signingKeyRepository, auditService)
+	if verifies(other, token) {
+		t.Error("Keyfunc() verifies a token of an expired key")
+	}
+	if jwks := other.JWKS(); len(jwks.Keys) != 1 {
+		t.Errorf("JWKS() = %v, want the active key only", jwks.Keys)
+	}
+}
+
+func TestKeyStoreRejectsForgedTokens(t *testing.T) {
+	database := dbtest.Open(t)
+	setKeystoreSettings(t, models.SigningAlgorithmEdDSA, "")
+	keyStore := newKeyStore(t, repositories.NewSigningKeyRepository(database), services.NewAuditService(repositories.NewAuditEventRepository(database)))
+
+	parsed, err := jwt.Parse(signToken(t, keyStore), keyStore.Keyfunc)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	keyID := parsed.Header["kid"].(string)
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    any
+		keyID  string
+	}{
+		{name: "another algorithm", method: jwt.SigningMethodHS256, key: []byte("secret"), keyID: keyID},
+		{name: "unknown key", method: jwt.SigningMethodHS256, key: []byte("secret"), keyID: "unknown"},
+		{name: "no key ID", method: jwt.SigningMethodHS256, key: []byte("secret")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(test.method, &jwt.StandardClaims{Subject: "1"})
+			if test.keyID != "" {
+				token.Header["kid"] = test.keyID
+			}
+			forged, err := token.SignedString(test.key)
+			if err != nil {
+				t.Fatalf("SignedString() error = %v", err)
+			}
+			if verifies(keyStore, forged) {
+				t.Error("Keyfunc() verifies a forged token")
+			}
+		})
+	}
+}
+
+func TestKeyStoreAlgorithmChange(t *testing.T) {
+	database := dbtest.Open(t)
+	signingKeyRepository := repositories.NewSigningKeyRepository(database)
+	auditService := services.NewAuditService(repositories.NewAuditEventRepository(database))
+	setKeystoreSettings(t, models.SigningAlgorithmEdDSA, "")
+
+	token := signToken(t, newKeyStore(t, signingKeyRepository, auditService))
+
+	// A key of another algorithm than JWT_ALGORITHM is rotated when loaded
+	viper.Set("JWT_ALGORITHM", models.SigningAlgorithmRS256)
+	keyStore := newKeyStore(t, signingKeyRepository, auditService)
+	signingKeys, err := keyStore.GetAll()
+	if err != nil || len(signingKeys) != 2 || signingKeys[0].Algorithm != models.SigningAlgorithmRS256 {
+		t.Fatalf("GetAll() = %v, %v, want a new RS256 key", signingKeys, err)
+	}
+	if signingKeys[1].RetiredAt == nil || signingKeys[1].ExpiresAt == nil {
+		t.Errorf("the EdDSA key isn't retired: %v", signingKeys[1])
+	}
+	if parsed, _ := jwt.Parse(signToken(t, keyStore), keyStore.Keyfunc); parsed == nil || parsed.Method.Alg() != models.SigningAlgorithmRS256 {
+		t.Error("Sign() doesn't sign with the RS256 key")
+	}
+	if !verifies(keyStore, token) {
+		t.Error("Keyfunc() doesn't verify a token of the retired EdDSA key")
+	}
+}
+
+func TestKeyStoreEncryption(t *testing.T) {
+	database := dbtest.Open(t)
+	signingKeyRepository := repositories.NewSigningKeyRepository(database)
+	auditService := services.NewAuditService(repositories.NewAuditEventRepository(database))
+	setKeystoreSettings(t, models.SigningAlgorithmEdDSA, "first secret")
+
+	keyStore := newKeyStore(t, signingKeyRepository, auditService)
+	token := signToken(t, keyStore)
+	if _, err := keyStore.Rotate(context.Background()); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	signingKeys, err := signingKeyRepository.GetAll()
+	if err != nil || len(signingKeys) != 2 {
+		t.Fatalf("GetAll() = %v, %v, want 2 keys", signingKeys, err)
+	}
+	for _, signingKey := range signingKeys {
+		if !signingKey.Encrypted || strings.Contains(signingKey.PrivateKey, "PRIVATE KEY") {
+			t.Errorf("the key %s is stored in clear", signingKey.KeyID)
+		}
+	}
+	if !verifies(newKeyStore(t, signingKeyRepository, auditService), token) {
+		t.Error("a keystore with the same secret doesn't verify the tokens")
+	}
+
+	for _, secret := range []string{"second secret", ""} {
+		viper.Set("JWT_KEYSTORE_SECRET", secret)
+		if _, err := services.NewKeyStoreService(signingKeyRepository, auditService); err == nil {
+			t.Errorf("NewKeyStoreService() with the secret %q succeeded", secret)
+		}
+	}
+
+	// The key ID is authenticated along with the key, so that a key can't be passed off as another one
+	viper.Set("JWT_KEYSTORE_SECRET", "first secret")
+	signingKeys[0].PrivateKey = signingKeys[1].PrivateKey
+	if err := signingKeyRepository.Update(signingKeys[0]); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := services.NewKeyStoreService(signingKeyRepository, auditService); err == nil {
+		t.Error("NewKeyStoreService() with a swapped key succeeded")
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/spf13/viper"
+)
+
+// LockoutService defines the methods for protecting the authentication against brute-force attacks.
+type LockoutService interface {
+	Check(keys ...LockoutKey) error
+	RegisterFailure(ctx context.Context, keys ...LockoutKey) error
+	RegisterSuccess(keys ...LockoutKey) error
+	GetLocked() ([]*models.LoginAttempt, error)
+	Unlock(ctx context.Context, key LockoutKey) error
+}
+
+// LockoutKey identifies what failed attempts are counted against, such as an account or an IP.
+type LockoutKey struct {
+	Scope string
+	Value string
+}
+
+// AccountLockoutKey returns the key counting the failed logins of an account.
+func AccountLockoutKey(name string) LockoutKey {
+	return LockoutKey{Scope: models.LockoutScopeAccount, Value: name}
+}
+
+// IPLockoutKey returns the key counting the failed logins coming from an IP.
+func IPLockoutKey(ip string) LockoutKey {
+	return LockoutKey{Scope: models.LockoutScopeIP, Value: ip}
+}
+
+// SignupLockoutKey returns the key counting the signups coming from an IP.
+func SignupLockoutKey(ip string) LockoutKey {
+	return LockoutKey{Scope: models.LockoutScopeSignup, Value: ip}
+}
+
+// EmailLockoutKey returns the key counting the emails requested from an IP.
+func EmailLockoutKey(ip string) LockoutKey {
+	return LockoutKey{Scope: models.LockoutScopeEmail, Value: ip}
+}
+
+// LockedError is returned when attempts are refused because too many of them failed.
+type LockedError struct {
+	Until time.Time // Until is the date from which attempts are accepted again.
+}
+
+func (err *LockedError) Error() string {
+	return fmt.Sprintf("too many attempts, try again after %s", err.Until.UTC().Format(time.RFC3339))
+}
+
+// lockoutThresholds are the settings holding the number of failures triggering a lockout of each scope.
+var lockoutThresholds = map[string]string{
+	models.LockoutScopeAccount: "LOCKOUT_ACCOUNT_THRESHOLD",
+	models.LockoutScopeIP:      "LOCKOUT_IP_THRESHOLD",
+	models.LockoutScopeSignup:  "LOCKOUT_SIGNUP_THRESHOLD",
+	models.LockoutScopeEmail:   "LOCKOUT_EMAIL_THRESHOLD",
+}
+
+// LockoutServiceImplementation is an implementation of the LockoutService.
+type LockoutServiceImplementation struct {
+	loginAttemptRepository repositories.LoginAttemptRepository
+	auditService           AuditService
+}
+
+func NewLockoutService(loginAttemptRepository repositories.LoginAttemptRepository, auditService AuditService) LockoutService {
+	return &LockoutServiceImplementation{
+		loginAttemptRepository: loginAttemptRepository,
+		auditService:           auditService,
+	}
+}
+
+// Check returns a LockedError if any of the keys is locked.
+func (service *LockoutServiceImplementation) Check(keys ...LockoutKey) error {
+	now := time.Now()
+	var lockedUntil time.Time
+	for _, key := range keys {
+		if key.Value == "" {
+			continue
+		}
+
+		loginAttempt, err := service.loginAttemptRepository.Get(key.Scope, key.Value)
+		if err != nil {
+			return err
+		}
+		if loginAttempt.LockedUntil.After(now) && loginAttempt.LockedUntil.After(lockedUntil) {
+			lockedUntil = loginAttempt.LockedUntil
+		}
+	}
+
+	if !lockedUntil.IsZero() {
+		return &LockedError{Until: lockedUntil}
+	}
+	return nil
+}
+
+// RegisterFailure counts a failed attempt against each key, locking the keys that reached their threshold.
+// Each failure past the threshold doubles the lockout duration, up to the configured maximum.
+func (service *LockoutServiceImplementation) RegisterFailure(ctx context.Context, keys ...LockoutKey) error {
+	now := time.Now()
+	resetAfter := viper.GetDuration("LOCKOUT_RESET_AFTER")
+
+	// Forget the counters nobody has failed against for a while
+	if err := service.loginAttemptRepository.DeleteOlderThan(now.Add(-resetAfter)); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key.Value == "" {
+			continue
+		}
+
+		// The counter is incremented atomically so that concurrent failures are all counted
+		failures, err := service.loginAttemptRepository.Increment(key.Scope, key.Value, now)
+		if err != nil {
+			return err
+		}
+
+		threshold := viper.GetInt(lockoutThresholds[key.Scope])
+		if threshold > 0 && failures >= threshold {
+			lockedUntil := now.Add(lockoutDuration(failures - threshold))
+			if err := service.loginAttemptRepository.Lock(key.Scope, key.Value, lockedUntil); err != nil {
+				return err
+			}
+			service.auditService.Record(ctx, &models.AuditEvent{
+				Action:     models.AuditAuthLockout,
+				TargetType: key.Scope,
+				Details:    fmt.Sprintf("%s locked until %s after %d failures", key.Value, lockedUntil.UTC().Format(time.RFC3339), failures),
+			}, nil, nil)
+		}
+	}
+
+	return nil
+}
+
+// RegisterSuccess resets the counters of the keys.
+func (service *LockoutServiceImplementation) RegisterSuccess(keys ...LockoutKey) error {
+	for _, key := range keys {
+		if err := service.loginAttemptRepository.Delete(key.Scope, key.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLocked retrieves the currently locked counters.
+func (service *LockoutServiceImplementation) GetLocked() ([]*models.LoginAttempt, error) {
+	return service.loginAttemptRepository.GetLocked(time.Now())
+}
+
+// Unlock lifts the lockout of a key and resets its counter.
+func (service *LockoutServiceImplementation) Unlock(ctx context.Context, key LockoutKey) error {
+	if _, ok := lockoutThresholds[key.Scope]; !ok {
+		return NewFieldValidationError(CodeInvalidRequest, "scope", "unknown lockout scope: %s", key.Scope)
+	}
+
+	if err := service.loginAttemptRepository.Delete(key.Scope, key.Value); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthUnlock, TargetType: key.Scope, Details: key.Value}, nil, nil)
+
+	return nil
+}
+
+// lockoutDuration returns the lockout duration after the given number of failures past the threshold.
+func lockoutDuration(extraFailures int) time.Duration {
+	duration := viper.GetDuration("LOCKOUT_BASE_DURATION")
+	maxDuration := viper.GetDuration("LOCKOUT_MAX_DURATION")
+	for i := 0; i < extraFailures && duration < maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+	return duration
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/spf13/viper"
+)
+
+// recoveryCodesCount is the number of recovery codes issued to a user.
+const recoveryCodesCount = 10
+
+// MFAService defines the methods for performing business operations on the users' second factor.
+type MFAService interface {
+	EnrollTOTP(ctx context.Context, userID uint) (*dtos.TOTPEnrollmentDTO, error)
+	ActivateTOTP(ctx context.Context, userID uint, code string) (*dtos.RecoveryCodesDTO, error)
+	DisableTOTP(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) (*dtos.RecoveryCodesDTO, error)
+	Reset(ctx context.Context, userID uint) error
+	Verify(ctx context.Context, user *models.User, code string) error
+	IsRequired(user *models.User) bool
+}
+
+// ErrInvalidMFACode is returned when a TOTP code or a recovery code is invalid.
+var ErrInvalidMFACode error = NewFieldValidationError(CodeInvalidMFACode, "code", "invalid code")
+
+// MFAServiceImplementation is an implementation of the MFAService.
+type MFAServiceImplementation struct {
+	userRepository         repositories.UserRepository
+	recoveryCodeRepository repositories.RecoveryCodeRepository
+	userGroupRepository    repositories.UserGroupRepository
+	auditService           AuditService
+}
+
+func NewMFAService(
+	userRepository repositories.UserRepository,
+	recoveryCodeRepository repositories.RecoveryCodeRepository,
+	userGroupRepository repositories.UserGroupRepository,
+	auditService AuditService,
+) MFAService {
+	return &MFAServiceImplementation{
+		userRepository:         userRepository,
+		recoveryCodeRepository: recoveryCodeRepository,
+		userGroupRepository:    userGroupRepository,
+		auditService:           auditService,
+	}
+}
+
+// EnrollTOTP generates a new TOTP secret for a user. It only takes effect once activated with a valid code.
+func (service *MFAServiceImplementation) EnrollTOTP(ctx context.Context, userID uint) (*dtos.TOTPEnrollmentDTO, error) {
+	user, err := service.userRepository.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, NewConflictError(CodeTOTPEnabled, "TOTP is already enabled")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = secret
+	user.TOTPLastCounter = 0
+	if err := service.userRepository.Update(user); err != nil {
+		return nil, err
+	}
+
+	return &dtos.TOTPEnrollmentDTO{
+		Secret: secret,
+		URI:    totpURI(viper.GetString("MFA_ISSUER"), user.Name, secret),
+	}, nil
+}
+
+// ActivateTOTP enables the TOTP of a user once they've proven their authenticator app generates valid codes,
+// and issues their recovery codes.
+func (service *MFAServiceImplementation) ActivateTOTP(ctx context.Context, userID uint, code string) (*dtos.RecoveryCodesDTO, error) {
+	user, err := service.userRepository.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, NewConflictError(CodeTOTPEnabled, "TOTP is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, NewConflictError(CodeTOTPNotEnrolled, "TOTP enrollment hasn't been started")
+	}
+
+	counter, ok := validateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastCounter)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	user.TOTPEnabled = true
+	user.TOTPLastCounter = counter
+	if err := service.userRepository.Update(user); err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserMFAEnable, TargetType: "user", TargetID: &user.ID}, nil, nil)
+
+	return service.issueRecoveryCodes(user)
+}
+
+// DisableTOTP disables the TOTP of a user, unless their groups require it.
+func (service *MFAServiceImplementation) DisableTOTP(ctx context.Context, userID uint, code string) error {
+	user, err := service.userRepository.Get(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return NewConflictError(CodeTOTPNotEnabled, "TOTP isn't enabled")
+	}
+	if service.IsRequired(user) {
+		return NewForbiddenError(CodeMFARequired, "two-factor authentication is required for the members of your groups")
+	}
+	if err := service.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := service.clear(user); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserMFADisable, TargetType: "user", TargetID: &user.ID}, nil, nil)
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user.
+func (service *MFAServiceImplementation) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) (*dtos.RecoveryCodesDTO, error) {
+	user, err := service.userRepository.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, NewConflictError(CodeTOTPNotEnabled, "TOTP isn't enabled")
+	}
+	if err := service.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserRecoveryCodes, TargetType: "user", TargetID: &user.ID}, nil, nil)
+
+	return service.issueRecoveryCodes(user)
+}
+
+// Reset removes the TOTP and the recovery codes of a user who lost them.
+// Users whose groups require a second factor will have to enroll again at their next login.
+func (service *MFAServiceImplementation) Reset(ctx context.Context, userID uint) error {
+	user, err := service.userRepository.Get(userID)
+	if err != nil {
+		return notFound(err, CodeUserNotFound, "user %d not found", userID)
+	}
+
+	if err := service.clear(user); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserMFAReset, TargetType: "user", TargetID: &user.ID}, nil, nil)
+
+	return nil
+}
+
+// Verify checks a TOTP code or, failing that, consumes a recovery code of the user.
+func (service *MFAServiceImplementation) Verify(ctx context.Context, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	// The counter is only advanced if no other login has, so that a code can't be replayed concurrently
+	if counter, ok := validateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastCounter); ok {
+		advanced, err := service.userRepository.AdvanceTOTPCounter(user.ID, counter)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidMFACode
+		}
+		user.TOTPLastCounter = counter
+		return nil
+	}
+
+	recoveryCode, err := service.recoveryCodeRepository.GetUnused(user.ID, hashToken(strings.ToLower(code)))
+	if err != nil {
+		return ErrInvalidMFACode
+	}
+	used, err := service.recoveryCodeRepository.MarkUsed(recoveryCode.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthRecoveryCodeUse, TargetType: "user", TargetID: &user.ID}, nil, nil)
+
+	return nil
+}
+
+// IsRequired tells if the user belongs to a group whose members must use a second factor, directly or through
+// nested groups.
+func (service *MFAServiceImplementation) IsRequired(user *models.User) bool {
+	requiredGroups := viper.GetStringSlice("MFA_REQUIRED_GROUPS")
+	if len(requiredGroups) == 0 {
+		return false
+	}
+
+	groups, _, err := service.userGroupRepository.GetEffectiveUserGroups(user.ID, nil)
+	if err != nil {
+		// Better ask for a second factor than let the user in without one
+		return true
+	}
+	for _, group := range groups {
+		if slices.Contains(requiredGroups, group.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// clear removes the TOTP settings and the recovery codes of a user.
+func (service *MFAServiceImplementation) clear(user *models.User) error {
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	user.TOTPLastCounter = 0
+	if err := service.userRepository.Update(user); err != nil {
+		return err
+	}
+
+	return service.recoveryCodeRepository.DeleteUserCodes(user.ID)
+}
+
+// issueRecoveryCodes generates new recovery codes for a user, replacing the previous ones.
+// Only their hashes are persisted, the codes are returned to be shown once.
+func (service *MFAServiceImplementation) issueRecoveryCodes(user *models.User) (*dtos.RecoveryCodesDTO, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	recoveryCodes := make([]*models.RecoveryCode, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		recoveryCodes = append(recoveryCodes, &models.RecoveryCode{UserID: user.ID, CodeHash: hashToken(code)})
+	}
+
+	if err := service.recoveryCodeRepository.Replace(user.ID, recoveryCodes); err != nil {
+		return nil, err
+	}
+
+	return &dtos.RecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+// generateRecoveryCode generates a random recovery code such as "abcd-efgh-ijkl".
+func generateRecoveryCode() (string, error) {
+	buffer := make([]byte, 8)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buffer))[:12]
+	return encoded[:4] + "-" + encoded[4:8] + "-" + encoded[8:], nil
+}
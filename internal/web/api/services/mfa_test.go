@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+)
+
+func TestMFAVerifyConsumesCodesOnce(t *testing.T) {
+	database := dbtest.Open(t)
+	userRepository := repositories.NewUserRepository(database)
+	recoveryCodeRepository := repositories.NewRecoveryCodeRepository(database)
+	auditService := NewAuditService(repositories.NewAuditEventRepository(database))
+	service := NewMFAService(userRepository, recoveryCodeRepository, repositories.NewUserGroupRepository(database), auditService)
+
+	user := &models.User{Name: "alice", Email: "alice@example.com", Password: "hash", TOTPSecret: rfcSecret, TOTPEnabled: true}
+	if err := userRepository.Create(user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := recoveryCodeRepository.Replace(user.ID, []*models.RecoveryCode{{UserID: user.ID, CodeHash: hashToken("abcd-efgh")}}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+
+	// Two concurrent logins load the user before either of them accepts the same code
+	first, err := userRepository.Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	second, err := userRepository.Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	code := mustTOTPCode(t, time.Now().Unix()/totpPeriod)
+	if err := service.Verify(context.Background(), first, code); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if err := service.Verify(context.Background(), second, code); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("Verify() of a replayed code error = %v, want ErrInvalidMFACode", err)
+	}
+
+	if err := service.Verify(context.Background(), first, "ABCD-EFGH"); err != nil {
+		t.Fatalf("Verify() of a recovery code error = %v", err)
+	}
+	if err := service.Verify(context.Background(), second, "abcd-efgh"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("Verify() of a used recovery code error = %v, want ErrInvalidMFACode", err)
+	}
+}
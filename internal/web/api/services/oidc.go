@@ -0,0 +1,491 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/golang-jwt/jwt"
+	"github.com/spf13/viper"
+)
+
+// OAuth2 error codes (RFC 6749).
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthInvalidToken            = "invalid_token"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthLoginRequired           = "login_required"
+)
+
+// ssoSessionAudience is the audience of the session tokens of the authorization endpoint.
+const ssoSessionAudience = "sso"
+
+// OAuthError is an error reported to the OAuth2 clients with one of the OAuth2 error codes.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (err *OAuthError) Error() string {
+	return err.Code + ": " + err.Description
+}
+
+// OIDCService defines the methods for acting as an OpenID Connect provider.
+type OIDCService interface {
+	Discovery() *dtos.DiscoveryDTO
+	CheckAuthorizeRequest(authorizeDTO *dtos.AuthorizeDTO) (*models.Client, error)
+	Authorize(ctx context.Context, authorizeDTO *dtos.AuthorizeDTO, user *models.User, authTime time.Time) (string, error)
+	AuthorizeErrorURL(authorizeDTO *dtos.AuthorizeDTO, oauthError *OAuthError) string
+	CreateSession(user *models.User) (string, error)
+	GetSession(sessionToken string) (*models.User, time.Time, error)
+	Token(ctx context.Context, tokenRequestDTO *dtos.TokenRequestDTO) (*dtos.OIDCTokenDTO, error)
+	UserInfo(accessToken string) (map[string]any, error)
+}
+
+// SSOSessionClaims represents the claims of the session tokens, which spare the users a login at each authorization.
+type SSOSessionClaims struct {
+	UserID       uint
+	TokenVersion uint
+	jwt.StandardClaims
+}
+
+// OIDCServiceImplementation is an implementation of the OIDCService.
+type OIDCServiceImplementation struct {
+	userRepository              repositories.UserRepository
+	userGroupRepository         repositories.UserGroupRepository
+	authorizationCodeRepository repositories.AuthorizationCodeRepository
+	clientService               ClientService
+	keyStoreService             KeyStoreService
+	auditService                AuditService
+}
+
+func NewOIDCService(
+	userRepository repositories.UserRepository,
+	userGroupRepository repositories.UserGroupRepository,
+	authorizationCodeRepository repositories.AuthorizationCodeRepository,
+	clientService ClientService,
+	keyStoreService KeyStoreService,
+	auditService AuditService,
+) OIDCService {
+	return &OIDCServiceImplementation{
+		userRepository:              userRepository,
+		userGroupRepository:         userGroupRepository,
+		authorizationCodeRepository: authorizationCodeRepository,
+		clientService:               clientService,
+		keyStoreService:             keyStoreService,
+		auditService:                auditService,
+	}
+}
+
+// Discovery returns the OpenID Connect discovery document.
+func (service *OIDCServiceImplementation) Discovery() *dtos.DiscoveryDTO {
+	issuer := oidcIssuer()
+	return &dtos.DiscoveryDTO{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth2/authorize",
+		TokenEndpoint:                     issuer + "/oauth2/token",
+		UserinfoEndpoint:                  issuer + "/oauth2/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   defaultClientScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{models.GrantTypeAuthorizationCode, models.GrantTypeClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{viper.GetString("JWT_ALGORITHM")},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256", "plain"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "name", "preferred_username", "email", "email_verified", "groups"},
+	}
+}
+
+// CheckAuthorizeRequest checks an authorization request and returns its client, completing the redirect URI
+// when the client only has one. Errors about the client or the redirect URI can't be sent back to the client and
+// must be shown to the user, the other ones are OAuthErrors to send back through AuthorizeErrorURL.
+func (service *OIDCServiceImplementation) CheckAuthorizeRequest(authorizeDTO *dtos.AuthorizeDTO) (*models.Client, error) {
+	// Clients aren't authenticated by the authorization endpoint, only by the token endpoint
+	client, err := service.clientService.GetByClientID(authorizeDTO.ClientID)
+	if err != nil {
+		return nil, errors.New("unknown client")
+	}
+
+	if authorizeDTO.RedirectURI == "" && len(client.RedirectURIs) == 1 {
+		authorizeDTO.RedirectURI = client.RedirectURIs[0]
+	}
+	if !slices.Contains(client.RedirectURIs, authorizeDTO.RedirectURI) {
+		return nil, errors.New("the redirect URI isn't registered for this client")
+	}
+
+	if !slices.Contains(client.GrantTypes, models.GrantTypeAuthorizationCode) {
+		return nil, &OAuthError{Code: OAuthUnauthorizedClient, Description: "the client can't use the authorization code grant"}
+	}
+	if authorizeDTO.ResponseType != "code" {
+		return nil, &OAuthError{Code: OAuthUnsupportedResponseType, Description: "only the code response type is supported"}
+	}
+
+	scopes := strings.Fields(authorizeDTO.Scope)
+	if !slices.Contains(scopes, "openid") {
+		return nil, &OAuthError{Code: OAuthInvalidScope, Description: "the openid scope is required"}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) {
+			return nil, &OAuthError{Code: OAuthInvalidScope, Description: "the client can't request the scope " + scope}
+		}
+	}
+
+	if authorizeDTO.CodeChallenge != "" && authorizeDTO.CodeChallengeMethod == "" {
+		authorizeDTO.CodeChallengeMethod = "plain"
+	}
+	if authorizeDTO.CodeChallengeMethod != "" && authorizeDTO.CodeChallengeMethod != "S256" && authorizeDTO.CodeChallengeMethod != "plain" {
+		return nil, &OAuthError{Code: OAuthInvalidRequest, Description: "unsupported code challenge method"}
+	}
+	if client.Public && authorizeDTO.CodeChallengeMethod != "S256" {
+		return nil, &OAuthError{Code: OAuthInvalidRequest, Description: "public clients must use PKCE with the S256 method"}
+	}
+
+	return client, nil
+}
+
+// Authorize issues an authorization code to the client for the user, and returns the URI to redirect the user to.
+func (service *OIDCServiceImplementation) Authorize(ctx context.Context, authorizeDTO *dtos.AuthorizeDTO, user *models.User, authTime time.Time) (string, error) {
+	client, err := service.CheckAuthorizeRequest(authorizeDTO)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	// Only the hash of the code is persisted
+	if err := service.authorizationCodeRepository.Create(&models.AuthorizationCode{
+		CodeHash:            hashToken(code),
+		ClientID:            client.ClientID,
+		UserID:              user.ID,
+		TokenVersion:        user.TokenVersion,
+		RedirectURI:         authorizeDTO.RedirectURI,
+		Scope:               authorizeDTO.Scope,
+		Nonce:               authorizeDTO.Nonce,
+		CodeChallenge:       authorizeDTO.CodeChallenge,
+		CodeChallengeMethod: authorizeDTO.CodeChallengeMethod,
+		AuthTime:            authTime,
+		ExpiresAt:           time.Now().Add(viper.GetDuration("OIDC_CODE_TTL")),
+	}); err != nil {
+		return "", err
+	}
+
+	// The user is authenticated by now, even if the request itself is anonymous
+	actor := ActorFromContext(ctx)
+	actor.UserID = &user.ID
+	service.auditService.Record(WithActor(ctx, actor), &models.AuditEvent{Action: models.AuditOIDCAuthorize, TargetType: "client", TargetID: &client.ID, Details: authorizeDTO.Scope}, nil, nil)
+
+	return redirectURL(authorizeDTO.RedirectURI, url.Values{"code": {code}}, authorizeDTO.State), nil
+}
+
+// AuthorizeErrorURL returns the URI sending an error back to the client of an authorization request.
+func (service *OIDCServiceImplementation) AuthorizeErrorURL(authorizeDTO *dtos.AuthorizeDTO, oauthError *OAuthError) string {
+	return redirectURL(authorizeDTO.RedirectURI, url.Values{
+		"error":             {oauthError.Code},
+		"error_description": {oauthError.Description},
+	}, authorizeDTO.State)
+}
+
+// CreateSession generates the session token of a user who logged in through the authorization endpoint.
+func (service *OIDCServiceImplementation) CreateSession(user *models.User) (string, error) {
+	tokenID, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &SSOSessionClaims{
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID,
+			Audience:  ssoSessionAudience,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(viper.GetDuration("OIDC_SESSION_TTL")).Unix(),
+		},
+	}
+
+	return service.keyStoreService.Sign(claims, "")
+}
+
+// GetSession parses a session token and returns its user and the date they logged in.
+// Sessions are invalidated along with the other tokens of the user.
+func (service *OIDCServiceImplementation) GetSession(sessionToken string) (*models.User, time.Time, error) {
+	claims := &SSOSessionClaims{}
+	token, err := jwt.ParseWithClaims(sessionToken, claims, service.keyStoreService.Keyfunc)
+	if err != nil || !token.Valid || !claims.VerifyAudience(ssoSessionAudience, true) {
+		return nil, time.Time{}, errors.New("invalid session")
+	}
+
+	user, err := service.userRepository.Get(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion {
+		return nil, time.Time{}, errors.New("invalid session")
+	}
+
+	return user, time.Unix(claims.IssuedAt, 0), nil
+}
+
+// Token runs the token endpoint, exchanging an authorization code or the client credentials for tokens.
+func (service *OIDCServiceImplementation) Token(ctx context.Context, tokenRequestDTO *dtos.TokenRequestDTO) (*dtos.OIDCTokenDTO, error) {
+	client, err := service.clientService.Authenticate(tokenRequestDTO.ClientID, tokenRequestDTO.ClientSecret)
+	if err != nil {
+		return nil, &OAuthError{Code: OAuthInvalidClient, Description: err.Error()}
+	}
+	if !slices.Contains(client.GrantTypes, tokenRequestDTO.GrantType) {
+		if tokenRequestDTO.GrantType != models.GrantTypeAuthorizationCode && tokenRequestDTO.GrantType != models.GrantTypeClientCredentials {
+			return nil, &OAuthError{Code: OAuthUnsupportedGrantType, Description: "unsupported grant type"}
+		}
+		return nil, &OAuthError{Code: OAuthUnauthorizedClient, Description: "the client can't use this grant type"}
+	}
+
+	if tokenRequestDTO.GrantType == models.GrantTypeClientCredentials {
+		return service.clientCredentialsToken(ctx, client, tokenRequestDTO)
+	}
+	return service.authorizationCodeToken(client, tokenRequestDTO)
+}
+
+// UserInfo returns the claims of the user an access token was issued to, as allowed by its scopes.
+func (service *OIDCServiceImplementation) UserInfo(accessToken string) (map[string]any, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(accessToken, claims, service.keyStoreService.Keyfunc)
+	if err != nil || !token.Valid || token.Header["typ"] != accessTokenType || !claims.VerifyIssuer(oidcIssuer(), true) {
+		return nil, &OAuthError{Code: OAuthInvalidToken, Description: "invalid access token"}
+	}
+
+	// Tokens issued through the client credentials grant have no user
+	subject, _ := claims["sub"].(string)
+	userID, err := strconv.ParseUint(subject, 10, 32)
+	if err != nil {
+		return nil, &OAuthError{Code: OAuthInvalidToken, Description: "the access token wasn't issued to a user"}
+	}
+	version, _ := claims["ver"].(float64)
+	user, err := service.userRepository.Get(uint(userID))
+	if err != nil || user.TokenVersion != uint(version) {
+		return nil, &OAuthError{Code: OAuthInvalidToken, Description: "the access token has been revoked"}
+	}
+
+	scope, _ := claims["scope"].(string)
+	userInfo, err := service.userClaims(user, strings.Fields(scope))
+	if err != nil {
+		return nil, err
+	}
+	userInfo["sub"] = subject
+	return userInfo, nil
+}
+
+// authorizationCodeToken exchanges an authorization code for an access token and an ID token.
+func (service *OIDCServiceImplementation) authorizationCodeToken(client *models.Client, tokenRequestDTO *dtos.TokenRequestDTO) (*dtos.OIDCTokenDTO, error) {
+	// The redirect URI may be left out like in the authorization request when the client only has one
+	if tokenRequestDTO.RedirectURI == "" && len(client.RedirectURIs) == 1 {
+		tokenRequestDTO.RedirectURI = client.RedirectURIs[0]
+	}
+
+	authorizationCode, err := service.authorizationCodeRepository.GetByHash(hashToken(tokenRequestDTO.Code))
+	if err != nil || authorizationCode.UsedAt != nil || time.Now().After(authorizationCode.ExpiresAt) ||
+		authorizationCode.ClientID != client.ClientID || authorizationCode.RedirectURI != tokenRequestDTO.RedirectURI {
+		return nil, &OAuthError{Code: OAuthInvalidGrant, Description: "invalid authorization code"}
+	}
+	if !verifyCodeChallenge(authorizationCode, tokenRequestDTO.CodeVerifier) {
+		return nil, &OAuthError{Code: OAuthInvalidGrant, Description: "invalid code verifier"}
+	}
+
+	// Mark the code as used so it can't be exchanged again, a concurrent exchange having used it first
+	now := time.Now()
+	used, err := service.authorizationCodeRepository.MarkUsed(authorizationCode.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, &OAuthError{Code: OAuthInvalidGrant, Description: "invalid authorization code"}
+	}
+
+	user, err := service.userRepository.Get(authorizationCode.UserID)
+	if err != nil || user.Disabled {
+		return nil, &OAuthError{Code: OAuthInvalidGrant, Description: "the user no longer exists or is disabled"}
+	}
+	// The code is revoked with the sessions of the user, such as when they log out everywhere or are deleted
+	if user.TokenVersion != authorizationCode.TokenVersion {
+		return nil, &OAuthError{Code: OAuthInvalidGrant, Description: "invalid authorization code"}
+	}
+
+	subject := strconv.FormatUint(uint64(user.ID), 10)
+	accessTokenTTL := viper.GetDuration("OIDC_ACCESS_TOKEN_TTL")
+	accessToken, err := service.sign(jwt.MapClaims{
+		"sub":       subject,
+		"aud":       client.ClientID,
+		"client_id": client.ClientID,
+		"scope":     authorizationCode.Scope,
+		"ver":       user.TokenVersion,
+		"exp":       now.Add(accessTokenTTL).Unix(),
+	}, accessTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := strings.Fields(authorizationCode.Scope)
+	claims, err := service.userClaims(user, scopes)
+	if err != nil {
+		return nil, err
+	}
+	idTokenClaims := jwt.MapClaims(claims)
+	idTokenClaims["sub"] = subject
+	idTokenClaims["aud"] = client.ClientID
+	idTokenClaims["azp"] = client.ClientID
+	idTokenClaims["auth_time"] = authorizationCode.AuthTime.Unix()
+	idTokenClaims["exp"] = now.Add(viper.GetDuration("OIDC_ID_TOKEN_TTL")).Unix()
+	if authorizationCode.Nonce != "" {
+		idTokenClaims["nonce"] = authorizationCode.Nonce
+	}
+	idToken, err := service.sign(idTokenClaims, "JWT")
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.OIDCTokenDTO{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(accessTokenTTL.Seconds()),
+		IDToken:     idToken,
+		Scope:       authorizationCode.Scope,
+	}, nil
+}
+
+// clientCredentialsToken issues an access token to a client acting on its own behalf.
+func (service *OIDCServiceImplementation) clientCredentialsToken(ctx context.Context, client *models.Client, tokenRequestDTO *dtos.TokenRequestDTO) (*dtos.OIDCTokenDTO, error) {
+	// The OpenID Connect scopes are about users, they don't apply to clients
+	scopes := strings.Fields(tokenRequestDTO.Scope)
+	if len(scopes) == 0 {
+		for _, scope := range client.Scopes {
+			if !slices.Contains(defaultClientScopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) || slices.Contains(defaultClientScopes, scope) {
+			return nil, &OAuthError{Code: OAuthInvalidScope, Description: "the client can't request the scope " + scope}
+		}
+	}
+	scope := strings.Join(scopes, " ")
+
+	accessTokenTTL := viper.GetDuration("OIDC_ACCESS_TOKEN_TTL")
+	accessToken, err := service.sign(jwt.MapClaims{
+		"sub":       client.ClientID,
+		"aud":       client.ClientID,
+		"client_id": client.ClientID,
+		"scope":     scope,
+		"exp":       time.Now().Add(accessTokenTTL).Unix(),
+	}, accessTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditOIDCClientToken, TargetType: "client", TargetID: &client.ID, Details: scope}, nil, nil)
+
+	return &dtos.OIDCTokenDTO{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(accessTokenTTL.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// sign completes the claims with the issuer, the issue date and an identifier, and signs them with the keystore.
+func (service *OIDCServiceImplementation) sign(claims jwt.MapClaims, tokenType string) (string, error) {
+	tokenID, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+	claims["iss"] = oidcIssuer()
+	claims["iat"] = time.Now().Unix()
+	claims["jti"] = tokenID
+
+	return service.keyStoreService.Sign(claims, tokenType)
+}
+
+// userClaims returns the claims describing a user allowed by the scopes. The groups claim lists the groups
+// the user belongs to through nested groups too.
+func (service *OIDCServiceImplementation) userClaims(user *models.User, scopes []string) (map[string]any, error) {
+	claims := map[string]any{}
+	if slices.Contains(scopes, "profile") {
+		claims["name"] = user.Name
+		claims["preferred_username"] = user.Name
+	}
+	if slices.Contains(scopes, "email") {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.EmailVerifiedAt != nil
+	}
+	if slices.Contains(scopes, "groups") {
+		effectiveGroups, _, err := service.userGroupRepository.GetEffectiveUserGroups(user.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		groups := make([]string, 0, len(effectiveGroups))
+		for _, group := range effectiveGroups {
+			groups = append(groups, group.Name)
+		}
+		claims["groups"] = groups
+	}
+	return claims, nil
+}
+
+// verifyCodeChallenge checks the PKCE code verifier sent with an authorization code, if it was issued with a challenge.
+func verifyCodeChallenge(authorizationCode *models.AuthorizationCode, codeVerifier string) bool {
+	if authorizationCode.CodeChallenge == "" {
+		return true
+	}
+	if codeVerifier == "" {
+		return false
+	}
+
+	expected := codeVerifier
+	if authorizationCode.CodeChallengeMethod == "S256" {
+		hash := sha256.Sum256([]byte(codeVerifier))
+		expected = base64.RawURLEncoding.EncodeToString(hash[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(authorizationCode.CodeChallenge)) == 1
+}
+
+// redirectURL adds the parameters and the state to the query of a redirect URI.
+func redirectURL(redirectURI string, parameters url.Values, state string) string {
+	parsedURI, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := parsedURI.Query()
+	for name, values := range parameters {
+		query[name] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	parsedURI.RawQuery = query.Encode()
+	return parsedURI.String()
+}
+
+// oidcIssuer returns the issuer identifier of the OpenID Connect provider.
+func oidcIssuer() string {
+	if issuer := viper.GetString("OIDC_ISSUER"); issuer != "" {
+		return strings.TrimSuffix(issuer, "/")
+	}
+	return publicURL("", nil)
+}
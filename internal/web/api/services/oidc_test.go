@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/spf13/viper"
+)
+
+func TestAuthorizationCodeTokenChecksTheTokenVersion(t *testing.T) {
+	viper.Set("OIDC_ISSUER", "https://id.example.com")
+	viper.Set("OIDC_ACCESS_TOKEN_TTL", "1h")
+	viper.Set("OIDC_ID_TOKEN_TTL", "1h")
+
+	database := dbtest.Open(t)
+	authService, user := newTestAuthService(t, database)
+	authorizationCodeRepository := repositories.NewAuthorizationCodeRepository(database)
+	service := &OIDCServiceImplementation{
+		userRepository:              authService.userRepository,
+		authorizationCodeRepository: authorizationCodeRepository,
+		keyStoreService:             authService.keyStoreService,
+		auditService:                authService.auditService,
+	}
+
+	client := &models.Client{ClientID: "app", RedirectURIs: []string{"https://app.example.com/callback"}}
+	for _, code := range []string{"before", "after"} {
+		if err := authorizationCodeRepository.Create(&models.AuthorizationCode{
+			CodeHash:     hashToken(code),
+			ClientID:     client.ClientID,
+			UserID:       user.ID,
+			TokenVersion: user.TokenVersion,
+			RedirectURI:  client.RedirectURIs[0],
+			Scope:        "openid",
+			AuthTime:     time.Now(),
+			ExpiresAt:    time.Now().Add(time.Minute),
+		}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	if tokens, err := service.authorizationCodeToken(client, &dtos.TokenRequestDTO{Code: "before"}); err != nil || tokens.AccessToken == "" {
+		t.Fatalf("authorizationCodeToken() = %v, %v, want tokens", tokens, err)
+	}
+
+	// The sessions of the user are revoked between the authorization and the exchange of the code
+	user.TokenVersion++
+	if err := authService.userRepository.Update(user); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	var oauthErr *OAuthError
+	if tokens, err := service.authorizationCodeToken(client, &dtos.TokenRequestDTO{Code: "after"}); !errors.As(err, &oauthErr) || oauthErr.Code != OAuthInvalidGrant {
+		t.Errorf("authorizationCodeToken() of a revoked code = %v, %v, want invalid_grant", tokens, err)
+	}
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Nokeni/GODS/internal/passhash"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/spf13/viper"
+)
+
+// The rules of the password policy, identifying the violations reported to the clients.
+const (
+	PasswordRuleMinLength  = "min_length"
+	PasswordRuleMaxLength  = "max_length"
+	PasswordRuleUppercase  = "uppercase"
+	PasswordRuleLowercase  = "lowercase"
+	PasswordRuleDigit      = "digit"
+	PasswordRuleSpecial    = "special"
+	PasswordRuleBlocklist  = "blocklist"
+	PasswordRuleSimilarity = "similarity"
+	PasswordRuleHistory    = "history"
+)
+
+// similarityMinLength is the length from which a username or an email local part can't be part of a password,
+// shorter ones being too likely to appear by chance.
+const similarityMinLength = 3
+
+// PasswordViolation is a rule of the password policy that a password fails.
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError is returned when a password fails the password policy, with every failed rule so that clients
+// can show them all at once.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (err *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+// PasswordPolicyService defines the methods for enforcing the password policy.
+type PasswordPolicyService interface {
+	Check(user *models.User, password string) error
+	SetPassword(user *models.User, password string) error
+	IsExpired(user *models.User) bool
+}
+
+// PasswordPolicyServiceImplementation is an implementation of the PasswordPolicyService.
+type PasswordPolicyServiceImplementation struct {
+	passwordHistoryRepository repositories.PasswordHistoryRepository
+	hasher                    passhash.Hasher
+	blocklist                 map[string]bool
+}
+
+// NewPasswordPolicyService creates the PasswordPolicyService, loading the blocklist of the policy.
+func NewPasswordPolicyService(passwordHistoryRepository repositories.PasswordHistoryRepository, hasher passhash.Hasher) (PasswordPolicyService, error) {
+	blocklist, err := loadPasswordBlocklist(viper.GetString("PASSWORD_BLOCKLIST_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasswordPolicyServiceImplementation{
+		passwordHistoryRepository: passwordHistoryRepository,
+		hasher:                    hasher,
+		blocklist:                 blocklist,
+	}, nil
+}
+
+// Check checks a password against the password policy, for the given user who may not be created yet, and returns
+// a PasswordPolicyError listing every failed rule.
+func (service *PasswordPolicyServiceImplementation) Check(user *models.User, password string) error {
+	var violations []PasswordViolation
+	violate := func(rule string, format string, args ...any) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if minLength := viper.GetInt("PASSWORD_MIN_LENGTH"); length < minLength {
+		violate(PasswordRuleMinLength, "password must be at least %d characters long", minLength)
+	}
+	if maxLength := viper.GetInt("PASSWORD_MAX_LENGTH"); maxLength > 0 && length > maxLength {
+		violate(PasswordRuleMaxLength, "password must be at most %d characters long", maxLength)
+	}
+
+	var hasUppercase, hasLowercase, hasDigit, hasSpecial bool
+	for _, character := range password {
+		switch {
+		case unicode.IsUpper(character):
+			hasUppercase = true
+		case unicode.IsLower(character):
+			hasLowercase = true
+		case unicode.IsDigit(character):
+			hasDigit = true
+		case !unicode.IsLetter(character):
+			hasSpecial = true
+		}
+	}
+	if viper.GetBool("PASSWORD_REQUIRE_UPPERCASE") && !hasUppercase {
+		violate(PasswordRuleUppercase, "password must contain at least one uppercase letter")
+	}
+	if viper.GetBool("PASSWORD_REQUIRE_LOWERCASE") && !hasLowercase {
+		violate(PasswordRuleLowercase, "password must contain at least one lowercase letter")
+	}
+	if viper.GetBool("PASSWORD_REQUIRE_DIGIT") && !hasDigit {
+		violate(PasswordRuleDigit, "password must contain at least one digit")
+	}
+	if viper.GetBool("PASSWORD_REQUIRE_SPECIAL") && !hasSpecial {
+		violate(PasswordRuleSpecial, "password must contain at least one special character")
+	}
+
+	if service.isBlocked(password) {
+		violate(PasswordRuleBlocklist, "password is too common or has appeared in a data breach")
+	}
+
+	if viper.GetBool("PASSWORD_REJECT_SIMILAR") && isSimilar(user, password) {
+		violate(PasswordRuleSimilarity, "password must not contain the username or the email")
+	}
+
+	reused, err := service.isReused(user, password)
+	if err != nil {
+		return err
+	}
+	if reused {
+		violate(PasswordRuleHistory, "password must not be one of the last %d passwords", viper.GetInt("PASSWORD_HISTORY"))
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// SetPassword checks a password against the password policy and sets it as the password of the user, who still has
+// to be saved. The replaced password is added to the history of the user.
+func (service *PasswordPolicyServiceImplementation) SetPassword(user *models.User, password string) error {
+	if err := service.Check(user, password); err != nil {
+		return err
+	}
+
+	hashedPassword, err := service.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	// The history only keeps the former passwords that can't be reused, the current one being checked apart
+	if keep := viper.GetInt("PASSWORD_HISTORY") - 1; user.ID != 0 && user.Password != "" && keep > 0 {
+		if err := service.passwordHistoryRepository.Create(&models.PasswordHistory{UserID: user.ID, Hash: user.Password}); err != nil {
+			return err
+		}
+		if err := service.passwordHistoryRepository.DeleteAllButLatest(user.ID, keep); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+
+	return nil
+}
+
+// IsExpired tells if the password of a user has reached its maximum age and has to be changed before logging in.
+func (service *PasswordPolicyServiceImplementation) IsExpired(user *models.User) bool {
+	maxAge := viper.GetDuration("PASSWORD_MAX_AGE")
+	return maxAge > 0 && !user.ServiceAccount && user.PasswordChangedAt != nil && time.Since(*user.PasswordChangedAt) > maxAge
+}
+
+// isBlocked tells if a password is in the blocklist, in clear or as its SHA-1 hash.
+func (service *PasswordPolicyServiceImplementation) isBlocked(password string) bool {
+	if len(service.blocklist) == 0 {
+		return false
+	}
+
+	hash := sha1.Sum([]byte(password))
+	return service.blocklist[strings.ToLower(password)] || service.blocklist[strings.ToUpper(hex.EncodeToString(hash[:]))]
+}
+
+// isReused tells if a password is the current password of the user or one of the former ones kept in their history.
+func (service *PasswordPolicyServiceImplementation) isReused(user *models.User, password string) (bool, error) {
+	history := viper.GetInt("PASSWORD_HISTORY")
+	if user == nil || user.ID == 0 || history <= 0 {
+		return false, nil
+	}
+
+	hashes := []string{user.Password}
+	if history > 1 {
+		passwordHistories, err := service.passwordHistoryRepository.GetLatest(user.ID, history-1)
+		if err != nil {
+			return false, err
+		}
+		for _, passwordHistory := range passwordHistories {
+			hashes = append(hashes, passwordHistory.Hash)
+		}
+	}
+
+	for _, hash := range hashes {
+		if matches, _ := passhash.Verify(hash, password); matches {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isSimilar tells if a password contains the username or the local part of the email of a user.
+func isSimilar(user *models.User, password string) bool {
+	if user == nil {
+		return false
+	}
+
+	password = strings.ToLower(password)
+	localPart, _, _ := strings.Cut(user.Email, "@")
+	for _, part := range []string{user.Name, localPart} {
+		if utf8.RuneCountInString(part) >= similarityMinLength && strings.Contains(password, strings.ToLower(part)) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPasswordBlocklist reads a blocklist file, made of passwords in clear or of hex SHA-1 hashes optionally followed
+// by ":count" as in the Pwned Passwords downloads. Passwords are kept lowercased and hashes uppercased.
+func loadPasswordBlocklist(path string) (map[string]bool, error) {
+	blocklist := make(map[string]bool)
+	if path == "" {
+		return blocklist, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the password blocklist: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1Hex(hash) {
+			blocklist[strings.ToUpper(hash)] = true
+		} else {
+			blocklist[strings.ToLower(line)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the password blocklist: %w", err)
+	}
+
+	return blocklist, nil
+}
+
+// isSHA1Hex tells if a string is a hex-encoded SHA-1 hash.
+func isSHA1Hex(value string) bool {
+	if len(value) != hex.EncodedLen(sha1.Size) {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
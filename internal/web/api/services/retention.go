@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// RetentionService defines the methods for enforcing the retention policy of the deleted users and groups.
+type RetentionService interface {
+	Run(ctx context.Context)
+}
+
+// RetentionServiceImplementation is an implementation of the RetentionService.
+type RetentionServiceImplementation struct {
+	userService  UserService
+	groupService GroupService
+}
+
+func NewRetentionService(userService UserService, groupService GroupService) RetentionService {
+	return &RetentionServiceImplementation{
+		userService:  userService,
+		groupService: groupService,
+	}
+}
+
+// Run purges the users and groups deleted for longer than DELETED_RETENTION_DAYS, every DELETED_PURGE_INTERVAL
+// until the context is done. The deleted records are kept forever when the retention is 0.
+func (service *RetentionServiceImplementation) Run(ctx context.Context) {
+	days := viper.GetInt("DELETED_RETENTION_DAYS")
+	if days <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(viper.GetDuration("DELETED_PURGE_INTERVAL"))
+	defer ticker.Stop()
+
+	for {
+		service.purge(ctx, time.Now().AddDate(0, 0, -days))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge permanently removes the users and groups deleted before a date.
+func (service *RetentionServiceImplementation) purge(ctx context.Context, date time.Time) {
+	if purged, err := service.userService.PurgeDeletedBefore(ctx, date); err != nil {
+		log.Printf("failed to purge the deleted users: %v", err)
+	} else if purged > 0 {
+		log.Printf("purged %d users deleted before %s", purged, date.Format(time.RFC3339))
+	}
+
+	if purged, err := service.groupService.PurgeDeletedBefore(ctx, date); err != nil {
+		log.Printf("failed to purge the deleted groups: %v", err)
+	} else if purged > 0 {
+		log.Printf("purged %d groups deleted before %s", purged, date.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+)
+
+// RoleService defines the methods for performing business operations on Roles and Permissions.
+type RoleService interface {
+	Get(id uint) (*models.Role, error)
+	GetAll() ([]*models.Role, error)
+	Create(ctx context.Context, roleDTO *dtos.CreateRoleDTO) (*models.Role, error)
+	Update(ctx context.Context, role *models.Role, roleDTO *dtos.UpdateRoleDTO) error
+	Delete(ctx context.Context, id uint) error
+	GetPermissions() ([]*models.Permission, error)
+	CreatePermission(permission *models.Permission) (*models.Permission, error)
+	AddPermissionToRole(ctx context.Context, roleID uint, permissionID uint) error
+	RemovePermissionFromRole(ctx context.Context, roleID uint, permissionID uint) error
+	AddRoleToGroup(ctx context.Context, roleID uint, groupID uint) error
+	RemoveRoleFromGroup(ctx context.Context, roleID uint, groupID uint) error
+	GetUserPermissions(userID uint) ([]string, error)
+	HasPermission(userID uint, permission string) (bool, error)
+}
+
+// RoleServiceImplementation is an implementation of the RoleService.
+type RoleServiceImplementation struct {
+	roleRepository       repositories.RoleRepository
+	permissionRepository repositories.PermissionRepository
+	userGroupRepository  repositories.UserGroupRepository
+	auditService         AuditService
+}
+
+func NewRoleService(
+	roleRepository repositories.RoleRepository,
+	permissionRepository repositories.PermissionRepository,
+	userGroupRepository repositories.UserGroupRepository,
+	auditService AuditService,
+) RoleService {
+	return &RoleServiceImplementation{
+		roleRepository:       roleRepository,
+		permissionRepository: permissionRepository,
+		userGroupRepository:  userGroupRepository,
+		auditService:         auditService,
+	}
+}
+
+// Get retrieves a role by ID.
+func (service *RoleServiceImplementation) Get(id uint) (*models.Role, error) {
+	role, err := service.roleRepository.Get(id)
+	if err != nil {
+		return nil, notFound(err, CodeRoleNotFound, "role %d not found", id)
+	}
+	return role, nil
+}
+
+// GetAll retrieves all roles.
+func (service *RoleServiceImplementation) GetAll() ([]*models.Role, error) {
+	return service.roleRepository.GetAll()
+}
+
+// Create adds a new role.
+func (service *RoleServiceImplementation) Create(ctx context.Context, roleDTO *dtos.CreateRoleDTO) (*models.Role, error) {
+	// Check if the role already exists
+	role, err := service.roleRepository.GetByName(roleDTO.Name)
+	if err == nil {
+		return role, NewConflictError(CodeRoleExists, "role already exists")
+	}
+
+	// Create the role model
+	role = &models.Role{
+		Name:        roleDTO.Name,
+		Description: roleDTO.Description,
+	}
+
+	if err := service.roleRepository.Create(role); err != nil {
+		return role, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditRoleCreate, TargetType: "role", TargetID: &role.ID}, nil, role)
+
+	return role, nil
+}
+
+// Update modifies an existing role.
+func (service *RoleServiceImplementation) Update(ctx context.Context, role *models.Role, roleDTO *dtos.UpdateRoleDTO) error {
+	before := *role
+
+	// Update role details depending on provided DTO fields
+	if roleDTO.Name != "" && roleDTO.Name != role.Name {
+		if _, err := service.roleRepository.GetByName(roleDTO.Name); err == nil {
+			return NewConflictError(CodeRoleExists, "role already exists")
+		}
+		role.Name = roleDTO.Name
+	}
+
+	if roleDTO.Description != "" {
+		role.Description = roleDTO.Description
+	}
+
+	if err := service.roleRepository.Update(role); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditRoleUpdate, TargetType: "role", TargetID: &role.ID}, &before, role)
+
+	return nil
+}
+
+// Delete removes a role by ID.
+func (service *RoleServiceImplementation) Delete(ctx context.Context, id uint) error {
+	role, err := service.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if err := service.roleRepository.Delete(id); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditRoleDelete, TargetType: "role", TargetID: &role.ID}, role, nil)
+
+	return nil
+}
+
+// GetPermissions retrieves all permissions.
+func (service *RoleServiceImplementation) GetPermissions() ([]*models.Permission, error) {
+	return service.permissionRepository.GetAll()
+}
+
+// CreatePermission adds a new permission.
+func (service *RoleServiceImplementation) CreatePermission(permission *models.Permission) (*models.Permission, error) {
+	// Check if the permission already exists
+	existingPermission, err := service.permissionRepository.GetByName(permission.Name)
+	if err == nil {
+		return existingPermission, NewConflictError(CodePermissionExists, "permission already exists")
+	}
+
+	err = service.permissionRepository.Create(permission)
+
+	return permission, err
+}
+
+// AddPermissionToRole grants a permission to a role.
+func (service *RoleServiceImplementation) AddPermissionToRole(ctx context.Context, roleID uint, permissionID uint) error {
+	if err := service.roleRepository.AddPermissionToRole(roleID, permissionID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditRolePermissionAdd, TargetType: "role", TargetID: &roleID}, nil, map[string]uint{"PermissionID": permissionID})
+
+	return nil
+}
+
+// RemovePermissionFromRole revokes a permission from a role.
+func (service *RoleServiceImplementation) RemovePermissionFromRole(ctx context.Context, roleID uint, permissionID uint) error {
+	if err := service.roleRepository.RemovePermissionFromRole(roleID, permissionID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditRolePermissionRemove, TargetType: "role", TargetID: &roleID}, map[string]uint{"PermissionID": permissionID}, nil)
+
+	return nil
+}
+
+// AddRoleToGroup grants a role to a group.
+func (service *RoleServiceImplementation) AddRoleToGroup(ctx context.Context, roleID uint, groupID uint) error {
+	if err := service.roleRepository.AddRoleToGroup(roleID, groupID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditRoleGroupAdd, TargetType: "role", TargetID: &roleID}, nil, map[string]uint{"GroupID": groupID})
+
+	return nil
+}
+
+// RemoveRoleFromGroup revokes a role from a group.
+func (service *RoleServiceImplementation) RemoveRoleFromGroup(ctx context.Context, roleID uint, groupID uint) error {
+	if err := service.roleRepository.RemoveRoleFromGroup(roleID, groupID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditRoleGroupRemove, TargetType: "role", TargetID: &roleID}, map[string]uint{"GroupID": groupID}, nil)
+
+	return nil
+}
+
+// GetUserPermissions retrieves the names of the permissions granted to a user through the roles of their groups,
+// including the groups they belong to through nested groups.
+func (service *RoleServiceImplementation) GetUserPermissions(userID uint) ([]string, error) {
+	groupIDs, err := service.userGroupRepository.GetEffectiveGroupIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.permissionRepository.GetGroupsPermissions(groupIDs)
+}
+
+// HasPermission checks if a user has been granted a permission.
+func (service *RoleServiceImplementation) HasPermission(userID uint, permission string) (bool, error) {
+	permissions, err := service.GetUserPermissions(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(permissions, permission), nil
+}
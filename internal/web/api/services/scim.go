@@ -0,0 +1,823 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"github.com/Nokeni/GODS/internal/web/common/scim"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// SCIM error types, as defined by RFC 7644 section 3.12.
+const (
+	SCIMInvalidFilter = "invalidFilter"
+	SCIMUniqueness    = "uniqueness"
+	SCIMInvalidSyntax = "invalidSyntax"
+	SCIMInvalidPath   = "invalidPath"
+	SCIMNoTarget      = "noTarget"
+	SCIMInvalidValue  = "invalidValue"
+	SCIMMutability    = "mutability"
+)
+
+// SCIMError is an error reported to the SCIM clients with an HTTP status and one of the SCIM error types.
+type SCIMError struct {
+	Status int
+	Type   string
+	Detail string
+}
+
+func (err *SCIMError) Error() string {
+	return err.Detail
+}
+
+// newSCIMError creates a SCIM error with a formatted detail.
+func newSCIMError(status int, scimType string, format string, args ...any) *SCIMError {
+	return &SCIMError{Status: status, Type: scimType, Detail: fmt.Sprintf(format, args...)}
+}
+
+// SCIMService defines the methods for provisioning the users and groups with SCIM 2.0.
+type SCIMService interface {
+	ServiceProviderConfig() *dtos.SCIMServiceProviderConfigDTO
+	ResourceTypes() []dtos.SCIMResourceTypeDTO
+	Schemas() []dtos.SCIMSchemaDTO
+	GetUser(id uint) (*dtos.SCIMUserDTO, error)
+	GetUsers(filter string, startIndex int, count int) ([]*dtos.SCIMUserDTO, int, error)
+	CreateUser(ctx context.Context, userDTO *dtos.SCIMUserDTO) (*dtos.SCIMUserDTO, error)
+	ReplaceUser(ctx context.Context, id uint, userDTO *dtos.SCIMUserDTO) (*dtos.SCIMUserDTO, error)
+	PatchUser(ctx context.Context, id uint, patchDTO *dtos.SCIMPatchDTO) (*dtos.SCIMUserDTO, error)
+	DeleteUser(ctx context.Context, id uint) error
+	GetGroup(id uint) (*dtos.SCIMGroupDTO, error)
+	GetGroups(filter string, startIndex int, count int) ([]*dtos.SCIMGroupDTO, int, error)
+	CreateGroup(ctx context.Context, groupDTO *dtos.SCIMGroupDTO) (*dtos.SCIMGroupDTO, error)
+	ReplaceGroup(ctx context.Context, id uint, groupDTO *dtos.SCIMGroupDTO) (*dtos.SCIMGroupDTO, error)
+	PatchGroup(ctx context.Context, id uint, patchDTO *dtos.SCIMPatchDTO) (*dtos.SCIMGroupDTO, error)
+	DeleteGroup(ctx context.Context, id uint) error
+}
+
+// SCIMServiceImplementation is an implementation of the SCIMService, mapping the SCIM resources onto
+// the users, groups and memberships services.
+type SCIMServiceImplementation struct {
+	userRepository   repositories.UserRepository
+	groupRepository  repositories.GroupRepository
+	userService      UserService
+	groupService     GroupService
+	userGroupService UserGroupService
+}
+
+func NewSCIMService(
+	userRepository repositories.UserRepository,
+	groupRepository repositories.GroupRepository,
+	userService UserService,
+	groupService GroupService,
+	userGroupService UserGroupService,
+) SCIMService {
+	return &SCIMServiceImplementation{
+		userRepository:   userRepository,
+		groupRepository:  groupRepository,
+		userService:      userService,
+		groupService:     groupService,
+		userGroupService: userGroupService,
+	}
+}
+
+// scimUser is the state of a user as described by SCIM requests, applied to the user once complete.
+type scimUser struct {
+	userName string
+	email    string
+	password string
+	active   bool
+}
+
+// scimGroup is the state of a group as described by SCIM requests, applied to the group once complete.
+type scimGroup struct {
+	displayName string
+	members     []uint
+}
+
+// ServiceProviderConfig returns the SCIM features supported by GODS.
+func (service *SCIMServiceImplementation) ServiceProviderConfig() *dtos.SCIMServiceProviderConfigDTO {
+	zero, maxResults := 0, query.MaxLimit
+	return &dtos.SCIMServiceProviderConfigDTO{
+		Schemas:        []string{dtos.SCIMSchemaServiceProviderConfig},
+		Patch:          dtos.SCIMSupportedDTO{Supported: true},
+		Bulk:           dtos.SCIMSupportedDTO{Supported: false, MaxOperations: &zero, MaxPayloadSize: &zero},
+		Filter:         dtos.SCIMSupportedDTO{Supported: true, MaxResults: &maxResults},
+		ChangePassword: dtos.SCIMSupportedDTO{Supported: true},
+		Sort:           dtos.SCIMSupportedDTO{Supported: false},
+		ETag:           dtos.SCIMSupportedDTO{Supported: false},
+		AuthenticationSchemes: []dtos.SCIMAuthenticationSchemeDTO{{
+			Type:        "oauthbearertoken",
+			Name:        "API key",
+			Description: "Authentication with an API key of a service account as bearer token",
+			Primary:     true,
+		}},
+		Meta: dtos.SCIMMetaDTO{ResourceType: "ServiceProviderConfig", Location: scimURL("/ServiceProviderConfig")},
+	}
+}
+
+// ResourceTypes returns the types of the SCIM resources.
+func (service *SCIMServiceImplementation) ResourceTypes() []dtos.SCIMResourceTypeDTO {
+	resourceType := func(name string, endpoint string, description string, schema string) dtos.SCIMResourceTypeDTO {
+		return dtos.SCIMResourceTypeDTO{
+			Schemas:     []string{dtos.SCIMSchemaResourceType},
+			ID:          name,
+			Name:        name,
+			Endpoint:    endpoint,
+			Description: description,
+			Schema:      schema,
+			Meta:        dtos.SCIMMetaDTO{ResourceType: "ResourceType", Location: scimURL("/ResourceTypes/" + name)},
+		}
+	}
+	return []dtos.SCIMResourceTypeDTO{
+		resourceType("User", "/Users", "User account", dtos.SCIMSchemaUser),
+		resourceType("Group", "/Groups", "Group of users", dtos.SCIMSchemaGroup),
+	}
+}
+
+// Schemas returns the schemas of the SCIM resources, restricted to the attributes GODS stores.
+func (service *SCIMServiceImplementation) Schemas() []dtos.SCIMSchemaDTO {
+	attribute := func(name string, attributeType string, description string) dtos.SCIMAttributeDTO {
+		return dtos.SCIMAttributeDTO{Name: name, Type: attributeType, Description: description, Mutability: "readWrite", Returned: "default", Uniqueness: "none"}
+	}
+	reference := func(description string) []dtos.SCIMAttributeDTO {
+		value := attribute("value", "string", "Identifier of the "+description)
+		value.Mutability = "immutable"
+		ref := attribute("$ref", "reference", "URI of the "+description)
+		ref.Mutability = "immutable"
+		display := attribute("display", "string", "Name of the "+description)
+		display.Mutability = "readOnly"
+		return []dtos.SCIMAttributeDTO{value, ref, display}
+	}
+
+	userName := attribute("userName", "string", "Unique name of the user, used to log in")
+	userName.Required, userName.Uniqueness = true, "server"
+	emails := attribute("emails", "complex", "Email address of the user, only the primary one is stored")
+	emails.MultiValued, emails.Required = true, true
+	emails.SubAttributes = []dtos.SCIMAttributeDTO{
+		attribute("value", "string", "Email address"),
+		attribute("type", "string", "Type of the email address"),
+		attribute("primary", "boolean", "Whether this is the primary email address"),
+	}
+	active := attribute("active", "boolean", "Whether the user may authenticate, inactive users are kept disabled")
+	password := attribute("password", "string", "Password of the user, a random one is set when none is provided")
+	password.Mutability, password.Returned = "writeOnly", "never"
+	groups := attribute("groups", "complex", "Groups the user belongs to")
+	groups.MultiValued, groups.Mutability, groups.SubAttributes = true, "readOnly", reference("group")
+
+	displayName := attribute("displayName", "string", "Unique name of the group")
+	displayName.Required, displayName.Uniqueness = true, "server"
+	members := attribute("members", "complex", "Users belonging to the group")
+	members.MultiValued, members.SubAttributes = true, append(reference("user"), attribute("type", "string", "Type of the member, User"))
+
+	schema := func(id string, name string, description string, attributes ...dtos.SCIMAttributeDTO) dtos.SCIMSchemaDTO {
+		return dtos.SCIMSchemaDTO{
+			Schemas:     []string{dtos.SCIMSchemaSchema},
+			ID:          id,
+			Name:        name,
+			Description: description,
+			Attributes:  attributes,
+			Meta:        dtos.SCIMMetaDTO{ResourceType: "Schema", Location: scimURL("/Schemas/" + id)},
+		}
+	}
+	return []dtos.SCIMSchemaDTO{
+		schema(dtos.SCIMSchemaUser, "User", "User account", userName, emails, active, password, groups),
+		schema(dtos.SCIMSchemaGroup, "Group", "Group of users", displayName, members),
+	}
+}
+
+// GetUser retrieves a user.
+func (service *SCIMServiceImplementation) GetUser(id uint) (*dtos.SCIMUserDTO, error) {
+	user, err := service.userService.Get(id)
+	if err != nil {
+		return nil, scimNotFound(err, "User", id)
+	}
+	return toSCIMUser(user), nil
+}
+
+// GetUsers retrieves a page of the users matching a filter, startIndex being the 1-based index of the first one.
+// It also returns the number of users matching the filter.
+func (service *SCIMServiceImplementation) GetUsers(filter string, startIndex int, count int) ([]*dtos.SCIMUserDTO, int, error) {
+	listQuery, parsed, err := scimListQuery(filter, startIndex, count, scimUserColumn)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, pageInfo, err := service.userRepository.GetAllWithGroups(listQuery)
+	if err != nil {
+		return nil, 0, err
+	}
+	resources := make([]*dtos.SCIMUserDTO, len(users))
+	for i, user := range users {
+		resources[i] = toSCIMUser(user)
+	}
+	if listQuery != nil {
+		return resources, int(pageInfo.Total), nil
+	}
+	page, total := filterSCIMResources(resources, parsed, startIndex, count)
+	return page, total, nil
+}
+
+// CreateUser provisions a user. Users provisioned without a password get a random one, and set theirs
+// through the password reset, unless they only log in through single sign-on.
+func (service *SCIMServiceImplementation) CreateUser(ctx context.Context, userDTO *dtos.SCIMUserDTO) (*dtos.SCIMUserDTO, error) {
+	state := &scimUser{userName: userDTO.UserName, email: primaryEmail(userDTO.Emails), password: userDTO.Password}
+	if err := state.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := service.userRepository.GetByName(state.userName); err == nil {
+		return nil, newSCIMError(http.StatusConflict, SCIMUniqueness, "user %q already exists", state.userName)
+	}
+
+	if state.password == "" {
+		randomPassword, err := generateRandomToken()
+		if err != nil {
+			return nil, err
+		}
+		// The suffix meets the character classes of the password policy whatever the random part, which is
+		// shortened to fit the maximum length
+		const suffix = "aA1!"
+		if maxLength := viper.GetInt("PASSWORD_MAX_LENGTH"); maxLength > len(suffix) && len(randomPassword)+len(suffix) > maxLength {
+			randomPassword = randomPassword[:maxLength-len(suffix)]
+		}
+		state.password = randomPassword + suffix
+	}
+
+	user, err := service.userService.Create(ctx, &dtos.CreateUserDTO{Name: state.userName, Email: state.email, Password: state.password})
+	if err != nil {
+		return nil, err
+	}
+	if userDTO.Active != nil && !*userDTO.Active {
+		disabled := true
+		if err := service.userService.Update(ctx, user, &dtos.UpdateUserDTO{Disabled: &disabled}); err != nil {
+			return nil, err
+		}
+	}
+
+	return toSCIMUser(user), nil
+}
+
+// ReplaceUser replaces the attributes of a user, disabling them when they're made inactive.
+func (service *SCIMServiceImplementation) ReplaceUser(ctx context.Context, id uint, userDTO *dtos.SCIMUserDTO) (*dtos.SCIMUserDTO, error) {
+	user, err := service.userService.Get(id)
+	if err != nil {
+		return nil, scimNotFound(err, "User", id)
+	}
+
+	state := &scimUser{userName: userDTO.UserName, email: primaryEmail(userDTO.Emails), password: userDTO.Password, active: true}
+	if userDTO.Active != nil {
+		state.active = *userDTO.Active
+	}
+
+	return service.applyUser(ctx, user, state)
+}
+
+// PatchUser modifies the attributes of a user with PATCH operations, disabling them when they're made inactive.
+func (service *SCIMServiceImplementation) PatchUser(ctx context.Context, id uint, patchDTO *dtos.SCIMPatchDTO) (*dtos.SCIMUserDTO, error) {
+	user, err := service.userService.Get(id)
+	if err != nil {
+		return nil, scimNotFound(err, "User", id)
+	}
+
+	state := &scimUser{userName: user.Name, email: user.Email, active: !user.Disabled}
+	err = applyPatch(patchDTO, func(op string, path *scim.Path, value json.RawMessage) error {
+		if op == "remove" {
+			return state.remove(path)
+		}
+		return state.set(path, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return service.applyUser(ctx, user, state)
+}
+
+// DeleteUser deprovisions a user.
+func (service *SCIMServiceImplementation) DeleteUser(ctx context.Context, id uint) error {
+	if err := service.userService.Delete(ctx, id); err != nil {
+		return scimNotFound(err, "User", id)
+	}
+	return nil
+}
+
+// GetGroup retrieves a group.
+func (service *SCIMServiceImplementation) GetGroup(id uint) (*dtos.SCIMGroupDTO, error) {
+	group, err := service.groupService.Get(id)
+	if err != nil {
+		return nil, scimNotFound(err, "Group", id)
+	}
+	return toSCIMGroup(group), nil
+}
+
+// GetGroups retrieves a page of the groups matching a filter, startIndex being the 1-based index of the first one.
+// It also returns the number of groups matching the filter.
+func (service *SCIMServiceImplementation) GetGroups(filter string, startIndex int, count int) ([]*dtos.SCIMGroupDTO, int, error) {
+	listQuery, parsed, err := scimListQuery(filter, startIndex, count, scimGroupColumn)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	groups, pageInfo, err := service.groupRepository.GetAllWithUsers(listQuery)
+	if err != nil {
+		return nil, 0, err
+	}
+	resources := make([]*dtos.SCIMGroupDTO, len(groups))
+	for i, group := range groups {
+		resources[i] = toSCIMGroup(group)
+	}
+	if listQuery != nil {
+		return resources, int(pageInfo.Total), nil
+	}
+	page, total := filterSCIMResources(resources, parsed, startIndex, count)
+	return page, total, nil
+}
+
+// CreateGroup provisions a group along with its members.
+func (service *SCIMServiceImplementation) CreateGroup(ctx context.Context, groupDTO *dtos.SCIMGroupDTO) (*dtos.SCIMGroupDTO, error) {
+	if groupDTO.DisplayName == "" {
+		return nil, newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "displayName is required")
+	}
+	members, err := memberIDs(groupDTO.Members)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.checkMembers(members); err != nil {
+		return nil, err
+	}
+	if _, err := service.groupRepository.GetByName(groupDTO.DisplayName); err == nil {
+		return nil, newSCIMError(http.StatusConflict, SCIMUniqueness, "group %q already exists", groupDTO.DisplayName)
+	}
+
+	group, err := service.groupService.Create(ctx, &dtos.CreateGroupDTO{Name: groupDTO.DisplayName})
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range members {
+		if err := service.userGroupService.AddUserToGroup(ctx, userID, group.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return service.GetGroup(group.ID)
+}
+
+// ReplaceGroup replaces the name and the members of a group.
+func (service *SCIMServiceImplementation) ReplaceGroup(ctx context.Context, id uint, groupDTO *dtos.SCIMGroupDTO) (*dtos.SCIMGroupDTO, error) {
+	group, err := service.groupService.Get(id)
+	if err != nil {
+		return nil, scimNotFound(err, "Group", id)
+	}
+
+	members, err := memberIDs(groupDTO.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.applyGroup(ctx, group, &scimGroup{displayName: groupDTO.DisplayName, members: members})
+}
+
+// PatchGroup modifies the name and the members of a group with PATCH operations.
+func (service *SCIMServiceImplementation) PatchGroup(ctx context.Context, id uint, patchDTO *dtos.SCIMPatchDTO) (*dtos.SCIMGroupDTO, error) {
+	group, err := service.groupService.Get(id)
+	if err != nil {
+		return nil, scimNotFound(err, "Group", id)
+	}
+
+	state := &scimGroup{displayName: group.Name}
+	for _, user := range group.Users {
+		state.members = append(state.members, user.ID)
+	}
+	err = applyPatch(patchDTO, func(op string, path *scim.Path, value json.RawMessage) error {
+		return state.apply(op, path, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return service.applyGroup(ctx, group, state)
+}
+
+// DeleteGroup deprovisions a group.
+func (service *SCIMServiceImplementation) DeleteGroup(ctx context.Context, id uint) error {
+	if err := service.groupService.Delete(ctx, id); err != nil {
+		return scimNotFound(err, "Group", id)
+	}
+	return nil
+}
+
+// applyUser updates a user to match the state described by a SCIM request.
+func (service *SCIMServiceImplementation) applyUser(ctx context.Context, user *models.User, state *scimUser) (*dtos.SCIMUserDTO, error) {
+	if err := state.validate(); err != nil {
+		return nil, err
+	}
+	updateDTO := &dtos.UpdateUserDTO{}
+	if state.userName != user.Name {
+		if _, err := service.userRepository.GetByName(state.userName); err == nil {
+			return nil, newSCIMError(http.StatusConflict, SCIMUniqueness, "user %q already exists", state.userName)
+		}
+		updateDTO.Name = state.userName
+	}
+	if state.email != user.Email {
+		updateDTO.Email = state.email
+	}
+	if state.password != "" {
+		if user.ServiceAccount {
+			return nil, newSCIMError(http.StatusBadRequest, SCIMMutability, "service accounts can't have a password")
+		}
+		updateDTO.Password = state.password
+	}
+	if state.active == user.Disabled {
+		disabled := !state.active
+		updateDTO.Disabled = &disabled
+	}
+
+	if *updateDTO != (dtos.UpdateUserDTO{}) {
+		if err := service.userService.Update(ctx, user, updateDTO); err != nil {
+			return nil, err
+		}
+	}
+
+	return toSCIMUser(user), nil
+}
+
+// applyGroup updates a group and its memberships to match the state described by a SCIM request.
+func (service *SCIMServiceImplementation) applyGroup(ctx context.Context, group *models.Group, state *scimGroup) (*dtos.SCIMGroupDTO, error) {
+	if state.displayName == "" {
+		return nil, newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "displayName is required")
+	}
+	if err := service.checkMembers(state.members); err != nil {
+		return nil, err
+	}
+
+	if state.displayName != group.Name {
+		if _, err := service.groupRepository.GetByName(state.displayName); err == nil {
+			return nil, newSCIMError(http.StatusConflict, SCIMUniqueness, "group %q already exists", state.displayName)
+		}
+		if err := service.groupService.Update(ctx, group, &dtos.UpdateGroupDTO{Name: state.displayName}); err != nil {
+			return nil, err
+		}
+	}
+
+	current := make([]uint, len(group.Users))
+	for i, user := range group.Users {
+		current[i] = user.ID
+	}
+	for _, userID := range state.members {
+		if !slices.Contains(current, userID) {
+			if err := service.userGroupService.AddUserToGroup(ctx, userID, group.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, userID := range current {
+		if !slices.Contains(state.members, userID) {
+			if err := service.userGroupService.RemoveUserFromGroup(ctx, userID, group.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return service.GetGroup(group.ID)
+}
+
+// checkMembers checks that the members of a group are existing users.
+func (service *SCIMServiceImplementation) checkMembers(members []uint) error {
+	for _, userID := range members {
+		if _, err := service.userService.Get(userID); err != nil {
+			return newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "user %d doesn't exist", userID)
+		}
+	}
+	return nil
+}
+
+// validate checks that the required attributes of a user are set.
+func (state *scimUser) validate() error {
+	if state.userName == "" {
+		return newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "userName is required")
+	}
+	if state.email == "" {
+		return newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "an email is required")
+	}
+	return nil
+}
+
+// set sets an attribute of a user, ignoring the attributes GODS doesn't store.
+func (state *scimUser) set(path *scim.Path, value json.RawMessage) error {
+	switch {
+	case path.Is("userName"):
+		return decodeSCIMValue(value, &state.userName, "userName")
+	case path.Is("password"):
+		return decodeSCIMValue(value, &state.password, "password")
+	case path.Is("active"):
+		// Some clients send booleans as strings
+		var active any
+		if err := decodeSCIMValue(value, &active, "active"); err != nil {
+			return err
+		}
+		switch active := active.(type) {
+		case bool:
+			state.active = active
+		case string:
+			parsed, err := strconv.ParseBool(active)
+			if err != nil {
+				return newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "invalid value for active")
+			}
+			state.active = parsed
+		default:
+			return newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "invalid value for active")
+		}
+	case path.Is("emails"):
+		switch {
+		case strings.EqualFold(path.SubAttribute, "value"):
+			return decodeSCIMValue(value, &state.email, "emails")
+		case path.SubAttribute != "":
+			return nil
+		}
+		var emails []dtos.SCIMEmailDTO
+		if json.Unmarshal(value, &emails) != nil {
+			var email dtos.SCIMEmailDTO
+			if err := decodeSCIMValue(value, &email, "emails"); err != nil {
+				return err
+			}
+			emails = []dtos.SCIMEmailDTO{email}
+		}
+		if email := primaryEmail(emails); email != "" {
+			state.email = email
+		}
+	}
+	return nil
+}
+
+// remove removes an attribute of a user, which is only possible for the attributes GODS doesn't store.
+func (state *scimUser) remove(path *scim.Path) error {
+	if path.Is("userName") || path.Is("emails") || path.Is("password") || path.Is("active") {
+		return newSCIMError(http.StatusBadRequest, SCIMMutability, "%s can't be removed", path.Attribute)
+	}
+	return nil
+}
+
+// apply applies a PATCH operation to a group, ignoring the attributes GODS doesn't store.
+func (state *scimGroup) apply(op string, path *scim.Path, value json.RawMessage) error {
+	switch {
+	case path.Is("displayName"):
+		if op == "remove" {
+			return newSCIMError(http.StatusBadRequest, SCIMMutability, "displayName can't be removed")
+		}
+		return decodeSCIMValue(value, &state.displayName, "displayName")
+	case path.Is("members"):
+		if path.SubAttribute != "" {
+			return newSCIMError(http.StatusBadRequest, SCIMMutability, "the members can only be added or removed")
+		}
+		var members []uint
+		if op != "remove" || len(value) > 0 {
+			var err error
+			if members, err = decodeMembers(value); err != nil {
+				return err
+			}
+		}
+
+		switch op {
+		case "add":
+			for _, userID := range members {
+				if !slices.Contains(state.members, userID) {
+					state.members = append(state.members, userID)
+				}
+			}
+		case "replace":
+			state.members = members
+		case "remove":
+			state.members = slices.DeleteFunc(state.members, func(userID uint) bool {
+				if path.Filter != nil {
+					return path.Filter.Match(map[string]any{"value": strconv.FormatUint(uint64(userID), 10), "type": "User"})
+				}
+				// The members listed in the value are removed, every member when there's none
+				return len(value) == 0 || slices.Contains(members, userID)
+			})
+		}
+	}
+	return nil
+}
+
+// applyPatch calls apply for each operation of a PATCH request, with a lowercase operation name. The operations
+// without path are split into one operation per attribute of their value.
+func applyPatch(patchDTO *dtos.SCIMPatchDTO, apply func(op string, path *scim.Path, value json.RawMessage) error) error {
+	for _, operation := range patchDTO.Operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return newSCIMError(http.StatusBadRequest, SCIMInvalidSyntax, "unknown operation %q", operation.Op)
+		}
+
+		if operation.Path != "" {
+			path, err := scim.ParsePath(operation.Path)
+			if err != nil {
+				return newSCIMError(http.StatusBadRequest, SCIMInvalidPath, "%v", err)
+			}
+			if err := apply(op, path, operation.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if op == "remove" {
+			return newSCIMError(http.StatusBadRequest, SCIMNoTarget, "remove operations require a path")
+		}
+		var attributes map[string]json.RawMessage
+		if err := decodeSCIMValue(operation.Value, &attributes, "the operation"); err != nil {
+			return err
+		}
+		for name, value := range attributes {
+			path, err := scim.ParsePath(name)
+			if err != nil {
+				return newSCIMError(http.StatusBadRequest, SCIMInvalidPath, "%v", err)
+			}
+			if err := apply(op, path, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scimOperators map the SCIM comparison operators that can be applied by the database to the query ones. The
+// attributes stored by GODS aren't case-exact, so they all ignore case.
+var scimOperators = map[string]string{"eq": "~=", "co": "~", "sw": "^"}
+
+// scimListQuery parses a filter and translates it into the query of a page of resources, columns returning the
+// column of an attribute, or an empty string when it can't be filtered in the database. The query is nil when the
+// filter can't be translated, in which case the parsed filter has to be matched against every resource.
+func scimListQuery(filter string, startIndex int, count int, columns func(path scim.AttributePath) string) (*query.ListQuery, scim.Filter, error) {
+	listQuery := &query.ListQuery{Limit: max(count, 0), Offset: max(startIndex, 1) - 1}
+	if filter == "" {
+		return listQuery, nil, nil
+	}
+
+	parsed, err := scim.Parse(filter)
+	if err != nil {
+		return nil, nil, newSCIMError(http.StatusBadRequest, SCIMInvalidFilter, "%v", err)
+	}
+	conditions, ok := scim.Conditions(parsed)
+	if !ok {
+		return nil, parsed, nil
+	}
+	for _, condition := range conditions {
+		operator, supported := scimOperators[condition.Operator]
+		column := columns(condition.Path)
+		if !supported || column == "" {
+			return nil, parsed, nil
+		}
+		listQuery.Filters = append(listQuery.Filters, query.Filter{Column: column, Operator: operator, Value: condition.Value})
+	}
+
+	return listQuery, parsed, nil
+}
+
+// scimUserColumn returns the column of a user attribute that can be filtered in the database.
+func scimUserColumn(path scim.AttributePath) string {
+	switch {
+	case path.Is("userName") && path.SubAttribute == "":
+		return "name"
+	case path.Is("emails") && (path.SubAttribute == "" || strings.EqualFold(path.SubAttribute, "value")):
+		return "email"
+	default:
+		return ""
+	}
+}
+
+// scimGroupColumn returns the column of a group attribute that can be filtered in the database.
+func scimGroupColumn(path scim.AttributePath) string {
+	if path.Is("displayName") && path.SubAttribute == "" {
+		return "name"
+	}
+	return ""
+}
+
+// filterSCIMResources returns a page of the resources matching a filter, along with the number of matching resources.
+func filterSCIMResources[T any](resources []T, filter scim.Filter, startIndex int, count int) ([]T, int) {
+	if filter != nil {
+		resources = slices.DeleteFunc(resources, func(resource T) bool {
+			object, err := scim.ToResource(resource)
+			return err != nil || !filter.Match(object)
+		})
+	}
+
+	start := min(max(startIndex, 1)-1, len(resources))
+	end := min(start+max(count, 0), len(resources))
+	return resources[start:end], len(resources)
+}
+
+// toSCIMUser returns the SCIM representation of a user.
+func toSCIMUser(user *models.User) *dtos.SCIMUserDTO {
+	id := strconv.FormatUint(uint64(user.ID), 10)
+	active := !user.Disabled
+	resource := &dtos.SCIMUserDTO{
+		Schemas:  []string{dtos.SCIMSchemaUser},
+		ID:       id,
+		UserName: user.Name,
+		Active:   &active,
+		Meta:     &dtos.SCIMMetaDTO{ResourceType: "User", Created: &user.CreatedAt, LastModified: &user.UpdatedAt, Location: scimURL("/Users/" + id)},
+	}
+	if user.Email != "" {
+		resource.Emails = []dtos.SCIMEmailDTO{{Value: user.Email, Type: "work", Primary: true}}
+	}
+	for _, group := range user.Groups {
+		groupID := strconv.FormatUint(uint64(group.ID), 10)
+		resource.Groups = append(resource.Groups, dtos.SCIMMemberDTO{Value: groupID, Ref: scimURL("/Groups/" + groupID), Display: group.Name, Type: "direct"})
+	}
+	return resource
+}
+
+// toSCIMGroup returns the SCIM representation of a group.
+func toSCIMGroup(group *models.Group) *dtos.SCIMGroupDTO {
+	id := strconv.FormatUint(uint64(group.ID), 10)
+	resource := &dtos.SCIMGroupDTO{
+		Schemas:     []string{dtos.SCIMSchemaGroup},
+		ID:          id,
+		DisplayName: group.Name,
+		Members:     []dtos.SCIMMemberDTO{},
+		Meta:        &dtos.SCIMMetaDTO{ResourceType: "Group", Created: &group.CreatedAt, LastModified: &group.UpdatedAt, Location: scimURL("/Groups/" + id)},
+	}
+	for _, user := range group.Users {
+		userID := strconv.FormatUint(uint64(user.ID), 10)
+		resource.Members = append(resource.Members, dtos.SCIMMemberDTO{Value: userID, Ref: scimURL("/Users/" + userID), Display: user.Name, Type: "User"})
+	}
+	return resource
+}
+
+// primaryEmail returns the primary email address among the ones of a SCIM user, or the first one.
+func primaryEmail(emails []dtos.SCIMEmailDTO) string {
+	for _, email := range emails {
+		if email.Primary && email.Value != "" {
+			return email.Value
+		}
+	}
+	for _, email := range emails {
+		if email.Value != "" {
+			return email.Value
+		}
+	}
+	return ""
+}
+
+// decodeMembers decodes the members of a group from a PATCH value, a list of members or a single one.
+func decodeMembers(value json.RawMessage) ([]uint, error) {
+	var members []dtos.SCIMMemberDTO
+	if json.Unmarshal(value, &members) != nil {
+		var member dtos.SCIMMemberDTO
+		if err := decodeSCIMValue(value, &member, "members"); err != nil {
+			return nil, err
+		}
+		members = []dtos.SCIMMemberDTO{member}
+	}
+	return memberIDs(members)
+}
+
+// memberIDs returns the IDs of the users among the members of a group.
+func memberIDs(members []dtos.SCIMMemberDTO) ([]uint, error) {
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		if member.Type != "" && !strings.EqualFold(member.Type, "User") {
+			return nil, newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "only users can be members of groups")
+		}
+		id, err := strconv.ParseUint(member.Value, 10, 32)
+		if err != nil {
+			return nil, newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "invalid member %q", member.Value)
+		}
+		if !slices.Contains(ids, uint(id)) {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids, nil
+}
+
+// decodeSCIMValue decodes the value of an attribute.
+func decodeSCIMValue(value json.RawMessage, dest any, attribute string) error {
+	if len(value) == 0 || json.Unmarshal(value, dest) != nil {
+		return newSCIMError(http.StatusBadRequest, SCIMInvalidValue, "invalid value for %s", attribute)
+	}
+	return nil
+}
+
+// scimNotFound reports a missing resource as a SCIM error, the other errors being returned as is.
+func scimNotFound(err error, resourceType string, id uint) error {
+	var notFoundErr *NotFoundError
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.As(err, &notFoundErr) {
+		return newSCIMError(http.StatusNotFound, "", "%s %d not found", resourceType, id)
+	}
+	return err
+}
+
+// scimURL returns the URL of a path of the SCIM endpoints.
+func scimURL(path string) string {
+	return publicURL("/scim/v2"+path, nil)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the secret of the test vectors of RFC 4226 and RFC 6238, "12345678901234567890" base32-encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The codes are the last 6 digits of the RFC 6238 SHA-1 vectors, and the RFC 4226 HOTP vectors
+	tests := []struct {
+		name    string
+		counter int64
+		want    string
+	}{
+		{name: "hotp 0", counter: 0, want: "755224"},
+		{name: "hotp 1", counter: 1, want: "287082"},
+		{name: "hotp 2", counter: 2, want: "359152"},
+		{name: "hotp 9", counter: 9, want: "520489"},
+		{name: "totp 59", counter: 59 / totpPeriod, want: "287082"},
+		{name: "totp 1111111109", counter: 1111111109 / totpPeriod, want: "081804"},
+		{name: "totp 1111111111", counter: 1111111111 / totpPeriod, want: "050471"},
+		{name: "totp 1234567890", counter: 1234567890 / totpPeriod, want: "005924"},
+		{name: "totp 2000000000", counter: 2000000000 / totpPeriod, want: "279037"},
+		{name: "totp 20000000000", counter: 20000000000 / totpPeriod, want: "353130"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := totpCode(rfcSecret, test.counter)
+			if err != nil {
+				t.Fatalf("totpCode() error = %v", err)
+			}
+			if got != test.want {
+				t.Errorf("totpCode() = %s, want %s", got, test.want)
+			}
+		})
+	}
+
+	if _, err := totpCode("not base32!", 0); err == nil {
+		t.Error("totpCode() of an invalid secret succeeded")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	date := time.Unix(1111111111, 0)
+	current := date.Unix() / totpPeriod
+
+	tests := []struct {
+		name        string
+		secret      string
+		code        string
+		lastCounter int64
+		want        bool
+		wantCounter int64
+	}{
+		{name: "current step", secret: rfcSecret, code: "050471", want: true, wantCounter: current},
+		{name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "050471", want: true, wantCounter: current},
+		{name: "previous step", secret: rfcSecret, code: mustTOTPCode(t, current-1), want: true, wantCounter: current - 1},
+		{name: "next step", secret: rfcSecret, code: mustTOTPCode(t, current+1), want: true, wantCounter: current + 1},
+		{name: "outside the skew", secret: rfcSecret, code: mustTOTPCode(t, current-2)},
+		{name: "replayed step", secret: rfcSecret, code: "050471", lastCounter: current},
+		{name: "later step after a replay", secret: rfcSecret, code: mustTOTPCode(t, current+1), lastCounter: current, want: true, wantCounter: current + 1},
+		{name: "wrong code", secret: rfcSecret, code: "000000"},
+		{name: "too short", secret: rfcSecret, code: "05047"},
+		{name: "eight digits", secret: rfcSecret, code: "07081804"},
+		{name: "no secret", code: "050471"},
+		{name: "invalid secret", secret: "not base32!", code: "050471"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counter, ok := validateTOTP(test.secret, test.code, date, test.lastCounter)
+			if ok != test.want || counter != test.wantCounter {
+				t.Errorf("validateTOTP() = %d, %v, want %d, %v", counter, ok, test.wantCounter, test.want)
+			}
+		})
+	}
+}
+
+func TestTOTPSecretAndURI(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generateTOTPSecret() error = %v", err)
+	}
+	// 160 bits are 32 base32 characters, without padding
+	if len(secret) != 32 {
+		t.Errorf("generateTOTPSecret() = %q, want 32 characters", secret)
+	}
+	if _, err := totpCode(secret, 0); err != nil {
+		t.Errorf("totpCode() of a generated secret error = %v", err)
+	}
+
+	uri, err := url.Parse(totpURI("GODS", "alice@example.com", secret))
+	if err != nil {
+		t.Fatalf("totpURI() isn't a URI: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/GODS:alice@example.com" {
+		t.Errorf("totpURI() = %s, want otpauth://totp/GODS:alice@example.com", uri)
+	}
+	query := uri.Query()
+	if query.Get("secret") != secret || query.Get("issuer") != "GODS" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("totpURI() parameters = %v", query)
+	}
+}
+
+// mustTOTPCode computes the code of a time step of the RFC secret.
+func mustTOTPCode(t *testing.T, counter int64) string {
+	t.Helper()
+
+	code, err := totpCode(rfcSecret, counter)
+	if err != nil {
+		t.Fatalf("totpCode() error = %v", err)
+	}
+	return code
+}
@@ -1,99 +1,302 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"time"
 
+	"github.com/Nokeni/GODS/internal/passhash"
 	"github.com/Nokeni/GODS/internal/web/api/models"
 	"github.com/Nokeni/GODS/internal/web/api/repositories"
 	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/common/query"
 )
 
 // UserService defines the methods for performing business operations on Users.
 type UserService interface {
 	Get(id uint) (*models.User, error)
-	GetAll() ([]*models.User, error)
-	Create(userDTO *dtos.CreateUserDTO) (*models.User, error)
-	Update(user *models.User, userDTO *dtos.UpdateUserDTO) error
-	Delete(id uint) error
+	GetAll(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error)
+	Create(ctx context.Context, userDTO *dtos.CreateUserDTO) (*models.User, error)
+	CreateServiceAccount(ctx context.Context, serviceAccountDTO *dtos.CreateServiceAccountDTO) (*models.User, error)
+	Update(ctx context.Context, user *models.User, userDTO *dtos.UpdateUserDTO) error
+	ChangePassword(ctx context.Context, user *models.User, changePasswordDTO *dtos.ChangePasswordDTO) error
+	Delete(ctx context.Context, id uint) error
+	GetAllDeleted(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error)
+	Restore(ctx context.Context, id uint) (*models.User, error)
+	Purge(ctx context.Context, id uint) error
+	PurgeDeletedBefore(ctx context.Context, date time.Time) (int, error)
 }
 
+// ErrDeletedNameConflict is returned when a user or a group is given the name of a deleted one: the name stays taken
+// until the deleted record is restored or purged.
+var ErrDeletedNameConflict error = NewConflictError(CodeDeletedNameConflict, "name belongs to a deleted record")
+
 // UserServiceImplementation is an implementation of the UserService.
 type UserServiceImplementation struct {
-	userRepository repositories.UserRepository
+	userRepository         repositories.UserRepository
+	refreshTokenRepository repositories.RefreshTokenRepository
+	apiKeyRepository       repositories.APIKeyRepository
+	passwordPolicyService  PasswordPolicyService
+	auditService           AuditService
+	webhookService         WebhookService
 }
 
-func NewUserService(userRepository repositories.UserRepository) UserService {
-	return &UserServiceImplementation{userRepository: userRepository}
+func NewUserService(
+	userRepository repositories.UserRepository,
+	refreshTokenRepository repositories.RefreshTokenRepository,
+	apiKeyRepository repositories.APIKeyRepository,
+	passwordPolicyService PasswordPolicyService,
+	auditService AuditService,
+	webhookService WebhookService,
+) UserService {
+	return &UserServiceImplementation{
+		userRepository:         userRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		apiKeyRepository:       apiKeyRepository,
+		passwordPolicyService:  passwordPolicyService,
+		auditService:           auditService,
+		webhookService:         webhookService,
+	}
 }
 
 // Get retrieves a user by ID.
 func (service *UserServiceImplementation) Get(id uint) (*models.User, error) {
-	return service.userRepository.Get(id)
+	user, err := service.userRepository.Get(id)
+	if err != nil {
+		return nil, notFound(err, CodeUserNotFound, "user %d not found", id)
+	}
+	return user, nil
 }
 
-// GetAll retrieves all users.
-func (service *UserServiceImplementation) GetAll() ([]*models.User, error) {
-	return service.userRepository.GetAll()
+// GetAll retrieves a page of users.
+func (service *UserServiceImplementation) GetAll(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error) {
+	return service.userRepository.GetAll(listQuery)
 }
 
 // Create adds a new user.
-func (service *UserServiceImplementation) Create(userDTO *dtos.CreateUserDTO) (*models.User, error) {
+func (service *UserServiceImplementation) Create(ctx context.Context, userDTO *dtos.CreateUserDTO) (*models.User, error) {
 	// Check if the user already exists
 	user, err := service.userRepository.GetByName(userDTO.Name)
 	if err == nil {
-		return user, errors.New("user already exists")
+		return user, NewConflictError(CodeUserExists, "user already exists")
+	}
+	if err := service.checkDeletedName(userDTO.Name); err != nil {
+		return nil, err
 	}
 
-	// Check the provided password strength
-	if err := models.ValidatePasswordStrength(userDTO.Password); err != nil {
+	// Create the user model, with a password meeting the policy
+	user = &models.User{
+		Name:  userDTO.Name,
+		Email: userDTO.Email,
+	}
+	if err := service.passwordPolicyService.SetPassword(user, userDTO.Password); err != nil {
 		return nil, err
 	}
 
-	hashedPassword, err := models.HashPassword(userDTO.Password)
-	if err != nil {
+	if err := service.userRepository.Create(user); err != nil {
+		return user, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserCreate, TargetType: "user", TargetID: &user.ID}, nil, user)
+	service.webhookService.Publish(models.WebhookUserCreated, user)
+
+	return user, nil
+}
+
+// CreateServiceAccount creates a non-human user, which has no password and authenticates with API keys.
+func (service *UserServiceImplementation) CreateServiceAccount(ctx context.Context, serviceAccountDTO *dtos.CreateServiceAccountDTO) (*models.User, error) {
+	if _, err := service.userRepository.GetByName(serviceAccountDTO.Name); err == nil {
+		return nil, NewConflictError(CodeUserExists, "user already exists")
+	}
+	if err := service.checkDeletedName(serviceAccountDTO.Name); err != nil {
 		return nil, err
 	}
 
-	// Create the user model
-	user = &models.User{
-		Name:     userDTO.Name,
-		Email:    userDTO.Email,
-		Password: hashedPassword,
+	user := &models.User{
+		Name:           serviceAccountDTO.Name,
+		Email:          serviceAccountDTO.Email,
+		ServiceAccount: true,
 	}
 
-	err = service.userRepository.Create(user)
+	if err := service.userRepository.Create(user); err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserCreate, TargetType: "user", TargetID: &user.ID}, nil, user)
+	service.webhookService.Publish(models.WebhookUserCreated, user)
 
-	return user, err
+	return user, nil
 }
 
 // Update modifies an existing user.
-func (service *UserServiceImplementation) Update(user *models.User, userDTO *dtos.UpdateUserDTO) error {
+func (service *UserServiceImplementation) Update(ctx context.Context, user *models.User, userDTO *dtos.UpdateUserDTO) error {
+	before := *user
+
 	// Update user details depending on provided DTO fields
-	if userDTO.Name != "" {
+	if userDTO.Name != "" && userDTO.Name != user.Name {
+		if _, err := service.userRepository.GetByName(userDTO.Name); err == nil {
+			return NewConflictError(CodeUserExists, "user already exists")
+		}
+		if err := service.checkDeletedName(userDTO.Name); err != nil {
+			return err
+		}
 		user.Name = userDTO.Name
 	}
 
-	if userDTO.Email != "" {
+	// A new email has to be verified again
+	if userDTO.Email != "" && userDTO.Email != user.Email {
 		user.Email = userDTO.Email
+		user.EmailVerifiedAt = nil
 	}
 
 	if userDTO.Password != "" {
-		if err := models.ValidatePasswordStrength(userDTO.Password); err != nil {
+		if user.ServiceAccount {
+			return NewFieldValidationError(CodeServiceAccount, "password", "service accounts can't have a password")
+		}
+		if err := service.passwordPolicyService.SetPassword(user, userDTO.Password); err != nil {
 			return err
 		}
+	}
 
-		hashedPassword, err := models.HashPassword(userDTO.Password)
-		if err != nil {
+	// Disabling a user ends their sessions, which aren't brought back when they're enabled again
+	disabling := userDTO.Disabled != nil && *userDTO.Disabled && !user.Disabled
+	if userDTO.Disabled != nil {
+		user.Disabled = *userDTO.Disabled
+	}
+	if disabling {
+		user.TokenVersion++
+	}
+
+	if err := service.userRepository.Update(user); err != nil {
+		return err
+	}
+	if disabling {
+		if err := service.refreshTokenRepository.RevokeUserTokens(user.ID); err != nil {
 			return err
 		}
+	}
 
-		user.Password = hashedPassword
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserUpdate, TargetType: "user", TargetID: &user.ID}, &before, user)
+	service.webhookService.Publish(models.WebhookUserUpdated, user)
+	if userDTO.Password != "" {
+		service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserPasswordChange, TargetType: "user", TargetID: &user.ID}, nil, nil)
 	}
 
-	return service.userRepository.Update(user)
+	return nil
 }
 
-// Delete removes a user by ID.
-func (service *UserServiceImplementation) Delete(id uint) error {
-	return service.userRepository.Delete(id)
+// ChangePassword modifies a user's password after checking their current one.
+func (service *UserServiceImplementation) ChangePassword(ctx context.Context, user *models.User, changePasswordDTO *dtos.ChangePasswordDTO) error {
+	// Check the current password
+	if matches, _ := passhash.Verify(user.Password, changePasswordDTO.CurrentPassword); !matches {
+		return NewFieldValidationError(CodeInvalidPassword, "current_password", "invalid current password")
+	}
+
+	// Check if passwords match
+	if changePasswordDTO.Password != changePasswordDTO.PasswordConfirmation {
+		return NewFieldValidationError(CodePasswordsMismatch, "password_confirmation", "passwords doesn't match")
+	}
+
+	return service.Update(ctx, user, &dtos.UpdateUserDTO{Password: changePasswordDTO.Password})
+}
+
+// Delete removes a user by ID. Their sessions end and their API keys are revoked, they aren't brought back when
+// the user is restored.
+func (service *UserServiceImplementation) Delete(ctx context.Context, id uint) error {
+	user, err := service.Get(id)
+	if err != nil {
+		return err
+	}
+
+	user.TokenVersion++
+	if err := service.userRepository.Update(user); err != nil {
+		return err
+	}
+	if err := service.refreshTokenRepository.RevokeUserTokens(user.ID); err != nil {
+		return err
+	}
+	if err := service.apiKeyRepository.DeleteUserKeys(user.ID); err != nil {
+		return err
+	}
+
+	if err := service.userRepository.Delete(id); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserDelete, TargetType: "user", TargetID: &user.ID}, user, nil)
+	service.webhookService.Publish(models.WebhookUserDeleted, user)
+
+	return nil
+}
+
+// GetAllDeleted retrieves a page of deleted users.
+func (service *UserServiceImplementation) GetAllDeleted(listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error) {
+	return service.userRepository.GetAllDeleted(listQuery)
+}
+
+// Restore undeletes a deleted user, along with their memberships.
+func (service *UserServiceImplementation) Restore(ctx context.Context, id uint) (*models.User, error) {
+	user, err := service.userRepository.GetDeleted(id)
+	if err != nil {
+		return nil, notFound(err, CodeUserNotFound, "deleted user %d not found", id)
+	}
+
+	if err := service.userRepository.Restore(user.ID); err != nil {
+		return nil, err
+	}
+
+	restored, err := service.userRepository.Get(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserRestore, TargetType: "user", TargetID: &restored.ID, Details: restored.Name}, nil, nil)
+	service.webhookService.Publish(models.WebhookUserRestored, restored)
+
+	return restored, nil
+}
+
+// Purge permanently removes a deleted user. Users have to be deleted before being purged.
+func (service *UserServiceImplementation) Purge(ctx context.Context, id uint) error {
+	user, err := service.userRepository.GetDeleted(id)
+	if err != nil {
+		return notFound(err, CodeUserNotFound, "deleted user %d not found", id)
+	}
+
+	return service.purge(ctx, user, "")
+}
+
+// PurgeDeletedBefore permanently removes the users deleted before a date, and returns how many were purged.
+func (service *UserServiceImplementation) PurgeDeletedBefore(ctx context.Context, date time.Time) (int, error) {
+	users, err := service.userRepository.GetDeletedBefore(date)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, user := range users {
+		if err := service.purge(ctx, user, "retention"); err != nil {
+			return i, err
+		}
+	}
+
+	return len(users), nil
+}
+
+// purge permanently removes a deleted user and records it, with the reason of the purge if it wasn't requested.
+func (service *UserServiceImplementation) purge(ctx context.Context, user *models.User, reason string) error {
+	if err := service.userRepository.Purge(user.ID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserPurge, TargetType: "user", TargetID: &user.ID, Details: reason}, user, nil)
+
+	return nil
+}
+
+// checkDeletedName returns ErrDeletedNameConflict if a deleted user has the name.
+func (service *UserServiceImplementation) checkDeletedName(name string) error {
+	if user, err := service.userRepository.GetDeletedByName(name); err == nil {
+		return fmt.Errorf("%w: restore or purge the deleted user %d first", ErrDeletedNameConflict, user.ID)
+	}
+	return nil
 }
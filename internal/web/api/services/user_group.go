@@ -1,43 +1,123 @@
 package services
 
 import (
+	"context"
+	"slices"
+
 	"github.com/Nokeni/GODS/internal/web/api/models"
 	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
 )
 
 // UserGroupService defines the methods for performing business operations on Groups.
 type UserGroupService interface {
-	AddUserToGroup(userID uint, groupID uint) error
-	RemoveUserFromGroup(userID uint, groupID uint) error
-	GetUserGroups(userID uint) ([]*models.Group, error)
-	GetGroupUsers(groupID uint) ([]*models.User, error)
+	AddUserToGroup(ctx context.Context, userID uint, groupID uint) error
+	RemoveUserFromGroup(ctx context.Context, userID uint, groupID uint) error
+	GetUserGroups(userID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	GetGroupUsers(groupID uint, listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error)
+	AddGroupToGroup(ctx context.Context, subgroupID uint, groupID uint) error
+	RemoveGroupFromGroup(ctx context.Context, subgroupID uint, groupID uint) error
+	GetSubgroups(groupID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	GetEffectiveUserGroups(userID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error)
+	GetEffectiveGroupUsers(groupID uint, listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error)
 }
 
+// ErrGroupCycle is returned when nesting a group would make it contain itself.
+var ErrGroupCycle error = NewConflictError(CodeGroupCycle, "a group can't be nested in itself or in one of its subgroups")
+
 // UserGroupServiceImplementation is an implementation of the GroupService.
 type UserGroupServiceImplementation struct {
 	userGroupRepository repositories.UserGroupRepository
+	auditService        AuditService
+	webhookService      WebhookService
 }
 
-func NewUserGroupService(userGroupRepository repositories.UserGroupRepository) UserGroupService {
-	return &UserGroupServiceImplementation{userGroupRepository: userGroupRepository}
+func NewUserGroupService(userGroupRepository repositories.UserGroupRepository, auditService AuditService, webhookService WebhookService) UserGroupService {
+	return &UserGroupServiceImplementation{
+		userGroupRepository: userGroupRepository,
+		auditService:        auditService,
+		webhookService:      webhookService,
+	}
 }
 
 // AddUserToGroup adds a user to a group.
-func (service *UserGroupServiceImplementation) AddUserToGroup(userID uint, groupID uint) error {
-	return service.userGroupRepository.AddUserToGroup(userID, groupID)
+func (service *UserGroupServiceImplementation) AddUserToGroup(ctx context.Context, userID uint, groupID uint) error {
+	if err := service.userGroupRepository.AddUserToGroup(userID, groupID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditMembershipAdd, TargetType: "group", TargetID: &groupID}, nil, map[string]uint{"UserID": userID})
+	service.webhookService.Publish(models.WebhookMembershipAdded, map[string]uint{"group_id": groupID, "user_id": userID})
+
+	return nil
 }
 
 // RemoveUserFromGroup removes a user from a group.
-func (service *UserGroupServiceImplementation) RemoveUserFromGroup(userID uint, groupID uint) error {
-	return service.userGroupRepository.RemoveUserFromGroup(userID, groupID)
+func (service *UserGroupServiceImplementation) RemoveUserFromGroup(ctx context.Context, userID uint, groupID uint) error {
+	if err := service.userGroupRepository.RemoveUserFromGroup(userID, groupID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditMembershipRemove, TargetType: "group", TargetID: &groupID}, map[string]uint{"UserID": userID}, nil)
+	service.webhookService.Publish(models.WebhookMembershipRemoved, map[string]uint{"group_id": groupID, "user_id": userID})
+
+	return nil
+}
+
+// GetUserGroups retrieves a page of the groups of a user.
+func (service *UserGroupServiceImplementation) GetUserGroups(userID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	return service.userGroupRepository.GetUserGroups(userID, listQuery)
+}
+
+// GetGroupUsers retrieves a page of the users of a group.
+func (service *UserGroupServiceImplementation) GetGroupUsers(groupID uint, listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error) {
+	return service.userGroupRepository.GetGroupUsers(groupID, listQuery)
+}
+
+// AddGroupToGroup nests a group in another one, making the members of the subgroup members of the group too.
+func (service *UserGroupServiceImplementation) AddGroupToGroup(ctx context.Context, subgroupID uint, groupID uint) error {
+	// The group can't be nested in a group it already contains
+	descendantIDs, err := service.userGroupRepository.GetDescendantGroupIDs(subgroupID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(descendantIDs, groupID) {
+		return ErrGroupCycle
+	}
+
+	if err := service.userGroupRepository.AddGroupToGroup(subgroupID, groupID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditMembershipAdd, TargetType: "group", TargetID: &groupID}, nil, map[string]uint{"SubgroupID": subgroupID})
+	service.webhookService.Publish(models.WebhookMembershipAdded, map[string]uint{"group_id": groupID, "subgroup_id": subgroupID})
+
+	return nil
+}
+
+// RemoveGroupFromGroup removes a group nested in another one.
+func (service *UserGroupServiceImplementation) RemoveGroupFromGroup(ctx context.Context, subgroupID uint, groupID uint) error {
+	if err := service.userGroupRepository.RemoveGroupFromGroup(subgroupID, groupID); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditMembershipRemove, TargetType: "group", TargetID: &groupID}, map[string]uint{"SubgroupID": subgroupID}, nil)
+	service.webhookService.Publish(models.WebhookMembershipRemoved, map[string]uint{"group_id": groupID, "subgroup_id": subgroupID})
+
+	return nil
+}
+
+// GetSubgroups retrieves a page of the groups directly nested in a group.
+func (service *UserGroupServiceImplementation) GetSubgroups(groupID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	return service.userGroupRepository.GetSubgroups(groupID, listQuery)
 }
 
-// GetUserGroups retrieves all groups for a user.
-func (service *UserGroupServiceImplementation) GetUserGroups(userID uint) ([]*models.Group, error) {
-	return service.userGroupRepository.GetUserGroups(userID)
+// GetEffectiveUserGroups retrieves a page of the groups a user belongs to, directly or through nested groups.
+func (service *UserGroupServiceImplementation) GetEffectiveUserGroups(userID uint, listQuery *query.ListQuery) ([]*models.Group, *query.PageInfo, error) {
+	return service.userGroupRepository.GetEffectiveUserGroups(userID, listQuery)
 }
 
-// GetGroupUsers retrieves all users for a group.
-func (service *UserGroupServiceImplementation) GetGroupUsers(groupID uint) ([]*models.User, error) {
-	return service.userGroupRepository.GetGroupUsers(groupID)
+// GetEffectiveGroupUsers retrieves a page of the users belonging to a group, directly or through nested groups.
+func (service *UserGroupServiceImplementation) GetEffectiveGroupUsers(groupID uint, listQuery *query.ListQuery) ([]*models.User, *query.PageInfo, error) {
+	return service.userGroupRepository.GetEffectiveGroupUsers(groupID, listQuery)
 }
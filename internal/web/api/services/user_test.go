@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"gorm.io/gorm"
+)
+
+func TestUserDeleteRevokesTokens(t *testing.T) {
+	database := dbtest.Open(t)
+	authService, user := newTestAuthService(t, database)
+	refreshTokenRepository := repositories.NewRefreshTokenRepository(database)
+	apiKeyRepository := repositories.NewAPIKeyRepository(database)
+	webhookService := NewWebhookService(repositories.NewWebhookRepository(database), repositories.NewWebhookDeliveryRepository(database), authService.auditService)
+	service := NewUserService(authService.userRepository, refreshTokenRepository, apiKeyRepository, nil, authService.auditService, webhookService)
+
+	accessToken, err := authService.generateJWTToken(user, time.Hour)
+	if err != nil {
+		t.Fatalf("generateJWTToken() error = %v", err)
+	}
+	if err := refreshTokenRepository.Create(&models.RefreshToken{UserID: user.ID, FamilyID: "family", TokenHash: "refresh", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := apiKeyRepository.Create(&models.APIKey{UserID: user.ID, Name: "ci", Prefix: "gods_abc", TokenHash: "key", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := service.Delete(context.Background(), user.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	restored, err := service.Restore(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	// The tokens issued before the deletion don't come back with the user
+	if claims, err := authService.ValidateToken(accessToken); err == nil {
+		t.Errorf("ValidateToken() after a restoration = %v, want an error", claims)
+	}
+	if refreshToken, err := refreshTokenRepository.GetByHash("refresh"); err != nil || refreshToken.RevokedAt == nil {
+		t.Errorf("GetByHash() after a restoration = %v, %v, want a revoked refresh token", refreshToken, err)
+	}
+	if _, err := apiKeyRepository.GetByHash("key"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetByHash() of an API key after a restoration error = %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	// The restored user logs in again
+	accessToken, err = authService.generateJWTToken(restored, time.Hour)
+	if err != nil {
+		t.Fatalf("generateJWTToken() error = %v", err)
+	}
+	if _, err := authService.ValidateToken(accessToken); err != nil {
+		t.Errorf("ValidateToken() of a new token error = %v", err)
+	}
+}
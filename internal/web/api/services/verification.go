@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/mail"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/spf13/viper"
+)
+
+// ErrEmailNotVerified is returned by the login of unverified users when a verified email is required.
+var ErrEmailNotVerified error = NewForbiddenError(CodeEmailNotVerified, "email address isn't verified")
+
+// ErrInvalidVerificationToken is returned when a token sent by email doesn't exist, has expired or has been used.
+var ErrInvalidVerificationToken error = NewFieldValidationError(CodeInvalidVerifyToken, "token", "invalid or expired token")
+
+// VerificationService defines the methods for performing the business operations relying on emails sent to users.
+type VerificationService interface {
+	SendEmailVerification(ctx context.Context, userID uint) error
+	ResendEmailVerification(ctx context.Context, emailDTO *dtos.EmailDTO) error
+	VerifyEmail(ctx context.Context, verifyEmailDTO *dtos.VerifyEmailDTO) error
+	ForgotPassword(ctx context.Context, emailDTO *dtos.EmailDTO) error
+	ResetPassword(ctx context.Context, resetPasswordDTO *dtos.ResetPasswordDTO) error
+}
+
+// VerificationServiceImplementation is an implementation of the VerificationService.
+type VerificationServiceImplementation struct {
+	userRepository              repositories.UserRepository
+	verificationTokenRepository repositories.VerificationTokenRepository
+	refreshTokenRepository      repositories.RefreshTokenRepository
+	lockoutService              LockoutService
+	passwordPolicyService       PasswordPolicyService
+	auditService                AuditService
+	mailer                      mail.Mailer
+}
+
+func NewVerificationService(
+	userRepository repositories.UserRepository,
+	verificationTokenRepository repositories.VerificationTokenRepository,
+	refreshTokenRepository repositories.RefreshTokenRepository,
+	lockoutService LockoutService,
+	passwordPolicyService PasswordPolicyService,
+	auditService AuditService,
+	mailer mail.Mailer,
+) VerificationService {
+	return &VerificationServiceImplementation{
+		userRepository:              userRepository,
+		verificationTokenRepository: verificationTokenRepository,
+		refreshTokenRepository:      refreshTokenRepository,
+		lockoutService:              lockoutService,
+		passwordPolicyService:       passwordPolicyService,
+		auditService:                auditService,
+		mailer:                      mailer,
+	}
+}
+
+// SendEmailVerification sends a verification link to the email of a user, superseding the previous ones.
+func (service *VerificationServiceImplementation) SendEmailVerification(ctx context.Context, userID uint) error {
+	user, err := service.userRepository.Get(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return NewConflictError(CodeEmailVerified, "email address is already verified")
+	}
+
+	token, err := service.issueToken(user, models.VerificationPurposeEmail, viper.GetDuration("EMAIL_VERIFICATION_TOKEN_TTL"))
+	if err != nil {
+		return err
+	}
+
+	return service.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease verify your email address by opening the following link:\n%s\n\nThe link expires in %s.",
+			user.Name, publicURL("/api/auth/verify-email", url.Values{"token": {token}}), viper.GetDuration("EMAIL_VERIFICATION_TOKEN_TTL")),
+	})
+}
+
+// ResendEmailVerification sends a new verification link to the unverified users having the given email.
+// It doesn't tell whether such users exist, and the emails requested from an IP are limited.
+func (service *VerificationServiceImplementation) ResendEmailVerification(ctx context.Context, emailDTO *dtos.EmailDTO) error {
+	users, err := service.requestEmails(ctx, emailDTO.Email)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.EmailVerifiedAt != nil {
+			continue
+		}
+		if err := service.SendEmailVerification(ctx, user.ID); err != nil {
+			log.Printf("failed to send the verification email of user %d: %v", user.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email of a user as verified with the token sent to it.
+func (service *VerificationServiceImplementation) VerifyEmail(ctx context.Context, verifyEmailDTO *dtos.VerifyEmailDTO) error {
+	verificationToken, user, err := service.useToken(models.VerificationPurposeEmail, verifyEmailDTO.Token)
+	if err != nil {
+		return err
+	}
+
+	before := *user
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := service.userRepository.Update(user); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditUserEmailVerify, TargetType: "user", TargetID: &user.ID, Details: verificationToken.Email}, &before, user)
+
+	return nil
+}
+
+// ForgotPassword sends a password reset link to the users having the given email.
+// It doesn't tell whether such users exist, and the emails requested from an IP are limited.
+func (service *VerificationServiceImplementation) ForgotPassword(ctx context.Context, emailDTO *dtos.EmailDTO) error {
+	users, err := service.requestEmails(ctx, emailDTO.Email)
+	if err != nil {
+		return err
+	}
+
+	ttl := viper.GetDuration("PASSWORD_RESET_TOKEN_TTL")
+	for _, user := range users {
+		token, err := service.issueToken(user, models.VerificationPurposePasswordReset, ttl)
+		if err != nil {
+			return err
+		}
+
+		if err := service.mailer.Send(&mail.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hello %s,\n\nA password reset has been requested for your account. Use the following token to choose a new password:\n%s\n\nThe token expires in %s. If you didn't request it, you can ignore this email.",
+				user.Name, token, ttl),
+		}); err != nil {
+			log.Printf("failed to send the password reset email of user %d: %v", user.ID, err)
+			continue
+		}
+
+		service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthPasswordForgot, TargetType: "user", TargetID: &user.ID}, nil, nil)
+	}
+
+	return nil
+}
+
+// ResetPassword replaces the password of a user with the token sent to them, and revokes their sessions.
+func (service *VerificationServiceImplementation) ResetPassword(ctx context.Context, resetPasswordDTO *dtos.ResetPasswordDTO) error {
+	// Check if passwords match
+	if resetPasswordDTO.Password != resetPasswordDTO.PasswordConfirmation {
+		return NewFieldValidationError(CodePasswordsMismatch, "password_confirmation", "passwords doesn't match")
+	}
+
+	// The token is only used once the password meets the policy, so that the user can choose another one
+	verificationToken, user, err := service.getToken(models.VerificationPurposePasswordReset, resetPasswordDTO.Token)
+	if err != nil {
+		return err
+	}
+	if err := service.passwordPolicyService.Check(user, resetPasswordDTO.Password); err != nil {
+		return err
+	}
+	if err := service.markTokenUsed(verificationToken); err != nil {
+		return err
+	}
+	if err := service.passwordPolicyService.SetPassword(user, resetPasswordDTO.Password); err != nil {
+		return err
+	}
+
+	// Receiving the token proves the user owns their email
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	// Bumping the version invalidates the access tokens issued with the previous password
+	user.TokenVersion++
+	if err := service.userRepository.Update(user); err != nil {
+		return err
+	}
+
+	if err := service.refreshTokenRepository.RevokeUserTokens(user.ID); err != nil {
+		return err
+	}
+	if err := service.verificationTokenRepository.InvalidateUserTokens(user.ID, models.VerificationPurposePasswordReset); err != nil {
+		return err
+	}
+	if err := service.lockoutService.RegisterSuccess(AccountLockoutKey(user.Name)); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditAuthPasswordReset, TargetType: "user", TargetID: &user.ID}, nil, nil)
+
+	return nil
+}
+
+// requestEmails counts an email request against the client IP and retrieves the users having the given email.
+func (service *VerificationServiceImplementation) requestEmails(ctx context.Context, email string) ([]*models.User, error) {
+	emailKey := EmailLockoutKey(ActorFromContext(ctx).IP)
+	if err := service.lockoutService.Check(emailKey); err != nil {
+		return nil, err
+	}
+	if err := service.lockoutService.RegisterFailure(ctx, emailKey); err != nil {
+		return nil, err
+	}
+
+	return service.userRepository.GetAllByEmail(email)
+}
+
+// issueToken generates a token sent by email to a user, superseding their previous tokens of the same purpose.
+// Only the hash of the token is persisted.
+func (service *VerificationServiceImplementation) issueToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	if err := service.verificationTokenRepository.InvalidateUserTokens(user.ID, purpose); err != nil {
+		return "", err
+	}
+
+	token, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.verificationTokenRepository.Create(&models.VerificationToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// useToken consumes a token and returns it along with its user.
+// Tokens sent to an email the user no longer has are refused.
+func (service *VerificationServiceImplementation) useToken(purpose string, token string) (*models.VerificationToken, *models.User, error) {
+	verificationToken, user, err := service.getToken(purpose, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := service.markTokenUsed(verificationToken); err != nil {
+		return nil, nil, err
+	}
+
+	return verificationToken, user, nil
+}
+
+// getToken checks a token sent by email and returns it along with its user, without using it.
+func (service *VerificationServiceImplementation) getToken(purpose string, token string) (*models.VerificationToken, *models.User, error) {
+	verificationToken, err := service.verificationTokenRepository.GetByHash(purpose, hashToken(token))
+	if err != nil || verificationToken.UsedAt != nil || time.Now().After(verificationToken.ExpiresAt) {
+		return nil, nil, ErrInvalidVerificationToken
+	}
+
+	user, err := service.userRepository.Get(verificationToken.UserID)
+	if err != nil || user.Email != verificationToken.Email {
+		return nil, nil, ErrInvalidVerificationToken
+	}
+
+	return verificationToken, user, nil
+}
+
+// markTokenUsed records the use of a token, which can't be used again. A token used in the meantime, by a
+// concurrent request, is refused.
+func (service *VerificationServiceImplementation) markTokenUsed(verificationToken *models.VerificationToken) error {
+	now := time.Now()
+	used, err := service.verificationTokenRepository.MarkUsed(verificationToken.ID, now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidVerificationToken
+	}
+	verificationToken.UsedAt = &now
+	return nil
+}
+
+// publicURL returns the URL of a path of the server, as reachable by the users.
+func publicURL(path string, parameters url.Values) string {
+	baseURL := viper.GetString("WEB_BASE_URL")
+	if baseURL == "" {
+		scheme := "http"
+		if viper.GetString("WEB_TLS_CERT_FILE") != "" {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + viper.GetString("WEB_DOMAIN") + ":" + viper.GetString("WEB_PORT")
+	}
+
+	publicURL := baseURL + path
+	if len(parameters) > 0 {
+		publicURL += "?" + parameters.Encode()
+	}
+	return publicURL
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// ErrWebhookDisabled is returned when sending an event to a disabled webhook.
+var ErrWebhookDisabled error = NewConflictError(CodeWebhookDisabled, "webhook is disabled")
+
+// webhookClaimLimit is the maximum number of deliveries attempted at once.
+const webhookClaimLimit = 50
+
+// webhookResponseLimit is the number of bytes of the responses kept in the delivery history.
+const webhookResponseLimit = 1024
+
+// WebhookService defines the methods for managing the webhooks and delivering the identity lifecycle events to them.
+type WebhookService interface {
+	Get(id uint) (*models.Webhook, error)
+	GetAll(listQuery *query.ListQuery) ([]*models.Webhook, *query.PageInfo, error)
+	Create(ctx context.Context, webhookDTO *dtos.CreateWebhookDTO) (*dtos.WebhookSecretDTO, error)
+	Update(ctx context.Context, webhook *models.Webhook, webhookDTO *dtos.UpdateWebhookDTO) error
+	RotateSecret(ctx context.Context, id uint) (*dtos.WebhookSecretDTO, error)
+	Delete(ctx context.Context, id uint) error
+	GetDeliveries(webhookID uint, listQuery *query.ListQuery) ([]*models.WebhookDelivery, *query.PageInfo, error)
+	Redeliver(ctx context.Context, webhookID uint, deliveryID uint) (*models.WebhookDelivery, error)
+	Ping(id uint) (*models.WebhookDelivery, error)
+	Publish(event string, data any)
+	Run(ctx context.Context)
+}
+
+// WebhookServiceImplementation is an implementation of the WebhookService.
+type WebhookServiceImplementation struct {
+	webhookRepository         repositories.WebhookRepository
+	webhookDeliveryRepository repositories.WebhookDeliveryRepository
+	auditService              AuditService
+	client                    *http.Client
+	wake                      chan struct{} // wake signals the worker that deliveries have been queued.
+}
+
+func NewWebhookService(
+	webhookRepository repositories.WebhookRepository,
+	webhookDeliveryRepository repositories.WebhookDeliveryRepository,
+	auditService AuditService,
+) WebhookService {
+	return &WebhookServiceImplementation{
+		webhookRepository:         webhookRepository,
+		webhookDeliveryRepository: webhookDeliveryRepository,
+		auditService:              auditService,
+		client: &http.Client{
+			Timeout: viper.GetDuration("WEBHOOK_TIMEOUT"),
+			// Redirects aren't followed, the signed payload must reach the registered URL
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Get retrieves a webhook by ID.
+func (service *WebhookServiceImplementation) Get(id uint) (*models.Webhook, error) {
+	webhook, err := service.webhookRepository.Get(id)
+	if err != nil {
+		return nil, notFound(err, CodeWebhookNotFound, "webhook %d not found", id)
+	}
+	return webhook, nil
+}
+
+// GetAll retrieves a page of webhooks.
+func (service *WebhookServiceImplementation) GetAll(listQuery *query.ListQuery) ([]*models.Webhook, *query.PageInfo, error) {
+	return service.webhookRepository.GetAll(listQuery)
+}
+
+// Create registers a new webhook, along with the secret signing its payloads, returned only once.
+func (service *WebhookServiceImplementation) Create(ctx context.Context, webhookDTO *dtos.CreateWebhookDTO) (*dtos.WebhookSecretDTO, error) {
+	secret, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &models.Webhook{
+		Name:   webhookDTO.Name,
+		URL:    webhookDTO.URL,
+		Secret: secret,
+		Events: webhookDTO.Events,
+	}
+	if err := validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+
+	if err := service.webhookRepository.Create(webhook); err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditWebhookCreate, TargetType: "webhook", TargetID: &webhook.ID}, nil, webhook)
+
+	return &dtos.WebhookSecretDTO{Webhook: webhook, Secret: secret}, nil
+}
+
+// Update modifies an existing webhook.
+func (service *WebhookServiceImplementation) Update(ctx context.Context, webhook *models.Webhook, webhookDTO *dtos.UpdateWebhookDTO) error {
+	before := *webhook
+
+	// Update webhook details depending on provided DTO fields
+	if webhookDTO.Name != "" {
+		webhook.Name = webhookDTO.Name
+	}
+	if webhookDTO.URL != "" {
+		webhook.URL = webhookDTO.URL
+	}
+	if len(webhookDTO.Events) > 0 {
+		webhook.Events = webhookDTO.Events
+	}
+	if webhookDTO.Disabled != nil {
+		webhook.Disabled = *webhookDTO.Disabled
+	}
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+
+	if err := service.webhookRepository.Update(webhook); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditWebhookUpdate, TargetType: "webhook", TargetID: &webhook.ID}, &before, webhook)
+
+	return nil
+}
+
+// RotateSecret replaces the secret of a webhook, the payloads sent from then on are signed with the new one.
+func (service *WebhookServiceImplementation) RotateSecret(ctx context.Context, id uint) (*dtos.WebhookSecretDTO, error) {
+	webhook, err := service.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+	if err := service.webhookRepository.Update(webhook); err != nil {
+		return nil, err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditWebhookSecretRotate, TargetType: "webhook", TargetID: &webhook.ID}, nil, nil)
+
+	return &dtos.WebhookSecretDTO{Webhook: webhook, Secret: secret}, nil
+}
+
+// Delete removes a webhook, its pending deliveries fail on their next attempt.
+func (service *WebhookServiceImplementation) Delete(ctx context.Context, id uint) error {
+	webhook, err := service.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if err := service.webhookRepository.Delete(id); err != nil {
+		return err
+	}
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditWebhookDelete, TargetType: "webhook", TargetID: &webhook.ID}, webhook, nil)
+
+	return nil
+}
+
+// GetDeliveries retrieves a page of the deliveries of a webhook.
+func (service *WebhookServiceImplementation) GetDeliveries(webhookID uint, listQuery *query.ListQuery) ([]*models.WebhookDelivery, *query.PageInfo, error) {
+	return service.webhookDeliveryRepository.GetAll(webhookID, listQuery)
+}
+
+// Redeliver queues a new delivery of the payload of a past one, which keeps its event ID so that the webhook can
+// tell it has already received it.
+func (service *WebhookServiceImplementation) Redeliver(ctx context.Context, webhookID uint, deliveryID uint) (*models.WebhookDelivery, error) {
+	webhook, err := service.Get(webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Disabled {
+		return nil, ErrWebhookDisabled
+	}
+	delivery, err := service.webhookDeliveryRepository.Get(webhookID, deliveryID)
+	if err != nil {
+		return nil, notFound(err, CodeWebhookDeliveryNotFound, "delivery %d of webhook %d not found", deliveryID, webhookID)
+	}
+
+	redelivery := newWebhookDelivery(webhook, delivery.EventID, delivery.Event, delivery.Payload)
+	if err := service.webhookDeliveryRepository.Create([]*models.WebhookDelivery{redelivery}); err != nil {
+		return nil, err
+	}
+	service.notify()
+
+	service.auditService.Record(ctx, &models.AuditEvent{Action: models.AuditWebhookRedeliver, TargetType: "webhook", TargetID: &webhook.ID, Details: fmt.Sprintf("delivery %d redelivered as %d", delivery.ID, redelivery.ID)}, nil, nil)
+
+	return redelivery, nil
+}
+
+// Ping queues a ping event for a webhook, whatever the events it subscribed to, to check it's reachable.
+func (service *WebhookServiceImplementation) Ping(id uint) (*models.WebhookDelivery, error) {
+	webhook, err := service.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Disabled {
+		return nil, ErrWebhookDisabled
+	}
+
+	eventID, payload, err := newWebhookPayload(models.WebhookPing, map[string]uint{"webhook_id": webhook.ID})
+	if err != nil {
+		return nil, err
+	}
+	delivery := newWebhookDelivery(webhook, eventID, models.WebhookPing, payload)
+	if err := service.webhookDeliveryRepository.Create([]*models.WebhookDelivery{delivery}); err != nil {
+		return nil, err
+	}
+	service.notify()
+
+	return delivery, nil
+}
+
+// Publish queues the delivery of an event to the webhooks that subscribed to it. Failing to queue an event doesn't
+// fail the operation that triggered it, the error is logged instead.
+func (service *WebhookServiceImplementation) Publish(event string, data any) {
+	webhooks, err := service.webhookRepository.GetEnabled()
+	if err != nil {
+		log.Printf("failed to get the webhooks receiving %s: %v", event, err)
+		return
+	}
+
+	var deliveries []*models.WebhookDelivery
+	var eventID string
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Receives(event) {
+			continue
+		}
+		// Every webhook receives the same event, which is only encoded if at least one of them subscribed to it
+		if payload == nil {
+			if eventID, payload, err = newWebhookPayload(event, data); err != nil {
+				log.Printf("failed to encode the payload of %s: %v", event, err)
+				return
+			}
+		}
+		deliveries = append(deliveries, newWebhookDelivery(webhook, eventID, event, payload))
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	if err := service.webhookDeliveryRepository.Create(deliveries); err != nil {
+		log.Printf("failed to queue the deliveries of %s: %v", event, err)
+		return
+	}
+	service.notify()
+}
+
+// Run delivers the queued events until the context is canceled. The queue is checked every WEBHOOK_POLL_INTERVAL,
+// and as soon as events are queued by this instance.
+func (service *WebhookServiceImplementation) Run(ctx context.Context) {
+	ticker := time.NewTicker(viper.GetDuration("WEBHOOK_POLL_INTERVAL"))
+	defer ticker.Stop()
+
+	for {
+		service.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-service.wake:
+		}
+	}
+}
+
+// notify wakes the worker up, unless it has already been.
+func (service *WebhookServiceImplementation) notify() {
+	select {
+	case service.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDue attempts the deliveries whose next attempt is due, concurrently.
+func (service *WebhookServiceImplementation) deliverDue(ctx context.Context) {
+	// The deliveries are leased long enough for their attempt to time out before another worker retries them
+	deliveries, err := service.webhookDeliveryRepository.Claim(time.Now(), 2*viper.GetDuration("WEBHOOK_TIMEOUT"), webhookClaimLimit)
+	if err != nil {
+		log.Printf("failed to claim webhook deliveries: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			service.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver attempts a delivery and schedules its next attempt if it fails. Deliveries to the webhooks that have been
+// deleted or disabled in the meantime aren't retried.
+func (service *WebhookServiceImplementation) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.LastError = ""
+
+	webhook, err := service.webhookRepository.Get(delivery.WebhookID)
+	if err == nil {
+		if webhook.Disabled {
+			err = ErrWebhookDisabled
+		} else {
+			err = service.post(ctx, webhook, delivery)
+		}
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, ErrWebhookDisabled), delivery.Attempts >= viper.GetInt("WEBHOOK_MAX_ATTEMPTS"):
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = err.Error()
+	default:
+		nextAttemptAt := now.Add(webhookRetryDelay(delivery.Attempts))
+		delivery.NextAttemptAt = &nextAttemptAt
+		delivery.LastError = err.Error()
+	}
+
+	if err := service.webhookDeliveryRepository.Update(delivery); err != nil {
+		log.Printf("failed to update webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// post sends the payload of a delivery to its webhook, and keeps the response in the delivery. The signature header
+// holds the timestamp of the request and the HMAC-SHA256 of "timestamp.payload", keyed with the secret of the webhook.
+func (service *WebhookServiceImplementation) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "GODS-Webhook")
+	request.Header.Set("X-GODS-Event", delivery.Event)
+	request.Header.Set("X-GODS-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	request.Header.Set("X-GODS-Signature", "t="+timestamp+",v1="+signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	response, err := service.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, webhookResponseLimit))
+	delivery.ResponseStatus = response.StatusCode
+	delivery.ResponseBody = strings.ToValidUTF8(string(body), "")
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// signWebhookPayload returns the hex-encoded HMAC-SHA256 of a payload sent at the given timestamp.
+func signWebhookPayload(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay returns the delay before the next attempt of a delivery that failed the given number of times.
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := viper.GetDuration("WEBHOOK_RETRY_BASE_DELAY")
+	maxDelay := viper.GetDuration("WEBHOOK_RETRY_MAX_DELAY")
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// newWebhookPayload returns the ID of a new event and the payload sent for it.
+func newWebhookPayload(event string, data any) (string, []byte, error) {
+	eventID, err := generateRandomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	eventID = eventID[:32]
+
+	payload, err := json.Marshal(&dtos.WebhookPayloadDTO{ID: eventID, Event: event, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return "", nil, err
+	}
+	return eventID, payload, nil
+}
+
+// newWebhookDelivery returns a delivery of an event to a webhook, due immediately.
+func newWebhookDelivery(webhook *models.Webhook, eventID string, event string, payload []byte) *models.WebhookDelivery {
+	now := time.Now()
+	return &models.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventID:       eventID,
+		Event:         event,
+		Payload:       payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+	}
+}
+
+// validateWebhook checks the URL of a webhook and the events it subscribed to.
+func validateWebhook(webhook *models.Webhook) error {
+	parsedURL, err := url.Parse(webhook.URL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return NewFieldValidationError(CodeInvalidWebhook, "url", "invalid webhook URL: %s", webhook.URL)
+	}
+
+	for _, event := range webhook.Events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			return NewFieldValidationError(CodeInvalidWebhook, "events", "unknown event: %s", event)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/db/dbtest"
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"github.com/spf13/viper"
+)
+
+// webhookStub is a local HTTP server receiving webhook deliveries, answering them with a status.
+type webhookStub struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+// newWebhookStub starts a webhook stub, stopped at the end of the test.
+func newWebhookStub(t *testing.T, status int) *webhookStub {
+	t.Helper()
+
+	stub := &webhookStub{status: status}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		stub.mutex.Lock()
+		defer stub.mutex.Unlock()
+		stub.requests = append(stub.requests, r)
+		stub.bodies = append(stub.bodies, body)
+		if stub.status == http.StatusFound {
+			w.Header().Set("Location", "/redirected")
+		}
+		w.WriteHeader(stub.status)
+		w.Write([]byte(http.StatusText(stub.status)))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// received returns the requests the stub received for a path.
+func (stub *webhookStub) received(path string) []int {
+	stub.mutex.Lock()
+	defer stub.mutex.Unlock()
+
+	var indexes []int
+	for i, request := range stub.requests {
+		if request.URL.Path == path {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// newTestWebhookService returns a webhook service on a test database, retrying the deliveries at most 3 times.
+func newTestWebhookService(t *testing.T) (*WebhookServiceImplementation, repositories.WebhookRepository, repositories.WebhookDeliveryRepository) {
+	t.Helper()
+
+	viper.Set("WEBHOOK_TIMEOUT", "5s")
+	viper.Set("WEBHOOK_MAX_ATTEMPTS", 3)
+	viper.Set("WEBHOOK_RETRY_BASE_DELAY", "30s")
+	viper.Set("WEBHOOK_RETRY_MAX_DELAY", "6h")
+
+	database := dbtest.Open(t)
+	webhookRepository := repositories.NewWebhookRepository(database)
+	deliveryRepository := repositories.NewWebhookDeliveryRepository(database)
+	auditService := NewAuditService(repositories.NewAuditEventRepository(database))
+	service := NewWebhookService(webhookRepository, deliveryRepository, auditService).(*WebhookServiceImplementation)
+	return service, webhookRepository, deliveryRepository
+}
+
+// createWebhook registers a webhook of a stub path.
+func createWebhook(t *testing.T, webhookRepository repositories.WebhookRepository, url string, disabled bool, events ...string) *models.Webhook {
+	t.Helper()
+
+	webhook := &models.Webhook{Name: url, URL: url, Secret: "whsec", Events: events, Disabled: disabled}
+	if err := webhookRepository.Create(webhook); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return webhook
+}
+
+// queueDelivery queues a ping delivery to a webhook.
+func queueDelivery(t *testing.T, deliveryRepository repositories.WebhookDeliveryRepository, webhook *models.Webhook) *models.WebhookDelivery {
+	t.Helper()
+
+	delivery := newWebhookDelivery(webhook, "event", models.WebhookPing, []byte(`{"id":"event"}`))
+	if err := deliveryRepository.Create([]*models.WebhookDelivery{delivery}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return delivery
+}
+
+func TestWebhookPublishSignsDeliveries(t *testing.T) {
+	service, webhookRepository, deliveryRepository := newTestWebhookService(t)
+	stub := newWebhookStub(t, http.StatusNoContent)
+
+	webhook := createWebhook(t, webhookRepository, stub.URL+"/users", false, models.WebhookUserCreated)
+	createWebhook(t, webhookRepository, stub.URL+"/groups", false, models.WebhookGroupCreated)
+	createWebhook(t, webhookRepository, stub.URL+"/disabled", true, models.WebhookUserCreated)
+
+	service.Publish(models.WebhookUserCreated, map[string]string{"name": "alice"})
+	service.deliverDue(context.Background())
+
+	if got := stub.received("/groups"); len(got) != 0 {
+		t.Error("a webhook received an event it didn't subscribe to")
+	}
+	if got := stub.received("/disabled"); len(got) != 0 {
+		t.Error("a disabled webhook received an event")
+	}
+	received := stub.received("/users")
+	if len(received) != 1 {
+		t.Fatalf("the webhook received %d requests, want 1", len(received))
+	}
+	request, body := stub.requests[received[0]], stub.bodies[received[0]]
+
+	deliveries, _, err := deliveryRepository.GetAll(webhook.ID, &query.ListQuery{Limit: 10})
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("GetAll() = %v, %v, want the delivery", deliveries, err)
+	}
+	delivery := deliveries[0]
+	if delivery.Status != models.DeliverySucceeded || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusNoContent || delivery.NextAttemptAt != nil {
+		t.Errorf("delivery = %s after %d attempts, status %d, want succeeded after 1 attempt", delivery.Status, delivery.Attempts, delivery.ResponseStatus)
+	}
+
+	if request.Method != http.MethodPost || request.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s %s, want a JSON POST", request.Method, request.Header.Get("Content-Type"))
+	}
+	if request.Header.Get("X-GODS-Event") != models.WebhookUserCreated || request.Header.Get("X-GODS-Delivery") != strconv.FormatUint(uint64(delivery.ID), 10) {
+		t.Errorf("event headers = %s %s, want %s %d", request.Header.Get("X-GODS-Event"), request.Header.Get("X-GODS-Delivery"), models.WebhookUserCreated, delivery.ID)
+	}
+
+	var payload struct {
+		ID    string            `json:"id"`
+		Event string            `json:"event"`
+		Data  map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ID != delivery.EventID || payload.Event != models.WebhookUserCreated || payload.Data["name"] != "alice" {
+		t.Errorf("payload = %s, %v, want the event of alice", body, err)
+	}
+
+	// The signature is the HMAC-SHA256 of "timestamp.payload", keyed with the secret
+	timestamp, signature, ok := strings.Cut(request.Header.Get("X-GODS-Signature"), ",v1=")
+	timestamp, found := strings.CutPrefix(timestamp, "t=")
+	if !ok || !found {
+		t.Fatalf("X-GODS-Signature = %q, want t=<timestamp>,v1=<signature>", request.Header.Get("X-GODS-Signature"))
+	}
+	if sentAt, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(sentAt, 0)) > time.Minute {
+		t.Errorf("signature timestamp = %s, want the current time", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte("whsec"))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if want := hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("signature = %s, want %s", signature, want)
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	got := signWebhookPayload("whsec", "1700000000", []byte(`{"id":"1"}`))
+	if want := "60734808e731b08d45bee887cade715d87211348f1bcb975b46c8d2e7fa5dbcd"; got != want {
+		t.Errorf("signWebhookPayload() = %s, want %s", got, want)
+	}
+}
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	service, webhookRepository, deliveryRepository := newTestWebhookService(t)
+	stub := newWebhookStub(t, http.StatusServiceUnavailable)
+	webhook := createWebhook(t, webhookRepository, stub.URL, false)
+	delivery := queueDelivery(t, deliveryRepository, webhook)
+
+	// The failed attempts are retried after an exponential delay, until WEBHOOK_MAX_ATTEMPTS
+	for attempt, wantDelay := range []time.Duration{30 * time.Second, time.Minute} {
+		service.deliver(context.Background(), delivery)
+		got, err := deliveryRepository.Get(webhook.ID, delivery.ID)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got.Status != models.DeliveryPending || got.Attempts != attempt+1 || got.NextAttemptAt == nil || got.LastAttemptAt == nil {
+			t.Fatalf("delivery after attempt %d = %s after %d attempts, want pending", attempt+1, got.Status, got.Attempts)
+		}
+		if delay := got.NextAttemptAt.Sub(*got.LastAttemptAt); delay < wantDelay-time.Second || delay > wantDelay+time.Second {
+			t.Errorf("delay after attempt %d = %s, want %s", attempt+1, delay, wantDelay)
+		}
+		if got.ResponseStatus != http.StatusServiceUnavailable || got.ResponseBody != "Service Unavailable" || !strings.Contains(got.LastError, "503") {
+			t.Errorf("delivery response = %d %q, error %q, want the 503 response", got.ResponseStatus, got.ResponseBody, got.LastError)
+		}
+	}
+
+	service.deliver(context.Background(), delivery)
+	got, err := deliveryRepository.Get(webhook.ID, delivery.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != models.DeliveryFailed || got.Attempts != 3 || got.NextAttemptAt != nil {
+		t.Errorf("delivery after the last attempt = %s after %d attempts, want failed after 3", got.Status, got.Attempts)
+	}
+	if received := stub.received("/"); len(received) != 3 {
+		t.Errorf("the webhook received %d requests, want 3", len(received))
+	}
+
+	// A failed delivery isn't claimed anymore
+	if claimed, err := deliveryRepository.Claim(time.Now().Add(24*time.Hour), time.Minute, 10); err != nil || len(claimed) != 0 {
+		t.Errorf("Claim() = %d deliveries, %v, want none", len(claimed), err)
+	}
+}
+
+func TestWebhookDeliveryDoesntFollowRedirects(t *testing.T) {
+	service, webhookRepository, deliveryRepository := newTestWebhookService(t)
+	stub := newWebhookStub(t, http.StatusFound)
+	webhook := createWebhook(t, webhookRepository, stub.URL+"/hook", false)
+	delivery := queueDelivery(t, deliveryRepository, webhook)
+
+	service.deliver(context.Background(), delivery)
+
+	if received := stub.received("/redirected"); len(received) != 0 {
+		t.Error("the redirect was followed")
+	}
+	got, err := deliveryRepository.Get(webhook.ID, delivery.ID)
+	if err != nil || got.Status != models.DeliveryPending || got.ResponseStatus != http.StatusFound {
+		t.Errorf("delivery = %v, %v, want pending after the 302 response", got, err)
+	}
+}
+
+func TestWebhookDeliveryToDisabledWebhook(t *testing.T) {
+	service, webhookRepository, deliveryRepository := newTestWebhookService(t)
+	stub := newWebhookStub(t, http.StatusNoContent)
+	webhook := createWebhook(t, webhookRepository, stub.URL, false)
+	delivery := queueDelivery(t, deliveryRepository, webhook)
+
+	// A webhook disabled once the delivery is queued doesn't receive it, and the delivery isn't retried
+	webhook.Disabled = true
+	if err := webhookRepository.Update(webhook); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	service.deliver(context.Background(), delivery)
+
+	got, err := deliveryRepository.Get(webhook.ID, delivery.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != models.DeliveryFailed || got.NextAttemptAt != nil || got.LastError != ErrWebhookDisabled.Error() {
+		t.Errorf("delivery = %s, error %q, want failed as the webhook is disabled", got.Status, got.LastError)
+	}
+	if received := stub.received("/"); len(received) != 0 {
+		t.Errorf("the disabled webhook received %d requests", len(received))
+	}
+	if _, err := service.Ping(webhook.ID); !errors.Is(err, ErrWebhookDisabled) {
+		t.Errorf("Ping() error = %v, want ErrWebhookDisabled", err)
+	}
+
+	// Neither does a deleted webhook
+	deleted := createWebhook(t, webhookRepository, stub.URL, false)
+	delivery = queueDelivery(t, deliveryRepository, deleted)
+	if err := webhookRepository.Delete(deleted.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	service.deliver(context.Background(), delivery)
+	if got, err := deliveryRepository.Get(deleted.ID, delivery.ID); err != nil || got.Status != models.DeliveryFailed {
+		t.Errorf("delivery to a deleted webhook = %v, %v, want failed", got, err)
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	viper.Set("WEBHOOK_RETRY_BASE_DELAY", "1s")
+	viper.Set("WEBHOOK_RETRY_MAX_DELAY", "10s")
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 100, want: 10 * time.Second},
+	}
+	for _, test := range tests {
+		if got := webhookRetryDelay(test.attempts); got != test.want {
+			t.Errorf("webhookRetryDelay(%d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+}
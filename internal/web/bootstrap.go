@@ -0,0 +1,86 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/repositories"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/spf13/viper"
+)
+
+// adminGroupName is the name of the group granted the admin role.
+const adminGroupName = "admin"
+
+// bootstrapAdminUser returns the admin user of the ADMIN_* settings, creating it, or restoring and enabling it so
+// that the administrators can't lock themselves out. It also tells whether the user was created or restored.
+func bootstrapAdminUser(ctx context.Context, userRepository repositories.UserRepository, userService services.UserService) (*models.User, bool, error) {
+	name := viper.GetString("ADMIN_NAME")
+
+	adminUser, err := userRepository.GetByName(name)
+	if err == nil {
+		if !adminUser.Disabled {
+			return adminUser, false, nil
+		}
+		log.Printf("enabling the disabled admin user %q", name)
+		disabled := false
+		if err := userService.Update(ctx, adminUser, &dtos.UpdateUserDTO{Disabled: &disabled}); err != nil {
+			return nil, false, err
+		}
+		return adminUser, true, nil
+	}
+
+	if deleted, err := userRepository.GetDeletedByName(name); err == nil {
+		log.Printf("restoring the deleted admin user %q", name)
+		adminUser, err := userService.Restore(ctx, deleted.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		return adminUser, true, nil
+	}
+
+	adminUser, err = userService.Create(ctx, &dtos.CreateUserDTO{Name: name, Email: viper.GetString("ADMIN_EMAIL"), Password: viper.GetString("ADMIN_PASSWORD")})
+	var policyErr *services.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return nil, false, fmt.Errorf("ADMIN_PASSWORD doesn't meet the password policy: %w", err)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The admin email comes from the configuration, there's nobody to verify it
+	now := time.Now()
+	adminUser.EmailVerifiedAt = &now
+	if err := userRepository.Update(adminUser); err != nil {
+		return nil, false, err
+	}
+	return adminUser, true, nil
+}
+
+// bootstrapAdminGroup returns the admin group, creating it or restoring it, and tells whether it was.
+func bootstrapAdminGroup(ctx context.Context, groupRepository repositories.GroupRepository, groupService services.GroupService) (*models.Group, bool, error) {
+	adminGroup, err := groupRepository.GetByName(adminGroupName)
+	if err == nil {
+		return adminGroup, false, nil
+	}
+
+	if deleted, err := groupRepository.GetDeletedByName(adminGroupName); err == nil {
+		log.Printf("restoring the deleted admin group")
+		adminGroup, err := groupService.Restore(ctx, deleted.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		return adminGroup, true, nil
+	}
+
+	adminGroup, err = groupService.Create(ctx, &dtos.CreateGroupDTO{Name: adminGroupName})
+	if err != nil {
+		return nil, false, err
+	}
+	return adminGroup, true, nil
+}
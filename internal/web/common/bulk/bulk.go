@@ -0,0 +1,157 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+)
+
+// Formats of the bulk import and export files.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// csvColumns are the columns of the CSV files, which only hold users. Only the name column is required.
+var csvColumns = []string{"name", "email", "password", "password_hash", "service_account", "groups"}
+
+// groupSeparator separates the names of the groups of a user in a CSV cell.
+const groupSeparator = ";"
+
+// FormatOf returns the format of a file from its extension, JSON when it's neither .csv nor .json.
+func FormatOf(filename string) string {
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+// Decode reads the users, groups and memberships of a file. JSON files hold a dtos.DirectoryDTO, while CSV files
+// hold users with a header row naming the columns, their groups being separated by semicolons.
+func Decode(r io.Reader, format string) (*dtos.DirectoryDTO, error) {
+	switch format {
+	case FormatJSON:
+		var directory dtos.DirectoryDTO
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&directory); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return &directory, nil
+	case FormatCSV:
+		return decodeCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// Encode writes the users, groups and memberships to a file. CSV files only hold the users and their groups.
+func Encode(w io.Writer, format string, directory *dtos.DirectoryDTO) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(directory)
+	case FormatCSV:
+		return encodeCSV(w, directory)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func decodeCSV(r io.Reader) (*dtos.DirectoryDTO, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing CSV header")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(csvColumns, header[i]) {
+			return nil, fmt.Errorf("unknown CSV column %q", column)
+		}
+	}
+	if !slices.Contains(header, "name") {
+		return nil, errors.New("missing name CSV column")
+	}
+
+	directory := &dtos.DirectoryDTO{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		var user dtos.DirectoryUserDTO
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			switch header[i] {
+			case "name":
+				user.Name = value
+			case "email":
+				user.Email = value
+			case "password":
+				user.Password = value
+			case "password_hash":
+				user.PasswordHash = value
+			case "service_account":
+				if value != "" {
+					if user.ServiceAccount, err = strconv.ParseBool(value); err != nil {
+						line, _ := reader.FieldPos(i)
+						return nil, fmt.Errorf("invalid service_account value %q on line %d", value, line)
+					}
+				}
+			case "groups":
+				for _, group := range strings.Split(value, groupSeparator) {
+					if group = strings.TrimSpace(group); group != "" {
+						user.Groups = append(user.Groups, group)
+					}
+				}
+			}
+		}
+		directory.Users = append(directory.Users, user)
+	}
+
+	return directory, nil
+}
+
+func encodeCSV(w io.Writer, directory *dtos.DirectoryDTO) error {
+	withHashes := slices.ContainsFunc(directory.Users, func(user dtos.DirectoryUserDTO) bool { return user.PasswordHash != "" })
+
+	writer := csv.NewWriter(w)
+	header := []string{"name", "email", "service_account", "groups"}
+	if withHashes {
+		header = append(header, "password_hash")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, user := range directory.Users {
+		record := []string{user.Name, user.Email, strconv.FormatBool(user.ServiceAccount), strings.Join(user.Groups, groupSeparator)}
+		if withHashes {
+			record = append(record, user.PasswordHash)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,26 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+)
+
+// CreateAPIKeyDTO represents the informations of a new API key or personal access token.
+type CreateAPIKeyDTO struct {
+	Name      string    `form:"name" binding:"required"`
+	Scopes    []string  `form:"scopes" binding:"required"`
+	ExpiresAt time.Time `form:"expires_at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// APIKeyTokenDTO represents an API key along with its token, which is only shown when created.
+type APIKeyTokenDTO struct {
+	*models.APIKey
+	Token string `json:"token"`
+}
+
+// CreateServiceAccountDTO represents the informations of a new service account.
+type CreateServiceAccountDTO struct {
+	Name  string `form:"name" binding:"required"`
+	Email string `form:"email" binding:"omitempty,email"`
+}
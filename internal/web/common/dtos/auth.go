@@ -13,3 +13,31 @@ type SignupDTO struct {
 	Password             string `form:"password" binding:"required"`
 	PasswordConfirmation string `form:"password_confirmation" binding:"required"`
 }
+
+// RefreshDTO represents a refresh token exchange request.
+type RefreshDTO struct {
+	RefreshToken string `form:"refresh_token" binding:"required"`
+}
+
+// LogoutDTO represents a logout request.
+type LogoutDTO struct {
+	RefreshToken string `form:"refresh_token"`
+}
+
+// TokenDTO represents the tokens issued to an authenticated user.
+type TokenDTO struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+
+	// RecoveryCodes are only returned by the login completing a TOTP enrollment.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// RenewPasswordDTO represents the replacement of an expired password, made with the token returned by the login.
+type RenewPasswordDTO struct {
+	PasswordToken        string `form:"password_token" binding:"required"`
+	Password             string `form:"password" binding:"required"`
+	PasswordConfirmation string `form:"password_confirmation" binding:"required"`
+}
@@ -0,0 +1,28 @@
+package dtos
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/models"
+)
+
+// CreateClientDTO represents the registration informations of an OpenID Connect client.
+type CreateClientDTO struct {
+	Name         string   `form:"name" binding:"required"`
+	RedirectURIs []string `form:"redirect_uris"`
+	GrantTypes   []string `form:"grant_types"`
+	Scopes       []string `form:"scopes"`
+	Public       bool     `form:"public"`
+}
+
+// UpdateClientDTO represents the update informations of an OpenID Connect client.
+type UpdateClientDTO struct {
+	Name         string   `form:"name"`
+	RedirectURIs []string `form:"redirect_uris"`
+	GrantTypes   []string `form:"grant_types"`
+	Scopes       []string `form:"scopes"`
+}
+
+// ClientSecretDTO represents a client along with its secret, which is only shown when generated.
+type ClientSecretDTO struct {
+	*models.Client
+	ClientSecret string `json:"client_secret,omitempty"`
+}
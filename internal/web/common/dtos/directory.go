@@ -0,0 +1,64 @@
+package dtos
+
+import "mime/multipart"
+
+// DirectoryDTO represents the users, groups and memberships of a bulk import or export.
+type DirectoryDTO struct {
+	Groups []DirectoryGroupDTO `json:"groups"`
+	Users  []DirectoryUserDTO  `json:"users"`
+}
+
+// DirectoryGroupDTO represents a group of a bulk import or export, along with the names of its subgroups.
+type DirectoryGroupDTO struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Subgroups   []string `json:"subgroups,omitempty"`
+}
+
+// DirectoryUserDTO represents a user of a bulk import or export, along with the names of their groups.
+// Imported users have either a password or the hash of one, in one of the formats supported by passhash, service
+// accounts have neither.
+type DirectoryUserDTO struct {
+	Name           string   `json:"name"`
+	Email          string   `json:"email,omitempty"`
+	Password       string   `json:"password,omitempty"`
+	PasswordHash   string   `json:"password_hash,omitempty"`
+	ServiceAccount bool     `json:"service_account,omitempty"`
+	Groups         []string `json:"groups,omitempty"`
+}
+
+// ImportOptionsDTO represents a bulk import request.
+type ImportOptionsDTO struct {
+	File   *multipart.FileHeader `form:"file" binding:"required"`
+	Format string                `form:"format" binding:"omitempty,oneof=csv json"`
+	DryRun bool                  `form:"dry_run"`
+	Atomic bool                  `form:"atomic"`
+}
+
+// ExportOptionsDTO represents a bulk export request.
+type ExportOptionsDTO struct {
+	Format                string `form:"format" binding:"omitempty,oneof=csv json"`
+	IncludePasswordHashes bool   `form:"include_password_hashes"`
+}
+
+// ImportReportDTO represents the result of a bulk import, row by row.
+type ImportReportDTO struct {
+	DryRun   bool           `json:"dry_run"`
+	Atomic   bool           `json:"atomic"`
+	Applied  bool           `json:"applied"`
+	Created  int            `json:"created"`
+	Existing int            `json:"existing"`
+	Invalid  int            `json:"invalid"`
+	Failed   int            `json:"failed"`
+	Rows     []ImportRowDTO `json:"rows"`
+}
+
+// ImportRowDTO represents the result of the import of a user or a group. Row is the 1-based position of the user
+// or group among those of the file, the CSV header not counting.
+type ImportRowDTO struct {
+	Row    int      `json:"row"`
+	Type   string   `json:"type"`
+	Name   string   `json:"name"`
+	Status string   `json:"status"`
+	Errors []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,35 @@
+package dtos
+
+// TOTPCodeDTO represents a TOTP code, or a recovery code where accepted.
+type TOTPCodeDTO struct {
+	Code string `form:"code" binding:"required"`
+}
+
+// TOTPEnrollmentDTO represents the TOTP secret generated for a user, to be added to an authenticator app.
+type TOTPEnrollmentDTO struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesDTO represents the one-time recovery codes issued to a user, only shown once.
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallengeDTO represents the challenge returned by a login that needs a second factor.
+type MFAChallengeDTO struct {
+	MFARequired        bool   `json:"mfa_required"`
+	MFAToken           string `json:"mfa_token"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+// MFAEnrollDTO represents a TOTP enrollment request made with a login challenge.
+type MFAEnrollDTO struct {
+	MFAToken string `form:"mfa_token" binding:"required"`
+}
+
+// MFAVerifyDTO represents the second step of a login, answering a challenge with a TOTP code or a recovery code.
+type MFAVerifyDTO struct {
+	MFAToken string `form:"mfa_token" binding:"required"`
+	Code     string `form:"code" binding:"required"`
+}
@@ -0,0 +1,27 @@
+package dtos
+
+// ProblemContentType is the media type of the problem details.
+const ProblemContentType = "application/problem+json"
+
+// ProblemDTO represents the problem details of an error, as defined by RFC 7807.
+type ProblemDTO struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Code identifies the error, clients can rely on it unlike on the detail.
+	Code string `json:"code"`
+	// Errors are the invalid fields of the request.
+	Errors []FieldErrorDTO `json:"errors,omitempty"`
+	// PasswordToken allows to replace an expired password, it's only returned by the login.
+	PasswordToken string `json:"password_token,omitempty"`
+}
+
+// FieldErrorDTO represents an invalid field of a request.
+type FieldErrorDTO struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,13 @@
+package dtos
+
+// CreateRoleDTO is a struct used for role input/output in API
+type CreateRoleDTO struct {
+	Name        string `form:"name" binding:"required"`
+	Description string `form:"description"`
+}
+
+// UpdateRoleDTO is a struct used for role input/output in API
+type UpdateRoleDTO struct {
+	Name        string `form:"name"`
+	Description string `form:"description"`
+}
@@ -0,0 +1,156 @@
+package dtos
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SCIM schema URNs, as defined by RFC 7643 and RFC 7644.
+const (
+	SCIMSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMSchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SCIMSchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+	SCIMSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// SCIMMetaDTO represents the metadata of a SCIM resource.
+type SCIMMetaDTO struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location"`
+}
+
+// SCIMEmailDTO represents an email address of a SCIM user.
+type SCIMEmailDTO struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMMemberDTO represents a member of a SCIM group, or a group of a SCIM user.
+type SCIMMemberDTO struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+}
+
+// SCIMUserDTO represents a SCIM user resource.
+type SCIMUserDTO struct {
+	Schemas  []string        `json:"schemas"`
+	ID       string          `json:"id,omitempty"`
+	UserName string          `json:"userName"`
+	Emails   []SCIMEmailDTO  `json:"emails,omitempty"`
+	Active   *bool           `json:"active,omitempty"`
+	Password string          `json:"password,omitempty"`
+	Groups   []SCIMMemberDTO `json:"groups,omitempty"`
+	Meta     *SCIMMetaDTO    `json:"meta,omitempty"`
+}
+
+// SCIMGroupDTO represents a SCIM group resource.
+type SCIMGroupDTO struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	DisplayName string          `json:"displayName"`
+	Members     []SCIMMemberDTO `json:"members"`
+	Meta        *SCIMMetaDTO    `json:"meta,omitempty"`
+}
+
+// SCIMListResponseDTO represents a page of SCIM resources.
+type SCIMListResponseDTO struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// SCIMPatchDTO represents a SCIM PATCH request.
+type SCIMPatchDTO struct {
+	Schemas    []string                `json:"schemas"`
+	Operations []SCIMPatchOperationDTO `json:"Operations" binding:"required"`
+}
+
+// SCIMPatchOperationDTO represents an operation of a SCIM PATCH request.
+type SCIMPatchOperationDTO struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// SCIMErrorDTO represents a SCIM error response.
+type SCIMErrorDTO struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// SCIMSupportedDTO represents a feature of the SCIM service provider configuration.
+type SCIMSupportedDTO struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  *int `json:"maxOperations,omitempty"`
+	MaxPayloadSize *int `json:"maxPayloadSize,omitempty"`
+	MaxResults     *int `json:"maxResults,omitempty"`
+}
+
+// SCIMAuthenticationSchemeDTO represents an authentication scheme of the SCIM service provider.
+type SCIMAuthenticationSchemeDTO struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+}
+
+// SCIMServiceProviderConfigDTO represents the SCIM features supported by GODS.
+type SCIMServiceProviderConfigDTO struct {
+	Schemas               []string                      `json:"schemas"`
+	Patch                 SCIMSupportedDTO              `json:"patch"`
+	Bulk                  SCIMSupportedDTO              `json:"bulk"`
+	Filter                SCIMSupportedDTO              `json:"filter"`
+	ChangePassword        SCIMSupportedDTO              `json:"changePassword"`
+	Sort                  SCIMSupportedDTO              `json:"sort"`
+	ETag                  SCIMSupportedDTO              `json:"etag"`
+	AuthenticationSchemes []SCIMAuthenticationSchemeDTO `json:"authenticationSchemes"`
+	Meta                  SCIMMetaDTO                   `json:"meta"`
+}
+
+// SCIMResourceTypeDTO represents a type of SCIM resources.
+type SCIMResourceTypeDTO struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Endpoint    string      `json:"endpoint"`
+	Description string      `json:"description"`
+	Schema      string      `json:"schema"`
+	Meta        SCIMMetaDTO `json:"meta"`
+}
+
+// SCIMAttributeDTO represents the definition of an attribute of a SCIM schema.
+type SCIMAttributeDTO struct {
+	Name          string             `json:"name"`
+	Type          string             `json:"type"`
+	MultiValued   bool               `json:"multiValued"`
+	Description   string             `json:"description"`
+	Required      bool               `json:"required"`
+	CaseExact     bool               `json:"caseExact"`
+	Mutability    string             `json:"mutability"`
+	Returned      string             `json:"returned"`
+	Uniqueness    string             `json:"uniqueness"`
+	SubAttributes []SCIMAttributeDTO `json:"subAttributes,omitempty"`
+}
+
+// SCIMSchemaDTO represents a SCIM schema.
+type SCIMSchemaDTO struct {
+	Schemas     []string           `json:"schemas"`
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Attributes  []SCIMAttributeDTO `json:"attributes"`
+	Meta        SCIMMetaDTO        `json:"meta"`
+}
@@ -12,4 +12,18 @@ type UpdateUserDTO struct {
 	Name     string `form:"name"`
 	Email    string `form:"email" binding:"email"`
 	Password string `form:"password"`
+	Disabled *bool  `form:"disabled"`
+}
+
+// UpdateProfileDTO represents the update informations of the authenticated user's profile.
+type UpdateProfileDTO struct {
+	Name  string `form:"name"`
+	Email string `form:"email" binding:"omitempty,email"`
+}
+
+// ChangePasswordDTO represents a password change of the authenticated user.
+type ChangePasswordDTO struct {
+	CurrentPassword      string `form:"current_password" binding:"required"`
+	Password             string `form:"password" binding:"required"`
+	PasswordConfirmation string `form:"password_confirmation" binding:"required"`
 }
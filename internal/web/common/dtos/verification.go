@@ -0,0 +1,18 @@
+package dtos
+
+// VerifyEmailDTO represents an email verification request.
+type VerifyEmailDTO struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// EmailDTO represents a request for an email to be sent to an address.
+type EmailDTO struct {
+	Email string `form:"email" binding:"required,email"`
+}
+
+// ResetPasswordDTO represents a password reset request.
+type ResetPasswordDTO struct {
+	Token                string `form:"token" binding:"required"`
+	Password             string `form:"password" binding:"required"`
+	PasswordConfirmation string `form:"password_confirmation" binding:"required"`
+}
@@ -0,0 +1,36 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+)
+
+// CreateWebhookDTO represents the registration informations of a webhook.
+type CreateWebhookDTO struct {
+	Name   string   `form:"name" binding:"required"`
+	URL    string   `form:"url" binding:"required,url"`
+	Events []string `form:"events"`
+}
+
+// UpdateWebhookDTO represents the update informations of a webhook.
+type UpdateWebhookDTO struct {
+	Name     string   `form:"name"`
+	URL      string   `form:"url" binding:"omitempty,url"`
+	Events   []string `form:"events"`
+	Disabled *bool    `form:"disabled"`
+}
+
+// WebhookSecretDTO represents a webhook along with its signing secret, which is only shown when generated.
+type WebhookSecretDTO struct {
+	*models.Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookPayloadDTO represents the body posted to the webhooks.
+type WebhookPayloadDTO struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
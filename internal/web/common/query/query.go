@@ -0,0 +1,309 @@
+package query
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultLimit is the number of results returned when no limit is requested.
+	DefaultLimit = 50
+	// MaxLimit is the maximum number of results that can be requested at once.
+	MaxLimit = 500
+)
+
+// FieldType is the type of a field, which determines how its filter values are parsed.
+type FieldType int
+
+const (
+	String FieldType = iota
+	Number
+	Time
+)
+
+// Field describes a column that can be filtered and sorted on.
+type Field struct {
+	Column string    // Column is the database column of the field.
+	Type   FieldType // Type is the type of the field.
+}
+
+// Fields maps the public names of the fields to their description.
+type Fields map[string]Field
+
+// Filter is a condition on a column.
+type Filter struct {
+	Column string
+	// Operator compares the column with the value: "=", ">" and "<" as in SQL, while "~" checks that it contains
+	// the value, "^" that it starts with it and "~=" that it equals it, these three ignoring case.
+	Operator string
+	Value    any
+}
+
+// Sort is an ordering on a column.
+type Sort struct {
+	Column     string
+	Descending bool
+}
+
+// ListQuery describes the page of results to retrieve.
+type ListQuery struct {
+	Limit   int      // Limit is the maximum number of results.
+	Offset  int      // Offset is the number of results to skip, when using offset pagination.
+	Cursor  *uint    // Cursor is the ID after which results start, when using cursor pagination.
+	Sorts   []Sort   // Sorts is the ordering of the results, by ID when empty.
+	Filters []Filter // Filters is the list of conditions the results must match.
+}
+
+// PageInfo describes a retrieved page of results.
+type PageInfo struct {
+	Total      int64  // Total is the number of results matching the filters.
+	Limit      int    // Limit is the maximum number of results of the page.
+	Offset     int    // Offset is the number of results skipped before the page.
+	NextCursor string // NextCursor is the cursor to request the next page, when there may be one.
+}
+
+// reservedParameters are the query parameters that aren't filters.
+var reservedParameters = map[string]bool{"limit": true, "offset": true, "cursor": true, "sort": true}
+
+// Parse builds a ListQuery from query parameters such as "limit=20&sort=-created_at&name~=adm&created_after=2024-01-01".
+func Parse(values url.Values, fields Fields) (*ListQuery, error) {
+	listQuery := &ListQuery{Limit: DefaultLimit}
+
+	if limit := values.Get("limit"); limit != "" {
+		parsedLimit, err := strconv.Atoi(limit)
+		if err != nil || parsedLimit < 1 || parsedLimit > MaxLimit {
+			return nil, fmt.Errorf("limit must be a number between 1 and %d", MaxLimit)
+		}
+		listQuery.Limit = parsedLimit
+	}
+
+	if offset := values.Get("offset"); offset != "" {
+		parsedOffset, err := strconv.Atoi(offset)
+		if err != nil || parsedOffset < 0 {
+			return nil, errors.New("offset must be a positive number")
+		}
+		listQuery.Offset = parsedOffset
+	}
+
+	if values.Has("cursor") {
+		cursor, err := decodeCursor(values.Get("cursor"))
+		if err != nil {
+			return nil, err
+		}
+		listQuery.Cursor = &cursor
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		for _, name := range strings.Split(sort, ",") {
+			descending := strings.HasPrefix(name, "-")
+			field, ok := fields[strings.TrimPrefix(name, "-")]
+			if !ok {
+				return nil, fmt.Errorf("unknown sort field %q", strings.TrimPrefix(name, "-"))
+			}
+			listQuery.Sorts = append(listQuery.Sorts, Sort{Column: field.Column, Descending: descending})
+		}
+	}
+
+	// Cursors are IDs, so they can only be used to walk through results ordered by ID
+	if listQuery.Cursor != nil && (listQuery.Offset > 0 || len(listQuery.Sorts) > 0) {
+		return nil, errors.New("cursor can't be combined with offset or sort")
+	}
+
+	for key, parameterValues := range values {
+		if reservedParameters[key] {
+			continue
+		}
+
+		filter, err := parseFilter(key, parameterValues[0], fields)
+		if err != nil {
+			return nil, err
+		}
+		listQuery.Filters = append(listQuery.Filters, *filter)
+	}
+
+	return listQuery, nil
+}
+
+// parseFilter builds a filter from a query parameter: "name=value" checks equality, "name~=value" checks
+// that the field contains the value, and "created_after=date" or "created_before=date" compare dates.
+func parseFilter(key string, value string, fields Fields) (*Filter, error) {
+	name, operator := key, "="
+	switch {
+	case strings.HasSuffix(key, "~"):
+		name, operator = strings.TrimSuffix(key, "~"), "~"
+	case strings.HasSuffix(key, "_after"):
+		name, operator = strings.TrimSuffix(key, "_after")+"_at", ">"
+	case strings.HasSuffix(key, "_before"):
+		name, operator = strings.TrimSuffix(key, "_before")+"_at", "<"
+	}
+
+	field, ok := fields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter %q", key)
+	}
+
+	switch field.Type {
+	case Time:
+		if operator == "~" {
+			return nil, fmt.Errorf("filter %q isn't supported", key)
+		}
+		parsedTime, err := parseTime(value)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q must be a date", key)
+		}
+		return &Filter{Column: field.Column, Operator: operator, Value: parsedTime}, nil
+	case Number:
+		if operator == "~" {
+			return nil, fmt.Errorf("filter %q isn't supported", key)
+		}
+		parsedNumber, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q must be a number", key)
+		}
+		return &Filter{Column: field.Column, Operator: operator, Value: parsedNumber}, nil
+	default:
+		if operator == ">" || operator == "<" {
+			return nil, fmt.Errorf("filter %q isn't supported", key)
+		}
+		return &Filter{Column: field.Column, Operator: operator, Value: value}, nil
+	}
+}
+
+// parseTime parses an RFC 3339 date-time or a plain date.
+func parseTime(value string) (time.Time, error) {
+	if parsedTime, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsedTime, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, and its escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Where applies the filters of the query to a database query.
+func (listQuery *ListQuery) Where(database *gorm.DB) *gorm.DB {
+	for _, filter := range listQuery.Filters {
+		switch filter.Operator {
+		case "~":
+			// The escape character is bound, as MySQL would read a backslash in a literal as the escape of the quote
+			database = database.Where("LOWER("+filter.Column+") LIKE ? ESCAPE ?", "%"+likeEscaper.Replace(strings.ToLower(fmt.Sprint(filter.Value)))+"%", `\`)
+		case "^":
+			database = database.Where("LOWER("+filter.Column+") LIKE ? ESCAPE ?", likeEscaper.Replace(strings.ToLower(fmt.Sprint(filter.Value)))+"%", `\`)
+		case "~=":
+			database = database.Where("LOWER("+filter.Column+") = ?", strings.ToLower(fmt.Sprint(filter.Value)))
+		default:
+			database = database.Where(filter.Column+" "+filter.Operator+" ?", filter.Value)
+		}
+	}
+	return database
+}
+
+// Find retrieves the page of results described by the query into dest, a pointer to a slice of models.
+// A nil query retrieves every result, ordered by ID.
+func Find(database *gorm.DB, listQuery *ListQuery, dest any) (*PageInfo, error) {
+	if listQuery == nil {
+		if err := database.Order("id").Find(dest).Error; err != nil {
+			return nil, err
+		}
+		total := int64(reflect.ValueOf(dest).Elem().Len())
+		return &PageInfo{Total: total, Limit: int(total)}, nil
+	}
+
+	database = listQuery.Where(database)
+
+	pageInfo := &PageInfo{Limit: listQuery.Limit, Offset: listQuery.Offset}
+	if err := database.Session(&gorm.Session{}).Count(&pageInfo.Total).Error; err != nil {
+		return nil, err
+	}
+
+	if listQuery.Cursor != nil {
+		database = database.Where("id > ?", *listQuery.Cursor)
+	}
+	for _, sort := range listQuery.Sorts {
+		if sort.Descending {
+			database = database.Order(sort.Column + " DESC")
+		} else {
+			database = database.Order(sort.Column)
+		}
+	}
+	database = database.Order("id")
+
+	if err := database.Limit(listQuery.Limit).Offset(listQuery.Offset).Find(dest).Error; err != nil {
+		return nil, err
+	}
+
+	// A full page ordered by ID can be followed by another one starting after its last result
+	results := reflect.ValueOf(dest).Elem()
+	if len(listQuery.Sorts) == 0 && results.Len() > 0 && results.Len() == listQuery.Limit {
+		last := reflect.Indirect(results.Index(results.Len() - 1))
+		pageInfo.NextCursor = encodeCursor(uint(last.FieldByName("ID").Uint()))
+	}
+
+	return pageInfo, nil
+}
+
+// Links builds the value of the Link header pointing to the next and previous pages of the request URL.
+func (pageInfo *PageInfo) Links(requestURL *url.URL) string {
+	var links []string
+	link := func(rel string, changes map[string]string) {
+		linkURL := *requestURL
+		values := linkURL.Query()
+		for key, value := range changes {
+			if value == "" {
+				values.Del(key)
+			} else {
+				values.Set(key, value)
+			}
+		}
+		linkURL.RawQuery = values.Encode()
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", linkURL.RequestURI(), rel))
+	}
+
+	if requestURL.Query().Has("cursor") {
+		if pageInfo.NextCursor != "" {
+			link("next", map[string]string{"cursor": pageInfo.NextCursor})
+		}
+		return strings.Join(links, ", ")
+	}
+
+	if int64(pageInfo.Offset+pageInfo.Limit) < pageInfo.Total {
+		link("next", map[string]string{"offset": strconv.Itoa(pageInfo.Offset + pageInfo.Limit)})
+	}
+	if pageInfo.Offset > 0 {
+		link("prev", map[string]string{"offset": strconv.Itoa(max(pageInfo.Offset-pageInfo.Limit, 0))})
+		link("first", map[string]string{"offset": ""})
+	}
+	return strings.Join(links, ", ")
+}
+
+// encodeCursor encodes an ID into an opaque cursor.
+func encodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+// decodeCursor decodes an opaque cursor into an ID. An empty cursor starts from the first result.
+func decodeCursor(cursor string) (uint, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+
+	id, err := strconv.ParseUint(string(decoded), 10, 32)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+
+	return uint(id), nil
+}
@@ -0,0 +1,435 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter is a parsed SCIM filter, as described by RFC 7644 section 3.4.2.2.
+type Filter interface {
+	// Match checks if a resource, or an element of a multi-valued attribute, matches the filter.
+	Match(resource map[string]any) bool
+}
+
+// Parse parses a filter such as `userName eq "bob" and emails[type eq "work" and value co "@example.com"]`.
+func Parse(filter string) (Filter, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	parsed, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q in filter", p.peek().text)
+	}
+
+	return parsed, nil
+}
+
+// Condition is a comparison of an attribute with a string, such as `userName eq "bob"`.
+type Condition struct {
+	Path     AttributePath
+	Operator string
+	Value    string
+}
+
+// Conditions returns the comparisons of a filter made only of string comparisons joined by "and", so that it can be
+// translated into a database query. It returns false when the filter has other expressions.
+func Conditions(filter Filter) ([]Condition, bool) {
+	switch filter := filter.(type) {
+	case *andFilter:
+		left, ok := Conditions(filter.left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := Conditions(filter.right)
+		if !ok {
+			return nil, false
+		}
+		return append(left, right...), true
+	case *compareFilter:
+		value, ok := filter.value.(string)
+		if !ok {
+			return nil, false
+		}
+		return []Condition{{Path: filter.path, Operator: filter.operator, Value: value}}, true
+	case *valuePathFilter:
+		// The comparisons of the elements are those of the sub-attributes, `emails[value eq "bob@example.com"]`
+		// being `emails.value eq "bob@example.com"`
+		conditions, ok := Conditions(filter.filter)
+		if !ok {
+			return nil, false
+		}
+		for i, condition := range conditions {
+			if condition.Path.SubAttribute != "" {
+				return nil, false
+			}
+			conditions[i].Path = AttributePath{Attribute: filter.attribute, SubAttribute: condition.Path.Attribute}
+		}
+		return conditions, true
+	default:
+		return nil, false
+	}
+}
+
+// andFilter matches the resources matching both filters.
+type andFilter struct {
+	left, right Filter
+}
+
+func (filter *andFilter) Match(resource map[string]any) bool {
+	return filter.left.Match(resource) && filter.right.Match(resource)
+}
+
+// orFilter matches the resources matching one of the filters.
+type orFilter struct {
+	left, right Filter
+}
+
+func (filter *orFilter) Match(resource map[string]any) bool {
+	return filter.left.Match(resource) || filter.right.Match(resource)
+}
+
+// notFilter matches the resources not matching a filter.
+type notFilter struct {
+	filter Filter
+}
+
+func (filter *notFilter) Match(resource map[string]any) bool {
+	return !filter.filter.Match(resource)
+}
+
+// presentFilter matches the resources having a non-empty value for an attribute.
+type presentFilter struct {
+	path AttributePath
+}
+
+func (filter *presentFilter) Match(resource map[string]any) bool {
+	for _, value := range filter.path.values(resource) {
+		if value != nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// compareFilter matches the resources having a value of an attribute comparing to a value with an operator.
+type compareFilter struct {
+	path     AttributePath
+	operator string
+	value    any
+}
+
+func (filter *compareFilter) Match(resource map[string]any) bool {
+	values := filter.path.values(resource)
+	if len(values) == 0 {
+		// Unassigned attributes are equivalent to null ones, RFC 7643 section 2.5
+		values = []any{nil}
+	}
+	if filter.operator == "ne" {
+		for _, value := range values {
+			if compare(value, "eq", filter.value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, value := range values {
+		if compare(value, filter.operator, filter.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuePathFilter matches the resources having an element of a multi-valued attribute matching a filter.
+type valuePathFilter struct {
+	attribute string
+	filter    Filter
+}
+
+func (filter *valuePathFilter) Match(resource map[string]any) bool {
+	for _, element := range elements(lookup(resource, filter.attribute)) {
+		if object, ok := element.(map[string]any); ok && filter.filter.Match(object) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare compares an attribute value to a filter value. Strings are compared case-insensitively,
+// and as dates when both are RFC 3339 date-times.
+func compare(value any, operator string, filterValue any) bool {
+	switch filterValue := filterValue.(type) {
+	case nil:
+		return operator == "eq" && value == nil
+	case bool:
+		b, ok := value.(bool)
+		return ok && operator == "eq" && b == filterValue
+	case float64:
+		n, ok := value.(float64)
+		if !ok {
+			return false
+		}
+		return compareOrdered(n, filterValue, operator)
+	case string:
+		s, ok := value.(string)
+		if !ok {
+			if n, isNumber := value.(float64); isNumber {
+				s, ok = strconv.FormatFloat(n, 'f', -1, 64), true
+			}
+		}
+		if !ok {
+			return false
+		}
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			if ft, err := time.Parse(time.RFC3339Nano, filterValue); err == nil {
+				return compareOrdered(t.UnixNano(), ft.UnixNano(), operator)
+			}
+		}
+		s, filterValue = strings.ToLower(s), strings.ToLower(filterValue)
+		switch operator {
+		case "co":
+			return strings.Contains(s, filterValue)
+		case "sw":
+			return strings.HasPrefix(s, filterValue)
+		case "ew":
+			return strings.HasSuffix(s, filterValue)
+		default:
+			return compareOrdered(s, filterValue, operator)
+		}
+	}
+	return false
+}
+
+// compareOrdered compares two ordered values with an operator.
+func compareOrdered[T int64 | float64 | string](a T, b T, operator string) bool {
+	switch operator {
+	case "eq":
+		return a == b
+	case "gt":
+		return a > b
+	case "ge":
+		return a >= b
+	case "lt":
+		return a < b
+	case "le":
+		return a <= b
+	default:
+		return false
+	}
+}
+
+// token is a lexical element of a filter.
+type token struct {
+	text   string
+	quoted bool // quoted is true for the JSON strings, whose text is their decoded value.
+}
+
+// tokenize splits a filter in words, JSON strings and brackets.
+func tokenize(filter string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(filter); {
+		c := filter[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("()[]", c) >= 0:
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(filter) && filter[end] != '"' {
+				if filter[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(filter) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(filter[i:end+1]), &value); err != nil {
+				return nil, fmt.Errorf("invalid string %s in filter", filter[i:end+1])
+			}
+			tokens = append(tokens, token{text: value, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(filter) && !unicode.IsSpace(rune(filter[end])) && strings.IndexByte("()[]\"", filter[end]) < 0 {
+				end++
+			}
+			tokens = append(tokens, token{text: filter[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// parser is a recursive descent parser of filters, "not" binding tighter than "and", itself tighter than "or".
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.position++
+	return t
+}
+
+// keyword checks if the next token is an unquoted keyword, consuming it when it is.
+func (p *parser) keyword(keyword string) bool {
+	if t := p.peek(); !t.quoted && strings.EqualFold(t.text, keyword) {
+		p.position++
+		return true
+	}
+	return false
+}
+
+// expect consumes a bracket.
+func (p *parser) expect(bracket string) error {
+	if t := p.next(); t.quoted || t.text != bracket {
+		return fmt.Errorf("expected %q in filter", bracket)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Filter, error) {
+	if !p.keyword("not") {
+		return p.parseAtom()
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &notFilter{filter: filter}, nil
+}
+
+func (p *parser) parseAtom() (Filter, error) {
+	if t := p.peek(); !t.quoted && t.text == "(" {
+		p.position++
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return filter, nil
+	}
+
+	t := p.next()
+	if t.quoted || t.text == "" || strings.ContainsAny(t.text, "()[]") {
+		return nil, fmt.Errorf("expected an attribute in filter")
+	}
+	path := parseAttributePath(t.text)
+
+	if next := p.peek(); !next.quoted && next.text == "[" {
+		p.position++
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &valuePathFilter{attribute: path.Attribute, filter: filter}, nil
+	}
+
+	if p.keyword("pr") {
+		return &presentFilter{path: path}, nil
+	}
+
+	operator := strings.ToLower(p.next().text)
+	switch operator {
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, fmt.Errorf("unknown operator %q in filter", operator)
+	}
+	if p.done() {
+		return nil, fmt.Errorf("missing value in filter")
+	}
+	value, err := parseValue(p.next())
+	if err != nil {
+		return nil, err
+	}
+
+	return &compareFilter{path: path, operator: operator, value: value}, nil
+}
+
+// parseValue decodes a comparison value: a string, a number, a boolean or null.
+func parseValue(t token) (any, error) {
+	if t.quoted {
+		return t.text, nil
+	}
+	switch strings.ToLower(t.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q in filter", t.text)
+	}
+	return number, nil
+}
@@ -0,0 +1,181 @@
+package scim_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/web/common/scim"
+)
+
+// bob is a user resource, as decoded from JSON.
+var bob = map[string]any{
+	"schemas":  []any{"urn:ietf:params:scim:schemas:core:2.0:User"},
+	"id":       "2",
+	"userName": "Bob",
+	"active":   true,
+	"name":     map[string]any{"givenName": "Bob", "familyName": "Smith"},
+	"emails": []any{
+		map[string]any{"value": "bob@example.com", "type": "work", "primary": true},
+		map[string]any{"value": "bob@home.example.org", "type": "home"},
+	},
+	"groups":     []any{map[string]any{"value": "7", "display": "admins"}},
+	"loginCount": float64(12),
+	"meta":       map[string]any{"resourceType": "User", "lastModified": "2024-05-01T10:00:00Z"},
+}
+
+func TestParseMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   bool
+	}{
+		{name: "eq", filter: `userName eq "bob"`, want: true},
+		{name: "eq of another value", filter: `userName eq "alice"`},
+		{name: "case-insensitive operator and attribute", filter: `USERNAME EQ "bob"`, want: true},
+		{name: "schema URN prefix", filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bob"`, want: true},
+		{name: "ne", filter: `userName ne "alice"`, want: true},
+		{name: "ne of the value", filter: `userName ne "bob"`},
+		{name: "ne of a missing attribute", filter: `title ne "manager"`, want: true},
+		{name: "co", filter: `userName co "O"`, want: true},
+		{name: "sw", filter: `userName sw "bo"`, want: true},
+		{name: "sw of the end", filter: `userName sw "ob"`},
+		{name: "ew", filter: `userName ew "OB"`, want: true},
+		{name: "gt", filter: `userName gt "alice"`, want: true},
+		{name: "ge", filter: `userName ge "bob"`, want: true},
+		{name: "lt", filter: `userName lt "alice"`},
+		{name: "le", filter: `userName le "bob"`, want: true},
+		{name: "pr", filter: `userName pr`, want: true},
+		{name: "pr of a missing attribute", filter: `title pr`},
+		{name: "sub-attribute", filter: `name.familyName eq "smith"`, want: true},
+		{name: "multi-valued sub-attribute", filter: `emails.type eq "home"`, want: true},
+		{name: "multi-valued attribute", filter: `emails eq "bob@example.com"`, want: true},
+		{name: "multi-valued co", filter: `emails co "example.org"`, want: true},
+		{name: "number", filter: `loginCount gt 10`, want: true},
+		{name: "number not greater", filter: `loginCount gt 12`},
+		{name: "number as a string", filter: `loginCount eq "12"`, want: true},
+		{name: "number against a string", filter: `userName eq 12`},
+		{name: "true", filter: `active eq true`, want: true},
+		{name: "false", filter: `active eq FALSE`},
+		{name: "null", filter: `title eq null`, want: true},
+		{name: "null of a present attribute", filter: `userName eq null`},
+		{name: "date", filter: `meta.lastModified gt "2024-04-30T23:00:00Z"`, want: true},
+		{name: "date in another time zone", filter: `meta.lastModified eq "2024-05-01T12:00:00+02:00"`, want: true},
+		{name: "date before", filter: `meta.lastModified lt "2024-05-01T09:59:59.5Z"`},
+		{name: "escaped string", filter: `userName eq "bob" and name.familyName ne "a \"quoted\" name"`, want: true},
+		{name: "and", filter: `userName eq "bob" and active eq true`, want: true},
+		{name: "and with a mismatch", filter: `userName eq "bob" and active eq false`},
+		{name: "or", filter: `userName eq "alice" or active eq true`, want: true},
+		{name: "not", filter: `not (userName eq "alice")`, want: true},
+		{name: "not of a match", filter: `not(userName eq "bob")`},
+		// "and" binds tighter than "or": true or (false and false)
+		{name: "and before or", filter: `userName eq "bob" or userName eq "alice" and active eq false`, want: true},
+		{name: "grouping", filter: `(userName eq "bob" or userName eq "alice") and active eq false`},
+		// "not" binds tighter than "and": (not false) and true
+		{name: "not before and", filter: `not (userName eq "alice") and active eq true`, want: true},
+		{name: "value path", filter: `emails[type eq "work" and value co "@example.com"]`, want: true},
+		{name: "value path of different elements", filter: `emails[type eq "home" and value co "@example.com"]`},
+		{name: "value path with or", filter: `emails[type eq "other" or primary eq true]`, want: true},
+		{name: "value path of a missing attribute", filter: `addresses[type eq "work"]`},
+		{name: "value path and another filter", filter: `groups[display eq "admins"] and userName sw "b"`, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := scim.Parse(test.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := filter.Match(bob); got != test.want {
+				t.Errorf("Match() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+	}{
+		{name: "empty", filter: ""},
+		{name: "unterminated string", filter: `userName eq "bob`},
+		{name: "unterminated escape", filter: `userName eq "bob\"`},
+		{name: "invalid escape", filter: `userName eq "b\qb"`},
+		{name: "unknown operator", filter: `userName is "bob"`},
+		{name: "missing operator", filter: `userName`},
+		{name: "missing value", filter: `userName eq`},
+		{name: "invalid value", filter: `userName eq bob`},
+		{name: "quoted attribute", filter: `"userName" eq "bob"`},
+		{name: "missing attribute", filter: `eq "bob"`},
+		{name: "dangling and", filter: `userName eq "bob" and`},
+		{name: "two expressions", filter: `userName eq "bob" active eq true`},
+		{name: "unclosed parenthesis", filter: `(userName eq "bob"`},
+		{name: "extra parenthesis", filter: `userName eq "bob")`},
+		{name: "not without parenthesis", filter: `not userName eq "bob"`},
+		{name: "unclosed value path", filter: `emails[type eq "work"`},
+		{name: "value path closed by a parenthesis", filter: `emails[type eq "work")`},
+		{name: "empty value path", filter: `emails[]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if filter, err := scim.Parse(test.filter); err == nil {
+				t.Errorf("Parse() = %v, want an error", filter)
+			}
+		})
+	}
+}
+
+func TestConditions(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   []scim.Condition
+		wantOK bool
+	}{
+		{
+			name:   "comparison",
+			filter: `userName eq "bob"`,
+			want:   []scim.Condition{{Path: scim.AttributePath{Attribute: "userName"}, Operator: "eq", Value: "bob"}},
+			wantOK: true,
+		},
+		{
+			name:   "and",
+			filter: `userName sw "b" and emails.value co "@example.com"`,
+			want: []scim.Condition{
+				{Path: scim.AttributePath{Attribute: "userName"}, Operator: "sw", Value: "b"},
+				{Path: scim.AttributePath{Attribute: "emails", SubAttribute: "value"}, Operator: "co", Value: "@example.com"},
+			},
+			wantOK: true,
+		},
+		{
+			name:   "value path",
+			filter: `emails[value eq "bob@example.com"] and displayName co "admin"`,
+			want: []scim.Condition{
+				{Path: scim.AttributePath{Attribute: "emails", SubAttribute: "value"}, Operator: "eq", Value: "bob@example.com"},
+				{Path: scim.AttributePath{Attribute: "displayName"}, Operator: "co", Value: "admin"},
+			},
+			wantOK: true,
+		},
+		{name: "or", filter: `userName eq "bob" or userName eq "alice"`},
+		{name: "not", filter: `not (userName eq "bob")`},
+		{name: "present", filter: `userName pr`},
+		{name: "number", filter: `loginCount gt 10`},
+		{name: "boolean", filter: `active eq true and userName eq "bob"`},
+		{name: "value path with or", filter: `emails[type eq "work" or value co "@example.com"]`},
+		{name: "value path of a sub-attribute", filter: `name[familyName.first eq "s"]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := scim.Parse(test.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, ok := scim.Conditions(filter)
+			if ok != test.wantOK || !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Conditions() = %+v, %v, want %+v, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
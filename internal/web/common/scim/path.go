@@ -0,0 +1,107 @@
+package scim
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AttributePath designates an attribute of a resource, or a sub-attribute of a complex attribute.
+type AttributePath struct {
+	Attribute    string // Attribute is the name of the attribute, without its schema URN.
+	SubAttribute string // SubAttribute is the name of the sub-attribute, empty for the attribute itself.
+}
+
+// Path is the target of a PATCH operation, such as `members[value eq "2"]` or `emails[type eq "work"].value`.
+type Path struct {
+	AttributePath
+	Filter Filter // Filter selects the elements of a multi-valued attribute, nil to target all of them.
+}
+
+// ParsePath parses the path of a PATCH operation, as described by RFC 7644 section 3.5.2.
+func ParsePath(path string) (*Path, error) {
+	open := strings.IndexByte(path, '[')
+	if open < 0 {
+		if path == "" || strings.ContainsAny(path, " ]\"") {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+		return &Path{AttributePath: parseAttributePath(path)}, nil
+	}
+
+	end := strings.LastIndexByte(path, ']')
+	if end < open {
+		return nil, fmt.Errorf("invalid path %q", path)
+	}
+	filter, err := Parse(path[open+1 : end])
+	if err != nil {
+		return nil, err
+	}
+	parsed := &Path{AttributePath: parseAttributePath(path[:open]), Filter: filter}
+	if rest := path[end+1:]; rest != "" {
+		if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+		parsed.SubAttribute = rest[1:]
+	}
+
+	return parsed, nil
+}
+
+// parseAttributePath parses an attribute path such as "userName", "emails.value" or
+// "urn:ietf:params:scim:schemas:core:2.0:User:userName".
+func parseAttributePath(path string) AttributePath {
+	// The attributes of the schema URN prefix are the core attributes themselves
+	if i := strings.LastIndexByte(path, ':'); i >= 0 {
+		path = path[i+1:]
+	}
+	attribute, subAttribute, _ := strings.Cut(path, ".")
+	return AttributePath{Attribute: attribute, SubAttribute: subAttribute}
+}
+
+// Is checks if the path designates an attribute, case-insensitively.
+func (path AttributePath) Is(attribute string) bool {
+	return strings.EqualFold(path.Attribute, attribute)
+}
+
+// values returns the values of the attribute in a resource, those of every element of a multi-valued attribute.
+// The elements of complex attributes compare as their value sub-attribute, such as `emails eq "bob@example.com"`.
+func (path AttributePath) values(resource map[string]any) []any {
+	var values []any
+	for _, element := range elements(lookup(resource, path.Attribute)) {
+		object, isObject := element.(map[string]any)
+		switch {
+		case path.SubAttribute != "" && isObject:
+			values = append(values, elements(lookup(object, path.SubAttribute))...)
+		case path.SubAttribute != "":
+		case isObject:
+			values = append(values, lookup(object, "value"))
+		default:
+			values = append(values, element)
+		}
+	}
+	return values
+}
+
+// lookup returns the value of an attribute of a resource by case-insensitive name, nil when it doesn't have it.
+func lookup(resource map[string]any, attribute string) any {
+	if value, ok := resource[attribute]; ok {
+		return value
+	}
+	for name, value := range resource {
+		if strings.EqualFold(name, attribute) {
+			return value
+		}
+	}
+	return nil
+}
+
+// elements returns the elements of a multi-valued attribute, or the value of a single-valued one.
+func elements(value any) []any {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return value
+	default:
+		return []any{value}
+	}
+}
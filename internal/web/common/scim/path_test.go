@@ -0,0 +1,56 @@
+package scim_test
+
+import (
+	"testing"
+
+	"github.com/Nokeni/GODS/internal/web/common/scim"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		want       scim.AttributePath
+		wantFilter bool
+		wantErr    bool
+	}{
+		{name: "attribute", path: "displayName", want: scim.AttributePath{Attribute: "displayName"}},
+		{name: "sub-attribute", path: "name.familyName", want: scim.AttributePath{Attribute: "name", SubAttribute: "familyName"}},
+		{name: "schema URN prefix", path: "urn:ietf:params:scim:schemas:core:2.0:User:active", want: scim.AttributePath{Attribute: "active"}},
+		{name: "filter", path: `members[value eq "2"]`, want: scim.AttributePath{Attribute: "members"}, wantFilter: true},
+		{name: "filter and sub-attribute", path: `emails[type eq "work"].value`, want: scim.AttributePath{Attribute: "emails", SubAttribute: "value"}, wantFilter: true},
+		{name: "brackets in the filter", path: `emails[value eq "[work]"].display`, want: scim.AttributePath{Attribute: "emails", SubAttribute: "display"}, wantFilter: true},
+		{name: "empty", path: "", wantErr: true},
+		{name: "space", path: "display name", wantErr: true},
+		{name: "unclosed filter", path: `members[value eq "2"`, wantErr: true},
+		{name: "invalid filter", path: `members[value is "2"]`, wantErr: true},
+		{name: "text after the filter", path: `emails[type eq "work"]value`, wantErr: true},
+		{name: "empty sub-attribute", path: `emails[type eq "work"].`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := scim.ParsePath(test.path)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParsePath() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if got.AttributePath != test.want || (got.Filter != nil) != test.wantFilter {
+				t.Errorf("ParsePath() = %+v, want %+v with a filter %v", got, test.want, test.wantFilter)
+			}
+		})
+	}
+
+	path, err := scim.ParsePath(`members[value eq "2"]`)
+	if err != nil {
+		t.Fatalf("ParsePath() error = %v", err)
+	}
+	if !path.Filter.Match(map[string]any{"value": "2"}) || path.Filter.Match(map[string]any{"value": "3"}) {
+		t.Error("the filter of ParsePath() doesn't select the member 2")
+	}
+	if !path.Is("MEMBERS") || path.Is("member") {
+		t.Error("Is() doesn't compare the attribute case-insensitively")
+	}
+}
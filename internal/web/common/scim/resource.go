@@ -0,0 +1,129 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// alwaysReturned are the attributes returned whatever the requested attributes.
+var alwaysReturned = []string{"schemas", "id"}
+
+// ToResource converts a representation of a resource to its JSON object, as filters and projections work on.
+func ToResource(representation any) (map[string]any, error) {
+	data, err := json.Marshal(representation)
+	if err != nil {
+		return nil, err
+	}
+	var resource map[string]any
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// SplitAttributes splits the comma-separated value of the attributes and excludedAttributes query parameters.
+func SplitAttributes(value string) []string {
+	var attributes []string
+	for _, attribute := range strings.Split(value, ",") {
+		if attribute = strings.TrimSpace(attribute); attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+	return attributes
+}
+
+// Project returns the requested attributes of a resource when some are, or its attributes but the excluded ones,
+// as described by RFC 7644 section 3.9. The schemas and the id are always returned.
+func Project(resource map[string]any, attributes []string, excludedAttributes []string) map[string]any {
+	if len(attributes) > 0 {
+		projected := make(map[string]any)
+		for _, name := range alwaysReturned {
+			if value, ok := resource[name]; ok {
+				projected[name] = value
+			}
+		}
+
+		subAttributes := make(map[string][]string)
+		for _, attribute := range attributes {
+			path := parseAttributePath(attribute)
+			key, ok := keyOf(resource, path.Attribute)
+			if !ok {
+				continue
+			}
+			if path.SubAttribute == "" {
+				projected[key] = resource[key]
+				subAttributes[key] = nil
+			} else if sub, selected := subAttributes[key]; !selected || sub != nil {
+				subAttributes[key] = append(sub, path.SubAttribute)
+			}
+		}
+		for key, sub := range subAttributes {
+			if sub != nil {
+				projected[key] = mapElements(resource[key], func(object map[string]any) map[string]any {
+					kept := make(map[string]any)
+					for _, name := range sub {
+						if subKey, ok := keyOf(object, name); ok {
+							kept[subKey] = object[subKey]
+						}
+					}
+					return kept
+				})
+			}
+		}
+		return projected
+	}
+
+	projected := make(map[string]any, len(resource))
+	for key, value := range resource {
+		projected[key] = value
+	}
+	for _, attribute := range excludedAttributes {
+		path := parseAttributePath(attribute)
+		key, ok := keyOf(projected, path.Attribute)
+		if !ok || key == "schemas" || key == "id" {
+			continue
+		}
+		if path.SubAttribute == "" {
+			delete(projected, key)
+			continue
+		}
+		projected[key] = mapElements(projected[key], func(object map[string]any) map[string]any {
+			kept := make(map[string]any, len(object))
+			for name, value := range object {
+				if !strings.EqualFold(name, path.SubAttribute) {
+					kept[name] = value
+				}
+			}
+			return kept
+		})
+	}
+	return projected
+}
+
+// keyOf returns the key of an attribute of a resource by case-insensitive name.
+func keyOf(resource map[string]any, attribute string) (string, bool) {
+	for key := range resource {
+		if strings.EqualFold(key, attribute) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// mapElements transforms the complex value of an attribute, or each of its elements when it's multi-valued.
+func mapElements(value any, transform func(object map[string]any) map[string]any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		return transform(value)
+	case []any:
+		transformed := make([]any, 0, len(value))
+		for _, element := range value {
+			if object, ok := element.(map[string]any); ok {
+				transformed = append(transformed, transform(object))
+			}
+		}
+		return transformed
+	default:
+		return value
+	}
+}
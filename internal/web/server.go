@@ -1,13 +1,25 @@
 package web
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
 	_ "github.com/Nokeni/GODS/docs"
+	"github.com/Nokeni/GODS/internal/ldap"
+	"github.com/Nokeni/GODS/internal/mail"
+	"github.com/Nokeni/GODS/internal/passhash"
 	"github.com/Nokeni/GODS/internal/web/api/handlers"
 	"github.com/Nokeni/GODS/internal/web/api/middlewares"
+	"github.com/Nokeni/GODS/internal/web/api/models"
 	"github.com/Nokeni/GODS/internal/web/api/repositories"
 	apiroutes "github.com/Nokeni/GODS/internal/web/api/routes"
 	"github.com/Nokeni/GODS/internal/web/api/services"
 	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	uihandlers "github.com/Nokeni/GODS/internal/web/ui/handlers"
+	uimiddlewares "github.com/Nokeni/GODS/internal/web/ui/middlewares"
+	uiroutes "github.com/Nokeni/GODS/internal/web/ui/routes"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	swaggerfiles "github.com/swaggo/files"
@@ -24,34 +36,126 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-// @description Type "Bearer" followed by a space and JWT token.
-func NewHTTPServer(database *gorm.DB) (*gin.Engine, error) {
+// @description Type "Bearer" followed by a space and a JWT or an API key.
+//
+// NewHTTPServer sets up the routes and starts the background tasks, which stop when the context is done. The
+// returned WaitGroup is done once they've all stopped, so that the database can be closed.
+func NewHTTPServer(ctx context.Context, database *gorm.DB) (*gin.Engine, *sync.WaitGroup, error) {
 	router := gin.Default()
 	if err := router.SetTrustedProxies([]string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.1"}); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	// Name the fields of the validation errors as the clients send them
+	handlers.RegisterFieldNames()
+
 	// Set up the api repositories
 	userRepository := repositories.NewUserRepository(database)
 	groupRepository := repositories.NewGroupRepository(database)
 	userGroupRepository := repositories.NewUserGroupRepository(database)
+	refreshTokenRepository := repositories.NewRefreshTokenRepository(database)
+	revokedTokenRepository := repositories.NewRevokedTokenRepository(database)
+	roleRepository := repositories.NewRoleRepository(database)
+	permissionRepository := repositories.NewPermissionRepository(database)
+	auditEventRepository := repositories.NewAuditEventRepository(database)
+	recoveryCodeRepository := repositories.NewRecoveryCodeRepository(database)
+	verificationTokenRepository := repositories.NewVerificationTokenRepository(database)
+	clientRepository := repositories.NewClientRepository(database)
+	authorizationCodeRepository := repositories.NewAuthorizationCodeRepository(database)
+	signingKeyRepository := repositories.NewSigningKeyRepository(database)
+	apiKeyRepository := repositories.NewAPIKeyRepository(database)
+	bulkRepository := repositories.NewBulkRepository(database)
+	webhookRepository := repositories.NewWebhookRepository(database)
+	webhookDeliveryRepository := repositories.NewWebhookDeliveryRepository(database)
+	passwordHistoryRepository := repositories.NewPasswordHistoryRepository(database)
+	loginAttemptRepository := repositories.NewInMemoryLoginAttemptRepository()
+
+	mailer, err := mail.NewMailer()
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Set up the api services
-	userService := services.NewUserService(userRepository)
-	groupService := services.NewGroupService(groupRepository)
-	userGroupService := services.NewUserGroupService(userGroupRepository)
-	authService := services.NewAuthService(userRepository)
+	auditService := services.NewAuditService(auditEventRepository)
+	webhookService := services.NewWebhookService(webhookRepository, webhookDeliveryRepository, auditService)
+	passwordHasher, err := passhash.NewHasher()
+	if err != nil {
+		return nil, nil, err
+	}
+	passwordPolicyService, err := services.NewPasswordPolicyService(passwordHistoryRepository, passwordHasher)
+	if err != nil {
+		return nil, nil, err
+	}
+	userService := services.NewUserService(userRepository, refreshTokenRepository, apiKeyRepository, passwordPolicyService, auditService, webhookService)
+	groupService := services.NewGroupService(groupRepository, auditService, webhookService)
+	userGroupService := services.NewUserGroupService(userGroupRepository, auditService, webhookService)
+	roleService := services.NewRoleService(roleRepository, permissionRepository, userGroupRepository, auditService)
+	keyStoreService, err := services.NewKeyStoreService(signingKeyRepository, auditService)
+	if err != nil {
+		return nil, nil, err
+	}
+	lockoutService := services.NewLockoutService(loginAttemptRepository, auditService)
+	mfaService := services.NewMFAService(userRepository, recoveryCodeRepository, userGroupRepository, auditService)
+	verificationService := services.NewVerificationService(userRepository, verificationTokenRepository, refreshTokenRepository, lockoutService, passwordPolicyService, auditService, mailer)
+	authService := services.NewAuthService(userRepository, refreshTokenRepository, revokedTokenRepository, lockoutService, mfaService, passwordPolicyService, passwordHasher, verificationService, keyStoreService, auditService, webhookService)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepository, userRepository, permissionRepository, auditService)
+	clientService := services.NewClientService(clientRepository, auditService)
+	oidcService := services.NewOIDCService(userRepository, userGroupRepository, authorizationCodeRepository, clientService, keyStoreService, auditService)
+	directoryService := services.NewDirectoryService(userRepository, groupRepository, userGroupRepository, authService, apiKeyService, roleService, auditService)
+	scimService := services.NewSCIMService(userRepository, groupRepository, userService, groupService, userGroupService)
+	bulkService := services.NewBulkService(bulkRepository, userRepository, groupRepository, passwordPolicyService, auditService, webhookService)
+	retentionService := services.NewRetentionService(userService, groupService)
+	consoleService := services.NewConsoleService(userRepository, revokedTokenRepository, authService, roleService, keyStoreService, auditService)
 
 	// Set up the api handlers
 	userHandler := handlers.NewUserHandler(userService)
 	groupHandler := handlers.NewGroupHandler(groupService)
 	userGroupHandler := handlers.NewUserGroupHandler(userGroupService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	meHandler := handlers.NewMeHandler(userService)
+	mfaHandler := handlers.NewMFAHandler(mfaService)
 	authHandler := handlers.NewAuthHandler(authService)
+	verificationHandler := handlers.NewVerificationHandler(verificationService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	lockoutHandler := handlers.NewLockoutHandler(lockoutService)
+	clientHandler := handlers.NewClientHandler(clientService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, authService)
+	signingKeyHandler := handlers.NewSigningKeyHandler(keyStoreService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	scimHandler := handlers.NewSCIMHandler(scimService)
+	bulkHandler := handlers.NewBulkHandler(bulkService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	// Set up the admin console handlers
+	uiAuthHandler := uihandlers.NewAuthHandler(consoleService)
+	uiUserHandler := uihandlers.NewUserHandler(userService, groupService, userGroupService)
+	uiGroupHandler := uihandlers.NewGroupHandler(groupService, userService, userGroupService)
 
-	// Create the admin user and group
-	adminUser, _ := userService.Create(&dtos.CreateUserDTO{Name: viper.GetString("ADMIN_NAME"), Email: viper.GetString("ADMIN_EMAIL"), Password: viper.GetString("ADMIN_PASSWORD")})
-	adminGroup, _ := groupService.Create(&dtos.CreateGroupDTO{Name: "admin"})
-	userGroupService.AddUserToGroup(adminUser.ID, adminGroup.ID)
+	// Create the admin user and group, or restore them, the associations are only made when they're created or
+	// restored so that the audit log isn't flooded on every startup
+	adminUser, userBootstrapped, err := bootstrapAdminUser(ctx, userRepository, userService)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set up the admin user: %w", err)
+	}
+	adminGroup, groupBootstrapped, err := bootstrapAdminGroup(ctx, groupRepository, groupService)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set up the admin group: %w", err)
+	}
+	if userBootstrapped || groupBootstrapped {
+		userGroupService.AddUserToGroup(ctx, adminUser.ID, adminGroup.ID)
+	}
+
+	// Create the permissions and the admin role granting all of them to the admin group
+	adminRole, roleErr := roleService.Create(ctx, &dtos.CreateRoleDTO{Name: "admin", Description: "Grants every permission"})
+	for _, defaultPermission := range models.DefaultPermissions {
+		permission, err := roleService.CreatePermission(&models.Permission{Name: defaultPermission.Name, Description: defaultPermission.Description})
+		if err == nil || roleErr == nil {
+			roleService.AddPermissionToRole(ctx, adminRole.ID, permission.ID)
+		}
+	}
+	if roleErr == nil || groupBootstrapped {
+		roleService.AddRoleToGroup(ctx, adminRole.ID, adminGroup.ID)
+	}
 
 	// Set up API routes
 	apiroutes.RegisterAPIRoutes(
@@ -59,15 +163,81 @@ func NewHTTPServer(database *gorm.DB) (*gin.Engine, error) {
 		userHandler,
 		groupHandler,
 		userGroupHandler,
+		roleHandler,
+		meHandler,
+		mfaHandler,
 		authHandler,
-		middlewares.AuthMiddleware(),
-		middlewares.AdminMiddleware(userService),
+		verificationHandler,
+		auditHandler,
+		lockoutHandler,
+		clientHandler,
+		signingKeyHandler,
+		apiKeyHandler,
+		bulkHandler,
+		webhookHandler,
+		middlewares.AuthMiddleware(authService, apiKeyService),
+		middlewares.RequireSession(),
+		middlewares.RequirePermission(roleService),
+		middlewares.ProblemDetails(),
 	)
 
-	// Set up UI routes
-	// uiroutes.SetupUIRoutes(router, nil)
+	// Set up the OpenID Connect provider routes
+	apiroutes.RegisterOIDCRoutes(router, oidcHandler, signingKeyHandler)
+
+	// Set up the SCIM provisioning routes
+	apiroutes.RegisterSCIMRoutes(router, scimHandler, middlewares.AuthMiddleware(authService, apiKeyService), middlewares.RequirePermission(roleService), middlewares.SCIMErrors())
+
+	var background sync.WaitGroup
+
+	// Deliver the events queued for the webhooks
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		webhookService.Run(ctx)
+	}()
+
+	// Purge the users and groups deleted for longer than the retention period
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		retentionService.Run(ctx)
+	}()
+
+	// Serve the users and groups over LDAP
+	if viper.GetBool("LDAP_ENABLED") {
+		ldapServer, err := ldap.NewServer(directoryService)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := ldapServer.Listen(viper.GetString("LDAP_ADDRESS")); err != nil {
+			return nil, nil, err
+		}
+		background.Add(2)
+		go func() {
+			defer background.Done()
+			if err := ldapServer.Serve(); err != nil {
+				log.Printf("LDAP server stopped: %v", err)
+			}
+		}()
+		go func() {
+			defer background.Done()
+			<-ctx.Done()
+			ldapServer.Close()
+			ldapServer.Wait()
+		}()
+	}
+
+	// Set up the admin console routes
+	uiroutes.SetupUIRoutes(
+		router,
+		uiAuthHandler,
+		uiUserHandler,
+		uiGroupHandler,
+		uimiddlewares.RequireSession(consoleService),
+		uimiddlewares.RequirePermission(roleService),
+	)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	return router, nil
+	return router, &background, nil
 }
@@ -0,0 +1,177 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// TLSReloader provides the TLS configuration of the web server from the WEB_TLS_* settings, reloading the certificate,
+// the key and the client CAs when their files change so that renewed certificates are used without a restart.
+type TLSReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+
+	mutex    sync.RWMutex
+	config   *tls.Config
+	modTimes []time.Time
+}
+
+// NewTLSReloader loads the configured certificate, and the client CAs when client certificates are verified. It
+// returns nil when no certificate is configured, the server being served over plain HTTP.
+func NewTLSReloader() (*TLSReloader, error) {
+	certFile, keyFile := viper.GetString("WEB_TLS_CERT_FILE"), viper.GetString("WEB_TLS_KEY_FILE")
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	reloader := &TLSReloader{certFile: certFile, keyFile: keyFile, clientCAFile: viper.GetString("WEB_TLS_CLIENT_CA_FILE")}
+	switch clientAuth := viper.GetString("WEB_TLS_CLIENT_AUTH"); clientAuth {
+	case "", "none":
+		reloader.clientAuth = tls.NoClientCert
+	case "optional":
+		reloader.clientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		reloader.clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("invalid WEB_TLS_CLIENT_AUTH %q, expected none, optional or require", clientAuth)
+	}
+	if reloader.clientAuth != tls.NoClientCert && reloader.clientCAFile == "" {
+		return nil, errors.New("WEB_TLS_CLIENT_CA_FILE is required to verify the client certificates")
+	}
+
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// TLSConfig returns the configuration of the server, which picks the current certificate and client CAs at each
+// handshake.
+func (reloader *TLSReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			reloader.mutex.RLock()
+			defer reloader.mutex.RUnlock()
+			return reloader.config, nil
+		},
+	}
+}
+
+// Run checks the files every WEB_TLS_RELOAD_INTERVAL until the context is done, and reloads them when they've been
+// modified. A certificate that fails to load is reported and the previous one is kept.
+func (reloader *TLSReloader) Run(ctx context.Context) {
+	interval := viper.GetDuration("WEB_TLS_RELOAD_INTERVAL")
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTimes, err := reloader.stat()
+		if err != nil {
+			log.Printf("failed to check the TLS certificate: %v", err)
+			continue
+		}
+		reloader.mutex.RLock()
+		modified := !equalTimes(modTimes, reloader.modTimes)
+		reloader.mutex.RUnlock()
+		if !modified {
+			continue
+		}
+
+		if err := reloader.reload(); err != nil {
+			log.Printf("failed to reload the TLS certificate, keeping the previous one: %v", err)
+			continue
+		}
+		log.Printf("reloaded the TLS certificate from %s", reloader.certFile)
+	}
+}
+
+// reload loads the files and replaces the configuration given to the new connections.
+func (reloader *TLSReloader) reload() error {
+	// The modification times are read first, a file changed while loading is then loaded again at the next check
+	modTimes, err := reloader.stat()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the TLS certificate: %v", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		ClientAuth:   reloader.clientAuth,
+	}
+
+	if reloader.clientAuth != tls.NoClientCert {
+		pem, err := os.ReadFile(reloader.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the client CAs: %v", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in %s", reloader.clientCAFile)
+		}
+		config.ClientCAs = clientCAs
+	}
+
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	reloader.config, reloader.modTimes = config, modTimes
+
+	return nil
+}
+
+// stat returns the modification times of the files.
+func (reloader *TLSReloader) stat() ([]time.Time, error) {
+	files := []string{reloader.certFile, reloader.keyFile}
+	if reloader.clientAuth != tls.NoClientCert {
+		files = append(files, reloader.clientCAFile)
+	}
+
+	modTimes := make([]time.Time, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// equalTimes reports whether two lists of times are the same.
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/ui/middlewares"
+	"github.com/Nokeni/GODS/internal/web/ui/views"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthHandler defines the interface for the login and logout pages of the admin console.
+type AuthHandler interface {
+	Home(c *gin.Context)
+	LoginPage(c *gin.Context)
+	Login(c *gin.Context)
+	Logout(c *gin.Context)
+}
+
+// AuthHandlerImplementation handles the login and logout of the admin console.
+type AuthHandlerImplementation struct {
+	consoleService services.ConsoleService
+}
+
+// NewAuthHandler creates a new instance of the AuthHandlerImplementation.
+func NewAuthHandler(consoleService services.ConsoleService) *AuthHandlerImplementation {
+	return &AuthHandlerImplementation{
+		consoleService: consoleService,
+	}
+}
+
+// loginForm is the data of the login page.
+type loginForm struct {
+	Name     string `form:"name"`
+	Password string `form:"password"`
+	Code     string `form:"code"`
+	Next     string `form:"next"`
+}
+
+// Home sends the user to the first page of the console.
+func (handler *AuthHandlerImplementation) Home(c *gin.Context) {
+	c.Redirect(http.StatusSeeOther, "/console/users")
+}
+
+// LoginPage shows the login form.
+func (handler *AuthHandlerImplementation) LoginPage(c *gin.Context) {
+	views.Render(c, http.StatusOK, "login.html", &views.Page{Title: "Sign in", Data: &loginForm{Next: c.Query("next")}})
+}
+
+// Login logs a user in to the console and sends them to the page they requested.
+func (handler *AuthHandlerImplementation) Login(c *gin.Context) {
+	var form loginForm
+	if err := c.ShouldBind(&form); err != nil {
+		views.Render(c, http.StatusBadRequest, "login.html", &views.Page{Title: "Sign in", Error: err.Error(), Data: &form})
+		return
+	}
+
+	sessionToken, err := handler.consoleService.Login(requestContext(c), &dtos.LoginDTO{Name: form.Name, Password: form.Password}, form.Code)
+	if err != nil {
+		status := http.StatusUnauthorized
+		var (
+			lockedError  *services.LockedError
+			forbiddenErr *services.ForbiddenError
+		)
+		switch {
+		case errors.As(err, &lockedError):
+			status = http.StatusTooManyRequests
+		case errors.As(err, &forbiddenErr):
+			status = http.StatusForbidden
+		}
+		views.Render(c, status, "login.html", &views.Page{Title: "Sign in", Error: err.Error(), Data: &form})
+		return
+	}
+
+	// A new CSRF token is used from the login on
+	if _, err := middlewares.NewCSRFToken(c); err != nil {
+		views.RenderError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(middlewares.SessionCookieName, sessionToken, 0, "/console", "", views.IsSecure(c), true)
+
+	// Only the console pages are valid destinations, the others could be other sites
+	next := form.Next
+	if !strings.HasPrefix(next, "/console/") {
+		next = "/console/"
+	}
+	c.Redirect(http.StatusSeeOther, next)
+}
+
+// Logout ends the console session of the user.
+func (handler *AuthHandlerImplementation) Logout(c *gin.Context) {
+	if claims, ok := c.MustGet("consoleClaims").(*services.ConsoleSessionClaims); ok {
+		if err := handler.consoleService.Logout(requestContext(c), claims); err != nil {
+			views.RenderError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	c.SetCookie(middlewares.SessionCookieName, "", -1, "/console", "", views.IsSecure(c), true)
+	views.Redirect(c, "/console/login", "You have been logged out")
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// requestContext returns the context of the request, carrying the actor recorded in the audit log.
+func requestContext(c *gin.Context) context.Context {
+	actor := services.Actor{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	if userID, exists := c.Get("userID"); exists {
+		if uid, ok := userID.(uint); ok {
+			actor.UserID = &uid
+		}
+	}
+
+	return services.WithActor(c.Request.Context(), actor)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/ui/views"
+	"github.com/gin-gonic/gin"
+)
+
+// GroupHandler defines the interface for the group pages of the admin console.
+type GroupHandler interface {
+	List(c *gin.Context)
+	Show(c *gin.Context)
+	New(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	AddUser(c *gin.Context)
+	RemoveUser(c *gin.Context)
+	AddSubgroup(c *gin.Context)
+	RemoveSubgroup(c *gin.Context)
+}
+
+// GroupHandlerImplementation handles the group pages of the admin console.
+type GroupHandlerImplementation struct {
+	groupService     services.GroupService
+	userService      services.UserService
+	userGroupService services.UserGroupService
+}
+
+// NewGroupHandler creates a new instance of the GroupHandlerImplementation.
+func NewGroupHandler(groupService services.GroupService, userService services.UserService, userGroupService services.UserGroupService) *GroupHandlerImplementation {
+	return &GroupHandlerImplementation{
+		groupService:     groupService,
+		userService:      userService,
+		userGroupService: userGroupService,
+	}
+}
+
+// List shows a page of groups, whose names contain the searched text if any.
+func (handler *GroupHandlerImplementation) List(c *gin.Context) {
+	listQuery, page := bindListQuery(c)
+
+	groups, pageInfo, err := handler.groupService.GetAll(listQuery)
+	if err != nil {
+		views.RenderError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	views.Render(c, http.StatusOK, "groups.html", &views.Page{Title: "Groups", Data: gin.H{
+		"Groups":     groups,
+		"Query":      c.Query("q"),
+		"Pagination": views.NewPagination(c, page, pageInfo),
+	}})
+}
+
+// Show shows a group, the form to edit it, its members and its subgroups.
+func (handler *GroupHandlerImplementation) Show(c *gin.Context) {
+	group, ok := handler.bindGroup(c)
+	if !ok {
+		return
+	}
+
+	handler.render(c, http.StatusOK, group, "")
+}
+
+// New shows the form to create a group.
+func (handler *GroupHandlerImplementation) New(c *gin.Context) {
+	views.Render(c, http.StatusOK, "group_new.html", &views.Page{Title: "New group", Data: &dtos.CreateGroupDTO{}})
+}
+
+// Create creates a group from the new group form.
+func (handler *GroupHandlerImplementation) Create(c *gin.Context) {
+	var groupDTO dtos.CreateGroupDTO
+	if err := c.ShouldBind(&groupDTO); err != nil {
+		views.Render(c, http.StatusBadRequest, "group_new.html", &views.Page{Title: "New group", Error: err.Error(), Data: &groupDTO})
+		return
+	}
+
+	group, err := handler.groupService.Create(requestContext(c), &groupDTO)
+	if err != nil {
+		views.Render(c, errorStatus(err), "group_new.html", &views.Page{Title: "New group", Error: err.Error(), Data: &groupDTO})
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/groups/%d", group.ID), "Group created")
+}
+
+// Update modifies a group from the edit form.
+func (handler *GroupHandlerImplementation) Update(c *gin.Context) {
+	group, ok := handler.bindGroup(c)
+	if !ok {
+		return
+	}
+
+	var groupDTO dtos.UpdateGroupDTO
+	if err := c.ShouldBind(&groupDTO); err != nil {
+		handler.render(c, http.StatusBadRequest, group, err.Error())
+		return
+	}
+
+	// The group is modified in place, so the form is shown again with the stored values if it fails
+	edited := *group
+	if err := handler.groupService.Update(requestContext(c), &edited, &groupDTO); err != nil {
+		handler.render(c, errorStatus(err), group, err.Error())
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/groups/%d", group.ID), "Group saved")
+}
+
+// Delete deletes a group, which can be restored through the API until purged.
+func (handler *GroupHandlerImplementation) Delete(c *gin.Context) {
+	group, ok := handler.bindGroup(c)
+	if !ok {
+		return
+	}
+
+	if err := handler.groupService.Delete(requestContext(c), group.ID); err != nil {
+		handler.render(c, http.StatusInternalServerError, group, err.Error())
+		return
+	}
+
+	views.Redirect(c, "/console/groups", fmt.Sprintf("Group %s deleted", group.Name))
+}
+
+// AddUser adds the user named in the form to a group.
+func (handler *GroupHandlerImplementation) AddUser(c *gin.Context) {
+	group, ok := handler.bindGroup(c)
+	if !ok {
+		return
+	}
+
+	users, _, err := handler.userService.GetAll(byName(c.PostForm("user")))
+	if err != nil {
+		handler.render(c, http.StatusInternalServerError, group, err.Error())
+		return
+	}
+	if len(users) == 0 {
+		handler.render(c, http.StatusBadRequest, group, fmt.Sprintf("User %q not found", c.PostForm("user")))
+		return
+	}
+
+	if err := handler.userGroupService.AddUserToGroup(requestContext(c), users[0].ID, group.ID); err != nil {
+		handler.render(c, http.StatusBadRequest, group, err.Error())
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/groups/%d", group.ID), fmt.Sprintf("%s added", users[0].Name))
+}
+
+// RemoveUser removes a user from a group.
+func (handler *GroupHandlerImplementation) RemoveUser(c *gin.Context) {
+	group, ok := handler.bindGroup(c)
+	if !ok {
+		return
+	}
+
+	uid, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		views.RenderError(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := handler.userGroupService.RemoveUserFromGroup(requestContext(c), uint(uid), group.ID); err != nil {
+		handler.render(c, http.StatusBadRequest, group, err.Error())
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/groups/%d", group.ID), "Member removed")
+}
+
+// AddSubgroup nests the group named in the form in a group.
+func (handler *GroupHandlerImplementation) AddSubgroup(c *gin.Context) {
+	group, ok := handler.bindGroup(c)
+	if !ok {
+		return
+	}
+
+	subgroups, _, err := handler.groupService.GetAll(byName(c.PostForm("group")))
+	if err != nil {
+		handler.render(c, http.StatusInternalServerError, group, err.Error())
+		return
+	}
+	if len(subgroups) == 0 {
+		handler.render(c, http.StatusBadRequest, group, fmt.Sprintf("Group %q not found", c.PostForm("group")))
+		return
+	}
+
+	if err := handler.userGroupService.AddGroupToGroup(requestContext(c), subgroups[0].ID, group.ID); err != nil {
+		handler.render(c, http.StatusBadRequest, group, err.Error())
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/groups/%d", group.ID), fmt.Sprintf("%s nested", subgroups[0].Name))
+}
+
+// RemoveSubgroup removes a subgroup from a group.
+func (handler *GroupHandlerImplementation) RemoveSubgroup(c *gin.Context) {
+	group, ok := handler.bindGroup(c)
+	if !ok {
+		return
+	}
+
+	sid, err := strconv.ParseUint(c.Param("subgroupId"), 10, 32)
+	if err != nil {
+		views.RenderError(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	if err := handler.userGroupService.RemoveGroupFromGroup(requestContext(c), uint(sid), group.ID); err != nil {
+		handler.render(c, http.StatusBadRequest, group, err.Error())
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/groups/%d", group.ID), "Subgroup removed")
+}
+
+// bindGroup retrieves the group of the page. It renders the error page and returns false when there's none.
+func (handler *GroupHandlerImplementation) bindGroup(c *gin.Context) (*models.Group, bool) {
+	gid, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		views.RenderError(c, http.StatusBadRequest, "Invalid group ID")
+		return nil, false
+	}
+
+	group, err := handler.groupService.Get(uint(gid))
+	if err != nil {
+		views.RenderError(c, http.StatusNotFound, "Group not found")
+		return nil, false
+	}
+
+	return group, true
+}
+
+// render renders the page of a group, with the error of the submitted form if any.
+func (handler *GroupHandlerImplementation) render(c *gin.Context, status int, group *models.Group, formError string) {
+	users, _, err := handler.userGroupService.GetGroupUsers(group.ID, nil)
+	if err != nil {
+		views.RenderError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	subgroups, _, err := handler.userGroupService.GetSubgroups(group.ID, nil)
+	if err != nil {
+		views.RenderError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	views.Render(c, status, "group.html", &views.Page{Title: group.Name, Error: formError, Data: gin.H{
+		"Group":     group,
+		"Users":     users,
+		"Subgroups": subgroups,
+	}})
+}
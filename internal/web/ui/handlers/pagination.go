@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"github.com/gin-gonic/gin"
+)
+
+// pageSize is the number of results shown by the list pages.
+const pageSize = 25
+
+// bindListQuery builds the query of a list page from its page and q query parameters, searching for the names
+// containing q.
+func bindListQuery(c *gin.Context) (*query.ListQuery, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	listQuery := &query.ListQuery{
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+		Sorts:  []query.Sort{{Column: "name"}},
+	}
+	if search := c.Query("q"); search != "" {
+		listQuery.Filters = append(listQuery.Filters, query.Filter{Column: "name", Operator: "~", Value: search})
+	}
+
+	return listQuery, page
+}
+
+// byName is the query of the record having a name.
+func byName(name string) *query.ListQuery {
+	return &query.ListQuery{Limit: 1, Filters: []query.Filter{{Column: "name", Operator: "=", Value: name}}}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/common/dtos"
+	"github.com/Nokeni/GODS/internal/web/ui/views"
+	"github.com/gin-gonic/gin"
+)
+
+// UserHandler defines the interface for the user pages of the admin console.
+type UserHandler interface {
+	List(c *gin.Context)
+	Show(c *gin.Context)
+	New(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	AddGroup(c *gin.Context)
+	RemoveGroup(c *gin.Context)
+}
+
+// UserHandlerImplementation handles the user pages of the admin console.
+type UserHandlerImplementation struct {
+	userService      services.UserService
+	groupService     services.GroupService
+	userGroupService services.UserGroupService
+}
+
+// NewUserHandler creates a new instance of the UserHandlerImplementation.
+func NewUserHandler(userService services.UserService, groupService services.GroupService, userGroupService services.UserGroupService) *UserHandlerImplementation {
+	return &UserHandlerImplementation{
+		userService:      userService,
+		groupService:     groupService,
+		userGroupService: userGroupService,
+	}
+}
+
+// List shows a page of users, whose names contain the searched text if any.
+func (handler *UserHandlerImplementation) List(c *gin.Context) {
+	listQuery, page := bindListQuery(c)
+
+	users, pageInfo, err := handler.userService.GetAll(listQuery)
+	if err != nil {
+		views.RenderError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	views.Render(c, http.StatusOK, "users.html", &views.Page{Title: "Users", Data: gin.H{
+		"Users":      users,
+		"Query":      c.Query("q"),
+		"Pagination": views.NewPagination(c, page, pageInfo),
+	}})
+}
+
+// Show shows a user, the form to edit them and their groups.
+func (handler *UserHandlerImplementation) Show(c *gin.Context) {
+	user, ok := handler.bindUser(c)
+	if !ok {
+		return
+	}
+
+	handler.render(c, http.StatusOK, user, "")
+}
+
+// New shows the form to create a user.
+func (handler *UserHandlerImplementation) New(c *gin.Context) {
+	views.Render(c, http.StatusOK, "user_new.html", &views.Page{Title: "New user", Data: &dtos.CreateUserDTO{}})
+}
+
+// Create creates a user from the new user form.
+func (handler *UserHandlerImplementation) Create(c *gin.Context) {
+	var userDTO dtos.CreateUserDTO
+	if err := c.ShouldBind(&userDTO); err != nil {
+		views.Render(c, http.StatusBadRequest, "user_new.html", &views.Page{Title: "New user", Error: err.Error(), Data: &userDTO})
+		return
+	}
+
+	user, err := handler.userService.Create(requestContext(c), &userDTO)
+	if err != nil {
+		views.Render(c, errorStatus(err), "user_new.html", &views.Page{Title: "New user", Error: err.Error(), Data: &userDTO})
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/users/%d", user.ID), "User created")
+}
+
+// Update modifies a user from the edit form.
+func (handler *UserHandlerImplementation) Update(c *gin.Context) {
+	user, ok := handler.bindUser(c)
+	if !ok {
+		return
+	}
+
+	var userDTO dtos.UpdateUserDTO
+	if err := c.ShouldBind(&userDTO); err != nil {
+		handler.render(c, http.StatusBadRequest, user, err.Error())
+		return
+	}
+
+	// The user is modified in place, so the form is shown again with the stored values if it fails
+	edited := *user
+	if err := handler.userService.Update(requestContext(c), &edited, &userDTO); err != nil {
+		handler.render(c, errorStatus(err), user, err.Error())
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/users/%d", user.ID), "User saved")
+}
+
+// Delete deletes a user, who can be restored through the API until purged.
+func (handler *UserHandlerImplementation) Delete(c *gin.Context) {
+	user, ok := handler.bindUser(c)
+	if !ok {
+		return
+	}
+
+	if err := handler.userService.Delete(requestContext(c), user.ID); err != nil {
+		handler.render(c, http.StatusInternalServerError, user, err.Error())
+		return
+	}
+
+	views.Redirect(c, "/console/users", fmt.Sprintf("User %s deleted", user.Name))
+}
+
+// AddGroup adds a user to the group named in the form.
+func (handler *UserHandlerImplementation) AddGroup(c *gin.Context) {
+	user, ok := handler.bindUser(c)
+	if !ok {
+		return
+	}
+
+	groups, _, err := handler.groupService.GetAll(byName(c.PostForm("group")))
+	if err != nil {
+		handler.render(c, http.StatusInternalServerError, user, err.Error())
+		return
+	}
+	if len(groups) == 0 {
+		handler.render(c, http.StatusBadRequest, user, fmt.Sprintf("Group %q not found", c.PostForm("group")))
+		return
+	}
+
+	if err := handler.userGroupService.AddUserToGroup(requestContext(c), user.ID, groups[0].ID); err != nil {
+		handler.render(c, http.StatusBadRequest, user, err.Error())
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/users/%d", user.ID), fmt.Sprintf("Added to %s", groups[0].Name))
+}
+
+// RemoveGroup removes a user from a group.
+func (handler *UserHandlerImplementation) RemoveGroup(c *gin.Context) {
+	user, ok := handler.bindUser(c)
+	if !ok {
+		return
+	}
+
+	gid, err := strconv.ParseUint(c.Param("groupId"), 10, 32)
+	if err != nil {
+		views.RenderError(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	if err := handler.userGroupService.RemoveUserFromGroup(requestContext(c), user.ID, uint(gid)); err != nil {
+		handler.render(c, http.StatusBadRequest, user, err.Error())
+		return
+	}
+
+	views.Redirect(c, fmt.Sprintf("/console/users/%d", user.ID), "Removed from the group")
+}
+
+// bindUser retrieves the user of the page. It renders the error page and returns false when there's none.
+func (handler *UserHandlerImplementation) bindUser(c *gin.Context) (*models.User, bool) {
+	uid, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		views.RenderError(c, http.StatusBadRequest, "Invalid user ID")
+		return nil, false
+	}
+
+	user, err := handler.userService.Get(uint(uid))
+	if err != nil {
+		views.RenderError(c, http.StatusNotFound, "User not found")
+		return nil, false
+	}
+
+	return user, true
+}
+
+// render renders the page of a user, with the error of the submitted form if any.
+func (handler *UserHandlerImplementation) render(c *gin.Context, status int, user *models.User, formError string) {
+	groups, _, err := handler.userGroupService.GetUserGroups(user.ID, nil)
+	if err != nil {
+		views.RenderError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	views.Render(c, status, "user.html", &views.Page{Title: user.Name, Error: formError, Data: gin.H{
+		"User":   user,
+		"Groups": groups,
+	}})
+}
+
+// errorStatus returns the status of an error of the services, the errors that aren't caused by the form being
+// internal server errors.
+func errorStatus(err error) int {
+	var (
+		validationErr *services.ValidationError
+		policyErr     *services.PasswordPolicyError
+		notFoundErr   *services.NotFoundError
+		conflictErr   *services.ConflictError
+	)
+	switch {
+	case errors.As(err, &validationErr), errors.As(err, &policyErr):
+		return http.StatusBadRequest
+	case errors.As(err, &notFoundErr):
+		return http.StatusNotFound
+	case errors.As(err, &conflictErr):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/Nokeni/GODS/internal/web/ui/views"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// csrfCookieName is the name of the cookie holding the CSRF token of the browser.
+	csrfCookieName = "gods_console_csrf"
+	// csrfTokenField is the name of the form field the CSRF token is sent back in.
+	csrfTokenField = "csrf_token"
+)
+
+// CSRF protects the console forms from cross-site request forgery: the token of the browser, kept in a cookie other
+// sites can't read, has to be sent back in the forms submitted.
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := c.Cookie(csrfCookieName)
+		if err != nil || token == "" {
+			if token, err = NewCSRFToken(c); err != nil {
+				views.RenderError(c, http.StatusInternalServerError, err.Error())
+				c.Abort()
+				return
+			}
+		}
+		c.Set("csrfToken", token)
+
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			if subtle.ConstantTimeCompare([]byte(c.PostForm(csrfTokenField)), []byte(token)) != 1 {
+				views.RenderError(c, http.StatusForbidden, "The form has expired, reload the page and try again")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// NewCSRFToken sets a new CSRF token for the browser, such as when a user logs in, and returns it.
+func NewCSRFToken(c *gin.Context) (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buffer)
+
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(csrfCookieName, token, 0, "/console", "", views.IsSecure(c), true)
+	c.Set("csrfToken", token)
+
+	return token, nil
+}
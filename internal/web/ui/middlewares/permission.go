@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/ui/views"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission returns a factory of middlewares checking if the logged in user has been granted a permission,
+// the same one as the matching API endpoint.
+func RequirePermission(roleService services.RoleService) func(permission string) gin.HandlerFunc {
+	return func(permission string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			granted, err := roleService.HasPermission(c.GetUint("userID"), permission)
+			if err != nil {
+				views.RenderError(c, http.StatusInternalServerError, err.Error())
+				c.Abort()
+				return
+			}
+			if !granted {
+				views.RenderError(c, http.StatusForbidden, "You need the "+permission+" permission to access this page")
+				c.Abort()
+				return
+			}
+
+			c.Next()
+		}
+	}
+}
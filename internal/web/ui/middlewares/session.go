@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/Nokeni/GODS/internal/web/api/services"
+	"github.com/Nokeni/GODS/internal/web/ui/views"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionCookieName is the name of the cookie holding the session of the admin console.
+const SessionCookieName = "gods_console"
+
+// RequireSession checks if the user is logged in to the console, and sends them to the login page otherwise.
+func RequireSession(consoleService services.ConsoleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionToken, err := c.Cookie(SessionCookieName)
+		if err != nil {
+			redirectToLogin(c)
+			return
+		}
+
+		user, claims, err := consoleService.GetSession(sessionToken)
+		if err != nil {
+			c.SetCookie(SessionCookieName, "", -1, "/console", "", views.IsSecure(c), true)
+			redirectToLogin(c)
+			return
+		}
+
+		// Set the user's ID like the API authentication does, along with the user shown by the pages
+		c.Set("userID", user.ID)
+		c.Set("user", user)
+		c.Set("consoleClaims", claims)
+
+		c.Next()
+	}
+}
+
+// redirectToLogin sends the user to the login page, which brings them back to the requested page once logged in.
+func redirectToLogin(c *gin.Context) {
+	location := "/console/login"
+	if c.Request.Method == http.MethodGet {
+		location += "?next=" + url.QueryEscape(c.Request.URL.RequestURI())
+	}
+	c.Redirect(http.StatusSeeOther, location)
+	c.Abort()
+}
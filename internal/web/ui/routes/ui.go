@@ -1 +1,60 @@
 package routes
+
+import (
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/ui/handlers"
+	"github.com/Nokeni/GODS/internal/web/ui/middlewares"
+	"github.com/Nokeni/GODS/internal/web/ui/views"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupUIRoutes sets up the routes of the admin console, whose pages require the same permissions as the matching
+// API endpoints. HTML forms only send GET and POST requests, so the updates and deletions are POST routes.
+func SetupUIRoutes(
+	router *gin.Engine,
+	authHandler handlers.AuthHandler,
+	userHandler handlers.UserHandler,
+	groupHandler handlers.GroupHandler,
+	sessionMiddleware gin.HandlerFunc,
+	requirePermission func(permission string) gin.HandlerFunc,
+) {
+	router.StaticFS("/console/assets", views.Assets())
+
+	console := router.Group("/console", middlewares.CSRF())
+	{
+		console.GET("/login", authHandler.LoginPage)
+		console.POST("/login", authHandler.Login)
+
+		sessionRoutes := console.Group("", sessionMiddleware)
+		{
+			sessionRoutes.GET("/", authHandler.Home)
+			sessionRoutes.POST("/logout", authHandler.Logout)
+
+			userRoutes := sessionRoutes.Group("/users")
+			{
+				userRoutes.GET("", requirePermission(models.PermissionUsersRead), userHandler.List)
+				userRoutes.GET("/new", requirePermission(models.PermissionUsersWrite), userHandler.New)
+				userRoutes.POST("", requirePermission(models.PermissionUsersWrite), userHandler.Create)
+				userRoutes.GET("/:id", requirePermission(models.PermissionUsersRead), requirePermission(models.PermissionMembershipsRead), userHandler.Show)
+				userRoutes.POST("/:id", requirePermission(models.PermissionUsersWrite), requirePermission(models.PermissionMembershipsRead), userHandler.Update)
+				userRoutes.POST("/:id/delete", requirePermission(models.PermissionUsersWrite), requirePermission(models.PermissionMembershipsRead), userHandler.Delete)
+				userRoutes.POST("/:id/groups", requirePermission(models.PermissionMembershipsWrite), requirePermission(models.PermissionGroupsRead), userHandler.AddGroup)
+				userRoutes.POST("/:id/groups/:groupId/remove", requirePermission(models.PermissionMembershipsWrite), userHandler.RemoveGroup)
+			}
+
+			groupRoutes := sessionRoutes.Group("/groups")
+			{
+				groupRoutes.GET("", requirePermission(models.PermissionGroupsRead), groupHandler.List)
+				groupRoutes.GET("/new", requirePermission(models.PermissionGroupsWrite), groupHandler.New)
+				groupRoutes.POST("", requirePermission(models.PermissionGroupsWrite), groupHandler.Create)
+				groupRoutes.GET("/:id", requirePermission(models.PermissionGroupsRead), requirePermission(models.PermissionMembershipsRead), groupHandler.Show)
+				groupRoutes.POST("/:id", requirePermission(models.PermissionGroupsWrite), requirePermission(models.PermissionMembershipsRead), groupHandler.Update)
+				groupRoutes.POST("/:id/delete", requirePermission(models.PermissionGroupsWrite), requirePermission(models.PermissionMembershipsRead), groupHandler.Delete)
+				groupRoutes.POST("/:id/users", requirePermission(models.PermissionMembershipsWrite), requirePermission(models.PermissionUsersRead), groupHandler.AddUser)
+				groupRoutes.POST("/:id/users/:userId/remove", requirePermission(models.PermissionMembershipsWrite), groupHandler.RemoveUser)
+				groupRoutes.POST("/:id/groups", requirePermission(models.PermissionMembershipsWrite), groupHandler.AddSubgroup)
+				groupRoutes.POST("/:id/groups/:subgroupId/remove", requirePermission(models.PermissionMembershipsWrite), groupHandler.RemoveSubgroup)
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package views
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Nokeni/GODS/internal/web/api/models"
+	"github.com/Nokeni/GODS/internal/web/common/query"
+	"github.com/gin-gonic/gin"
+)
+
+// flashCookieName is the name of the cookie holding the message shown by the next page, after a redirection.
+const flashCookieName = "gods_console_flash"
+
+//go:embed templates
+var templatesFS embed.FS
+
+//go:embed assets
+var assetsFS embed.FS
+
+// functions are the functions available to the templates.
+var functions = template.FuncMap{
+	"date": func(date time.Time) string { return date.Format("2006-01-02 15:04") },
+}
+
+// pages are the templates of the console pages, each one parsed along with the layout.
+var pages = parsePages("login.html", "error.html", "users.html", "user.html", "user_new.html", "groups.html", "group.html", "group_new.html")
+
+// parsePages parses the templates of the pages.
+func parsePages(names ...string) map[string]*template.Template {
+	pages := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		pages[name] = template.Must(template.New(name).Funcs(functions).ParseFS(templatesFS, "templates/layout.html", "templates/"+name))
+	}
+	return pages
+}
+
+// Assets returns the static files of the console, such as its stylesheet.
+func Assets() http.FileSystem {
+	assets, err := fs.Sub(assetsFS, "assets")
+	if err != nil {
+		panic(err)
+	}
+	return http.FS(assets)
+}
+
+// Page is the data of a console page.
+type Page struct {
+	Title     string       // Title is the title of the page.
+	User      *models.User // User is the logged in user, nil on the login page.
+	CSRFToken string       // CSRFToken is the token the forms of the page have to send back.
+	Flash     string       // Flash is the message left by the previous request, such as "User saved".
+	Error     string       // Error is the error of the submitted form.
+	Data      any          // Data is the data specific to the page.
+}
+
+// Render renders a console page, which mustn't be cached nor framed by other sites.
+func Render(c *gin.Context, status int, name string, page *Page) {
+	if user, exists := c.Get("user"); exists {
+		page.User, _ = user.(*models.User)
+	}
+	page.CSRFToken = c.GetString("csrfToken")
+	if flash, err := c.Cookie(flashCookieName); err == nil {
+		page.Flash = flash
+		c.SetCookie(flashCookieName, "", -1, "/console", "", IsSecure(c), true)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'self'; form-action 'self'; frame-ancestors 'none'")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := pages[name].ExecuteTemplate(c.Writer, "layout", page); err != nil {
+		c.Error(err)
+	}
+}
+
+// RenderError renders the error page of the console.
+func RenderError(c *gin.Context, status int, message string) {
+	Render(c, status, "error.html", &Page{Title: http.StatusText(status), Error: message})
+}
+
+// Redirect sends the browser to another console page after a form submission, with a message to show there.
+func Redirect(c *gin.Context, location string, flash string) {
+	if flash != "" {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(flashCookieName, flash, 60, "/console", "", IsSecure(c), true)
+	}
+	c.Redirect(http.StatusSeeOther, location)
+}
+
+// IsSecure tells if the request was made over HTTPS, in which case the cookies are only sent back over HTTPS.
+func IsSecure(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+// Pagination describes the links to the previous and next pages of a list.
+type Pagination struct {
+	Page        int    // Page is the number of the current page, from 1.
+	Pages       int    // Pages is the number of pages.
+	Total       int64  // Total is the number of results.
+	PreviousURL string // PreviousURL is the URL of the previous page, empty on the first one.
+	NextURL     string // NextURL is the URL of the next page, empty on the last one.
+}
+
+// NewPagination describes the pagination of a list requested with the page query parameter.
+func NewPagination(c *gin.Context, page int, pageInfo *query.PageInfo) *Pagination {
+	pagination := &Pagination{Page: page, Pages: 1, Total: pageInfo.Total}
+	if pageInfo.Limit > 0 && pageInfo.Total > 0 {
+		pagination.Pages = int((pageInfo.Total + int64(pageInfo.Limit) - 1) / int64(pageInfo.Limit))
+	}
+
+	pageURL := func(page int) string {
+		pageURL := *c.Request.URL
+		values := pageURL.Query()
+		values.Set("page", strconv.Itoa(page))
+		pageURL.RawQuery = values.Encode()
+		return pageURL.RequestURI()
+	}
+	if page > 1 {
+		pagination.PreviousURL = pageURL(page - 1)
+	}
+	if page < pagination.Pages {
+		pagination.NextURL = pageURL(page + 1)
+	}
+
+	return pagination
+}